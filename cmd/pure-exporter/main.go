@@ -0,0 +1,67 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+// Command pure-exporter exports the metrics of FlashArrays and of the Pure1
+// fleet to Prometheus.
+//
+// Usage:
+//
+//	pure-exporter -config /etc/pure-exporter/config.json [-listen :9491]
+//
+// The targets are read from the JSON configuration file, as documented by
+// exporter.Config.  The metrics of all the targets are served at /metrics,
+// and those of some targets with the target parameter, i.e.
+// /metrics?target=fa1,fa2.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/devans10/go-purestorage/exporter"
+	"github.com/devans10/go-purestorage/flasharray"
+)
+
+func main() {
+	configFile := flag.String("config", "", "path of the JSON configuration file")
+	listen := flag.String("listen", "", "address to listen on, overriding the configuration")
+	flag.Parse()
+
+	logger := log.New(os.Stderr, "", log.LstdFlags)
+	if *configFile == "" {
+		fmt.Fprintln(os.Stderr, "pure-exporter: -config is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+	cfg, err := exporter.LoadConfig(*configFile)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	addr := cfg.Listen
+	if *listen != "" {
+		addr = *listen
+	}
+	if addr == "" {
+		addr = exporter.DefaultListenAddress
+	}
+
+	e := cfg.Exporter([]flasharray.Option{flasharray.WithUserAgent("pure-exporter"), flasharray.WithLogger(logger)}, nil)
+	e.Logger = logger
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", e)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintln(w, `<html><head><title>Pure Storage exporter</title></head><body><h1>Pure Storage exporter</h1><p><a href="/metrics">Metrics</a></p></body></html>`)
+	})
+
+	logger.Printf("[info] pure-exporter listening on %s with %d targets", addr, len(e.Targets))
+	logger.Fatal(http.ListenAndServe(addr, mux))
+}
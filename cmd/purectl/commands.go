@@ -0,0 +1,145 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/devans10/go-purestorage/flasharray"
+)
+
+// commands returns the command tree of purectl.
+func commands() *command {
+	root := &command{name: "purectl"}
+	root.sub = []*command{
+		volumeCommands(),
+		hostCommands(),
+		hgroupCommands(),
+		pgroupCommands(),
+		podCommands(),
+		vgroupCommands(),
+		networkCommands(),
+		userCommands(),
+		alertCommands(),
+		certCommands(),
+		arrayCommands(),
+		profileCommands(),
+		completionCommand(root),
+	}
+	return root
+}
+
+// resource returns the command of a resource, with its subcommands.
+func resource(name string, summary string, sub ...*command) *command {
+	return &command{name: name, summary: summary, sub: sub}
+}
+
+// do sets the function run by the command with the client of the array, and
+// returns the command.
+func (c *command) do(run func(fa *flasharray.Client, args []string) (interface{}, error)) *command {
+	c.run = func(x *cli, args []string) (interface{}, error) {
+		fa, err := x.connect()
+		if err != nil {
+			return nil, err
+		}
+		return run(fa, args)
+	}
+	return c
+}
+
+// confirm makes the command confirm the operation op of the object of the
+// given kind named by its argument name, if when is nil or returns true,
+// unless -yes is set.  The guard of the client only confirms DELETE
+// requests, this confirms the other commands losing data.
+func (c *command) confirm(op string, kind string, name int, when func() bool) *command {
+	run := c.run
+	c.run = func(x *cli, args []string) (interface{}, error) {
+		if !x.yes && (when == nil || when()) {
+			o := &flasharray.GuardedOperation{Op: op, Kind: kind, Name: args[name]}
+			if !x.confirm(o) {
+				return nil, fmt.Errorf("refused to %s: not confirmed", o)
+			}
+		}
+		return run(x, args)
+	}
+	return c
+}
+
+// queryFlags adds the flags of a flasharray.Query to the flag set of a list
+// command, and returns the function building the query parameters.
+func queryFlags(fs *flag.FlagSet, space bool, pending bool) func(fa *flasharray.Client) (map[string]string, error) {
+	q := &flasharray.Query{}
+	names := fs.String("names", "", "names of the objects, separated by commas")
+	filter := fs.String("filter", "", "filter expression, i.e. \"name='vol*'\" (REST 1.4)")
+	sort := fs.String("sort", "", "fields to sort by, descending if suffixed with -, separated by commas (REST 1.4)")
+	fs.IntVar(&q.Limit, "limit", 0, "maximum number of objects (REST 1.4)")
+	if space {
+		fs.BoolVar(&q.Space, "space", false, "list the space metrics")
+		fs.BoolVar(&q.Monitor, "monitor", false, "list the performance metrics")
+		fs.StringVar(&q.Historical, "historical", "", "historical window of the metrics: "+strings.Join(flasharray.HistoricalWindows, ", "))
+	}
+	if pending {
+		fs.BoolVar(&q.Pending, "pending", false, "also list the destroyed objects")
+		fs.BoolVar(&q.PendingOnly, "pending-only", false, "only list the destroyed objects")
+	}
+	return func(fa *flasharray.Client) (map[string]string, error) {
+		q.Names = list(*names)
+		q.Filter = flasharray.Filter(*filter)
+		q.Sort = list(*sort)
+		return q.Params(fa.RestVersion)
+	}
+}
+
+// list splits a list separated by commas, nil if s is empty.
+func list(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+// parseSize parses a size like "10G" or "512M", in binary units of bytes,
+// or a number of bytes.
+func parseSize(s string) (int, error) {
+	u := strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(s)), "B")
+	multiplier := 1
+	if n := len(u); n > 0 {
+		if i := strings.IndexByte("KMGTP", u[n-1]); i >= 0 {
+			multiplier = 1 << (10 * uint(i+1))
+			u = u[:n-1]
+		}
+	}
+	n, err := strconv.Atoi(u)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size %q, i.e. 10G", s)
+	}
+	return n * multiplier, nil
+}
+
+// profileCommands returns the commands of the profiles of the configuration
+// file, which do not connect to an array.
+func profileCommands() *command {
+	ls := newCommand("list", "", "List the profiles of the configuration file", 0, 0)
+	ls.run = func(x *cli, args []string) (interface{}, error) {
+		cfg, err := loadConfig(x.configPath, x.configSet)
+		if err != nil {
+			return nil, err
+		}
+		type row struct {
+			Name    string `json:"name"`
+			Target  string `json:"target"`
+			Default bool   `json:"default"`
+		}
+		rows := []row{}
+		for _, name := range cfg.profileNames() {
+			rows = append(rows, row{Name: name, Target: cfg.Profiles[name].Target, Default: name == cfg.DefaultProfile})
+		}
+		return rows, nil
+	}
+	return resource("profile", "Profiles of the arrays", ls)
+}
@@ -0,0 +1,184 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Shells of the completion scripts
+var completionShells = []string{"bash", "zsh", "fish"}
+
+// completionCommand returns the command writing the completion script of
+// the commands of root for a shell.
+func completionCommand(root *command) *command {
+	c := newCommand("completion", "bash|zsh|fish", "Write the shell completion script, i.e. source <(purectl completion bash)", 1, 1)
+	c.run = func(x *cli, args []string) (interface{}, error) {
+		b := &bytes.Buffer{}
+		switch args[0] {
+		case "bash":
+			bashCompletion(b, root)
+		case "zsh":
+			b.WriteString("#compdef purectl\nautoload -U +X bashcompinit && bashcompinit\n")
+			bashCompletion(b, root)
+		case "fish":
+			fishCompletion(b, root)
+		default:
+			return nil, fmt.Errorf("unknown shell %s, must be one of %s", args[0], strings.Join(completionShells, ", "))
+		}
+		_, err := x.stdout.Write(b.Bytes())
+		return nil, err
+	}
+	return c
+}
+
+// flagNames returns the names of the flags of fs, prefixed with '-'.
+func flagNames(fs *flag.FlagSet) []string {
+	l := []string{}
+	if fs != nil {
+		fs.VisitAll(func(f *flag.Flag) { l = append(l, "-"+f.Name) })
+	}
+	sort.Strings(l)
+	return l
+}
+
+// valueFlags returns the names of the flags of fs taking a value.
+func valueFlags(fs *flag.FlagSet) []string {
+	l := []string{}
+	if fs != nil {
+		fs.VisitAll(func(f *flag.Flag) {
+			if b, ok := f.Value.(interface{ IsBoolFlag() bool }); !ok || !b.IsBoolFlag() {
+				l = append(l, "-"+f.Name)
+			}
+		})
+	}
+	sort.Strings(l)
+	return l
+}
+
+func subNames(c *command) []string {
+	l := make([]string, len(c.sub))
+	for i, s := range c.sub {
+		l[i] = s.name
+	}
+	return l
+}
+
+// bashCompletion writes the bash completion of the resources, commands and
+// flags of root, and of the output formats and profiles.
+func bashCompletion(b *bytes.Buffer, root *command) {
+	fmt.Fprintf(b, `_purectl() {
+	local cur=${COMP_WORDS[COMP_CWORD]} prev=${COMP_WORDS[COMP_CWORD-1]}
+	local words=() i opts
+
+	case $prev in
+	-output|-o)
+		COMPREPLY=($(compgen -W "%s" -- "$cur"))
+		return ;;
+	-profile)
+		COMPREPLY=($(compgen -W "$(purectl -columns name profile list 2>/dev/null | tail -n +2)" -- "$cur"))
+		return ;;
+	-config)
+		COMPREPLY=($(compgen -f -- "$cur"))
+		return ;;
+	completion)
+		COMPREPLY=($(compgen -W "%s" -- "$cur"))
+		return ;;
+	esac
+
+	# The resource and command, skipping the flags and their values
+	for ((i = 1; i < COMP_CWORD; i++)); do
+		case ${COMP_WORDS[i]} in
+		%s) ((i++)) ;;
+		-*) ;;
+		*) words+=("${COMP_WORDS[i]}") ;;
+		esac
+	done
+
+	case ${#words[@]} in
+	0)
+		opts="%s" ;;
+	1)
+		case ${words[0]} in
+`, strings.Join(outputFormats, " "), strings.Join(completionShells, " "),
+		strings.Join(allValueFlags(root), "|"),
+		strings.Join(append(subNames(root), flagNames(root.flags)...), " "))
+	for _, r := range root.sub {
+		if len(r.sub) > 0 {
+			fmt.Fprintf(b, "\t\t%s) opts=\"%s\" ;;\n", r.name, strings.Join(subNames(r), " "))
+		}
+	}
+	b.WriteString("\t\tesac ;;\n\t*)\n\t\tcase \"${words[0]} ${words[1]}\" in\n")
+	for _, r := range root.sub {
+		for _, c := range r.sub {
+			if names := flagNames(c.flags); len(names) > 0 {
+				fmt.Fprintf(b, "\t\t\"%s %s\") opts=\"%s\" ;;\n", r.name, c.name, strings.Join(names, " "))
+			}
+		}
+	}
+	b.WriteString(`		esac ;;
+	esac
+	COMPREPLY=($(compgen -W "$opts" -- "$cur"))
+}
+complete -F _purectl purectl
+`)
+}
+
+// allValueFlags returns the names of the flags taking a value of root and
+// its commands, skipped with their values to find the command of a command
+// line.
+func allValueFlags(root *command) []string {
+	seen := map[string]bool{}
+	l := []string{}
+	var walk func(c *command)
+	walk = func(c *command) {
+		for _, f := range valueFlags(c.flags) {
+			if !seen[f] {
+				seen[f] = true
+				l = append(l, f)
+			}
+		}
+		for _, s := range c.sub {
+			walk(s)
+		}
+	}
+	walk(root)
+	sort.Strings(l)
+	return l
+}
+
+// fishCompletion writes the fish completion of the resources, commands and
+// flags of root.
+func fishCompletion(b *bytes.Buffer, root *command) {
+	resources := strings.Join(subNames(root), " ")
+	root.flags.VisitAll(func(f *flag.Flag) {
+		fmt.Fprintf(b, "complete -c purectl -n '__fish_use_subcommand' -o %s -d %s\n", f.Name, fishQuote(f.Usage))
+	})
+	fmt.Fprintf(b, "complete -c purectl -n '__fish_use_subcommand' -o output -o o -xa '%s'\n", strings.Join(outputFormats, " "))
+	for _, r := range root.sub {
+		fmt.Fprintf(b, "complete -c purectl -f -n 'not __fish_seen_subcommand_from %s' -a %s -d %s\n", resources, r.name, fishQuote(r.summary))
+		if len(r.sub) == 0 {
+			continue
+		}
+		commands := strings.Join(subNames(r), " ")
+		for _, c := range r.sub {
+			fmt.Fprintf(b, "complete -c purectl -f -n '__fish_seen_subcommand_from %s; and not __fish_seen_subcommand_from %s' -a %s -d %s\n",
+				r.name, commands, c.name, fishQuote(c.summary))
+			c.flags.VisitAll(func(f *flag.Flag) {
+				fmt.Fprintf(b, "complete -c purectl -n '__fish_seen_subcommand_from %s; and __fish_seen_subcommand_from %s' -o %s -d %s\n",
+					r.name, c.name, f.Name, fishQuote(f.Usage))
+			})
+		}
+	}
+	fmt.Fprintf(b, "complete -c purectl -f -n '__fish_seen_subcommand_from completion' -a '%s'\n", strings.Join(completionShells, " "))
+}
+
+func fishQuote(s string) string {
+	return "'" + strings.Replace(strings.Replace(s, `\`, `\\`, -1), "'", `\'`, -1) + "'"
+}
@@ -0,0 +1,163 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/devans10/go-purestorage/flasharray"
+)
+
+// Environment variables of the configuration, the names of those of the
+// acceptance tests for the array
+const (
+	envConfig   = "PURECTL_CONFIG"
+	envProfile  = "PURECTL_PROFILE"
+	envTarget   = "PURE_TARGET"
+	envAPIToken = "PURE_APITOKEN"
+	envUsername = "PURE_USERNAME"
+	envPassword = "PURE_PASSWORD"
+)
+
+// config struct for the configuration file of purectl, in JSON:
+//
+//	{
+//		"default_profile": "prod",
+//		"profiles": {
+//			"prod": {"target": "fa1.example.com", "api_token_env": "FA1_API_TOKEN", "verify_https": true},
+//			"lab": {"target": "10.0.0.10", "username": "pureuser", "password_env": "LAB_PASSWORD"}
+//		}
+//	}
+type config struct {
+	DefaultProfile string              `json:"default_profile"`
+	Profiles       map[string]*profile `json:"profiles"`
+}
+
+// profile struct for the connection settings of an array.  The credentials
+// are either an API token or a username and password, read from the
+// environment variables APITokenEnv and PasswordEnv if set.
+type profile struct {
+	Target      string `json:"target"`
+	APIToken    string `json:"api_token,omitempty"`
+	APITokenEnv string `json:"api_token_env,omitempty"`
+	Username    string `json:"username,omitempty"`
+	Password    string `json:"password,omitempty"`
+	PasswordEnv string `json:"password_env,omitempty"`
+	RestVersion string `json:"rest_version,omitempty"`
+
+	VerifyHTTPS bool   `json:"verify_https,omitempty"`
+	CAFile      string `json:"ca_file,omitempty"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// defaultConfigPath returns the path of the configuration file,
+// $PURECTL_CONFIG or ~/.purectl.json.
+func defaultConfigPath() string {
+	if path := os.Getenv(envConfig); path != "" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".purectl.json")
+}
+
+// loadConfig reads the configuration file path.  A missing file is an
+// empty configuration unless required.
+func loadConfig(path string, required bool) (*config, error) {
+	cfg := &config{Profiles: map[string]*profile{}}
+	if path == "" {
+		return cfg, nil
+	}
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) && !required {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, cfg); err != nil {
+		return nil, fmt.Errorf("parsing %s: %s", path, err)
+	}
+	for name, p := range cfg.Profiles {
+		if p == nil || p.Target == "" {
+			return nil, fmt.Errorf("profile %s of %s has no target", name, path)
+		}
+	}
+	if cfg.DefaultProfile != "" && cfg.Profiles[cfg.DefaultProfile] == nil {
+		return nil, fmt.Errorf("default profile %s of %s does not exist", cfg.DefaultProfile, path)
+	}
+	return cfg, nil
+}
+
+// profile returns the profile name, or if empty the profile of
+// $PURECTL_PROFILE, the default profile, or the profile of the PURE_TARGET,
+// PURE_APITOKEN, PURE_USERNAME and PURE_PASSWORD environment variables.
+func (cfg *config) profile(name string) (*profile, error) {
+	if name == "" {
+		name = os.Getenv(envProfile)
+	}
+	if name == "" {
+		name = cfg.DefaultProfile
+	}
+	if name != "" {
+		p, ok := cfg.Profiles[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown profile %s", name)
+		}
+		return p, nil
+	}
+	if target := os.Getenv(envTarget); target != "" {
+		return &profile{Target: target, APITokenEnv: envAPIToken, Username: os.Getenv(envUsername), PasswordEnv: envPassword}, nil
+	}
+	return nil, fmt.Errorf("no profile selected: set -profile, a default profile in %s, or %s", defaultConfigPath(), envTarget)
+}
+
+// profileNames returns the names of the profiles, sorted.
+func (cfg *config) profileNames() []string {
+	names := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// connect returns a client of the array of the profile.
+func (p *profile) connect(ctx context.Context, opts ...flasharray.Option) (*flasharray.Client, error) {
+	o := []flasharray.Option{
+		flasharray.WithUserAgent("purectl"),
+		flasharray.WithTLSConfig(&flasharray.TLSConfig{VerifyHTTPS: p.VerifyHTTPS, CAFile: p.CAFile, Fingerprint: p.Fingerprint}),
+	}
+	if p.RestVersion != "" {
+		o = append(o, flasharray.WithRestVersion(p.RestVersion))
+	}
+	if token := envOr(p.APITokenEnv, p.APIToken); token != "" {
+		o = append(o, flasharray.WithAPIToken(token))
+	} else if p.Username != "" {
+		o = append(o, flasharray.WithUsernamePassword(p.Username, envOr(p.PasswordEnv, p.Password)))
+	} else {
+		return nil, fmt.Errorf("no API token or username for %s", p.Target)
+	}
+	return flasharray.NewWithContext(ctx, p.Target, append(o, opts...)...)
+}
+
+// envOr returns the value of the environment variable name if set, or
+// value.
+func envOr(name string, value string) string {
+	if name != "" {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return value
+}
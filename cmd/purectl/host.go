@@ -0,0 +1,157 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"strings"
+
+	"github.com/devans10/go-purestorage/flasharray"
+)
+
+func hostCommands() *command {
+	ls := newCommand("list", "", "List the hosts", 0, 0)
+	lsQuery := queryFlags(ls.flags, true, false)
+	ls.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		params, err := lsQuery(fa)
+		if err != nil {
+			return nil, err
+		}
+		return fa.Hosts.ListHosts(params)
+	})
+
+	get := newCommand("get", "NAME", "Show a host", 1, 1)
+	get.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Hosts.GetHost(args[0], nil)
+	})
+
+	create := newCommand("create", "NAME", "Create a host", 1, 1)
+	wwns := create.flags.String("wwns", "", "Fibre Channel WWNs, separated by commas")
+	iqns := create.flags.String("iqns", "", "iSCSI IQNs, separated by commas")
+	nqns := create.flags.String("nqns", "", "NVMe NQNs, separated by commas")
+	personality := create.flags.String("personality", "", "host personality, i.e. "+flasharray.HostPersonalityESXi)
+	create.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Hosts.CreateHost(args[0], &flasharray.CreateHostOptions{
+			Wwns:        list(*wwns),
+			Iqns:        list(*iqns),
+			Nqns:        list(*nqns),
+			Personality: *personality,
+		})
+	})
+
+	set := newCommand("set", "NAME", "Change the initiators of a host", 1, 1)
+	o := &flasharray.SetHostOptions{}
+	addWwns := set.flags.String("add-wwns", "", "Fibre Channel WWNs to add, separated by commas")
+	remWwns := set.flags.String("remove-wwns", "", "Fibre Channel WWNs to remove, separated by commas")
+	addIqns := set.flags.String("add-iqns", "", "iSCSI IQNs to add, separated by commas")
+	remIqns := set.flags.String("remove-iqns", "", "iSCSI IQNs to remove, separated by commas")
+	addNqns := set.flags.String("add-nqns", "", "NVMe NQNs to add, separated by commas")
+	remNqns := set.flags.String("remove-nqns", "", "NVMe NQNs to remove, separated by commas")
+	set.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		o.AddWwns, o.RemWwns = list(*addWwns), list(*remWwns)
+		o.AddIqns, o.RemIqns = list(*addIqns), list(*remIqns)
+		o.AddNqns, o.RemNqns = list(*addNqns), list(*remNqns)
+		return fa.Hosts.SetHost(args[0], o)
+	})
+
+	rename := newCommand("rename", "NAME NEW_NAME", "Rename a host", 2, 2)
+	rename.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Hosts.RenameHost(args[0], args[1])
+	})
+
+	del := newCommand("delete", "NAME", "Delete a host", 1, 1)
+	del.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Hosts.DeleteHost(args[0])
+	})
+
+	connect := newCommand("connect", "NAME VOLUME", "Connect a volume to a host", 2, 2)
+	lun := connect.flags.Int("lun", 0, "LUN of the volume, chosen by the array if 0")
+	connect.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Hosts.ConnectHost(args[0], args[1], &flasharray.ConnectVolumeOptions{Lun: *lun})
+	})
+
+	disconnect := newCommand("disconnect", "NAME VOLUME", "Disconnect a volume from a host", 2, 2)
+	disconnect.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Hosts.DisconnectHost(args[0], args[1])
+	})
+
+	connections := newCommand("connections", "NAME", "List the volumes connected to a host", 1, 1)
+	connections.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Hosts.ListHostConnections(args[0], nil)
+	})
+
+	monitor := newCommand("monitor", "NAME", "Show the performance of a host", 1, 1)
+	historical := monitor.flags.String("historical", "", "historical window: "+strings.Join(flasharray.HistoricalWindows, ", "))
+	monitor.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Hosts.GetHostPerformanceHistory(args[0], *historical)
+	})
+
+	return resource("host", "Hosts and their volume connections",
+		ls, get, create, set, rename, del, connect, disconnect, connections, monitor)
+}
+
+func hgroupCommands() *command {
+	ls := newCommand("list", "", "List the host groups", 0, 0)
+	lsQuery := queryFlags(ls.flags, true, false)
+	ls.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		params, err := lsQuery(fa)
+		if err != nil {
+			return nil, err
+		}
+		return fa.Hostgroups.ListHostgroups(params)
+	})
+
+	get := newCommand("get", "NAME", "Show a host group", 1, 1)
+	get.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Hostgroups.GetHostgroup(args[0], nil)
+	})
+
+	create := newCommand("create", "NAME", "Create a host group", 1, 1)
+	hosts := create.flags.String("hosts", "", "member hosts, separated by commas")
+	create.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Hostgroups.CreateHostgroup(args[0], &flasharray.CreateHostgroupOptions{Hosts: list(*hosts)})
+	})
+
+	set := newCommand("set", "NAME", "Change the member hosts of a host group", 1, 1)
+	addHosts := set.flags.String("add-hosts", "", "hosts to add, separated by commas")
+	remHosts := set.flags.String("remove-hosts", "", "hosts to remove, separated by commas")
+	set.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Hostgroups.SetHostgroup(args[0], &flasharray.SetHostgroupOptions{AddHosts: list(*addHosts), RemHosts: list(*remHosts)})
+	})
+
+	rename := newCommand("rename", "NAME NEW_NAME", "Rename a host group", 2, 2)
+	rename.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Hostgroups.RenameHostgroup(args[0], args[1])
+	})
+
+	del := newCommand("delete", "NAME", "Delete a host group", 1, 1)
+	del.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Hostgroups.DeleteHostgroup(args[0])
+	})
+
+	connect := newCommand("connect", "NAME VOLUME", "Connect a volume to a host group", 2, 2)
+	lun := connect.flags.Int("lun", 0, "LUN of the volume, chosen by the array if 0")
+	connect.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Hostgroups.ConnectHostgroup(args[0], args[1], &flasharray.ConnectVolumeOptions{Lun: *lun})
+	})
+
+	disconnect := newCommand("disconnect", "NAME VOLUME", "Disconnect a volume from a host group", 2, 2)
+	disconnect.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Hostgroups.DisconnectHostgroup(args[0], args[1])
+	})
+
+	connections := newCommand("connections", "NAME", "List the volumes connected to a host group", 1, 1)
+	connections.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Hostgroups.ListHostgroupConnections(args[0])
+	})
+
+	monitor := newCommand("monitor", "NAME", "Show the performance of a host group", 1, 1)
+	historical := monitor.flags.String("historical", "", "historical window: "+strings.Join(flasharray.HistoricalWindows, ", "))
+	monitor.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Hostgroups.GetHostgroupPerformanceHistory(args[0], *historical)
+	})
+
+	return resource("hgroup", "Host groups and their volume connections",
+		ls, get, create, set, rename, del, connect, disconnect, connections, monitor)
+}
@@ -0,0 +1,320 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+// Command purectl manages FlashArrays from the command line.
+//
+// Usage:
+//
+//	purectl [-profile NAME] [-output table|json|yaml] RESOURCE COMMAND [FLAGS] [ARGS]
+//
+// The resources are volume, host, hgroup, pgroup, pod, vgroup, network,
+// user, alert, cert and array, i.e.
+//
+//	purectl volume list
+//	purectl volume create vol1 10G
+//	purectl -output yaml host get host1
+//	purectl host connect host1 vol1 -lun 10
+//
+// The arrays are selected by the profiles of the configuration file,
+// ~/.purectl.json or $PURECTL_CONFIG, in JSON:
+//
+//	{
+//		"default_profile": "prod",
+//		"profiles": {
+//			"prod": {"target": "fa1.example.com", "api_token_env": "FA1_API_TOKEN", "verify_https": true}
+//		}
+//	}
+//
+// Without a profile, the array is that of the PURE_TARGET, PURE_APITOKEN,
+// PURE_USERNAME and PURE_PASSWORD environment variables.  The destructive
+// commands, destroying, eradicating, deleting, disconnecting, truncating,
+// overwriting copies, demoting and unstretching pods and promoting them
+// from undo pods, are confirmed unless -yes is set, and -dry-run prints the
+// requests changing the array instead of sending them, without results.
+//
+// Shell completion is set up with, i.e. for bash:
+//
+//	source <(purectl completion bash)
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/devans10/go-purestorage/flasharray"
+)
+
+// errUsage is returned for invalid command lines, once the usage is printed
+var errUsage = errors.New("usage")
+
+// command struct for a command of purectl, either a resource with
+// subcommands or a command run with arguments
+type command struct {
+	name    string
+	args    string
+	summary string
+	// minArgs and maxArgs bound the number of arguments, maxArgs is -1 for
+	// any number
+	minArgs int
+	maxArgs int
+	flags   *flag.FlagSet
+	run     func(x *cli, args []string) (interface{}, error)
+	sub     []*command
+}
+
+// newCommand returns a command with its flag set, taking between minArgs
+// and maxArgs arguments.
+func newCommand(name string, args string, summary string, minArgs int, maxArgs int) *command {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	return &command{name: name, args: args, summary: summary, minArgs: minArgs, maxArgs: maxArgs, flags: fs}
+}
+
+// lookup returns the subcommand name.
+func (c *command) lookup(name string) *command {
+	for _, s := range c.sub {
+		if s.name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// cli struct for the state of a run of purectl
+type cli struct {
+	ctx    context.Context
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+
+	// configPath is the configuration file, required if configSet
+	configPath string
+	configSet  bool
+	profile    string
+	output     string
+	columns    string
+	yes        bool
+	dryRun     bool
+	// dryRan is set once a request is printed instead of sent
+	dryRan bool
+
+	// options are added to the options of the client, i.e. by the tests
+	options []flasharray.Option
+	client  *flasharray.Client
+}
+
+func main() {
+	x := &cli{ctx: context.Background(), stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}
+	os.Exit(x.run(os.Args[1:]))
+}
+
+// run runs the command line args, and returns the exit status.
+func (x *cli) run(args []string) int {
+	root := commands()
+	fs := flag.NewFlagSet("purectl", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	fs.StringVar(&x.configPath, "config", defaultConfigPath(), "path of the configuration file")
+	fs.StringVar(&x.profile, "profile", "", "profile of the array, the default profile if empty")
+	fs.StringVar(&x.output, "output", outputTable, "output format: "+strings.Join(outputFormats, ", "))
+	fs.StringVar(&x.output, "o", outputTable, "shorthand for -output")
+	fs.StringVar(&x.columns, "columns", "", "columns of the tables, separated by commas")
+	fs.BoolVar(&x.yes, "yes", false, "do not confirm the destructive commands")
+	fs.BoolVar(&x.dryRun, "dry-run", false, "print the requests changing the array instead of sending them")
+	root.flags = fs
+
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			x.usage(root, nil)
+			return 0
+		}
+		return x.fail(root, nil, err)
+	}
+	fs.Visit(func(f *flag.Flag) { x.configSet = x.configSet || f.Name == "config" })
+	x.configSet = x.configSet || os.Getenv(envConfig) != ""
+	if !contains(outputFormats, x.output) {
+		return x.fail(root, nil, fmt.Errorf("unknown output format %s, must be one of %s", x.output, strings.Join(outputFormats, ", ")))
+	}
+
+	// Find the command of the arguments, i.e. "volume create"
+	path := []*command{}
+	c := root
+	args = fs.Args()
+	for len(c.sub) > 0 {
+		if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "-help" {
+			x.usage(c, path)
+			if len(args) == 0 {
+				return 2
+			}
+			return 0
+		}
+		s := c.lookup(args[0])
+		if s == nil {
+			return x.fail(c, path, fmt.Errorf("unknown command %s", args[0]))
+		}
+		path = append(path, s)
+		c, args = s, args[1:]
+	}
+
+	args, err := parseInterspersed(c.flags, args)
+	if err == flag.ErrHelp {
+		x.usage(c, path)
+		return 0
+	}
+	if err != nil {
+		return x.fail(c, path, err)
+	}
+	if len(args) < c.minArgs || (c.maxArgs >= 0 && len(args) > c.maxArgs) {
+		return x.fail(c, path, fmt.Errorf("wrong number of arguments"))
+	}
+
+	v, err := c.run(x, args)
+	if err == errUsage {
+		return x.fail(c, path, nil)
+	}
+	if err != nil {
+		fmt.Fprintf(x.stderr, "purectl: %s\n", err)
+		return 1
+	}
+	// The results of a dry run are empty objects
+	if v == nil || x.dryRan {
+		return 0
+	}
+	var columns []string
+	if x.columns != "" {
+		columns = strings.Split(x.columns, ",")
+	}
+	if err := write(x.stdout, x.output, v, columns); err != nil {
+		fmt.Fprintf(x.stderr, "purectl: %s\n", err)
+		return 1
+	}
+	return 0
+}
+
+// parseInterspersed parses the flags of fs among the arguments, which the
+// flag package stops at, and returns the other arguments.  The arguments
+// after "--" are not parsed.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	rest := []string{}
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		// The flag package stops after "--", which it drops
+		if n := len(args) - fs.NArg(); n > 0 && args[n-1] == "--" {
+			return append(rest, fs.Args()...), nil
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return rest, nil
+		}
+		rest = append(rest, args[0])
+		args = args[1:]
+	}
+}
+
+// fail prints err and the usage of the command c, and returns the exit
+// status of an invalid command line.
+func (x *cli) fail(c *command, path []*command, err error) int {
+	if err != nil {
+		fmt.Fprintf(x.stderr, "purectl: %s\n", err)
+	}
+	x.usage(c, path)
+	return 2
+}
+
+// usage prints the usage of the command c, at path.
+func (x *cli) usage(c *command, path []*command) {
+	name := "purectl"
+	for _, p := range path {
+		name += " " + p.name
+	}
+	w := x.stderr
+	if len(c.sub) > 0 {
+		if len(path) == 0 {
+			fmt.Fprintf(w, "Usage: %s [flags] RESOURCE COMMAND [flags] [args]\n\nResources:\n", name)
+		} else {
+			fmt.Fprintf(w, "Usage: %s COMMAND [flags] [args]\n\n%s\n\nCommands:\n", name, c.summary)
+		}
+		width := 0
+		for _, s := range c.sub {
+			if len(s.name) > width {
+				width = len(s.name)
+			}
+		}
+		for _, s := range c.sub {
+			fmt.Fprintf(w, "  %-*s  %s\n", width, s.name, s.summary)
+		}
+	} else {
+		fmt.Fprintf(w, "Usage: %s [flags] %s\n\n%s\n", name, c.args, c.summary)
+	}
+	if hasFlags(c.flags) {
+		fmt.Fprintf(w, "\nFlags:\n")
+		c.flags.SetOutput(w)
+		c.flags.PrintDefaults()
+		c.flags.SetOutput(ioutil.Discard)
+	}
+}
+
+func hasFlags(fs *flag.FlagSet) bool {
+	n := 0
+	if fs != nil {
+		fs.VisitAll(func(*flag.Flag) { n++ })
+	}
+	return n > 0
+}
+
+// connect returns the client of the array of the profile, connecting it on
+// first use.
+func (x *cli) connect() (*flasharray.Client, error) {
+	if x.client != nil {
+		return x.client, nil
+	}
+	cfg, err := loadConfig(x.configPath, x.configSet)
+	if err != nil {
+		return nil, err
+	}
+	p, err := cfg.profile(x.profile)
+	if err != nil {
+		return nil, err
+	}
+	guard := &flasharray.Guard{DryRun: x.dryRun, Logger: dryRunLogger{x}}
+	if !x.yes {
+		guard.Confirm = x.confirm
+	}
+	c, err := p.connect(x.ctx, append([]flasharray.Option{flasharray.WithGuard(guard)}, x.options...)...)
+	if err != nil {
+		return nil, err
+	}
+	x.client = c
+	return c, nil
+}
+
+// confirm asks to confirm a destructive operation on the standard input.
+func (x *cli) confirm(op *flasharray.GuardedOperation) bool {
+	target := op.Kind + " " + op.Name
+	if op.Volume != "" {
+		target = "volume " + op.Volume + " from " + target
+	}
+	fmt.Fprintf(x.stderr, "%s %s? [y/N] ", op.Op, target)
+	answer, _ := bufio.NewReader(x.stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// dryRunLogger prints the requests of a dry run.
+type dryRunLogger struct {
+	x *cli
+}
+
+func (l dryRunLogger) Printf(format string, v ...interface{}) {
+	l.x.dryRan = true
+	fmt.Fprintf(l.x.stderr, format+"\n", v...)
+}
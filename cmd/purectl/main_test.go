@@ -0,0 +1,264 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/devans10/go-purestorage/flasharray"
+	"github.com/devans10/go-purestorage/flasharray/flasharraytest"
+)
+
+// testPurectl returns a fake array, and a function running purectl with the
+// profile of the array as default profile, with the standard input stdin.
+func testPurectl(t *testing.T) (*flasharraytest.Server, func(stdin string, args ...string) (string, string, int)) {
+	s := flasharraytest.NewServer()
+	t.Cleanup(s.Close)
+
+	cfg := fmt.Sprintf(`{"default_profile": "fake", "profiles": {"fake": {"target": %q, "api_token": %q}, "other": {"target": "other.example.com", "username": "pureuser"}}}`, s.Target(), s.APIToken)
+	path := filepath.Join(t.TempDir(), "purectl.json")
+	if err := ioutil.WriteFile(path, []byte(cfg), 0600); err != nil {
+		t.Fatalf("error writing configuration: %s", err)
+	}
+
+	return s, func(stdin string, args ...string) (string, string, int) {
+		stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+		x := &cli{
+			ctx:     context.Background(),
+			stdin:   strings.NewReader(stdin),
+			stdout:  stdout,
+			stderr:  stderr,
+			options: []flasharray.Option{flasharray.WithHTTPClient(s.Client())},
+		}
+		code := x.run(append([]string{"-config", path}, args...))
+		return stdout.String(), stderr.String(), code
+	}
+}
+
+// testSetenv sets the environment variable key for the duration of the test.
+func testSetenv(t *testing.T, key string, value string) {
+	prev, ok := os.LookupEnv(key)
+	os.Setenv(key, value)
+	t.Cleanup(func() {
+		if ok {
+			os.Setenv(key, prev)
+		} else {
+			os.Unsetenv(key)
+		}
+	})
+}
+
+func TestPurectlVolume(t *testing.T) {
+	_, purectl := testPurectl(t)
+
+	if _, stderr, code := purectl("", "volume", "create", "vol1", "10G"); code != 0 {
+		t.Fatalf("volume create exited with %d: %s", code, stderr)
+	}
+	if _, stderr, code := purectl("", "volume", "snapshot", "vol1", "-suffix", "snap1"); code != 0 {
+		t.Fatalf("volume snapshot exited with %d: %s", code, stderr)
+	}
+
+	stdout, _, code := purectl("", "-o", "json", "volume", "get", "vol1")
+	if code != 0 {
+		t.Fatalf("volume get exited with %d", code)
+	}
+	v := &flasharray.Volume{}
+	if err := json.Unmarshal([]byte(stdout), v); err != nil {
+		t.Fatalf("error decoding volume: %s\n%s", err, stdout)
+	}
+	if v.Name != "vol1" || v.Size != 10<<30 {
+		t.Errorf("wrong volume %+v", v)
+	}
+
+	stdout, _, _ = purectl("", "-columns", "name,size", "volume", "list")
+	if expected := fmt.Sprintf("NAME  SIZE\nvol1  %d\n", 10<<30); stdout != expected {
+		t.Errorf("wrong volume list:\n%s\nexpected:\n%s", stdout, expected)
+	}
+	stdout, _, _ = purectl("", "-o", "yaml", "volume", "snapshots", "vol1")
+	if !strings.Contains(stdout, "- name: vol1.snap1\n") {
+		t.Errorf("snapshot not listed:\n%s", stdout)
+	}
+
+	// Destructive commands are confirmed on the standard input
+	if _, stderr, code := purectl("n\n", "volume", "destroy", "vol1"); code != 1 || !strings.Contains(stderr, "destroy volume vol1? [y/N]") {
+		t.Errorf("volume destroy refused exited with %d: %s", code, stderr)
+	}
+	if _, stderr, code := purectl("", "-dry-run", "-yes", "volume", "destroy", "vol1"); code != 0 || !strings.Contains(stderr, "dry run: DELETE") {
+		t.Errorf("volume destroy dry run exited with %d: %s", code, stderr)
+	}
+	stdout, _, _ = purectl("", "-columns", "name", "volume", "list")
+	if stdout != "NAME\nvol1\n" {
+		t.Errorf("volume destroyed by a refused or dry run command:\n%s", stdout)
+	}
+
+	// So are the other commands losing data, which are not DELETE requests
+	if _, stderr, code := purectl("n\n", "volume", "truncate", "vol1", "5G"); code != 1 || !strings.Contains(stderr, "truncate volume vol1? [y/N]") {
+		t.Errorf("volume truncate refused exited with %d: %s", code, stderr)
+	}
+	if _, stderr, code := purectl("n\n", "volume", "copy", "vol1", "vol2", "-overwrite"); code != 1 || !strings.Contains(stderr, "overwrite volume vol2? [y/N]") {
+		t.Errorf("volume copy -overwrite refused exited with %d: %s", code, stderr)
+	}
+	if _, stderr, code := purectl("", "volume", "copy", "vol1", "vol2"); code != 0 || stderr != "" {
+		t.Errorf("volume copy exited with %d: %s", code, stderr)
+	}
+	if _, stderr, code := purectl("", "-yes", "volume", "truncate", "vol2", "5G"); code != 0 {
+		t.Errorf("volume truncate exited with %d: %s", code, stderr)
+	}
+	stdout, _, _ = purectl("", "-columns", "name,size", "volume", "list")
+	if expected := fmt.Sprintf("NAME  SIZE\nvol1  %d\nvol2  %d\n", 10<<30, 5<<30); stdout != expected {
+		t.Errorf("wrong volume list:\n%s\nexpected:\n%s", stdout, expected)
+	}
+	if _, stderr, code := purectl("", "-yes", "volume", "destroy", "vol2"); code != 0 {
+		t.Errorf("volume destroy exited with %d: %s", code, stderr)
+	}
+
+	// A dry run prints the requests only, not the empty results
+	stdout, stderr, code := purectl("", "-dry-run", "volume", "create", "vol3", "1G")
+	if code != 0 || stdout != "" || !strings.Contains(stderr, "dry run: POST") {
+		t.Errorf("volume create dry run exited with %d:\n%s\n%s", code, stdout, stderr)
+	}
+	if _, _, code := purectl("", "volume", "get", "vol3"); code != 1 {
+		t.Errorf("volume created by a dry run")
+	}
+
+	if _, stderr, code := purectl("y\n", "volume", "destroy", "vol1"); code != 0 {
+		t.Errorf("volume destroy exited with %d: %s", code, stderr)
+	}
+	stdout, _, _ = purectl("", "-columns", "name", "volume", "list", "-pending-only")
+	if stdout != "NAME\nvol1\nvol2\n" {
+		t.Errorf("volumes not destroyed:\n%s", stdout)
+	}
+}
+
+func TestPurectlHost(t *testing.T) {
+	_, purectl := testPurectl(t)
+
+	for _, args := range [][]string{
+		{"volume", "create", "vol1", "1G"},
+		{"host", "create", "host1", "-iqns", "iqn.2018-01.com.example:host1"},
+		{"host", "connect", "host1", "vol1", "-lun", "10"},
+		{"hgroup", "create", "hgroup1", "-hosts", "host1"},
+	} {
+		if _, stderr, code := purectl("", args...); code != 0 {
+			t.Fatalf("%s exited with %d: %s", strings.Join(args, " "), code, stderr)
+		}
+	}
+	stdout, _, _ := purectl("", "-o", "json", "host", "connections", "host1")
+	c := []flasharray.ConnectedVolume{}
+	if err := json.Unmarshal([]byte(stdout), &c); err != nil {
+		t.Fatalf("error decoding connections: %s\n%s", err, stdout)
+	}
+	if len(c) != 1 || c[0].Vol != "vol1" || c[0].Lun != 10 {
+		t.Errorf("wrong connections %+v", c)
+	}
+	if _, stderr, code := purectl("", "host", "connect", "host1", "vol2", "-lun", "100000"); code != 1 || !strings.Contains(stderr, "LUN") {
+		t.Errorf("invalid LUN accepted, exited with %d: %s", code, stderr)
+	}
+}
+
+func TestPurectlArray(t *testing.T) {
+	s, purectl := testPurectl(t)
+	s.SetHardwareStatus("CT0.FAN0", flasharray.HardwareStatusCritical)
+	s.AddAlert("fan failure", flasharray.AlertSeverityCritical, "fan", "CT0.FAN0")
+
+	stdout, _, _ := purectl("", "array", "get")
+	if !strings.Contains(stdout, "array_name  "+s.ArrayName) {
+		t.Errorf("wrong array:\n%s", stdout)
+	}
+	stdout, _, _ = purectl("", "-columns", "name,status", "array", "hardware")
+	if !strings.Contains(stdout, "CT0.FAN0  critical") {
+		t.Errorf("wrong hardware:\n%s", stdout)
+	}
+	stdout, _, _ = purectl("", "-columns", "event,current_severity", "alert", "list")
+	if !strings.Contains(stdout, "fan failure  critical") {
+		t.Errorf("wrong alerts:\n%s", stdout)
+	}
+}
+
+func TestPurectlUsage(t *testing.T) {
+	_, purectl := testPurectl(t)
+
+	invalid := [][]string{
+		{},
+		{"disk", "list"},
+		{"volume"},
+		{"volume", "resize", "vol1"},
+		{"volume", "create", "vol1"},
+		{"volume", "create", "vol1", "1G", "2G"},
+		{"volume", "list", "-unknown"},
+		{"-o", "xml", "volume", "list"},
+		{"array", "set"},
+	}
+	for _, args := range invalid {
+		if _, stderr, code := purectl("", args...); code != 2 || !strings.Contains(stderr, "Usage: purectl") {
+			t.Errorf("purectl %s exited with %d, expected the usage:\n%s", strings.Join(args, " "), code, stderr)
+		}
+	}
+	if _, stderr, code := purectl("", "volume", "create", "-h"); code != 0 || !strings.Contains(stderr, "Usage: purectl volume create [flags] NAME SIZE") {
+		t.Errorf("volume create -h exited with %d:\n%s", code, stderr)
+	}
+	if _, stderr, code := purectl("", "volume", "create", "vol1", "1X"); code != 1 || !strings.Contains(stderr, "invalid size") {
+		t.Errorf("invalid size exited with %d:\n%s", code, stderr)
+	}
+}
+
+func TestPurectlProfiles(t *testing.T) {
+	_, purectl := testPurectl(t)
+
+	stdout, _, code := purectl("", "profile", "list")
+	if code != 0 || !strings.Contains(stdout, "fake   ") || !strings.Contains(stdout, "other  other.example.com  false") {
+		t.Errorf("wrong profiles, exited with %d:\n%s", code, stdout)
+	}
+	if _, stderr, code := purectl("", "-profile", "missing", "volume", "list"); code != 1 || !strings.Contains(stderr, "unknown profile missing") {
+		t.Errorf("unknown profile exited with %d: %s", code, stderr)
+	}
+
+	testSetenv(t, envProfile, "")
+	testSetenv(t, envPassword, "")
+	testSetenv(t, envTarget, "")
+	cfg := &config{Profiles: map[string]*profile{}}
+	if _, err := cfg.profile(""); err == nil {
+		t.Errorf("An Error was NOT raised for no profile")
+	}
+	testSetenv(t, envTarget, "fa1.example.com")
+	testSetenv(t, envUsername, "pureuser")
+	p, err := cfg.profile("")
+	if err != nil || p.Target != "fa1.example.com" || p.Username != "pureuser" {
+		t.Errorf("wrong profile of the environment %+v, %v", p, err)
+	}
+
+	invalid := map[string]string{
+		"syntax":          `{"profiles": }`,
+		"target":          `{"profiles": {"fa1": {"api_token": "t"}}}`,
+		"default profile": `{"default_profile": "fa2", "profiles": {"fa1": {"target": "fa1"}}}`,
+	}
+	for name, content := range invalid {
+		path := filepath.Join(t.TempDir(), "purectl.json")
+		ioutil.WriteFile(path, []byte(content), 0600)
+		if _, err := loadConfig(path, true); err == nil {
+			t.Errorf("An Error was NOT raised for the invalid %s", name)
+		}
+	}
+	if _, err := loadConfig(filepath.Join(t.TempDir(), "missing.json"), false); err != nil {
+		t.Errorf("error loading a missing optional configuration: %s", err)
+	}
+}
+
+func TestParseInterspersed(t *testing.T) {
+	c := newCommand("create", "", "", 0, -1)
+	size := c.flags.Int("size", 0, "")
+	args, err := parseInterspersed(c.flags, []string{"vol1", "-size", "10", "vol2", "--", "-vol3"})
+	if err != nil || *size != 10 || strings.Join(args, " ") != "vol1 vol2 -vol3" {
+		t.Errorf("wrong arguments %q, size %d, %v", args, *size, err)
+	}
+}
@@ -0,0 +1,321 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Output formats
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+var outputFormats = []string{outputTable, outputJSON, outputYAML}
+
+// field is a field of an object, which keeps the order of the fields of the
+// types of the flasharray package.
+type field struct {
+	key   string
+	value interface{}
+}
+
+// object is a JSON object with ordered fields.
+type object []field
+
+// get returns the value of the field key.
+func (o object) get(key string) (interface{}, bool) {
+	for _, f := range o {
+		if f.key == key {
+			return f.value, true
+		}
+	}
+	return nil, false
+}
+
+// write writes v in the format, selecting the columns of the tables if
+// columns is not empty.  The tables of values with a String method, i.e.
+// flasharray.ProtectiongroupPolicy, are their string.
+func write(w io.Writer, format string, v interface{}, columns []string) error {
+	if s, ok := v.(fmt.Stringer); ok && format == outputTable {
+		_, err := fmt.Fprintln(w, s)
+		return err
+	}
+	switch format {
+	case outputJSON:
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", b)
+		return err
+	case outputYAML, outputTable:
+		tree, err := toTree(v)
+		if err != nil {
+			return err
+		}
+		if format == outputYAML {
+			b := &bytes.Buffer{}
+			writeYAML(b, tree, 0)
+			_, err = w.Write(b.Bytes())
+			return err
+		}
+		return writeTable(w, tree, columns)
+	}
+	return fmt.Errorf("unknown output format %s, must be one of %s", format, strings.Join(outputFormats, ", "))
+}
+
+// toTree converts v to its JSON tree of objects, []interface{} and scalars,
+// keeping the order of the fields.
+func toTree(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	return decodeTree(dec)
+}
+
+func decodeTree(dec *json.Decoder) (interface{}, error) {
+	t, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t {
+	case json.Delim('{'):
+		o := object{}
+		for dec.More() {
+			k, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			v, err := decodeTree(dec)
+			if err != nil {
+				return nil, err
+			}
+			o = append(o, field{key: k.(string), value: v})
+		}
+		_, err = dec.Token()
+		return o, err
+	case json.Delim('['):
+		l := []interface{}{}
+		for dec.More() {
+			v, err := decodeTree(dec)
+			if err != nil {
+				return nil, err
+			}
+			l = append(l, v)
+		}
+		_, err = dec.Token()
+		return l, err
+	}
+	return t, nil
+}
+
+// writeTable writes a list of objects as a table with a column per field,
+// and an object as a table of its fields and values.
+func writeTable(w io.Writer, tree interface{}, columns []string) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	switch t := tree.(type) {
+	case []interface{}:
+		if len(columns) == 0 {
+			columns = tableColumns(t)
+		}
+		if len(columns) == 0 {
+			for _, v := range t {
+				fmt.Fprintln(tw, cell(v))
+			}
+			break
+		}
+		header := make([]string, len(columns))
+		for i, c := range columns {
+			header[i] = strings.ToUpper(c)
+		}
+		fmt.Fprintln(tw, strings.Join(header, "\t"))
+		for _, v := range t {
+			o, _ := v.(object)
+			row := make([]string, len(columns))
+			for i, c := range columns {
+				value, _ := o.get(c)
+				row[i] = cell(value)
+			}
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+	case object:
+		for _, f := range t {
+			if len(columns) == 0 || contains(columns, f.key) {
+				fmt.Fprintf(tw, "%s\t%s\n", f.key, cell(f.value))
+			}
+		}
+	default:
+		fmt.Fprintln(tw, cell(t))
+	}
+	return tw.Flush()
+}
+
+// tableColumns returns the fields of the objects of a list, in the order
+// they first appear.
+func tableColumns(l []interface{}) []string {
+	columns := []string{}
+	for _, v := range l {
+		o, ok := v.(object)
+		if !ok {
+			continue
+		}
+		for _, f := range o {
+			if !contains(columns, f.key) {
+				columns = append(columns, f.key)
+			}
+		}
+	}
+	return columns
+}
+
+// cell formats a value of a table: lists of scalars separated by commas, and
+// objects as JSON.
+func cell(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return "-"
+	case string:
+		if t == "" {
+			return "-"
+		}
+		return t
+	case []interface{}:
+		l := make([]string, len(t))
+		for i, e := range t {
+			if _, ok := e.(object); ok {
+				return compact(t)
+			}
+			l[i] = cell(e)
+		}
+		return strings.Join(l, ",")
+	case object:
+		return compact(t)
+	}
+	return fmt.Sprint(v)
+}
+
+// compact formats a value of the tree as JSON on one line.
+func compact(v interface{}) string {
+	switch t := v.(type) {
+	case object:
+		l := make([]string, len(t))
+		for i, f := range t {
+			l[i] = strconv.Quote(f.key) + ":" + compact(f.value)
+		}
+		return "{" + strings.Join(l, ",") + "}"
+	case []interface{}:
+		l := make([]string, len(t))
+		for i, e := range t {
+			l[i] = compact(e)
+		}
+		return "[" + strings.Join(l, ",") + "]"
+	case string:
+		return strconv.Quote(t)
+	case nil:
+		return "null"
+	}
+	return fmt.Sprint(v)
+}
+
+// yamlPlainRegexp matches the strings written without quotes in YAML.
+var yamlPlainRegexp = regexp.MustCompile(`^[A-Za-z_/][A-Za-z0-9_/.@+=-]*( [A-Za-z0-9_/.@+=()-]+)*$`)
+
+// yamlReserved are the plain strings YAML reads as other types.
+var yamlReserved = []string{"true", "false", "yes", "no", "on", "off", "y", "n", "null"}
+
+// writeYAML writes a value of the tree as a YAML block, at the indentation
+// of level.
+func writeYAML(b *bytes.Buffer, v interface{}, level int) {
+	indent := strings.Repeat("  ", level)
+	switch t := v.(type) {
+	case object:
+		if len(t) == 0 {
+			b.WriteString(indent + "{}\n")
+			return
+		}
+		for _, f := range t {
+			b.WriteString(indent + yamlScalar(f.key) + ":")
+			writeYAMLValue(b, f.value, level+1)
+		}
+	case []interface{}:
+		if len(t) == 0 {
+			b.WriteString(indent + "[]\n")
+			return
+		}
+		for _, e := range t {
+			b.WriteString(indent + "-")
+			if o, ok := e.(object); ok && len(o) > 0 {
+				// The first field follows the dash, the others are
+				// aligned with it.
+				sub := &bytes.Buffer{}
+				writeYAML(sub, o, level+1)
+				b.WriteString(" " + strings.TrimPrefix(sub.String(), indent+"  "))
+				continue
+			}
+			writeYAMLValue(b, e, level+1)
+		}
+	default:
+		b.WriteString(indent + yamlScalar(t) + "\n")
+	}
+}
+
+// writeYAMLValue writes the value of a field or list item, after its key or
+// dash, nested at level if it is a non empty object or list.
+func writeYAMLValue(b *bytes.Buffer, v interface{}, level int) {
+	switch t := v.(type) {
+	case object:
+		if len(t) > 0 {
+			b.WriteString("\n")
+			writeYAML(b, t, level)
+			return
+		}
+		b.WriteString(" {}\n")
+	case []interface{}:
+		if len(t) > 0 {
+			b.WriteString("\n")
+			writeYAML(b, t, level)
+			return
+		}
+		b.WriteString(" []\n")
+	default:
+		b.WriteString(" " + yamlScalar(t) + "\n")
+	}
+}
+
+// yamlScalar formats a scalar, quoting the strings which are not plain.
+func yamlScalar(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		if yamlPlainRegexp.MatchString(t) && !contains(yamlReserved, strings.ToLower(t)) {
+			return t
+		}
+		return strconv.Quote(t)
+	}
+	return fmt.Sprint(v)
+}
+
+func contains(l []string, s string) bool {
+	for _, e := range l {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
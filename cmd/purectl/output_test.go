@@ -0,0 +1,105 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+type testItem struct {
+	Name   string            `json:"name"`
+	Size   int               `json:"size,omitempty"`
+	Hosts  []string          `json:"hosts"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+func TestWriteTable(t *testing.T) {
+	items := []testItem{
+		{Name: "vol1", Size: 1024, Hosts: []string{"host1", "host2"}},
+		{Name: "vol2", Labels: map[string]string{"env": "prod"}},
+	}
+	tests := []struct {
+		v        interface{}
+		columns  []string
+		expected string
+	}{
+		{items, nil, "NAME  SIZE  HOSTS        LABELS\n" +
+			"vol1  1024  host1,host2  -\n" +
+			"vol2  -     -            {\"env\":\"prod\"}\n"},
+		{items, []string{"name", "hosts"}, "NAME  HOSTS\nvol1  host1,host2\nvol2  -\n"},
+		{items[0], nil, "name   vol1\nsize   1024\nhosts  host1,host2\n"},
+		{items[0], []string{"size"}, "size  1024\n"},
+		{[]string{"a", "b"}, nil, "a\nb\n"},
+	}
+	for _, test := range tests {
+		b := &bytes.Buffer{}
+		if err := write(b, outputTable, test.v, test.columns); err != nil {
+			t.Fatalf("error writing table: %s", err)
+		}
+		if b.String() != test.expected {
+			t.Errorf("wrong table of %+v:\n%s\nexpected:\n%s", test.v, b.String(), test.expected)
+		}
+	}
+}
+
+func TestWriteYAML(t *testing.T) {
+	v := []interface{}{
+		testItem{Name: "vol1", Size: 1024, Hosts: []string{"host1"}, Labels: map[string]string{"env": "prod: eu"}},
+		testItem{Name: "true", Hosts: []string{}},
+		map[string]interface{}{"time": "2018-01-02T03:04:05Z", "nested": []interface{}{[]int{1, 2}}, "empty": map[string]int{}, "null": nil},
+	}
+	expected := `- name: vol1
+  size: 1024
+  hosts:
+    - host1
+  labels:
+    env: "prod: eu"
+- name: "true"
+  hosts: []
+- empty: {}
+  nested:
+    -
+      - 1
+      - 2
+  "null": null
+  time: "2018-01-02T03:04:05Z"
+`
+	b := &bytes.Buffer{}
+	if err := write(b, outputYAML, v, nil); err != nil {
+		t.Fatalf("error writing YAML: %s", err)
+	}
+	if b.String() != expected {
+		t.Errorf("wrong YAML:\n%s\nexpected:\n%s", b.String(), expected)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	b := &bytes.Buffer{}
+	if err := write(b, outputJSON, testItem{Name: "vol1", Hosts: []string{}}, nil); err != nil {
+		t.Fatalf("error writing JSON: %s", err)
+	}
+	expected := "{\n  \"name\": \"vol1\",\n  \"hosts\": []\n}\n"
+	if b.String() != expected {
+		t.Errorf("wrong JSON:\n%s\nexpected:\n%s", b.String(), expected)
+	}
+	if err := write(b, "xml", nil, nil); err == nil {
+		t.Errorf("An Error was NOT raised for the output format xml")
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	valid := map[string]int{"512": 512, "1K": 1 << 10, "10G": 10 << 30, "2tb": 2 << 40, "1P": 1 << 50}
+	for s, expected := range valid {
+		if n, err := parseSize(s); err != nil || n != expected {
+			t.Errorf("size %s parsed as %d, %v, expected %d", s, n, err, expected)
+		}
+	}
+	for _, s := range []string{"", "G", "0", "-1G", "10X", "1.5G"} {
+		if _, err := parseSize(s); err == nil {
+			t.Errorf("An Error was NOT raised for size %q", s)
+		}
+	}
+}
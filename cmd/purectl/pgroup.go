@@ -0,0 +1,122 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"strings"
+
+	"github.com/devans10/go-purestorage/flasharray"
+)
+
+func pgroupCommands() *command {
+	ls := newCommand("list", "", "List the protection groups", 0, 0)
+	lsQuery := queryFlags(ls.flags, false, true)
+	ls.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		params, err := lsQuery(fa)
+		if err != nil {
+			return nil, err
+		}
+		return fa.Protectiongroups.ListProtectiongroups(params)
+	})
+
+	get := newCommand("get", "NAME", "Show a protection group", 1, 1)
+	get.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Protectiongroups.GetProtectiongroup(args[0], nil)
+	})
+
+	create := newCommand("create", "NAME", "Create a protection group of volumes, hosts or host groups", 1, 1)
+	volumes := create.flags.String("volumes", "", "member volumes, separated by commas")
+	hosts := create.flags.String("hosts", "", "member hosts, separated by commas")
+	hgroups := create.flags.String("hgroups", "", "member host groups, separated by commas")
+	targets := create.flags.String("targets", "", "arrays the snapshots are replicated to, separated by commas")
+	create.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Protectiongroups.CreateProtectiongroup(args[0], &flasharray.CreateProtectiongroupOptions{
+			Volumes: list(*volumes),
+			Hosts:   list(*hosts),
+			Hgroups: list(*hgroups),
+			Targets: list(*targets),
+		})
+	})
+
+	set := newCommand("set", "NAME", "Change the members of a protection group", 1, 1)
+	o := &flasharray.SetProtectiongroupOptions{}
+	addVolumes := set.flags.String("add-volumes", "", "volumes to add, separated by commas")
+	remVolumes := set.flags.String("remove-volumes", "", "volumes to remove, separated by commas")
+	addHosts := set.flags.String("add-hosts", "", "hosts to add, separated by commas")
+	remHosts := set.flags.String("remove-hosts", "", "hosts to remove, separated by commas")
+	addHgroups := set.flags.String("add-hgroups", "", "host groups to add, separated by commas")
+	remHgroups := set.flags.String("remove-hgroups", "", "host groups to remove, separated by commas")
+	set.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		o.AddVolumes, o.RemVolumes = list(*addVolumes), list(*remVolumes)
+		o.AddHosts, o.RemHosts = list(*addHosts), list(*remHosts)
+		o.AddHgroups, o.RemHgroups = list(*addHgroups), list(*remHgroups)
+		return fa.Protectiongroups.SetProtectiongroup(args[0], o)
+	})
+
+	rename := newCommand("rename", "NAME NEW_NAME", "Rename a protection group", 2, 2)
+	rename.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Protectiongroups.RenameProtectiongroup(args[0], args[1])
+	})
+
+	destroy := newCommand("destroy", "NAME", "Destroy a protection group, which can be recovered until eradicated", 1, 1)
+	destroy.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Protectiongroups.DestroyProtectiongroup(args[0])
+	})
+
+	recover := newCommand("recover", "NAME", "Recover a destroyed protection group", 1, 1)
+	recover.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Protectiongroups.RecoverProtectiongroup(args[0])
+	})
+
+	eradicate := newCommand("eradicate", "NAME", "Eradicate a destroyed protection group", 1, 1)
+	eradicate.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Protectiongroups.EradicateProtectiongroup(args[0])
+	})
+
+	snapshot := newCommand("snapshot", "NAME...", "Take snapshots of protection groups", 1, -1)
+	suffix := snapshot.flags.String("suffix", "", "suffix of the snapshots, numbered by the array if empty")
+	snapshot.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Protectiongroups.CreatePgroupSnapshotsWithSuffix(args, *suffix)
+	})
+
+	snapshots := newCommand("snapshots", "[NAME...]", "List the snapshots of protection groups, or of all of them", 0, -1)
+	snapshotsQuery := queryFlags(snapshots.flags, false, true)
+	snapshots.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		params, err := snapshotsQuery(fa)
+		if err != nil {
+			return nil, err
+		}
+		if len(args) > 0 {
+			params["names"] = strings.Join(args, ",")
+		}
+		return fa.Protectiongroups.ListPgroupSnapshots(params)
+	})
+
+	policy := newCommand("policy", "NAME", "Show the snapshot, replication and retention policy of a protection group", 1, 1)
+	policy.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Protectiongroups.GetPgroupPolicy(args[0])
+	})
+
+	enable := newCommand("enable", "NAME", "Enable the scheduled snapshots, or replication, of a protection group", 1, 1)
+	enableReplication := enable.flags.Bool("replication", false, "enable the scheduled replication instead of the snapshots")
+	enable.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		if *enableReplication {
+			return fa.Protectiongroups.EnablePgroupReplication(args[0])
+		}
+		return fa.Protectiongroups.EnablePgroupSnapshots(args[0])
+	})
+
+	disable := newCommand("disable", "NAME", "Disable the scheduled snapshots, or replication, of a protection group", 1, 1)
+	disableReplication := disable.flags.Bool("replication", false, "disable the scheduled replication instead of the snapshots")
+	disable.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		if *disableReplication {
+			return fa.Protectiongroups.DisablePgroupReplication(args[0])
+		}
+		return fa.Protectiongroups.DisablePgroupSnapshots(args[0])
+	})
+
+	return resource("pgroup", "Protection groups and their snapshots",
+		ls, get, create, set, rename, destroy, recover, eradicate, snapshot, snapshots, policy, enable, disable)
+}
@@ -0,0 +1,157 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"time"
+
+	"github.com/devans10/go-purestorage/flasharray"
+)
+
+func podCommands() *command {
+	ls := newCommand("list", "", "List the pods", 0, 0)
+	lsQuery := queryFlags(ls.flags, false, true)
+	ls.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		params, err := lsQuery(fa)
+		if err != nil {
+			return nil, err
+		}
+		return fa.Pods.ListPods(params)
+	})
+
+	get := newCommand("get", "NAME", "Show a pod", 1, 1)
+	get.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Pods.GetPod(args[0], nil)
+	})
+
+	create := newCommand("create", "NAME", "Create a pod", 1, 1)
+	source := create.flags.String("source", "", "pod the new pod is cloned from")
+	failover := create.flags.String("failover-preference", "", "arrays which keep the pod online first, separated by commas")
+	create.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Pods.CreatePod(args[0], &flasharray.CreatePodOptions{Source: *source, FailoverPreference: list(*failover)})
+	})
+
+	rename := newCommand("rename", "NAME NEW_NAME", "Rename a pod", 2, 2)
+	rename.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Pods.RenamePod(args[0], args[1])
+	})
+
+	destroy := newCommand("destroy", "NAME", "Destroy a pod, which can be recovered until eradicated", 1, 1)
+	destroy.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Pods.DeletePod(args[0])
+	})
+
+	recover := newCommand("recover", "NAME", "Recover a destroyed pod", 1, 1)
+	recover.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Pods.RecoverPod(args[0])
+	})
+
+	eradicate := newCommand("eradicate", "NAME", "Eradicate a destroyed pod", 1, 1)
+	eradicate.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Pods.EradicatePod(args[0])
+	})
+
+	stretch := newCommand("stretch", "NAME ARRAY", "Stretch a pod to a connected array", 2, 2)
+	wait := stretch.flags.Bool("wait", false, "wait until the pod is in sync on both arrays")
+	stretch.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		pod, err := fa.Pods.ConnectPod(args[0], args[1])
+		if err != nil || !*wait {
+			return pod, err
+		}
+		return fa.Pods.WaitForPodSync(args[0], 5*time.Second)
+	})
+
+	unstretch := newCommand("unstretch", "NAME ARRAY", "Remove an array from a stretched pod", 2, 2)
+	unstretch.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Pods.DisconnectPod(args[0], args[1])
+	}).confirm("unstretch", "pod", 0, nil)
+
+	promote := newCommand("promote", "NAME", "Promote a demoted pod", 1, 1)
+	promoteFrom := promote.flags.String("from", "", "undo pod the pod is promoted from, discarding the changes replicated since the demotion")
+	promoteWait := promote.flags.Bool("wait", false, "wait until the pod is promoted")
+	promote.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		pod, err := fa.Pods.PromotePod(args[0], *promoteFrom)
+		if err != nil || !*promoteWait {
+			return pod, err
+		}
+		return fa.Pods.WaitForPodPromotion(args[0], 5*time.Second)
+	}).confirm("promote", "pod", 0, func() bool { return *promoteFrom != "" })
+
+	demote := newCommand("demote", "NAME", "Demote a pod", 1, 1)
+	mode := demote.flags.String("mode", "", "demotion of the source of a replica link: "+flasharray.DemoteQuiesce+" or "+flasharray.DemoteSkipQuiesce)
+	demote.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Pods.DemotePod(args[0], *mode)
+	}).confirm("demote", "pod", 0, nil)
+
+	links := newCommand("replica-links", "", "List the replica links of the pods", 0, 0)
+	links.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Pods.ListPodReplicaLinks(nil)
+	})
+
+	return resource("pod", "Pods, their arrays and replica links",
+		ls, get, create, rename, destroy, recover, eradicate, stretch, unstretch, promote, demote, links)
+}
+
+func vgroupCommands() *command {
+	ls := newCommand("list", "", "List the volume groups", 0, 0)
+	ls.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Vgroups.ListVgroups()
+	})
+
+	get := newCommand("get", "NAME", "Show a volume group", 1, 1)
+	get.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Vgroups.GetVgroup(args[0])
+	})
+
+	create := newCommand("create", "NAME", "Create a volume group", 1, 1)
+	create.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Vgroups.CreateVgroup(args[0])
+	})
+
+	rename := newCommand("rename", "NAME NEW_NAME", "Rename a volume group", 2, 2)
+	rename.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Vgroups.RenameVgroup(args[0], args[1])
+	})
+
+	destroy := newCommand("destroy", "NAME", "Destroy a volume group, which can be recovered until eradicated", 1, 1)
+	destroy.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Vgroups.DestroyVgroup(args[0])
+	})
+
+	recover := newCommand("recover", "NAME", "Recover a destroyed volume group", 1, 1)
+	recover.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Vgroups.RecoverVgroup(args[0])
+	})
+
+	eradicate := newCommand("eradicate", "NAME", "Eradicate a destroyed volume group", 1, 1)
+	eradicate.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Vgroups.EradicateVgroup(args[0])
+	})
+
+	qos := newCommand("qos", "NAME", "Show the QoS limits of a volume group", 1, 1)
+	qos.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Vgroups.GetVgroupQoS(args[0])
+	})
+
+	limit := newCommand("limit", "NAME", "Set the QoS limits of a volume group", 1, 1)
+	bandwidth := limit.flags.String("bandwidth", "", "bandwidth limit, i.e. 100M")
+	iops := limit.flags.String("iops", "", "IOPS limit, i.e. 10K")
+	limit.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return setQoS(*bandwidth, *iops,
+			func(b flasharray.Bandwidth) (*flasharray.QoS, error) {
+				return fa.Vgroups.SetVgroupBandwidthLimit(args[0], b)
+			},
+			func(n flasharray.IOPS) (*flasharray.QoS, error) { return fa.Vgroups.SetVgroupIopsLimit(args[0], n) },
+			func(q flasharray.QoS) (*flasharray.QoS, error) { return fa.Vgroups.SetVgroupQoS(args[0], q) })
+	})
+
+	unlimit := newCommand("unlimit", "NAME", "Clear the QoS limits of a volume group", 1, 1)
+	unlimit.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Vgroups.ClearVgroupQoS(args[0])
+	})
+
+	return resource("vgroup", "Volume groups",
+		ls, get, create, rename, destroy, recover, eradicate, qos, limit, unlimit)
+}
@@ -0,0 +1,314 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/devans10/go-purestorage/flasharray"
+)
+
+func networkCommands() *command {
+	ls := newCommand("list", "", "List the network interfaces", 0, 0)
+	ls.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Networks.ListNetworkInterfaces()
+	})
+
+	get := newCommand("get", "IFACE", "Show a network interface", 1, 1)
+	get.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Networks.GetNetworkInterface(args[0])
+	})
+
+	set := newCommand("set", "IFACE", "Change the address of a network interface", 1, 1)
+	o := &flasharray.SetNetworkInterfaceOptions{}
+	set.flags.StringVar(&o.Address, "address", "", "IP address, with or without a prefix length, i.e. 10.0.0.10/24")
+	set.flags.StringVar(&o.Netmask, "netmask", "", "netmask")
+	set.flags.StringVar(&o.Gateway, "gateway", "", "gateway")
+	set.flags.IntVar(&o.Mtu, "mtu", 0, "MTU")
+	set.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Networks.SetNetworkInterface(args[0], o)
+	})
+
+	enable := newCommand("enable", "IFACE", "Enable a network interface", 1, 1)
+	enable.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Networks.EnableNetworkInterface(args[0])
+	})
+
+	disable := newCommand("disable", "IFACE", "Disable a network interface", 1, 1)
+	disable.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Networks.DisableNetworkInterface(args[0])
+	})
+
+	subnets := newCommand("subnets", "", "List the subnets", 0, 0)
+	subnets.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Networks.ListSubnets()
+	})
+
+	dns := newCommand("dns", "", "Show the DNS settings", 0, 0)
+	dns.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Networks.GetDNS()
+	})
+
+	setDNS := newCommand("set-dns", "", "Change the DNS settings", 0, 0)
+	domain := setDNS.flags.String("domain", "", "domain of the array")
+	nameservers := setDNS.flags.String("nameservers", "", "IP addresses of the DNS servers, separated by commas")
+	setDNS.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		o := &flasharray.SetDNSOptions{}
+		visit(setDNS.flags, map[string]func(){
+			"domain":      func() { o.Domain = domain },
+			"nameservers": func() { l := list(*nameservers); o.Nameservers = &l },
+		})
+		return fa.Networks.SetDNS(o)
+	})
+
+	ports := newCommand("ports", "", "List the ports of the array", 0, 0)
+	ports.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Networks.ListPorts(nil)
+	})
+
+	return resource("network", "Network interfaces, subnets and DNS",
+		ls, get, set, enable, disable, subnets, dns, setDNS, ports)
+}
+
+func userCommands() *command {
+	ls := newCommand("list", "", "List the local administrators", 0, 0)
+	ls.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Users.ListAdmins()
+	})
+
+	get := newCommand("get", "NAME", "Show an administrator", 1, 1)
+	get.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Users.GetAdmin(args[0])
+	})
+
+	create := newCommand("create", "NAME", "Create a local administrator", 1, 1)
+	create.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Users.CreateAdmin(args[0])
+	})
+
+	del := newCommand("delete", "NAME", "Delete a local administrator", 1, 1)
+	del.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Users.DeleteAdmin(args[0])
+	})
+
+	unlock := newCommand("unlock", "NAME", "Unlock an administrator locked out by failed logins", 1, 1)
+	unlock.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Users.UnlockAdmin(args[0])
+	})
+
+	tokens := newCommand("tokens", "", "List the API tokens", 0, 0)
+	tokens.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Users.ListAPITokens()
+	})
+
+	createToken := newCommand("create-token", "NAME", "Create an API token for an administrator", 1, 1)
+	createToken.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Users.CreateAPIToken(args[0])
+	})
+
+	deleteToken := newCommand("delete-token", "NAME", "Delete the API token of an administrator", 1, 1)
+	deleteToken.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Users.DeleteAPIToken(args[0])
+	})
+
+	return resource("user", "Administrators and their API tokens",
+		ls, get, create, del, unlock, tokens, createToken, deleteToken)
+}
+
+func alertCommands() *command {
+	ls := newCommand("list", "", "List the open alerts", 0, 0)
+	all := ls.flags.Bool("all", false, "list all the alert messages, not only the open alerts")
+	ls.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		params := map[string]string{"open": "true"}
+		if *all {
+			params = nil
+		}
+		return fa.Messages.ListMessages(params)
+	})
+
+	flagAlert := newCommand("flag", "ID", "Flag an alert message", 1, 1)
+	flagAlert.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid alert ID %s", args[0])
+		}
+		return fa.Messages.FlagMessage(id)
+	})
+
+	unflagAlert := newCommand("unflag", "ID", "Unflag an alert message", 1, 1)
+	unflagAlert.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid alert ID %s", args[0])
+		}
+		return fa.Messages.UnflagMessage(id)
+	})
+
+	recipients := newCommand("recipients", "", "List the alert recipients", 0, 0)
+	recipients.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Alerts.ListAlerts(nil)
+	})
+
+	add := newCommand("add-recipient", "ADDRESS", "Add an alert recipient", 1, 1)
+	add.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Alerts.CreateAlert(args[0], nil)
+	})
+
+	remove := newCommand("remove-recipient", "ADDRESS", "Remove an alert recipient", 1, 1)
+	remove.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Alerts.DeleteAlert(args[0])
+	})
+
+	enable := newCommand("enable-recipient", "ADDRESS", "Enable the alerts sent to a recipient", 1, 1)
+	enable.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Alerts.EnableAlert(args[0])
+	})
+
+	disable := newCommand("disable-recipient", "ADDRESS", "Disable the alerts sent to a recipient", 1, 1)
+	disable.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Alerts.DisableAlert(args[0])
+	})
+
+	test := newCommand("test", "", "Send a test alert to the recipients", 0, 0)
+	test.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Alerts.TestAlert()
+	})
+
+	return resource("alert", "Alert messages and recipients",
+		ls, flagAlert, unflagAlert, recipients, add, remove, enable, disable, test)
+}
+
+func certCommands() *command {
+	ls := newCommand("list", "", "List the certificates", 0, 0)
+	ls.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Cert.ListCert()
+	})
+
+	get := newCommand("get", "NAME", "Show a certificate", 1, 1)
+	get.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Cert.GetCert(args[0], nil)
+	})
+
+	csr := newCommand("csr", "NAME", "Create a certificate signing request", 1, 1)
+	commonName := csr.flags.String("common-name", "", "common name of the certificate")
+	csr.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		params := map[string]string{}
+		if *commonName != "" {
+			params["common_name"] = *commonName
+		}
+		return fa.Cert.GetCSR(args[0], params)
+	})
+
+	del := newCommand("delete", "NAME", "Delete a certificate", 1, 1)
+	del.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Cert.DeleteCert(args[0])
+	})
+
+	return resource("cert", "SSL certificates", ls, get, csr, del)
+}
+
+func arrayCommands() *command {
+	get := newCommand("get", "", "Show the array", 0, 0)
+	get.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Array.Get(nil)
+	})
+
+	set := newCommand("set", "", "Change the settings of the array", 0, 0)
+	ntpServers := set.flags.String("ntp-servers", "", "NTP servers, separated by commas")
+	syslogServers := set.flags.String("syslog-servers", "", "syslog servers, separated by commas")
+	idleTimeout := set.flags.Int("idle-timeout", 0, "idle timeout of the GUI and CLI sessions, in minutes, 0 to disable")
+	banner := set.flags.String("banner", "", "login banner")
+	proxy := set.flags.String("proxy", "", "URL of the HTTPS proxy of the phone home")
+	set.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		data := map[string]interface{}{}
+		visit(set.flags, map[string]func(){
+			"ntp-servers":    func() { data["ntpserver"] = list(*ntpServers) },
+			"syslog-servers": func() { data["syslogserver"] = list(*syslogServers) },
+			"idle-timeout":   func() { data["idle_timeout"] = *idleTimeout },
+			"banner":         func() { data["banner"] = *banner },
+			"proxy":          func() { data["proxy"] = *proxy },
+		})
+		if len(data) == 0 {
+			return nil, errUsage
+		}
+		return fa.Array.Set(data)
+	})
+
+	rename := newCommand("rename", "NAME", "Rename the array", 1, 1)
+	rename.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Array.Rename(args[0])
+	})
+
+	space := newCommand("space", "", "Show the space of the array", 0, 0)
+	spaceHistorical := space.flags.String("historical", "", "historical window: "+strings.Join(flasharray.HistoricalWindows, ", "))
+	space.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Array.GetSpaceHistory(*spaceHistorical)
+	})
+
+	monitor := newCommand("monitor", "", "Show the performance of the array", 0, 0)
+	monitorHistorical := monitor.flags.String("historical", "", "historical window: "+strings.Join(flasharray.HistoricalWindows, ", "))
+	monitor.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Array.GetPerformanceHistory(*monitorHistorical)
+	})
+
+	hardware := newCommand("hardware", "", "List the hardware components and their status", 0, 0)
+	hardware.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Hardware.ListHardware()
+	})
+
+	drives := newCommand("drives", "", "List the drives and their status", 0, 0)
+	drives.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Hardware.ListDrives()
+	})
+
+	connections := newCommand("connections", "", "List the connected arrays", 0, 0)
+	connections.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Array.ListArrayConnections(nil)
+	})
+
+	phonehome := newCommand("phonehome", "[enable|disable]", "Show, enable or disable the phone home", 0, 1)
+	phonehome.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		if len(args) == 0 {
+			return fa.Array.GetPhoneHome()
+		}
+		switch args[0] {
+		case "enable":
+			return fa.Array.EnablePhoneHome()
+		case "disable":
+			return fa.Array.DisablePhoneHome()
+		}
+		return nil, errUsage
+	})
+
+	remoteAssist := newCommand("remote-assist", "[enable|disable]", "Show, enable or disable the remote assist session", 0, 1)
+	remoteAssist.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		if len(args) == 0 {
+			return fa.Array.GetRemoteAssist()
+		}
+		switch args[0] {
+		case "enable":
+			return fa.Array.EnableRemoteAssist()
+		case "disable":
+			return fa.Array.DisableRemoteAssist()
+		}
+		return nil, errUsage
+	})
+
+	return resource("array", "Settings, space, performance and hardware of the array",
+		get, set, rename, space, monitor, hardware, drives, connections, phonehome, remoteAssist)
+}
+
+// visit calls the functions of the flags of fs set on the command line,
+// by flag name.
+func visit(fs *flag.FlagSet, set map[string]func()) {
+	fs.Visit(func(f *flag.Flag) {
+		if fn, ok := set[f.Name]; ok {
+			fn()
+		}
+	})
+}
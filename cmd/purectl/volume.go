@@ -0,0 +1,177 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/devans10/go-purestorage/flasharray"
+)
+
+func volumeCommands() *command {
+	ls := newCommand("list", "", "List the volumes", 0, 0)
+	lsQuery := queryFlags(ls.flags, true, true)
+	ls.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		params, err := lsQuery(fa)
+		if err != nil {
+			return nil, err
+		}
+		return fa.Volumes.ListVolumes(params)
+	})
+
+	get := newCommand("get", "NAME", "Show a volume", 1, 1)
+	getSpace := get.flags.Bool("space", false, "show the space metrics")
+	get.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		params := map[string]string{}
+		if *getSpace {
+			params["space"] = "true"
+		}
+		return fa.Volumes.GetVolume(args[0], params)
+	})
+
+	create := newCommand("create", "NAME SIZE", "Create a volume of SIZE, i.e. 10G", 2, 2)
+	create.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		size, err := parseSize(args[1])
+		if err != nil {
+			return nil, err
+		}
+		return fa.Volumes.CreateVolume(args[0], size)
+	})
+
+	cp := newCommand("copy", "SOURCE NAME", "Copy a volume or snapshot to a volume", 2, 2)
+	overwrite := cp.flags.Bool("overwrite", false, "overwrite the volume NAME if it exists")
+	cp.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Volumes.CopyVolume(args[1], args[0], *overwrite)
+	}).confirm("overwrite", "volume", 1, func() bool { return *overwrite })
+
+	extend := newCommand("extend", "NAME SIZE", "Extend a volume to SIZE", 2, 2)
+	extend.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		size, err := parseSize(args[1])
+		if err != nil {
+			return nil, err
+		}
+		return fa.Volumes.ExtendVolume(args[0], size)
+	})
+
+	truncate := newCommand("truncate", "NAME SIZE", "Truncate a volume to SIZE", 2, 2)
+	truncate.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		size, err := parseSize(args[1])
+		if err != nil {
+			return nil, err
+		}
+		return fa.Volumes.TruncateVolume(args[0], size)
+	}).confirm("truncate", "volume", 0, nil)
+
+	rename := newCommand("rename", "NAME NEW_NAME", "Rename a volume", 2, 2)
+	rename.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Volumes.RenameVolume(args[0], args[1])
+	})
+
+	destroy := newCommand("destroy", "NAME", "Destroy a volume, which can be recovered until eradicated", 1, 1)
+	destroy.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Volumes.DeleteVolume(args[0])
+	})
+
+	recover := newCommand("recover", "NAME", "Recover a destroyed volume", 1, 1)
+	recover.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Volumes.RecoverVolume(args[0])
+	})
+
+	eradicate := newCommand("eradicate", "NAME", "Eradicate a destroyed volume", 1, 1)
+	eradicate.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Volumes.EradicateVolume(args[0])
+	})
+
+	snapshot := newCommand("snapshot", "NAME...", "Take snapshots of volumes", 1, -1)
+	suffix := snapshot.flags.String("suffix", "", "suffix of the snapshots, numbered by the array if empty")
+	snapshot.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Volumes.CreateSnapshots(args, *suffix)
+	})
+
+	snapshots := newCommand("snapshots", "[NAME]", "List the snapshots of a volume, or of all volumes", 0, 1)
+	filter := &flasharray.VolumeSnapshotFilter{}
+	snapshots.flags.StringVar(&filter.Suffix, "suffix", "", "pattern of the suffixes of the snapshots, i.e. daily-*")
+	snapshots.flags.BoolVar(&filter.Pending, "pending", false, "also list the destroyed snapshots")
+	snapshots.flags.BoolVar(&filter.PendingOnly, "pending-only", false, "only list the destroyed snapshots")
+	snapshots.flags.BoolVar(&filter.Space, "space", false, "list the space of the snapshots")
+	snapshots.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		volume := ""
+		if len(args) > 0 {
+			volume = args[0]
+		}
+		return fa.Volumes.ListVolumeSnapshots(volume, filter)
+	})
+
+	connections := newCommand("connections", "NAME", "List the hosts and host groups a volume is connected to", 1, 1)
+	shared := connections.flags.Bool("shared", false, "list the host group connections instead of the private host connections")
+	connections.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		if *shared {
+			return fa.Volumes.ListVolumeSharedConnections(args[0])
+		}
+		return fa.Volumes.ListVolumePrivateConnections(args[0])
+	})
+
+	monitor := newCommand("monitor", "NAME", "Show the performance of a volume", 1, 1)
+	historical := monitor.flags.String("historical", "", "historical window: "+strings.Join(flasharray.HistoricalWindows, ", "))
+	monitor.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Volumes.GetVolumePerformanceHistory(args[0], *historical)
+	})
+
+	qos := newCommand("qos", "NAME", "Show the QoS limits of a volume", 1, 1)
+	qos.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Volumes.GetVolumeQoS(args[0])
+	})
+
+	limit := newCommand("limit", "NAME", "Set the QoS limits of a volume", 1, 1)
+	bandwidth := limit.flags.String("bandwidth", "", "bandwidth limit, i.e. 100M")
+	iops := limit.flags.String("iops", "", "IOPS limit, i.e. 10K")
+	limit.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return setQoS(*bandwidth, *iops,
+			func(b flasharray.Bandwidth) (*flasharray.QoS, error) {
+				return fa.Volumes.SetVolumeBandwidthLimit(args[0], b)
+			},
+			func(n flasharray.IOPS) (*flasharray.QoS, error) { return fa.Volumes.SetVolumeIopsLimit(args[0], n) },
+			func(q flasharray.QoS) (*flasharray.QoS, error) { return fa.Volumes.SetVolumeQoS(args[0], q) })
+	})
+
+	unlimit := newCommand("unlimit", "NAME", "Clear the QoS limits of a volume", 1, 1)
+	unlimit.do(func(fa *flasharray.Client, args []string) (interface{}, error) {
+		return fa.Volumes.ClearVolumeQoS(args[0])
+	})
+
+	return resource("volume", "Volumes and their snapshots",
+		ls, get, create, cp, extend, truncate, rename, destroy, recover, eradicate,
+		snapshot, snapshots, connections, monitor, qos, limit, unlimit)
+}
+
+// setQoS parses the bandwidth and IOPS limits, and sets those given with
+// setBandwidth, setIOPS, or setBoth.
+func setQoS(bandwidth string, iops string,
+	setBandwidth func(flasharray.Bandwidth) (*flasharray.QoS, error),
+	setIOPS func(flasharray.IOPS) (*flasharray.QoS, error),
+	setBoth func(flasharray.QoS) (*flasharray.QoS, error)) (*flasharray.QoS, error) {
+	var q flasharray.QoS
+	var err error
+	if bandwidth != "" {
+		if q.BandwidthLimit, err = flasharray.ParseBandwidth(bandwidth); err != nil {
+			return nil, err
+		}
+	}
+	if iops != "" {
+		if q.IopsLimit, err = flasharray.ParseIOPS(iops); err != nil {
+			return nil, err
+		}
+	}
+	switch {
+	case bandwidth != "" && iops != "":
+		return setBoth(q)
+	case bandwidth != "":
+		return setBandwidth(q.BandwidthLimit)
+	case iops != "":
+		return setIOPS(q.IopsLimit)
+	}
+	return nil, fmt.Errorf("-bandwidth or -iops is required")
+}
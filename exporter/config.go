@@ -0,0 +1,225 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/devans10/go-purestorage/flasharray"
+	"github.com/devans10/go-purestorage/pure1"
+)
+
+// DefaultListenAddress is the address the exporter listens on by default
+const DefaultListenAddress = ":9491"
+
+// Config struct for the configuration of an exporter, read from JSON with
+// LoadConfig:
+//
+//	{
+//		"listen": ":9491",
+//		"concurrency": 4,
+//		"timeout": "30s",
+//		"flasharrays": [
+//			{"name": "fa1", "address": "fa1.example.com", "api_token_env": "FA1_API_TOKEN", "verify_https": true}
+//		],
+//		"pure1": [
+//			{"name": "pure1", "app_id": "pure1:apikey:abc", "private_key_file": "/etc/pure-exporter/pure1.pem"}
+//		]
+//	}
+type Config struct {
+	// Listen is the address of the HTTP server, DefaultListenAddress if
+	// empty
+	Listen string `json:"listen"`
+	// Concurrency and Timeout are those of the Exporter
+	Concurrency int      `json:"concurrency"`
+	Timeout     Duration `json:"timeout"`
+
+	FlashArrays []FlashArrayConfig `json:"flasharrays"`
+	Pure1       []Pure1Config      `json:"pure1"`
+}
+
+// FlashArrayConfig struct for a FlashArray target of a Config.  The
+// credentials are either an API token or a username and password, read from
+// the environment variables APITokenEnv and PasswordEnv if set.
+type FlashArrayConfig struct {
+	// Name is the name of the target, and the array label if Array is empty
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	Array   string `json:"array"`
+
+	APIToken    string `json:"api_token"`
+	APITokenEnv string `json:"api_token_env"`
+	Username    string `json:"username"`
+	Password    string `json:"password"`
+	PasswordEnv string `json:"password_env"`
+
+	VerifyHTTPS bool   `json:"verify_https"`
+	CAFile      string `json:"ca_file"`
+	Fingerprint string `json:"fingerprint"`
+
+	Groups      []string `json:"groups"`
+	Concurrency int      `json:"concurrency"`
+}
+
+// Pure1Config struct for a Pure1 target of a Config
+type Pure1Config struct {
+	Name           string `json:"name"`
+	AppID          string `json:"app_id"`
+	PrivateKeyFile string `json:"private_key_file"`
+	// BaseURL is the URL of the Pure1 API, the default of pure1 if empty
+	BaseURL string `json:"base_url"`
+
+	Metrics     []string `json:"metrics"`
+	Resolution  Duration `json:"resolution"`
+	Aggregation string   `json:"aggregation"`
+}
+
+// Duration is a time.Duration read from JSON as a string, i.e. "30s"
+type Duration time.Duration
+
+// UnmarshalJSON parses a duration string.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("exporter: duration must be a string, i.e. \"30s\"")
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("exporter: %s", err)
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalJSON formats the duration as a string.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// LoadConfig reads and validates the configuration file path.
+func LoadConfig(path string) (*Config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Config{}
+	if err := json.Unmarshal(b, cfg); err != nil {
+		return nil, fmt.Errorf("exporter: parsing %s: %s", path, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Validate checks that the targets have unique names and their settings.
+func (cfg *Config) Validate() error {
+	if cfg.Concurrency < 0 || cfg.Timeout < 0 {
+		return fmt.Errorf("exporter: concurrency and timeout must not be negative")
+	}
+	names := map[string]bool{}
+	checkName := func(name string) error {
+		if name == "" {
+			return fmt.Errorf("exporter: target without name")
+		}
+		if names[name] {
+			return fmt.Errorf("exporter: duplicate target %s", name)
+		}
+		names[name] = true
+		return nil
+	}
+	for _, fa := range cfg.FlashArrays {
+		if err := checkName(fa.Name); err != nil {
+			return err
+		}
+		if fa.Address == "" {
+			return fmt.Errorf("exporter: FlashArray %s without address", fa.Name)
+		}
+		if fa.APIToken == "" && fa.APITokenEnv == "" && fa.Username == "" {
+			return fmt.Errorf("exporter: FlashArray %s without API token or username", fa.Name)
+		}
+		for _, g := range fa.Groups {
+			if !contains(DefaultGroups, g) {
+				return fmt.Errorf("exporter: unknown metric group %s of FlashArray %s", g, fa.Name)
+			}
+		}
+	}
+	for _, p := range cfg.Pure1 {
+		if err := checkName(p.Name); err != nil {
+			return err
+		}
+		if p.AppID == "" || p.PrivateKeyFile == "" {
+			return fmt.Errorf("exporter: Pure1 target %s requires app_id and private_key_file", p.Name)
+		}
+		if p.Aggregation != "" && p.Aggregation != "avg" && p.Aggregation != "max" {
+			return fmt.Errorf("exporter: invalid aggregation %s of Pure1 target %s, must be avg or max", p.Aggregation, p.Name)
+		}
+	}
+	return nil
+}
+
+// Exporter returns the exporter of the targets of the configuration.  The
+// clients of the targets connect on their first scrape.  The flasharray
+// and pure1 options are added to the options of the clients, i.e. for a
+// logger.
+func (cfg *Config) Exporter(faOpts []flasharray.Option, p1Opts []pure1.Option) *Exporter {
+	e := &Exporter{Concurrency: cfg.Concurrency, Timeout: time.Duration(cfg.Timeout)}
+	for _, fa := range cfg.FlashArrays {
+		fa := fa
+		connect := func(ctx context.Context) (*flasharray.Client, error) {
+			o := []flasharray.Option{flasharray.WithTLSConfig(&flasharray.TLSConfig{VerifyHTTPS: fa.VerifyHTTPS, CAFile: fa.CAFile, Fingerprint: fa.Fingerprint})}
+			o = append(o, faOpts...)
+			if token := envOr(fa.APITokenEnv, fa.APIToken); token != "" {
+				o = append(o, flasharray.WithAPIToken(token))
+			} else {
+				o = append(o, flasharray.WithUsernamePassword(fa.Username, envOr(fa.PasswordEnv, fa.Password)))
+			}
+			return flasharray.NewWithContext(ctx, fa.Address, o...)
+		}
+		e.Targets = append(e.Targets, Target{Name: fa.Name, Collector: &FlashArrayCollector{
+			Connect:     connect,
+			Array:       fa.Array,
+			Groups:      fa.Groups,
+			Concurrency: fa.Concurrency,
+		}})
+	}
+	for _, p := range cfg.Pure1 {
+		p := p
+		connect := func(ctx context.Context) (*pure1.Client, error) {
+			key, err := ioutil.ReadFile(p.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			o := p1Opts
+			if p.BaseURL != "" {
+				o = append([]pure1.Option{pure1.WithBaseURL(p.BaseURL)}, p1Opts...)
+			}
+			return pure1.NewWithContext(ctx, p.AppID, key, o...)
+		}
+		e.Targets = append(e.Targets, Target{Name: p.Name, Collector: &Pure1Collector{
+			Connect:     connect,
+			Metrics:     p.Metrics,
+			Resolution:  time.Duration(p.Resolution),
+			Aggregation: p.Aggregation,
+		}})
+	}
+	return e
+}
+
+// envOr returns the value of the environment variable name if set, or
+// value.
+func envOr(name string, value string) string {
+	if name != "" {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return value
+}
@@ -0,0 +1,120 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package exporter
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devans10/go-purestorage/flasharray"
+	"github.com/devans10/go-purestorage/pure1"
+)
+
+func testWriteFile(t *testing.T, name string, content []byte) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := ioutil.WriteFile(path, content, 0600); err != nil {
+		t.Fatalf("error writing %s: %s", name, err)
+	}
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := testWriteFile(t, "config.json", []byte(`{
+		"timeout": "10s",
+		"flasharrays": [
+			{"name": "fa1", "address": "fa1.example.com", "api_token_env": "FA1_API_TOKEN", "groups": ["array", "alerts"]},
+			{"name": "fa2", "address": "fa2.example.com", "username": "pureuser", "password_env": "FA2_PASSWORD"}
+		],
+		"pure1": [
+			{"name": "pure1", "app_id": "pure1:apikey:abc", "private_key_file": "pure1.pem", "resolution": "1m"}
+		]
+	}`))
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("error loading configuration: %s", err)
+	}
+	if time.Duration(cfg.Timeout) != 10*time.Second || time.Duration(cfg.Pure1[0].Resolution) != time.Minute {
+		t.Errorf("wrong durations %s and %s", time.Duration(cfg.Timeout), time.Duration(cfg.Pure1[0].Resolution))
+	}
+	if len(cfg.FlashArrays) != 2 || cfg.FlashArrays[0].Groups[1] != GroupAlerts {
+		t.Errorf("wrong FlashArray targets %+v", cfg.FlashArrays)
+	}
+
+	invalid := map[string]string{
+		"syntax":           `{"flasharrays": [}`,
+		"duration":         `{"timeout": 10}`,
+		"negative timeout": `{"timeout": "-1s"}`,
+		"unnamed target":   `{"flasharrays": [{"address": "fa1", "api_token": "t"}]}`,
+		"duplicate target": `{"flasharrays": [{"name": "fa1", "address": "fa1", "api_token": "t"}], "pure1": [{"name": "fa1", "app_id": "a", "private_key_file": "k"}]}`,
+		"address":          `{"flasharrays": [{"name": "fa1", "api_token": "t"}]}`,
+		"credentials":      `{"flasharrays": [{"name": "fa1", "address": "fa1"}]}`,
+		"group":            `{"flasharrays": [{"name": "fa1", "address": "fa1", "api_token": "t", "groups": ["disks"]}]}`,
+		"private key":      `{"pure1": [{"name": "pure1", "app_id": "a"}]}`,
+		"aggregation":      `{"pure1": [{"name": "pure1", "app_id": "a", "private_key_file": "k", "aggregation": "sum"}]}`,
+		"missing file":     "",
+	}
+	for name, content := range invalid {
+		path := filepath.Join(t.TempDir(), "missing.json")
+		if content != "" {
+			path = testWriteFile(t, "config.json", []byte(content))
+		}
+		if _, err := LoadConfig(path); err == nil {
+			t.Errorf("An Error was NOT raised for the invalid %s", name)
+		}
+	}
+}
+
+// testSetenv sets the environment variable key for the duration of the test.
+func testSetenv(t *testing.T, key string, value string) {
+	prev, ok := os.LookupEnv(key)
+	os.Setenv(key, value)
+	t.Cleanup(func() {
+		if ok {
+			os.Setenv(key, prev)
+		} else {
+			os.Unsetenv(key)
+		}
+	})
+}
+
+func TestConfigExporter(t *testing.T) {
+	fa, _ := testFakeArray(t)
+	p1, _ := testFakePure1(t)
+	testSetenv(t, "FA1_API_TOKEN", fa.APIToken)
+
+	cfg := &Config{
+		FlashArrays: []FlashArrayConfig{
+			{Name: "fa1", Address: fa.Target(), APITokenEnv: "FA1_API_TOKEN", Groups: []string{GroupArray}},
+		},
+		Pure1: []Pure1Config{
+			{Name: "pure1", AppID: p1.AppID, PrivateKeyFile: testWriteFile(t, "pure1.pem", p1.PrivateKey), BaseURL: p1.URL, Metrics: []string{"array_read_iops"}},
+		},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("error validating configuration: %s", err)
+	}
+	e := cfg.Exporter(
+		[]flasharray.Option{flasharray.WithHTTPClient(fa.Client())},
+		[]pure1.Option{pure1.WithHTTPClient(p1.Client())},
+	)
+
+	b := &strings.Builder{}
+	e.Scrape(context.Background()).WriteTo(b)
+	for _, l := range []string{
+		`pure_up{target="fa1"} 1`,
+		`pure_up{target="pure1"} 1`,
+		`purefa_array_capacity_bytes{array="` + fa.ArrayName + `"}`,
+		`pure1_array_read_iops{array="array1"}`,
+	} {
+		if !strings.Contains(b.String(), l) {
+			t.Errorf("metrics do not contain %q:\n%s", l, b.String())
+		}
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+// Package exporter exports the metrics of FlashArrays and of the Pure1 fleet
+// to Prometheus.
+//
+// An Exporter scrapes its targets concurrently when it serves an HTTP
+// request, and writes their metrics in the Prometheus text exposition
+// format.  The metrics of a FlashArray are collected by a
+// FlashArrayCollector, and the fleet metrics of Pure1 by a Pure1Collector.
+// Each target reports whether its scrape succeeded with pure_up.
+//
+//	e := &exporter.Exporter{
+//		Targets: []exporter.Target{
+//			{Name: "fa1", Collector: &exporter.FlashArrayCollector{Client: fa1}},
+//			{Name: "pure1", Collector: &exporter.Pure1Collector{Client: p1}},
+//		},
+//	}
+//	http.Handle("/metrics", e)
+//
+// The targets can also be read from a Config, as by the pure-exporter
+// command.
+package exporter
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults of an Exporter
+const (
+	DefaultConcurrency = 4
+	DefaultTimeout     = 30 * time.Second
+)
+
+// Collector collects the metrics of a target into a metric set.  It adds
+// the metrics it could collect even if it returns an error.
+type Collector interface {
+	Collect(ctx context.Context, m *MetricSet) error
+}
+
+// Logger logs the errors of the scrapes, i.e. a *log.Logger
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// Target struct for a scrape target of an Exporter
+type Target struct {
+	// Name is the value of the target label of the metrics of the scrape
+	Name      string
+	Collector Collector
+}
+
+// Exporter struct for the handler of the Prometheus scrapes of a set of
+// targets
+type Exporter struct {
+	Targets []Target
+
+	// Concurrency is the number of targets scraped at the same time,
+	// DefaultConcurrency if zero
+	Concurrency int
+	// Timeout is the time limit of the scrape of each target,
+	// DefaultTimeout if zero
+	Timeout time.Duration
+	// Logger logs the errors of the scrapes, if set
+	Logger Logger
+
+	mu     sync.Mutex
+	errors map[string]int
+}
+
+// ServeHTTP scrapes the targets and writes their metrics.  The target query
+// parameter selects the targets scraped, by a list of names separated by
+// commas; unknown targets are not found.
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	targets := e.Targets
+	if names := r.URL.Query().Get("target"); names != "" {
+		targets = nil
+		for _, name := range strings.Split(names, ",") {
+			t, ok := e.target(name)
+			if !ok {
+				http.Error(w, "unknown target "+name, http.StatusNotFound)
+				return
+			}
+			targets = append(targets, t)
+		}
+	}
+
+	m := e.scrape(r.Context(), targets)
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+// Scrape scrapes all the targets, and returns their metrics.
+func (e *Exporter) Scrape(ctx context.Context) *MetricSet {
+	return e.scrape(ctx, e.Targets)
+}
+
+func (e *Exporter) target(name string) (Target, bool) {
+	for _, t := range e.Targets {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return Target{}, false
+}
+
+func (e *Exporter) scrape(ctx context.Context, targets []Target) *MetricSet {
+	concurrency := e.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	timeout := e.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	m := NewMetricSet()
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for _, t := range targets {
+		wg.Add(1)
+		go func(t Target) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			tctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			start := time.Now()
+			err := t.Collector.Collect(tctx, m)
+			e.report(m, t.Name, time.Since(start), err)
+		}(t)
+	}
+	wg.Wait()
+	return m
+}
+
+// report adds the metrics of the scrape of the target name.
+func (e *Exporter) report(m *MetricSet, name string, d time.Duration, err error) {
+	e.mu.Lock()
+	if e.errors == nil {
+		e.errors = map[string]int{}
+	}
+	if err != nil {
+		e.errors[name]++
+		if e.Logger != nil {
+			e.Logger.Printf("[error] scrape of %s failed: %s", name, err)
+		}
+	}
+	errors := e.errors[name]
+	e.mu.Unlock()
+
+	up := 1.0
+	if err != nil {
+		up = 0
+	}
+	m.Gauge("pure_up", "Whether the last scrape of the target succeeded.", up, "target", name)
+	m.Gauge("pure_scrape_duration_seconds", "Duration of the last scrape of the target.", d.Seconds(), "target", name)
+	m.Counter("pure_scrape_errors_total", "Number of failed scrapes of the target.", float64(errors), "target", name)
+}
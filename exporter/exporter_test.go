@@ -0,0 +1,104 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package exporter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testCollector is a collector adding the gauge test_value, or failing with
+// err.
+type testCollector struct {
+	value float64
+	err   error
+	calls int32
+	delay time.Duration
+}
+
+func (c *testCollector) Collect(ctx context.Context, m *MetricSet) error {
+	atomic.AddInt32(&c.calls, 1)
+	if c.delay > 0 {
+		select {
+		case <-time.After(c.delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if c.err != nil {
+		return c.err
+	}
+	m.Gauge("test_value", "Value of the test collector.", c.value)
+	return nil
+}
+
+func testScrape(t *testing.T, e *Exporter, url string) (int, string) {
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+	if w.Code == http.StatusOK {
+		if ct := w.Header().Get("Content-Type"); ct != "text/plain; version=0.0.4; charset=utf-8" {
+			t.Errorf("wrong content type %s", ct)
+		}
+	}
+	return w.Code, w.Body.String()
+}
+
+func TestExporterServeHTTP(t *testing.T) {
+	ok := &testCollector{value: 42}
+	failing := &testCollector{err: errors.New("unreachable")}
+	e := &Exporter{Targets: []Target{{Name: "ok", Collector: ok}, {Name: "failing", Collector: failing}}}
+
+	code, body := testScrape(t, e, "/metrics")
+	if code != http.StatusOK {
+		t.Fatalf("scrape returned %d", code)
+	}
+	for _, l := range []string{
+		"test_value 42\n",
+		`pure_up{target="ok"} 1` + "\n",
+		`pure_up{target="failing"} 0` + "\n",
+		`pure_scrape_errors_total{target="ok"} 0` + "\n",
+		`pure_scrape_errors_total{target="failing"} 1` + "\n",
+		"# TYPE pure_scrape_errors_total counter\n",
+	} {
+		if !strings.Contains(body, l) {
+			t.Errorf("metrics do not contain %q:\n%s", l, body)
+		}
+	}
+
+	code, body = testScrape(t, e, "/metrics?target=failing")
+	if code != http.StatusOK {
+		t.Fatalf("scrape of failing returned %d", code)
+	}
+	if strings.Contains(body, `target="ok"`) {
+		t.Errorf("scrape of failing reported ok:\n%s", body)
+	}
+	if !strings.Contains(body, `pure_scrape_errors_total{target="failing"} 2`) {
+		t.Errorf("errors of failing not counted:\n%s", body)
+	}
+	if ok.calls != 1 {
+		t.Errorf("ok scraped %d times, expected 1", ok.calls)
+	}
+
+	if code, _ := testScrape(t, e, "/metrics?target=ok,unknown"); code != http.StatusNotFound {
+		t.Errorf("scrape of an unknown target returned %d, expected %d", code, http.StatusNotFound)
+	}
+}
+
+func TestExporterTimeout(t *testing.T) {
+	slow := &testCollector{value: 1, delay: time.Minute}
+	e := &Exporter{Targets: []Target{{Name: "slow", Collector: slow}}, Timeout: 10 * time.Millisecond}
+
+	b := &strings.Builder{}
+	e.Scrape(context.Background()).WriteTo(b)
+	if !strings.Contains(b.String(), `pure_up{target="slow"} 0`) {
+		t.Errorf("slow target did not time out:\n%s", b.String())
+	}
+}
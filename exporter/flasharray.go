@@ -0,0 +1,320 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/devans10/go-purestorage/flasharray"
+)
+
+// Groups of the metrics of a FlashArrayCollector
+const (
+	GroupArray      = "array"
+	GroupVolumes    = "volumes"
+	GroupHosts      = "hosts"
+	GroupHostgroups = "hostgroups"
+	GroupHardware   = "hardware"
+	GroupAlerts     = "alerts"
+)
+
+// DefaultGroups are the metric groups collected by default
+var DefaultGroups = []string{GroupArray, GroupVolumes, GroupHosts, GroupHostgroups, GroupHardware, GroupAlerts}
+
+// alertSeverities are the severities of the open alerts counted, reported
+// even if there are no alerts
+var alertSeverities = []string{flasharray.AlertSeverityInfo, flasharray.AlertSeverityWarning, flasharray.AlertSeverityCritical}
+
+// FlashArrayCollector struct for the collector of the metrics of a
+// FlashArray: the performance and space of the array, its volumes, hosts and
+// host groups, the status of its hardware and its open alerts.  The metrics
+// are labelled with the name of the array.
+type FlashArrayCollector struct {
+	// Client is the client of the array.  If nil, it is created with
+	// Connect by the first successful scrape, so that an exporter can start
+	// while the array is unreachable.
+	Client  *flasharray.Client
+	Connect func(ctx context.Context) (*flasharray.Client, error)
+
+	// Array is the value of the array label, the name of the array if empty
+	Array string
+	// Groups are the metric groups collected, DefaultGroups if empty
+	Groups []string
+	// Concurrency is the number of volumes monitored at the same time,
+	// DefaultConcurrency if zero
+	Concurrency int
+
+	mu sync.Mutex
+}
+
+// Validate checks the metric groups of the collector.
+func (f *FlashArrayCollector) Validate() error {
+	if f.Client == nil && f.Connect == nil {
+		return fmt.Errorf("exporter: FlashArrayCollector requires a Client or Connect")
+	}
+	for _, g := range f.Groups {
+		if !contains(DefaultGroups, g) {
+			return fmt.Errorf("exporter: unknown metric group %s", g)
+		}
+	}
+	return nil
+}
+
+// Collect collects the metrics of the array.  The groups failing to be
+// collected are skipped, and the first error is returned.
+func (f *FlashArrayCollector) Collect(ctx context.Context, m *MetricSet) error {
+	if err := f.Validate(); err != nil {
+		return err
+	}
+	c, err := f.client(ctx)
+	if err != nil {
+		return err
+	}
+	c = c.WithContext(ctx)
+
+	array, err := c.Array.Get(nil)
+	if err != nil {
+		return err
+	}
+	name := f.Array
+	if name == "" {
+		name = array.ArrayName
+	}
+	m.Gauge("purefa_info", "Information about the FlashArray.", 1, "array", name, "id", array.ID, "version", array.Version, "revision", array.Revision)
+
+	groups := f.Groups
+	if len(groups) == 0 {
+		groups = DefaultGroups
+	}
+	collectors := map[string]func(*flasharray.Client, *MetricSet, string) error{
+		GroupArray:      f.collectArray,
+		GroupVolumes:    f.collectVolumes,
+		GroupHosts:      f.collectHosts,
+		GroupHostgroups: f.collectHostgroups,
+		GroupHardware:   f.collectHardware,
+		GroupAlerts:     f.collectAlerts,
+	}
+	var first error
+	for _, g := range groups {
+		if err := collectors[g](c, m, name); err != nil && first == nil {
+			first = fmt.Errorf("exporter: collecting %s of %s: %s", g, name, err)
+		}
+	}
+	return first
+}
+
+// client returns the client of the array, connecting it if needed.
+func (f *FlashArrayCollector) client(ctx context.Context) (*flasharray.Client, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Client == nil {
+		c, err := f.Connect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		f.Client = c
+	}
+	return f.Client, nil
+}
+
+func (f *FlashArrayCollector) collectArray(c *flasharray.Client, m *MetricSet, array string) error {
+	perf, err := c.Array.GetPerformanceHistory("")
+	if err != nil {
+		return err
+	}
+	if len(perf) > 0 {
+		s := perf[len(perf)-1]
+		performanceMetrics(m, "purefa_array", "the array", s, &s.QueueDepth, "array", array)
+	}
+
+	space, err := c.Array.GetSpaceHistory("")
+	if err != nil {
+		return err
+	}
+	if len(space) > 0 {
+		s := space[len(space)-1]
+		m.Gauge("purefa_array_capacity_bytes", "Usable capacity of the array.", float64(s.Capacity), "array", array)
+		m.Gauge("purefa_array_provisioned_bytes", "Provisioned size of the volumes of the array.", float64(s.Provisioned), "array", array)
+		m.Gauge("purefa_array_used_bytes", "Physical space used on the array.", float64(s.Total), "array", array)
+		for typ, v := range map[string]int{"volumes": s.Volumes, "snapshots": s.Snapshots, "shared": s.SharedSpace, "system": s.System} {
+			m.Gauge("purefa_array_space_bytes", "Physical space used on the array, by type.", float64(v), "array", array, "type", typ)
+		}
+		m.Gauge("purefa_array_data_reduction_ratio", "Data reduction ratio of the array.", s.DataReduction, "array", array)
+		m.Gauge("purefa_array_total_reduction_ratio", "Total reduction ratio of the array, including thin provisioning.", s.TotalReduction, "array", array)
+	}
+	return nil
+}
+
+func (f *FlashArrayCollector) collectVolumes(c *flasharray.Client, m *MetricSet, array string) error {
+	volumes, err := c.Volumes.ListVolumes(map[string]string{"space": "true"})
+	if err != nil {
+		return err
+	}
+	for _, v := range volumes {
+		m.Gauge("purefa_volume_size_bytes", "Provisioned size of the volume.", float64(v.Size), "array", array, "volume", v.Name)
+		m.Gauge("purefa_volume_used_bytes", "Physical space used by the volume.", float64(intValue(v.Total)), "array", array, "volume", v.Name)
+		m.Gauge("purefa_volume_snapshots_bytes", "Physical space used by the snapshots of the volume.", float64(intValue(v.Snapshots)), "array", array, "volume", v.Name)
+		m.Gauge("purefa_volume_data_reduction_ratio", "Data reduction ratio of the volume.", floatValue(v.DataReduction), "array", array, "volume", v.Name)
+	}
+
+	concurrency := f.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	names := make(chan string)
+	errs := make(chan error, len(volumes))
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for name := range names {
+				l, err := c.Volumes.MonitorVolume(name, nil)
+				if err != nil {
+					errs <- err
+					continue
+				}
+				for _, v := range l {
+					performanceMetrics(m, "purefa_volume", "the volume", volumeSample(v), nil, "array", array, "volume", name)
+				}
+			}
+		}()
+	}
+	for _, v := range volumes {
+		names <- v.Name
+	}
+	close(names)
+	wg.Wait()
+	close(errs)
+	return <-errs
+}
+
+func (f *FlashArrayCollector) collectHosts(c *flasharray.Client, m *MetricSet, array string) error {
+	hosts, err := c.Hosts.ListHosts(map[string]string{"action": "monitor"})
+	if err != nil {
+		return err
+	}
+	for _, h := range hosts {
+		s := flasharray.PerformanceSample{
+			ReadsPerSec: intValue(h.ReadsPerSec), WritesPerSec: intValue(h.WritesPerSec),
+			InputPerSec: intValue(h.InputPerSec), OutputPerSec: intValue(h.OutputPerSec),
+			UsecPerReadOp: intValue(h.UsecPerReadOp), UsecPerWriteOp: intValue(h.UsecPerWriteOp),
+		}
+		performanceMetrics(m, "purefa_host", "the host", s, h.QueueDepth, "array", array, "host", h.Name)
+	}
+	return nil
+}
+
+func (f *FlashArrayCollector) collectHostgroups(c *flasharray.Client, m *MetricSet, array string) error {
+	hgroups, err := c.Hostgroups.ListHostgroups(map[string]string{"action": "monitor"})
+	if err != nil {
+		return err
+	}
+	for _, g := range hgroups {
+		s := flasharray.PerformanceSample{
+			ReadsPerSec: intValue(g.ReadsPerSec), WritesPerSec: intValue(g.WritesPerSec),
+			InputPerSec: intValue(g.InputPerSec), OutputPerSec: intValue(g.OutputPerSec),
+			UsecPerReadOp: intValue(g.UsecPerReadOp), UsecPerWriteOp: intValue(g.UsecPerWriteOp),
+		}
+		performanceMetrics(m, "purefa_hostgroup", "the host group", s, g.QueueDepth, "array", array, "hostgroup", g.Name)
+	}
+	return nil
+}
+
+func (f *FlashArrayCollector) collectHardware(c *flasharray.Client, m *MetricSet, array string) error {
+	components, err := c.Hardware.ListHardware()
+	if err != nil {
+		return err
+	}
+	for _, h := range components {
+		m.Gauge("purefa_hardware_status", "Status of the hardware component, 1 for the status reported.", 1, "array", array, "component", h.Name, "status", h.Status)
+		healthy := 0.0
+		if h.Status == flasharray.HardwareStatusOK || h.Status == flasharray.HardwareStatusNotInstalled {
+			healthy = 1
+		}
+		m.Gauge("purefa_hardware_healthy", "Whether the hardware component is ok, or not installed.", healthy, "array", array, "component", h.Name)
+		if h.Temperature != nil {
+			m.Gauge("purefa_hardware_temperature_celsius", "Temperature of the hardware component.", float64(*h.Temperature), "array", array, "component", h.Name)
+		}
+	}
+
+	drives, err := c.Hardware.ListDrives()
+	if err != nil {
+		return err
+	}
+	for _, d := range drives {
+		m.Gauge("purefa_drive_status", "Status of the drive, 1 for the status reported.", 1, "array", array, "drive", d.Name, "status", d.Status, "type", d.Type)
+		m.Gauge("purefa_drive_capacity_bytes", "Capacity of the drive.", float64(d.Capacity), "array", array, "drive", d.Name)
+	}
+	return nil
+}
+
+func (f *FlashArrayCollector) collectAlerts(c *flasharray.Client, m *MetricSet, array string) error {
+	messages, err := c.Messages.ListMessages(map[string]string{"open": "true"})
+	if err != nil {
+		return err
+	}
+	counts := map[string]int{}
+	for _, s := range alertSeverities {
+		counts[s] = 0
+	}
+	for _, msg := range messages {
+		counts[msg.CurrentSeverity]++
+	}
+	for s, n := range counts {
+		m.Gauge("purefa_alerts_open", "Number of open alerts of the array, by severity.", float64(n), "array", array, "severity", s)
+	}
+	return nil
+}
+
+// performanceMetrics adds the performance metrics of the sample s of an
+// object, described as what, to m.  The queue depth is only added if the
+// array returned it, i.e. not for volumes.
+func performanceMetrics(m *MetricSet, prefix string, what string, s flasharray.PerformanceSample, queueDepth *int, labels ...string) {
+	m.Gauge(prefix+"_reads_per_second", "Read operations per second of "+what+".", float64(s.ReadsPerSec), labels...)
+	m.Gauge(prefix+"_writes_per_second", "Write operations per second of "+what+".", float64(s.WritesPerSec), labels...)
+	m.Gauge(prefix+"_read_bytes_per_second", "Bytes read per second from "+what+".", float64(s.OutputPerSec), labels...)
+	m.Gauge(prefix+"_write_bytes_per_second", "Bytes written per second to "+what+".", float64(s.InputPerSec), labels...)
+	m.Gauge(prefix+"_read_latency_seconds", "Average latency of the reads of "+what+".", float64(s.UsecPerReadOp)/1e6, labels...)
+	m.Gauge(prefix+"_write_latency_seconds", "Average latency of the writes of "+what+".", float64(s.UsecPerWriteOp)/1e6, labels...)
+	if queueDepth != nil {
+		m.Gauge(prefix+"_queue_depth", "Average number of queued operations of "+what+".", float64(*queueDepth), labels...)
+	}
+}
+
+// volumeSample returns the performance sample of a volume returned by
+// MonitorVolume.
+func volumeSample(v flasharray.Volume) flasharray.PerformanceSample {
+	return flasharray.PerformanceSample{
+		ReadsPerSec: intValue(v.ReadsPerSec), WritesPerSec: intValue(v.WritesPerSec),
+		InputPerSec: intValue(v.InputPerSec), OutputPerSec: intValue(v.OutputPerSec),
+		UsecPerReadOp: intValue(v.UsecPerReadOp), UsecPerWriteOp: intValue(v.UsecPerWriteOp),
+	}
+}
+
+func intValue(p *int) int {
+	if p == nil {
+		return 0
+	}
+	return *p
+}
+
+func floatValue(p *float64) float64 {
+	if p == nil {
+		return 0
+	}
+	return *p
+}
+
+func contains(l []string, s string) bool {
+	for _, e := range l {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
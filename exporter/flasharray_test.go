@@ -0,0 +1,134 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package exporter
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/devans10/go-purestorage/flasharray"
+	"github.com/devans10/go-purestorage/flasharray/flasharraytest"
+)
+
+func testFakeArray(t *testing.T) (*flasharraytest.Server, *flasharray.Client) {
+	s := flasharraytest.NewServer()
+	t.Cleanup(s.Close)
+
+	c, err := flasharray.New(s.Target(), flasharray.WithAPIToken(s.APIToken), flasharray.WithHTTPClient(s.Client()))
+	if err != nil {
+		t.Fatalf("error setting up client of the fake array: %s", err)
+	}
+	return s, c
+}
+
+func testCollect(t *testing.T, c Collector) string {
+	m := NewMetricSet()
+	if err := c.Collect(context.Background(), m); err != nil {
+		t.Fatalf("error collecting metrics: %s", err)
+	}
+	b := &strings.Builder{}
+	m.WriteTo(b)
+	return b.String()
+}
+
+func TestFlashArrayCollector(t *testing.T) {
+	s, c := testFakeArray(t)
+	for _, name := range []string{"vol1", "vol2"} {
+		if _, err := c.Volumes.CreateVolume(name, 1<<30); err != nil {
+			t.Fatalf("error creating volume %s: %s", name, err)
+		}
+	}
+	s.SetVolumeIO("vol1", 100, 50)
+	if _, err := c.Hosts.CreateHost("host1", nil); err != nil {
+		t.Fatalf("error creating host: %s", err)
+	}
+	if _, err := c.Hostgroups.CreateHostgroup("hgroup1", nil); err != nil {
+		t.Fatalf("error creating host group: %s", err)
+	}
+	s.SetHardwareStatus("CT0.FAN0", flasharray.HardwareStatusCritical)
+	s.AddAlert("fan failure", flasharray.AlertSeverityCritical, "fan", "CT0.FAN0")
+
+	metrics := testCollect(t, &FlashArrayCollector{Client: c, Array: "fa1", Concurrency: 2})
+	for _, l := range []string{
+		`purefa_info{array="fa1",`,
+		`purefa_array_capacity_bytes{array="fa1"} `,
+		`purefa_array_reads_per_second{array="fa1"} `,
+		`purefa_array_space_bytes{array="fa1",type="snapshots"} `,
+		`purefa_volume_size_bytes{array="fa1",volume="vol1"} 1.073741824e+09`,
+		`purefa_volume_reads_per_second{array="fa1",volume="vol1"} 100`,
+		`purefa_volume_writes_per_second{array="fa1",volume="vol1"} 50`,
+		`purefa_volume_reads_per_second{array="fa1",volume="vol2"} 0`,
+		`purefa_host_reads_per_second{array="fa1",host="host1"} `,
+		`purefa_hostgroup_reads_per_second{array="fa1",hostgroup="hgroup1"} `,
+		`purefa_hardware_status{array="fa1",component="CT0.FAN0",status="critical"} 1`,
+		`purefa_hardware_healthy{array="fa1",component="CT0.FAN0"} 0`,
+		`purefa_hardware_healthy{array="fa1",component="CT1.FAN0"} 1`,
+		`purefa_drive_status{array="fa1",drive="CH0.BAY0",status="ok",type="SSD"} 1`,
+		`purefa_alerts_open{array="fa1",severity="critical"} 1`,
+		`purefa_alerts_open{array="fa1",severity="warning"} 0`,
+	} {
+		if !strings.Contains(metrics, l) {
+			t.Errorf("metrics do not contain %q:\n%s", l, metrics)
+		}
+	}
+
+	// The queue depth is only exported if the array returned it
+	if !strings.Contains(metrics, `purefa_host_queue_depth{array="fa1",host="host1"} 0`) {
+		t.Errorf("host queue depth not collected:\n%s", metrics)
+	}
+	if strings.Contains(metrics, "purefa_volume_queue_depth") {
+		t.Errorf("volume queue depth collected, which volumes do not return:\n%s", metrics)
+	}
+	m := NewMetricSet()
+	performanceMetrics(m, "purefa_host", "the host", flasharray.PerformanceSample{}, nil, "host", "host2")
+	b := &strings.Builder{}
+	m.WriteTo(b)
+	if strings.Contains(b.String(), "queue_depth") {
+		t.Errorf("queue depth exported without a value:\n%s", b.String())
+	}
+}
+
+func TestFlashArrayCollectorGroups(t *testing.T) {
+	s, c := testFakeArray(t)
+
+	metrics := testCollect(t, &FlashArrayCollector{Client: c, Groups: []string{GroupAlerts}})
+	if !strings.Contains(metrics, `purefa_alerts_open{array="`+s.ArrayName+`",severity="info"} 0`) {
+		t.Errorf("alerts not collected:\n%s", metrics)
+	}
+	if strings.Contains(metrics, "purefa_hardware_") || strings.Contains(metrics, "purefa_array_") {
+		t.Errorf("groups not selected collected:\n%s", metrics)
+	}
+
+	invalid := []*FlashArrayCollector{
+		{},
+		{Client: c, Groups: []string{"disks"}},
+	}
+	for _, f := range invalid {
+		if err := f.Validate(); err == nil {
+			t.Errorf("An Error was NOT raised for collector %+v", f)
+		}
+	}
+}
+
+func TestFlashArrayCollectorConnect(t *testing.T) {
+	s, c := testFakeArray(t)
+	connects := 0
+	f := &FlashArrayCollector{
+		Connect: func(ctx context.Context) (*flasharray.Client, error) {
+			connects++
+			return c, nil
+		},
+		Groups: []string{GroupArray},
+	}
+	testCollect(t, f)
+	metrics := testCollect(t, f)
+	if connects != 1 {
+		t.Errorf("collector connected %d times, expected 1", connects)
+	}
+	if !strings.Contains(metrics, `purefa_array_capacity_bytes{array="`+s.ArrayName+`"}`) {
+		t.Errorf("array metrics not collected:\n%s", metrics)
+	}
+}
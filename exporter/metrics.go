@@ -0,0 +1,191 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package exporter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Types of the metric families
+const (
+	Gauge   = "gauge"
+	Counter = "counter"
+)
+
+var (
+	metricNameRegexp = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelNameRegexp  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// MetricSet struct for the metric families collected by a scrape, written
+// in the Prometheus text exposition format with WriteTo.  It is safe for
+// concurrent use by the collectors of the targets.
+type MetricSet struct {
+	mu       sync.Mutex
+	families map[string]*family
+}
+
+type family struct {
+	name    string
+	help    string
+	typ     string
+	samples []sample
+}
+
+type sample struct {
+	labels []string
+	value  float64
+}
+
+// NewMetricSet returns an empty metric set.
+func NewMetricSet() *MetricSet {
+	return &MetricSet{families: map[string]*family{}}
+}
+
+// Gauge adds a sample of the gauge name.  labels are the names and values of
+// the labels of the sample, in pairs, i.e. "array", "fa1", "volume", "vol1".
+func (s *MetricSet) Gauge(name string, help string, value float64, labels ...string) {
+	s.add(Gauge, name, help, value, labels)
+}
+
+// Counter adds a sample of the counter name, with labels as of Gauge.
+func (s *MetricSet) Counter(name string, help string, value float64, labels ...string) {
+	s.add(Counter, name, help, value, labels)
+}
+
+// add adds a sample to the family name.  It panics if the name, the labels
+// or the type of the family are invalid, which are errors of the collectors.
+func (s *MetricSet) add(typ string, name string, help string, value float64, labels []string) {
+	if !metricNameRegexp.MatchString(name) {
+		panic("exporter: invalid metric name " + name)
+	}
+	if len(labels)%2 != 0 {
+		panic("exporter: odd number of label names and values of " + name)
+	}
+	for i := 0; i < len(labels); i += 2 {
+		if !labelNameRegexp.MatchString(labels[i]) || strings.HasPrefix(labels[i], "__") {
+			panic("exporter: invalid label name " + labels[i] + " of " + name)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, ok := s.families[name]
+	if !ok {
+		f = &family{name: name, help: help, typ: typ}
+		s.families[name] = f
+	}
+	if f.typ != typ {
+		panic(fmt.Sprintf("exporter: metric %s is a %s, not a %s", name, f.typ, typ))
+	}
+	f.samples = append(f.samples, sample{labels: append([]string(nil), labels...), value: value})
+}
+
+// Len returns the number of samples of the set.
+func (s *MetricSet) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, f := range s.families {
+		n += len(f.samples)
+	}
+	return n
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format,
+// version 0.0.4, the families sorted by name and their samples by labels.
+func (s *MetricSet) WriteTo(w io.Writer) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := make([]string, 0, len(s.families))
+	for name := range s.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	cw := &countWriter{w: bufio.NewWriter(w)}
+	for _, name := range names {
+		f := s.families[name]
+		if f.help != "" {
+			fmt.Fprintf(cw, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+		}
+		fmt.Fprintf(cw, "# TYPE %s %s\n", f.name, f.typ)
+
+		lines := make([]string, len(f.samples))
+		for i, smp := range f.samples {
+			lines[i] = f.name + formatLabels(smp.labels) + " " + formatValue(smp.value) + "\n"
+		}
+		sort.Strings(lines)
+		for _, l := range lines {
+			io.WriteString(cw, l)
+		}
+	}
+	if cw.err != nil {
+		return cw.n, cw.err
+	}
+	return cw.n, cw.w.(*bufio.Writer).Flush()
+}
+
+func formatLabels(labels []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	l := make([]string, 0, len(labels)/2)
+	for i := 0; i < len(labels); i += 2 {
+		l = append(l, labels[i]+`="`+escapeLabelValue(labels[i+1])+`"`)
+	}
+	return "{" + strings.Join(l, ",") + "}"
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpReplacer       = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelValueReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string {
+	return helpReplacer.Replace(s)
+}
+
+func escapeLabelValue(s string) string {
+	return labelValueReplacer.Replace(s)
+}
+
+// countWriter counts the bytes written to w, and keeps the first error.
+type countWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
@@ -0,0 +1,68 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package exporter
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestMetricSetWriteTo(t *testing.T) {
+	m := NewMetricSet()
+	m.Gauge("b_gauge", "A gauge\\with \"help\"\nlines.", 2, "volume", "vol2")
+	m.Gauge("b_gauge", "A gauge\\with \"help\"\nlines.", 1.5, "volume", "vol\"1\"\n")
+	m.Counter("a_total", "", 3)
+	m.Gauge("c_gauge", "Special values.", math.NaN(), "v", "nan")
+	m.Gauge("c_gauge", "Special values.", math.Inf(1), "v", "pinf")
+	m.Gauge("c_gauge", "Special values.", math.Inf(-1), "v", "ninf")
+
+	expected := `# TYPE a_total counter
+a_total 3
+# HELP b_gauge A gauge\\with "help"\nlines.
+# TYPE b_gauge gauge
+b_gauge{volume="vol2"} 2
+b_gauge{volume="vol\"1\"\n"} 1.5
+# HELP c_gauge Special values.
+# TYPE c_gauge gauge
+c_gauge{v="nan"} NaN
+c_gauge{v="ninf"} -Inf
+c_gauge{v="pinf"} +Inf
+`
+	b := &bytes.Buffer{}
+	n, err := m.WriteTo(b)
+	if err != nil {
+		t.Fatalf("error writing metrics: %s", err)
+	}
+	if b.String() != expected {
+		t.Errorf("wrong metrics:\n%s\nexpected:\n%s", b.String(), expected)
+	}
+	if n != int64(b.Len()) {
+		t.Errorf("wrote %d bytes, returned %d", b.Len(), n)
+	}
+	if m.Len() != 6 {
+		t.Errorf("metric set has %d samples, expected 6", m.Len())
+	}
+}
+
+func TestMetricSetInvalid(t *testing.T) {
+	invalid := map[string]func(m *MetricSet){
+		"metric name":       func(m *MetricSet) { m.Gauge("0metric", "", 1) },
+		"label name":        func(m *MetricSet) { m.Gauge("metric", "", 1, "a-b", "c") },
+		"reserved label":    func(m *MetricSet) { m.Gauge("metric", "", 1, "__name", "c") },
+		"odd labels":        func(m *MetricSet) { m.Gauge("metric", "", 1, "volume") },
+		"conflicting types": func(m *MetricSet) { m.Gauge("metric", "", 1); m.Counter("metric", "", 1) },
+	}
+	for name, f := range invalid {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("A panic was NOT raised for the invalid %s", name)
+				}
+			}()
+			f(NewMetricSet())
+		}()
+	}
+}
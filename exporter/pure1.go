@@ -0,0 +1,197 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/devans10/go-purestorage/pure1"
+)
+
+// Defaults of a Pure1Collector
+const (
+	DefaultPure1Resolution  = 5 * time.Minute
+	DefaultPure1Aggregation = "avg"
+)
+
+// DefaultPure1Metrics are the Pure1 array metrics collected by default
+var DefaultPure1Metrics = []string{
+	"array_read_iops",
+	"array_write_iops",
+	"array_read_bandwidth",
+	"array_write_bandwidth",
+	"array_read_latency_us",
+	"array_write_latency_us",
+	"array_total_load",
+	"array_total_capacity",
+	"array_effective_used_space",
+	"array_data_reduction",
+}
+
+// metricNameInvalidRegexp matches the characters of Pure1 metric names not
+// valid in Prometheus metric names.
+var metricNameInvalidRegexp = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// Pure1Collector struct for the collector of the fleet metrics of the
+// arrays of Pure1.  Each Pure1 metric, i.e. array_read_iops, is exported as
+// the gauge pure1_array_read_iops, labelled with the name of the array, with
+// its latest value at the resolution of the collector.
+type Pure1Collector struct {
+	// Client is the client of Pure1.  If nil, it is created with Connect by
+	// the first successful scrape.
+	Client  *pure1.Client
+	Connect func(ctx context.Context) (*pure1.Client, error)
+
+	// Metrics are the names of the array metrics collected,
+	// DefaultPure1Metrics if empty
+	Metrics []string
+	// Resolution is the resolution of the metrics, DefaultPure1Resolution
+	// if zero, and Aggregation their aggregation, avg or max,
+	// DefaultPure1Aggregation if empty
+	Resolution  time.Duration
+	Aggregation string
+
+	// now returns the end of the time range of the metrics, time.Now if nil
+	now func() time.Time
+
+	mu sync.Mutex
+}
+
+// Validate checks the metrics and their resolution and aggregation.
+func (p *Pure1Collector) Validate() error {
+	if p.Client == nil && p.Connect == nil {
+		return fmt.Errorf("exporter: Pure1Collector requires a Client or Connect")
+	}
+	if p.Resolution < 0 || p.Resolution%time.Millisecond != 0 {
+		return fmt.Errorf("exporter: invalid Pure1 resolution %s", p.Resolution)
+	}
+	if p.Aggregation != "" && p.Aggregation != "avg" && p.Aggregation != "max" {
+		return fmt.Errorf("exporter: invalid Pure1 aggregation %s, must be avg or max", p.Aggregation)
+	}
+	for _, name := range p.Metrics {
+		if name == "" {
+			return fmt.Errorf("exporter: empty Pure1 metric name")
+		}
+	}
+	return nil
+}
+
+// Collect collects the metrics of the arrays of Pure1.  The metrics failing
+// to be collected are skipped, and the first error is returned.
+func (p *Pure1Collector) Collect(ctx context.Context, m *MetricSet) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	c, err := p.client(ctx)
+	if err != nil {
+		return err
+	}
+	c = c.WithContext(ctx)
+
+	arrays, err := c.Arrays.ListAll(nil)
+	if err != nil {
+		return err
+	}
+	if len(arrays) == 0 {
+		return nil
+	}
+	ids := make([]string, len(arrays))
+	names := map[string]string{}
+	for i, a := range arrays {
+		ids[i] = a.ID
+		names[a.ID] = a.Name
+		m.Gauge("pure1_array_info", "Information about the array in Pure1.", 1, "array", a.Name, "id", a.ID, "model", a.Model, "os", a.OS, "version", a.Version)
+	}
+	resourceIDs, err := (&pure1.Query{IDs: ids}).Params(c.RestVersion)
+	if err != nil {
+		return err
+	}
+
+	metrics := p.Metrics
+	if len(metrics) == 0 {
+		metrics = DefaultPure1Metrics
+	}
+	resolution := p.Resolution
+	if resolution == 0 {
+		resolution = DefaultPure1Resolution
+	}
+	aggregation := p.Aggregation
+	if aggregation == "" {
+		aggregation = DefaultPure1Aggregation
+	}
+	now := time.Now
+	if p.now != nil {
+		now = p.now
+	}
+	end := int(now().UnixNano() / int64(time.Millisecond))
+	start := end - int(4*resolution/time.Millisecond)
+
+	var first error
+	for _, metric := range metrics {
+		params := map[string]string{"names": "'" + metric + "'", "resource_ids": resourceIDs["ids"]}
+		history, err := c.Metrics.GetMetricHistory(aggregation, start, end, int(resolution/time.Millisecond), params)
+		if err != nil {
+			if first == nil {
+				first = fmt.Errorf("exporter: collecting Pure1 metric %s: %s", metric, err)
+			}
+			continue
+		}
+		name := "pure1_" + metricNameInvalidRegexp.ReplaceAllString(metric, "_")
+		for _, h := range history {
+			v, ok := latestValue(h.Data)
+			if !ok {
+				continue
+			}
+			for _, r := range h.Resources {
+				id, _ := r.(map[string]interface{})["id"].(string)
+				array := names[id]
+				if array == "" {
+					array, _ = r.(map[string]interface{})["name"].(string)
+				}
+				m.Gauge(name, fmt.Sprintf("Pure1 metric %s, in %s.", metric, h.Unit), v, "array", array)
+			}
+		}
+	}
+	return first
+}
+
+// client returns the client of Pure1, connecting it if needed.
+func (p *Pure1Collector) client(ctx context.Context) (*pure1.Client, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.Client == nil {
+		c, err := p.Connect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		p.Client = c
+	}
+	return p.Client, nil
+}
+
+// latestValue returns the value of the latest data point of a metric, the
+// data points being [timestamp, value] pairs.  Points without a value are
+// skipped.
+func latestValue(data []interface{}) (float64, bool) {
+	var latest float64
+	var value float64
+	ok := false
+	for _, d := range data {
+		point, _ := d.([]interface{})
+		if len(point) != 2 {
+			continue
+		}
+		t, tok := point[0].(float64)
+		v, vok := point[1].(float64)
+		if tok && vok && (!ok || t >= latest) {
+			latest, value, ok = t, v, true
+		}
+	}
+	return value, ok
+}
@@ -0,0 +1,83 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package exporter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devans10/go-purestorage/pure1"
+	"github.com/devans10/go-purestorage/pure1/pure1test"
+)
+
+func testFakePure1(t *testing.T) (*pure1test.Server, *pure1.Client) {
+	s := pure1test.NewServer()
+	t.Cleanup(s.Close)
+	s.Add(pure1test.Arrays, map[string]interface{}{"name": "array1", "model": "FA-X70R3", "os": "Purity//FA", "version": "6.1.0"})
+
+	c, err := pure1.New(s.AppID, s.PrivateKey, pure1.WithBaseURL(s.URL), pure1.WithHTTPClient(s.Client()))
+	if err != nil {
+		t.Fatalf("error setting up client of the fake Pure1 API: %s", err)
+	}
+	return s, c
+}
+
+func TestPure1Collector(t *testing.T) {
+	s, c := testFakePure1(t)
+	s.Add(pure1test.Arrays, map[string]interface{}{"name": "array2", "model": "FA-C60", "os": "Purity//FA", "version": "6.2.0"})
+	s.MetricValue = func(metric string, resourceID string, ts time.Time) float64 {
+		if metric == "array_read_iops" {
+			return 42
+		}
+		return 1.5
+	}
+
+	metrics := testCollect(t, &Pure1Collector{Client: c, Metrics: []string{"array_read_iops", "array_data_reduction"}, Aggregation: "max"})
+	for _, l := range []string{
+		`pure1_array_info{array="array1",`,
+		`pure1_array_info{array="array2",`,
+		`pure1_array_read_iops{array="array1"} 42`,
+		`pure1_array_read_iops{array="array2"} 42`,
+		`pure1_array_data_reduction{array="array2"} 1.5`,
+	} {
+		if !strings.Contains(metrics, l) {
+			t.Errorf("metrics do not contain %q:\n%s", l, metrics)
+		}
+	}
+	if strings.Contains(metrics, "pure1_array_write_iops") {
+		t.Errorf("metrics not selected collected:\n%s", metrics)
+	}
+}
+
+func TestPure1CollectorValidate(t *testing.T) {
+	_, c := testFakePure1(t)
+	invalid := []*Pure1Collector{
+		{},
+		{Client: c, Aggregation: "sum"},
+		{Client: c, Resolution: -time.Minute},
+		{Client: c, Resolution: time.Microsecond},
+		{Client: c, Metrics: []string{""}},
+	}
+	for _, p := range invalid {
+		if err := p.Validate(); err == nil {
+			t.Errorf("An Error was NOT raised for collector %+v", p)
+		}
+	}
+}
+
+func TestLatestValue(t *testing.T) {
+	data := []interface{}{
+		[]interface{}{float64(2000), float64(2)},
+		[]interface{}{float64(3000), nil},
+		[]interface{}{float64(1000), float64(1)},
+	}
+	if v, ok := latestValue(data); !ok || v != 2 {
+		t.Errorf("latest value is %v, %t, expected 2", v, ok)
+	}
+	if _, ok := latestValue(nil); ok {
+		t.Errorf("latest value of no data points found")
+	}
+}
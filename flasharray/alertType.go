@@ -9,3 +9,18 @@ type Alert struct {
 	Name    string `json:"name,omitempty"`
 	Enabled bool   `json:"enabled,omitempty"`
 }
+
+// SetAlertOptions struct for the changes of an alert recipient made with
+// SetAlert
+type SetAlertOptions struct {
+	// Enabled enables or disables the alert messages sent to the recipient
+	Enabled *bool `json:"enabled,omitempty"`
+}
+
+// Validate checks that a change is set.
+func (o *SetAlertOptions) Validate() error {
+	if o.Enabled == nil {
+		return &PureError{Reason: "[error] Alert recipient options do not change anything"}
+	}
+	return nil
+}
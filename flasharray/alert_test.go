@@ -86,3 +86,15 @@ func testAccDeleteAlert(address string, c *Client) func(t *testing.T) {
 		}
 	}
 }
+
+func TestListAlerts(t *testing.T) {
+	c := testResponseClient(t, "alert", `[{"name": "admin@example.com", "enabled": true}, {"name": "flasharray-alerts@purestorage.com", "enabled": false}]`)
+
+	l, err := c.Alerts.ListAlerts(nil)
+	if err != nil {
+		t.Fatalf("error listing alert recipients: %s", err)
+	}
+	if len(l) != 2 || l[0].Name != "admin@example.com" || !l[0].Enabled {
+		t.Errorf("expected the alert recipients admin@example.com and flasharray-alerts@purestorage.com; got %+v", l)
+	}
+}
@@ -4,6 +4,10 @@
 
 package flasharray
 
+import (
+	"fmt"
+)
+
 // ArrayService type creates a service to perform functions for administering
 // and querying the flash array itself
 type ArrayService struct {
@@ -84,6 +88,20 @@ func (v *ArrayService) Get(data interface{}) (*Array, error) {
 	return m, err
 }
 
+// GetPerformanceHistory returns the performance samples of the array over
+// the historical window, one of HistoricalWindows, or the current sample if
+// historical is empty
+func (v *ArrayService) GetPerformanceHistory(historical string) (PerformanceHistory, error) {
+	return v.client.getPerformanceHistory("array", historical)
+}
+
+// GetSpaceHistory returns the space samples of the array over the historical
+// window, one of HistoricalWindows, or the current sample if historical is
+// empty
+func (v *ArrayService) GetSpaceHistory(historical string) (SpaceHistory, error) {
+	return v.client.getSpaceHistory("array", historical)
+}
+
 // Set will change the parameter on the array that is passed in the data map
 func (v *ArrayService) Set(data interface{}) (*Array, error) {
 
@@ -254,3 +272,132 @@ func (v *ArrayService) Phonehome(action string) (*Phonehome, error) {
 
 	return m, err
 }
+
+// GetConnectionKey returns the key remote arrays use to connect to the array
+func (v *ArrayService) GetConnectionKey() (*ConnectionKey, error) {
+
+	params := map[string]string{"connection_key": "true"}
+	req, err := v.client.NewRequest("GET", "array", params, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &ConnectionKey{}
+	_, err = v.client.Do(req, m, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, err
+}
+
+// ListArrayConnections lists the arrays connected to the array
+func (v *ArrayService) ListArrayConnections(params map[string]string) ([]ArrayConnection, error) {
+
+	req, err := v.client.NewRequest("GET", "array/connection", params, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	m := []ArrayConnection{}
+	_, err = v.client.Do(req, &m, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, err
+}
+
+// ConnectArray connects the array to a remote array for replication
+//
+// Parameters
+// managementAddress
+// The management address of the remote array
+// connectionKey
+// The connection key of the remote array, see GetConnectionKey
+// replicationTypes
+// ReplicationTypeAsync and/or ReplicationTypeSync.  The array default is used if empty.
+// replicationAddress
+// The replication address of the remote array.  The array discovers it if empty.
+func (v *ArrayService) ConnectArray(managementAddress string, connectionKey string, replicationTypes []string, replicationAddress string) (*ArrayConnection, error) {
+
+	data := map[string]interface{}{"management_address": managementAddress, "connection_key": connectionKey}
+	if len(replicationTypes) > 0 {
+		data["type"] = replicationTypes
+	}
+	if replicationAddress != "" {
+		data["replication_address"] = replicationAddress
+	}
+	req, err := v.client.NewRequest("POST", "array/connection", nil, data)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &ArrayConnection{}
+	_, err = v.client.Do(req, m, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, err
+}
+
+// DisconnectArray disconnects the array from the connected array name
+func (v *ArrayService) DisconnectArray(name string) (*ArrayConnection, error) {
+
+	path := fmt.Sprintf("array/connection/%s", name)
+	req, err := v.client.NewRequest("DELETE", path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &ArrayConnection{}
+	_, err = v.client.Do(req, m, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, err
+}
+
+// ListReplicationThrottles lists the replication throttles of the connected arrays
+func (v *ArrayService) ListReplicationThrottles() ([]ReplicationThrottle, error) {
+
+	params := map[string]string{"throttle": "true"}
+	req, err := v.client.NewRequest("GET", "array/connection", params, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	m := []ReplicationThrottle{}
+	_, err = v.client.Do(req, &m, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, err
+}
+
+// SetReplicationThrottle sets the replication throttle of the connected array
+// name.  The limits and window of the array are replaced by those of throttle,
+// which is validated first; a zero ReplicationThrottle removes the throttle.
+func (v *ArrayService) SetReplicationThrottle(name string, throttle ReplicationThrottle) (*ReplicationThrottle, error) {
+
+	if err := throttle.Validate(); err != nil {
+		return nil, err
+	}
+	throttle.ArrayName = ""
+	path := fmt.Sprintf("array/connection/%s", name)
+	req, err := v.client.NewRequest("PUT", path, nil, throttle)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &ReplicationThrottle{}
+	_, err = v.client.Do(req, m, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, err
+}
@@ -4,6 +4,12 @@
 
 package flasharray
 
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
 // ConsoleLock type console_lock describes the console_lock status of the array.
 type ConsoleLock struct {
 	ConsoleLock string `json:"console_lock"`
@@ -43,3 +49,114 @@ type ArrayConnection struct {
 	Type               []string `json:"type"`
 	ID                 string   `json:"id"`
 }
+
+// Replication types of an array connection
+const (
+	ReplicationTypeAsync = "async-replication"
+	ReplicationTypeSync  = "sync-replication"
+)
+
+// ConnectionKey struct for the key remote arrays use to connect to the array
+type ConnectionKey struct {
+	ConnectionKey string `json:"connection_key"`
+}
+
+// Bounds of the replication bandwidth limits of an array connection, in bytes per second
+const (
+	MinReplicationLimit = 1 << 20
+	MaxReplicationLimit = 4 << 30
+)
+
+// ThrottleWindow is the daily time window in which the window limit of a
+// ReplicationThrottle applies.  Start and End are times of day after midnight,
+// on the hour.  A window ending before it starts spans midnight.
+type ThrottleWindow struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// throttleWindow is the window as sent by the array, in milliseconds.
+type throttleWindow struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+// NewThrottleWindow returns the window from start to end, which are durations
+// after midnight, i.e. NewThrottleWindow(22*time.Hour, 6*time.Hour).
+func NewThrottleWindow(start time.Duration, end time.Duration) *ThrottleWindow {
+	return &ThrottleWindow{Start: start, End: end}
+}
+
+// MarshalJSON encodes the times in milliseconds, as expected by the array.
+func (w ThrottleWindow) MarshalJSON() ([]byte, error) {
+	return json.Marshal(throttleWindow{
+		Start: int64(w.Start / time.Millisecond),
+		End:   int64(w.End / time.Millisecond),
+	})
+}
+
+// UnmarshalJSON decodes the times in milliseconds returned by the array.
+func (w *ThrottleWindow) UnmarshalJSON(b []byte) error {
+	var m throttleWindow
+	if err := json.Unmarshal(b, &m); err != nil {
+		return err
+	}
+	w.Start = time.Duration(m.Start) * time.Millisecond
+	w.End = time.Duration(m.End) * time.Millisecond
+	return nil
+}
+
+// Validate checks that the window starts and ends on the hour, within a day.
+func (w *ThrottleWindow) Validate() error {
+	for _, t := range []time.Duration{w.Start, w.End} {
+		if err := validateTimeOfDay("Throttle window time", t); err != nil {
+			return err
+		}
+	}
+	if w.Start == w.End {
+		return &PureError{Reason: "[error] Throttle window must not start and end at the same time"}
+	}
+	return nil
+}
+
+// ReplicationThrottle limits the replication bandwidth to a connected array.
+// DefaultLimit applies outside of Window, and WindowLimit within it.  The
+// limits are in bytes per second; zero means unlimited.
+type ReplicationThrottle struct {
+	ArrayName    string          `json:"array_name,omitempty"`
+	DefaultLimit int64           `json:"default_limit"`
+	WindowLimit  int64           `json:"window_limit"`
+	Window       *ThrottleWindow `json:"window"`
+}
+
+// MarshalJSON encodes the unlimited limits as null, as expected by the array.
+func (t ReplicationThrottle) MarshalJSON() ([]byte, error) {
+	m := map[string]interface{}{"default_limit": nil, "window_limit": nil, "window": t.Window}
+	if t.ArrayName != "" {
+		m["array_name"] = t.ArrayName
+	}
+	if t.DefaultLimit != 0 {
+		m["default_limit"] = t.DefaultLimit
+	}
+	if t.WindowLimit != 0 {
+		m["window_limit"] = t.WindowLimit
+	}
+	return json.Marshal(m)
+}
+
+// Validate checks the limits of the throttle, and that a window is set if
+// and only if a window limit is.
+func (t *ReplicationThrottle) Validate() error {
+	for _, l := range []int64{t.DefaultLimit, t.WindowLimit} {
+		if l != 0 && (l < MinReplicationLimit || l > MaxReplicationLimit) {
+			return &PureError{Reason: fmt.Sprintf("[error] Replication limit %d must be between %d and %d bytes per second", l, MinReplicationLimit, MaxReplicationLimit)}
+		}
+	}
+	if (t.WindowLimit != 0) != (t.Window != nil) {
+		return &PureError{Reason: "[error] A window limit requires a throttle window, and a throttle window requires a window limit"}
+	}
+	if t.Window != nil {
+		return t.Window.Validate()
+	}
+	return nil
+}
@@ -5,7 +5,9 @@
 package flasharray
 
 import (
+	"encoding/json"
 	"testing"
+	"time"
 )
 
 func TestAccArrayConsoleLock(t *testing.T) {
@@ -88,3 +90,141 @@ func testAccDisableRemoteAssist(c *Client) func(t *testing.T) {
 		}
 	}
 }
+
+func TestArrayConnections(t *testing.T) {
+	s, c := testFakeArray(t)
+	s.AddRemoteArray("remote1", "10.0.0.2", "remote-key")
+
+	t.Run("GetConnectionKey", testGetConnectionKey(c, s.ConnectionKey))
+	t.Run("ConnectArray", testConnectArray(c))
+	t.Run("ListArrayConnections", testListArrayConnections(c))
+	t.Run("SetReplicationThrottle", testSetReplicationThrottle(c))
+	t.Run("DisconnectArray", testDisconnectArray(c))
+}
+
+func testGetConnectionKey(c *Client, key string) func(t *testing.T) {
+	return func(t *testing.T) {
+		k, err := c.Array.GetConnectionKey()
+		if err != nil {
+			t.Fatalf("error getting connection key: %s", err)
+		}
+		if k.ConnectionKey != key {
+			t.Fatalf("expected connection key: %s; got %s", key, k.ConnectionKey)
+		}
+	}
+}
+
+func testConnectArray(c *Client) func(t *testing.T) {
+	return func(t *testing.T) {
+		if _, err := c.Array.ConnectArray("10.0.0.2", "invalid", nil, ""); err == nil {
+			t.Errorf("An Error was NOT raised for an invalid connection key")
+		}
+		conn, err := c.Array.ConnectArray("10.0.0.2", "remote-key", []string{ReplicationTypeAsync, ReplicationTypeSync}, "10.0.1.2")
+		if err != nil {
+			t.Fatalf("error connecting array: %s", err)
+		}
+		if conn.ArrayName != "remote1" || !conn.Connected || conn.ReplicationAddress != "10.0.1.2" || len(conn.Type) != 2 {
+			t.Fatalf("unexpected array connection: %+v", conn)
+		}
+	}
+}
+
+func testListArrayConnections(c *Client) func(t *testing.T) {
+	return func(t *testing.T) {
+		l, err := c.Array.ListArrayConnections(nil)
+		if err != nil {
+			t.Fatalf("error listing array connections: %s", err)
+		}
+		if len(l) != 1 || l[0].ArrayName != "remote1" || l[0].Throttled {
+			t.Fatalf("expected an unthrottled connection to remote1; got %+v", l)
+		}
+	}
+}
+
+func testSetReplicationThrottle(c *Client) func(t *testing.T) {
+	return func(t *testing.T) {
+		throttle := ReplicationThrottle{
+			DefaultLimit: 100 << 20,
+			WindowLimit:  10 << 20,
+			Window:       NewThrottleWindow(8*time.Hour, 18*time.Hour),
+		}
+		m, err := c.Array.SetReplicationThrottle("remote1", throttle)
+		if err != nil {
+			t.Fatalf("error setting replication throttle: %s", err)
+		}
+		if m.DefaultLimit != throttle.DefaultLimit || m.WindowLimit != throttle.WindowLimit || *m.Window != *throttle.Window {
+			t.Fatalf("expected throttle %+v; got %+v", throttle, m)
+		}
+
+		l, err := c.Array.ListReplicationThrottles()
+		if err != nil || len(l) != 1 || l[0].ArrayName != "remote1" || l[0].Window == nil {
+			t.Fatalf("expected the throttle of remote1; got %+v, %v", l, err)
+		}
+
+		m, err = c.Array.SetReplicationThrottle("remote1", ReplicationThrottle{})
+		if err != nil {
+			t.Fatalf("error removing replication throttle: %s", err)
+		}
+		if m.DefaultLimit != 0 || m.WindowLimit != 0 || m.Window != nil {
+			t.Fatalf("expected the throttle to be removed; got %+v", m)
+		}
+	}
+}
+
+func testDisconnectArray(c *Client) func(t *testing.T) {
+	return func(t *testing.T) {
+		if _, err := c.Array.DisconnectArray("remote1"); err != nil {
+			t.Fatalf("error disconnecting array: %s", err)
+		}
+		if _, err := c.Array.DisconnectArray("remote1"); !IsNotFound(err) {
+			t.Fatalf("expected a not found error; got %v", err)
+		}
+	}
+}
+
+func TestReplicationThrottleValidate(t *testing.T) {
+	valid := []ReplicationThrottle{
+		{},
+		{DefaultLimit: MinReplicationLimit},
+		{WindowLimit: MaxReplicationLimit, Window: NewThrottleWindow(22*time.Hour, 6*time.Hour)},
+	}
+	for _, throttle := range valid {
+		if err := throttle.Validate(); err != nil {
+			t.Errorf("unexpected error for throttle %+v: %s", throttle, err)
+		}
+	}
+
+	invalid := []ReplicationThrottle{
+		{DefaultLimit: MinReplicationLimit - 1},
+		{DefaultLimit: MaxReplicationLimit + 1},
+		{WindowLimit: MinReplicationLimit},
+		{Window: NewThrottleWindow(0, time.Hour)},
+		{WindowLimit: MinReplicationLimit, Window: NewThrottleWindow(0, 90*time.Minute)},
+		{WindowLimit: MinReplicationLimit, Window: NewThrottleWindow(time.Hour, time.Hour)},
+		{WindowLimit: MinReplicationLimit, Window: NewThrottleWindow(0, 24*time.Hour)},
+	}
+	for _, throttle := range invalid {
+		if err := throttle.Validate(); err == nil {
+			t.Errorf("An Error was NOT raised for throttle %+v", throttle)
+		}
+	}
+}
+
+func TestThrottleWindowJSON(t *testing.T) {
+	w := NewThrottleWindow(22*time.Hour, 6*time.Hour)
+	b, err := json.Marshal(w)
+	if err != nil {
+		t.Fatalf("error encoding throttle window: %s", err)
+	}
+	if string(b) != `{"start":79200000,"end":21600000}` {
+		t.Fatalf("expected the window in milliseconds; got %s", b)
+	}
+
+	var m ThrottleWindow
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatalf("error decoding throttle window: %s", err)
+	}
+	if m != *w {
+		t.Fatalf("expected window %+v; got %+v", *w, m)
+	}
+}
@@ -0,0 +1,187 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package flasharray
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// BlockDiffer lists the blocks of a volume or snapshot changed since a base
+// snapshot.  VolumeService implements it.
+type BlockDiffer interface {
+	GetVolume(name string, params map[string]string) (*Volume, error)
+	ListVolumeBlockDiff(name string, params map[string]string) ([]Block, error)
+}
+
+// BlockReader reads the content of the snapshot being backed up, i.e. from
+// the device of a host connected to a copy of it.  *os.File implements it.
+type BlockReader interface {
+	ReadAt(p []byte, off int64) (n int, err error)
+}
+
+// WalkVolumeBlockDiff calls fn for each block of the volume or snapshot name
+// changed since the snapshot base, or allocated if base is empty.  The diff
+// is requested segmentSize bytes at a time, DefaultBackupSegmentSize if zero.
+func (v *VolumeService) WalkVolumeBlockDiff(name string, base string, segmentSize int, fn func(Block) error) error {
+	return walkBlockDiff(v, name, base, segmentSize, fn)
+}
+
+func walkBlockDiff(d BlockDiffer, name string, base string, segmentSize int, fn func(Block) error) error {
+	vol, err := d.GetVolume(name, nil)
+	if err != nil {
+		return err
+	}
+	return walkBlockDiffOf(d, vol, base, segmentSize, fn)
+}
+
+func walkBlockDiffOf(d BlockDiffer, vol *Volume, base string, segmentSize int, fn func(Block) error) error {
+	if segmentSize <= 0 {
+		segmentSize = DefaultBackupSegmentSize
+	}
+	for offset := 0; offset < vol.Size; offset += segmentSize {
+		length := segmentSize
+		if offset+length > vol.Size {
+			length = vol.Size - offset
+		}
+		params := map[string]string{"offset": strconv.Itoa(offset), "length": strconv.Itoa(length)}
+		if base != "" {
+			params["base"] = base
+		}
+		blocks, err := d.ListVolumeBlockDiff(vol.Name, params)
+		if err != nil {
+			return err
+		}
+		for _, b := range blocks {
+			if err := fn(b); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// BlockBackup backs up the blocks of snapshots changed since previous backups.
+type BlockBackup struct {
+	// Volumes lists the changed blocks, usually the Volumes service of a Client
+	Volumes BlockDiffer
+	// SegmentSize is the length of the volume diffed per request,
+	// DefaultBackupSegmentSize if zero
+	SegmentSize int
+	// ChunkSize is the maximum length of a block of the manifest, the
+	// granularity of its checksums, DefaultBackupChunkSize if zero
+	ChunkSize int
+}
+
+// Backup writes the data of the blocks of snapshot changed since the
+// snapshot of the backup parent to w, reading it from r, and returns the
+// manifest of the backup.  A nil parent makes a full backup.
+func (b *BlockBackup) Backup(snapshot string, parent *BackupManifest, r BlockReader, w io.Writer) (*BackupManifest, error) {
+	vol, err := b.Volumes.GetVolume(snapshot, nil)
+	if err != nil {
+		return nil, err
+	}
+	m := &BackupManifest{Format: BackupManifestFormat, Volume: snapshotVolume(vol.Name), Source: vol.Source, Snapshot: vol.Name, Size: vol.Size, Created: vol.Created, Blocks: []BackupBlock{}}
+	if parent != nil {
+		if parent.Volume != m.Volume {
+			return nil, &PureError{Reason: fmt.Sprintf("[error] Backup of %s cannot be based on a backup of %s", m.Volume, parent.Volume)}
+		}
+		m.Base = parent.Snapshot
+	}
+
+	chunkSize := b.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = DefaultBackupChunkSize
+	}
+	buf := make([]byte, chunkSize)
+	err = walkBlockDiffOf(b.Volumes, vol, m.Base, b.SegmentSize, func(block Block) error {
+		for offset, end := block.Offset, block.Offset+block.Length; offset < end; offset += chunkSize {
+			length := chunkSize
+			if offset+length > end {
+				length = end - offset
+			}
+			data := buf[:length]
+			if n, err := r.ReadAt(data, int64(offset)); n < length {
+				return &PureError{Reason: fmt.Sprintf("[error] Error reading %d bytes at offset %d of %s: %v", length, offset, m.Snapshot, err)}
+			}
+			if _, err := w.Write(data); err != nil {
+				return err
+			}
+			m.Blocks = append(m.Blocks, BackupBlock{Offset: offset, Length: length, Checksum: checksum(data)})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// ValidateBackupChain checks that manifests start with a full backup, and
+// that each following backup is based on the previous one.
+func ValidateBackupChain(manifests []*BackupManifest) error {
+	if len(manifests) == 0 {
+		return &PureError{Reason: "[error] Backup chain is empty"}
+	}
+	for i, m := range manifests {
+		if err := m.Validate(); err != nil {
+			return err
+		}
+		switch {
+		case i == 0 && !m.Full():
+			return &PureError{Reason: fmt.Sprintf("[error] Backup chain starts with the incremental backup of %s", m.Snapshot)}
+		case i > 0 && m.Volume != manifests[0].Volume:
+			return &PureError{Reason: fmt.Sprintf("[error] Backup of %s is not a backup of %s", m.Snapshot, manifests[0].Volume)}
+		case i > 0 && m.Base != manifests[i-1].Snapshot:
+			return &PureError{Reason: fmt.Sprintf("[error] Backup of %s is not based on the backup of %s", m.Snapshot, manifests[i-1].Snapshot)}
+		}
+	}
+	return nil
+}
+
+// RestoreBackupChain reconstructs the full image of the snapshot of the
+// last backup of chain to w, which must initially be zeroed, by applying
+// the blocks of each backup in order.  The checksums of the blocks are
+// verified as they are read.  If w has a Truncate method, like *os.File,
+// the image is truncated to the size of the snapshot.
+func RestoreBackupChain(w io.WriterAt, chain []BackupImage) error {
+	manifests := make([]*BackupManifest, len(chain))
+	for i, image := range chain {
+		manifests[i] = image.Manifest
+	}
+	if err := ValidateBackupChain(manifests); err != nil {
+		return err
+	}
+
+	for _, image := range chain {
+		m := image.Manifest
+		for _, b := range m.Blocks {
+			var data bytes.Buffer
+			if _, err := io.CopyN(&data, image.Data, int64(b.Length)); err != nil {
+				return &PureError{Reason: fmt.Sprintf("[error] Error reading block at offset %d of backup of %s: %v", b.Offset, m.Snapshot, err)}
+			}
+			if checksum(data.Bytes()) != b.Checksum {
+				return &PureError{Reason: fmt.Sprintf("[error] Checksum mismatch of block at offset %d of backup of %s", b.Offset, m.Snapshot)}
+			}
+			if _, err := w.WriteAt(data.Bytes(), int64(b.Offset)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if t, ok := w.(interface{ Truncate(int64) error }); ok {
+		return t.Truncate(int64(manifests[len(manifests)-1].Size))
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package flasharray
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// BackupManifestFormat is the version of the format of the backup manifests
+// written by BlockBackup.
+const BackupManifestFormat = 1
+
+// Defaults of BlockBackup
+const (
+	// DefaultBackupSegmentSize is the length of the volume diffed per request
+	DefaultBackupSegmentSize = 1 << 30
+	// DefaultBackupChunkSize is the maximum length of a block of a manifest
+	DefaultBackupChunkSize = 1 << 20
+)
+
+// BackupManifest struct for the changed blocks of a snapshot saved by a
+// backup.  The data of the blocks is stored separately, in the order of the
+// blocks.  A full backup has no base; an incremental backup holds the blocks
+// changed since the snapshot of its base backup.  Volume is the volume backed
+// up: the volume of the snapshot, or the volume itself, i.e. a copy of a
+// snapshot.  Source is the source of the snapshot or volume on the array.
+type BackupManifest struct {
+	Format   int           `json:"format"`
+	Volume   string        `json:"volume"`
+	Source   string        `json:"source,omitempty"`
+	Snapshot string        `json:"snapshot"`
+	Base     string        `json:"base,omitempty"`
+	Size     int           `json:"size"`
+	Created  string        `json:"created,omitempty"`
+	Blocks   []BackupBlock `json:"blocks"`
+}
+
+// BackupBlock struct for a block of a backup manifest.  Checksum is the hex
+// encoded SHA-256 of the data of the block.
+type BackupBlock struct {
+	Offset   int    `json:"offset"`
+	Length   int    `json:"length"`
+	Checksum string `json:"checksum"`
+}
+
+// Full reports whether the manifest is the manifest of a full backup.
+func (m *BackupManifest) Full() bool {
+	return m.Base == ""
+}
+
+// DataSize returns the length of the data of the backup.
+func (m *BackupManifest) DataSize() int {
+	n := 0
+	for _, b := range m.Blocks {
+		n += b.Length
+	}
+	return n
+}
+
+// Validate checks that the blocks of the manifest are ordered, do not
+// overlap and are within the size of the snapshot.
+func (m *BackupManifest) Validate() error {
+	if m.Format != BackupManifestFormat {
+		return &PureError{Reason: fmt.Sprintf("[error] Unsupported backup manifest format %d", m.Format)}
+	}
+	if m.Snapshot == "" {
+		return &PureError{Reason: "[error] Backup manifest has no snapshot"}
+	}
+	if m.Size < 0 {
+		return &PureError{Reason: fmt.Sprintf("[error] Invalid size %d of backup of %s", m.Size, m.Snapshot)}
+	}
+	end := 0
+	for _, b := range m.Blocks {
+		if b.Offset < end || b.Length <= 0 || b.Offset+b.Length > m.Size {
+			return &PureError{Reason: fmt.Sprintf("[error] Invalid block at offset %d of backup of %s", b.Offset, m.Snapshot)}
+		}
+		if c, err := hex.DecodeString(b.Checksum); err != nil || len(c) != 32 {
+			return &PureError{Reason: fmt.Sprintf("[error] Invalid checksum of block at offset %d of backup of %s", b.Offset, m.Snapshot)}
+		}
+		end = b.Offset + b.Length
+	}
+	return nil
+}
+
+// Write writes the manifest as JSON to w.
+func (m *BackupManifest) Write(w io.Writer) error {
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	return e.Encode(m)
+}
+
+// ReadBackupManifest reads and validates a manifest written by Write.
+func ReadBackupManifest(r io.Reader) (*BackupManifest, error) {
+	m := &BackupManifest{}
+	if err := json.NewDecoder(r).Decode(m); err != nil {
+		return nil, err
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// BackupImage struct for a backup of a chain to restore: its manifest and a
+// reader of its data.
+type BackupImage struct {
+	Manifest *BackupManifest
+	Data     io.Reader
+}
@@ -0,0 +1,241 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package flasharray
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// testSnapshotDiffer diffs snapshots held in memory, in blocks of 4K.
+type testSnapshotDiffer map[string][]byte
+
+func (d testSnapshotDiffer) GetVolume(name string, params map[string]string) (*Volume, error) {
+	data, ok := d[name]
+	if !ok {
+		return nil, &PureError{Reason: "[error] Volume does not exist."}
+	}
+	return &Volume{Name: name, Source: strings.SplitN(name, ".", 2)[0], Size: len(data)}, nil
+}
+
+func (d testSnapshotDiffer) ListVolumeBlockDiff(name string, params map[string]string) ([]Block, error) {
+	offset, _ := strconv.Atoi(params["offset"])
+	length, _ := strconv.Atoi(params["length"])
+	data, base := d[name], d[params["base"]]
+	var l []Block
+	for o := offset; o < offset+length; o += 4096 {
+		n := 4096
+		if o+n > len(data) {
+			n = len(data) - o
+		}
+		if o+n <= len(base) && bytes.Equal(data[o:o+n], base[o:o+n]) {
+			continue
+		}
+		if k := len(l) - 1; k >= 0 && l[k].Offset+l[k].Length == o {
+			l[k].Length += n
+		} else {
+			l = append(l, Block{Offset: o, Length: n})
+		}
+	}
+	return l, nil
+}
+
+// testDiscardWriterAt discards the images restored by failing tests.
+type testDiscardWriterAt struct{}
+
+func (testDiscardWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	return len(p), nil
+}
+
+func testBackupData(size int) []byte {
+	data := make([]byte, size)
+	rand.New(rand.NewSource(int64(size))).Read(data)
+	return data
+}
+
+func testRestoreBackupChain(t *testing.T, chain []BackupImage) []byte {
+	path := filepath.Join(t.TempDir(), "image")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("error creating image: %s", err)
+	}
+	defer f.Close()
+	if err := RestoreBackupChain(f, chain); err != nil {
+		t.Fatalf("error restoring backup chain: %s", err)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("error reading image: %s", err)
+	}
+	return data
+}
+
+func TestBlockBackup(t *testing.T) {
+	_, c := testFakeArray(t)
+
+	if _, err := c.Volumes.CreateVolume("testbackupvol", 4<<20); err != nil {
+		t.Fatalf("error creating volume: %s", err)
+	}
+	if _, err := c.Volumes.CreateSnapshot("testbackupvol", "backup1"); err != nil {
+		t.Fatalf("error creating snapshot: %s", err)
+	}
+
+	var blocks []Block
+	err := c.Volumes.WalkVolumeBlockDiff("testbackupvol.backup1", "", 1<<20, func(b Block) error {
+		blocks = append(blocks, b)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("error walking block diff: %s", err)
+	}
+	if len(blocks) != 4 || blocks[3].Offset != 3<<20 || blocks[3].Length != 1<<20 {
+		t.Fatalf("expected 4 segments of 1M; got %+v", blocks)
+	}
+
+	// The snapshot is read from a local file standing for a copy of it
+	content := testBackupData(4 << 20)
+	path := filepath.Join(t.TempDir(), "snapshot")
+	if err := ioutil.WriteFile(path, content, 0600); err != nil {
+		t.Fatalf("error writing snapshot: %s", err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("error opening snapshot: %s", err)
+	}
+	defer f.Close()
+
+	var data bytes.Buffer
+	b := &BlockBackup{Volumes: c.Volumes, SegmentSize: 1 << 20, ChunkSize: 512 << 10}
+	m, err := b.Backup("testbackupvol.backup1", nil, f, &data)
+	if err != nil {
+		t.Fatalf("error backing up snapshot: %s", err)
+	}
+	if !m.Full() || m.Volume != "testbackupvol" || len(m.Blocks) != 8 || m.DataSize() != data.Len() {
+		t.Fatalf("expected a full backup of testbackupvol in 8 blocks; got %+v", m)
+	}
+	if image := testRestoreBackupChain(t, []BackupImage{{m, &data}}); !bytes.Equal(image, content) {
+		t.Fatalf("restored image does not match the snapshot")
+	}
+
+	// A copy of the snapshot is backed up as a volume of its own
+	if _, err := c.Volumes.CopyVolume("testbackupcopy", "testbackupvol.backup1", false); err != nil {
+		t.Fatalf("error copying snapshot: %s", err)
+	}
+	m, err = b.Backup("testbackupcopy", nil, f, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("error backing up volume copy: %s", err)
+	}
+	if m.Volume != "testbackupcopy" || m.Snapshot != "testbackupcopy" || m.Source != "testbackupvol" {
+		t.Fatalf("expected a backup of testbackupcopy copied from testbackupvol; got %+v", m)
+	}
+	if _, err := b.Backup("testbackupcopy", &BackupManifest{Volume: "testbackupvol", Snapshot: "testbackupvol.backup1"}, f, &bytes.Buffer{}); err == nil {
+		t.Errorf("An Error was NOT raised for a backup of a copy based on a backup of its source")
+	}
+}
+
+func TestBlockBackupChain(t *testing.T) {
+	snap1 := testBackupData(64 << 10)
+	snap2 := append([]byte{}, snap1...)
+	copy(snap2[5000:], "changed")
+	snap3 := append(append([]byte{}, snap2...), testBackupData(8<<10)...)
+	copy(snap3[40000:], "changed again")
+	d := testSnapshotDiffer{"vol1.snap1": snap1, "vol1.snap2": snap2, "vol1.snap3": snap3, "vol2.snap1": snap1}
+
+	b := &BlockBackup{Volumes: d, SegmentSize: 16 << 10}
+	var chain []BackupImage
+	var parent *BackupManifest
+	for _, snap := range []string{"vol1.snap1", "vol1.snap2", "vol1.snap3"} {
+		data := &bytes.Buffer{}
+		m, err := b.Backup(snap, parent, bytes.NewReader(d[snap]), data)
+		if err != nil {
+			t.Fatalf("error backing up %s: %s", snap, err)
+		}
+
+		// Round trip the manifest as it would be stored
+		var buf bytes.Buffer
+		if err := m.Write(&buf); err != nil {
+			t.Fatalf("error writing manifest: %s", err)
+		}
+		if m, err = ReadBackupManifest(&buf); err != nil {
+			t.Fatalf("error reading manifest: %s", err)
+		}
+		chain = append(chain, BackupImage{m, data})
+		parent = m
+	}
+
+	if len(chain[1].Manifest.Blocks) != 1 || chain[1].Manifest.DataSize() != 4096 || chain[1].Manifest.Base != "vol1.snap1" {
+		t.Fatalf("expected one changed block of 4K based on vol1.snap1; got %+v", chain[1].Manifest)
+	}
+	if chain[2].Manifest.DataSize() != 12<<10 {
+		t.Fatalf("expected 12K of changed and extended blocks; got %+v", chain[2].Manifest.Blocks)
+	}
+	if _, err := b.Backup("vol2.snap1", parent, bytes.NewReader(snap1), &bytes.Buffer{}); err == nil {
+		t.Errorf("An Error was NOT raised for a backup based on a backup of another volume")
+	}
+
+	data := make([][]byte, len(chain))
+	for i, image := range chain {
+		data[i] = image.Data.(*bytes.Buffer).Bytes()
+	}
+	images := func(i ...int) []BackupImage {
+		var l []BackupImage
+		for _, e := range i {
+			l = append(l, BackupImage{chain[e].Manifest, bytes.NewReader(data[e])})
+		}
+		return l
+	}
+	if image := testRestoreBackupChain(t, images(0, 1)); !bytes.Equal(image, snap2) {
+		t.Errorf("restored image does not match vol1.snap2")
+	}
+	if image := testRestoreBackupChain(t, images(0, 1, 2)); !bytes.Equal(image, snap3) {
+		t.Errorf("restored image does not match vol1.snap3")
+	}
+
+	invalid := [][]BackupImage{nil, images(1, 2), images(0, 2)}
+	for _, c := range invalid {
+		if err := RestoreBackupChain(testDiscardWriterAt{}, c); err == nil {
+			t.Errorf("An Error was NOT raised for chain %d", len(c))
+		}
+	}
+	corrupted := append([]byte{}, data[1]...)
+	corrupted[0] ^= 0xff
+	l := images(0, 1)
+	l[1].Data = bytes.NewReader(corrupted)
+	if err := RestoreBackupChain(testDiscardWriterAt{}, l); err == nil {
+		t.Errorf("An Error was NOT raised for corrupted data")
+	}
+}
+
+func TestBackupManifestValidate(t *testing.T) {
+	sum := checksum(nil)
+	valid := BackupManifest{Format: BackupManifestFormat, Volume: "vol1", Snapshot: "vol1.snap1", Size: 8192, Blocks: []BackupBlock{{0, 4096, sum}, {4096, 4096, sum}}}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("error validating manifest: %s", err)
+	}
+
+	invalid := map[string]func(m *BackupManifest){
+		"format":          func(m *BackupManifest) { m.Format = 2 },
+		"snapshot":        func(m *BackupManifest) { m.Snapshot = "" },
+		"overlap":         func(m *BackupManifest) { m.Blocks[1].Offset = 2048 },
+		"size":            func(m *BackupManifest) { m.Size = 4096 },
+		"empty block":     func(m *BackupManifest) { m.Blocks[0].Length = 0 },
+		"checksum":        func(m *BackupManifest) { m.Blocks[0].Checksum = "abc" },
+		"checksum length": func(m *BackupManifest) { m.Blocks[0].Checksum = "abcd" },
+	}
+	for name, f := range invalid {
+		m := valid
+		m.Blocks = append([]BackupBlock{}, valid.Blocks...)
+		f(&m)
+		if err := m.Validate(); err == nil {
+			t.Errorf("An Error was NOT raised for %s", name)
+		}
+	}
+}
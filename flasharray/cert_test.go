@@ -68,3 +68,15 @@ func testAccDeleteCert(name string, c *Client) func(t *testing.T) {
 		}
 	}
 }
+
+func TestListCert(t *testing.T) {
+	c := testResponseClient(t, "cert", `[{"name": "management", "status": "self-signed"}, {"name": "web", "status": "imported"}]`)
+
+	l, err := c.Cert.ListCert()
+	if err != nil {
+		t.Fatalf("error listing certificates: %s", err)
+	}
+	if len(l) != 2 || l[0].Name != "management" || l[1].Status != "imported" {
+		t.Errorf("expected the certificates management and web; got %+v", l)
+	}
+}
@@ -4,6 +4,11 @@
 
 package flasharray
 
+import (
+	"fmt"
+	"net/url"
+)
+
 // Dirsrv struct for data returned by array
 type Dirsrv struct {
 	BindUser     string   `json:"bind_user"`
@@ -25,3 +30,42 @@ type DirsrvRole struct {
 	Group     string `json:"group,omitempty"`
 	GroupBase string `json:"group_base,omitempty"`
 }
+
+// MaxDirectoryServiceURIs is the maximum number of URIs of the directory
+// service
+const MaxDirectoryServiceURIs = 30
+
+// SetDirectoryServiceOptions struct for the changes of the directory service
+// made with SetDirectoryService.  Nil fields are left unchanged.
+type SetDirectoryServiceOptions struct {
+	// URI are the ldap:// or ldaps:// URIs of the directory servers
+	URI          *[]string `json:"uri,omitempty"`
+	BaseDn       *string   `json:"base_dn,omitempty"`
+	BindUser     *string   `json:"bind_user,omitempty"`
+	BindPassword *string   `json:"bind_password,omitempty"`
+	// Certificate is the PEM encoded CA certificate of the servers
+	Certificate *string `json:"certificate,omitempty"`
+	CheckPeer   *bool   `json:"check_peer,omitempty"`
+	Enabled     *bool   `json:"enabled,omitempty"`
+}
+
+// Validate checks the URIs, which must all be ldaps:// URIs to check the
+// certificate of the servers.
+func (o *SetDirectoryServiceOptions) Validate() error {
+	if o.URI == nil {
+		return nil
+	}
+	if len(*o.URI) > MaxDirectoryServiceURIs {
+		return &PureError{Reason: fmt.Sprintf("[error] At most %d directory service URIs can be set", MaxDirectoryServiceURIs)}
+	}
+	for _, uri := range *o.URI {
+		u, err := url.Parse(uri)
+		if err != nil || (u.Scheme != "ldap" && u.Scheme != "ldaps") || u.Host == "" {
+			return &PureError{Reason: fmt.Sprintf("[error] Invalid directory service URI %q, must be ldap://host or ldaps://host", uri)}
+		}
+		if o.CheckPeer != nil && *o.CheckPeer && u.Scheme != "ldaps" {
+			return &PureError{Reason: fmt.Sprintf("[error] Directory service URI %s must be ldaps:// to check the certificate of the servers", uri)}
+		}
+	}
+	return nil
+}
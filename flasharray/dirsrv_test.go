@@ -17,3 +17,28 @@ func TestAccGetDirectoryService(t *testing.T) {
 		t.Fatalf("error getting directory service: %s", err)
 	}
 }
+
+func TestDirectoryServiceOptionsValidate(t *testing.T) {
+	checkPeer := true
+	valid := []validator{
+		&SetDirectoryServiceOptions{URI: &[]string{"ldaps://ad1.example.com", "ldaps://ad2.example.com:636"}, CheckPeer: &checkPeer},
+		&SetDirectoryServiceOptions{URI: &[]string{"ldap://ad1.example.com"}},
+	}
+	for _, o := range valid {
+		if err := o.Validate(); err != nil {
+			t.Errorf("unexpected error for options %+v: %s", o, err)
+		}
+	}
+
+	invalid := []validator{
+		&SetDirectoryServiceOptions{URI: &[]string{"ad1.example.com"}},
+		&SetDirectoryServiceOptions{URI: &[]string{"https://ad1.example.com"}},
+		&SetDirectoryServiceOptions{URI: &[]string{"ldap://ad1.example.com"}, CheckPeer: &checkPeer},
+		&SetAlertOptions{},
+	}
+	for _, o := range invalid {
+		if err := o.Validate(); err == nil {
+			t.Errorf("An Error was NOT raised for options %+v", o)
+		}
+	}
+}
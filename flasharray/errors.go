@@ -0,0 +1,145 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package flasharray
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// PureError is the base error returned by the library.  It is the equivalent
+// of PureError in the Python REST client.
+type PureError struct {
+	Reason string
+}
+
+func (e *PureError) Error() string {
+	return e.Reason
+}
+
+// PureHTTPError is returned when the array responds with a status code outside
+// the 200 range.  It is the equivalent of PureHTTPError in the Python REST client.
+//
+// A PureHTTPError is also a PureError; errors.As will match either type.
+type PureHTTPError struct {
+	PureError
+
+	// Target is the array the request was sent to
+	Target string
+	// RestVersion is the REST API version of the request
+	RestVersion string
+	// Method and URL of the failed request
+	Method string
+	URL    string
+	// Code is the HTTP status code of the response
+	Code int
+	// Text is the raw body of the response
+	Text string
+	// Errors is the list of error messages parsed from the body of the response
+	Errors []ErrorMessage
+}
+
+// ErrorMessage is a single error reported by the array in the body of a
+// failed response.
+type ErrorMessage struct {
+	Context string `json:"ctx,omitempty"`
+	Msg     string `json:"msg,omitempty"`
+	Key     string `json:"pure_err_key,omitempty"`
+	Code    int    `json:"code,omitempty"`
+}
+
+func (e *PureHTTPError) Error() string {
+	msg := fmt.Sprintf("PureHTTPError status code %d returned by REST version %s at %s: %s", e.Code, e.RestVersion, e.Target, e.Reason)
+	for _, m := range e.Errors {
+		if m.Context != "" {
+			msg += fmt.Sprintf("\n%s: %s", m.Context, m.Msg)
+		} else {
+			msg += fmt.Sprintf("\n%s", m.Msg)
+		}
+	}
+	if len(e.Errors) == 0 && e.Text != "" {
+		msg += "\n" + e.Text
+	}
+	return msg
+}
+
+// As allows errors.As to match a PureHTTPError with a target of type **PureError.
+func (e *PureHTTPError) As(target interface{}) bool {
+	if p, ok := target.(**PureError); ok {
+		*p = &e.PureError
+		return true
+	}
+	return false
+}
+
+// hasMessage reports whether any of the error messages contains s.
+func (e *PureHTTPError) hasMessage(s string) bool {
+	for _, m := range e.Errors {
+		if strings.Contains(strings.ToLower(m.Msg), s) {
+			return true
+		}
+	}
+	return false
+}
+
+// newPureHTTPError builds a PureHTTPError from the response r.  The body of the
+// response is parsed for the list of error messages returned by the array.
+func newPureHTTPError(r *http.Response, restVersion string, body []byte) *PureHTTPError {
+	e := &PureHTTPError{
+		PureError:   PureError{Reason: http.StatusText(r.StatusCode)},
+		RestVersion: restVersion,
+		Code:        r.StatusCode,
+		Text:        string(body),
+	}
+	if r.Request != nil {
+		e.Target = r.Request.URL.Host
+		e.Method = r.Request.Method
+		e.URL = r.Request.URL.String()
+	}
+
+	var msgs []ErrorMessage
+	if err := json.Unmarshal(body, &msgs); err == nil {
+		e.Errors = msgs
+	} else {
+		var msg ErrorMessage
+		if err := json.Unmarshal(body, &msg); err == nil && msg.Msg != "" {
+			e.Errors = []ErrorMessage{msg}
+		}
+	}
+	return e
+}
+
+// IsNotFound reports whether err was caused by the array not finding the
+// requested object, i.e. "Volume does not exist."
+func IsNotFound(err error) bool {
+	var e *PureHTTPError
+	if !errors.As(err, &e) {
+		return false
+	}
+	return e.Code == http.StatusNotFound || e.hasMessage("does not exist") || e.hasMessage("not found")
+}
+
+// IsAlreadyExists reports whether err was caused by the object to be created
+// already existing on the array, i.e. "Volume already exists."
+func IsAlreadyExists(err error) bool {
+	var e *PureHTTPError
+	if !errors.As(err, &e) {
+		return false
+	}
+	return e.Code == http.StatusConflict || e.hasMessage("already exists")
+}
+
+// IsUnauthorized reports whether err was caused by failed authentication or
+// authorization, i.e. an invalid API token or an expired session.
+func IsUnauthorized(err error) bool {
+	var e *PureHTTPError
+	if !errors.As(err, &e) {
+		return false
+	}
+	return e.Code == http.StatusUnauthorized || e.Code == http.StatusForbidden
+}
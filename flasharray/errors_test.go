@@ -0,0 +1,83 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package flasharray
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func testErrorResponse(code int, body string) *http.Response {
+	req, _ := http.NewRequest("POST", "https://flasharray.example.com/api/1.16/volume/vol1", nil)
+	return &http.Response{
+		StatusCode: code,
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}
+}
+
+func TestValidateResponse(t *testing.T) {
+	c := &Client{Target: "flasharray.example.com", RestVersion: "1.16"}
+
+	err := c.validateResponse(testErrorResponse(400, `[{"pure_err_key": "err.friendly", "code": 0, "ctx": "vol1", "msg": "Volume already exists."}]`))
+
+	var e *PureHTTPError
+	if !errors.As(err, &e) {
+		t.Fatalf("expected a PureHTTPError; got %T", err)
+	}
+	if e.Code != 400 || e.Method != "POST" || e.Target != "flasharray.example.com" || e.RestVersion != "1.16" {
+		t.Errorf("unexpected PureHTTPError: %+v", e)
+	}
+	if e.URL != "https://flasharray.example.com/api/1.16/volume/vol1" {
+		t.Errorf("expected: https://flasharray.example.com/api/1.16/volume/vol1; got %s", e.URL)
+	}
+	if len(e.Errors) != 1 || e.Errors[0].Context != "vol1" || e.Errors[0].Msg != "Volume already exists." {
+		t.Errorf("error messages were not parsed; got %+v", e.Errors)
+	}
+
+	var p *PureError
+	if !errors.As(err, &p) || p.Reason != "Bad Request" {
+		t.Errorf("PureHTTPError did not match PureError; got %+v", p)
+	}
+
+	if err := c.validateResponse(testErrorResponse(200, `{}`)); err != nil {
+		t.Errorf("unexpected error for status code 200: %s", err)
+	}
+}
+
+func TestErrorHelpers(t *testing.T) {
+	c := &Client{Target: "flasharray.example.com", RestVersion: "1.16"}
+
+	exists := c.validateResponse(testErrorResponse(400, `[{"ctx": "vol1", "msg": "Volume already exists."}]`))
+	notFound := c.validateResponse(testErrorResponse(400, `[{"ctx": "vol1", "msg": "Volume does not exist."}]`))
+	unauthorized := c.validateResponse(testErrorResponse(401, `[{"msg": "Session has expired."}]`))
+	wrapped := fmt.Errorf("creating volume: %w", exists)
+
+	tests := []struct {
+		name  string
+		f     func(error) bool
+		err   error
+		match bool
+	}{
+		{"IsAlreadyExists", IsAlreadyExists, exists, true},
+		{"IsAlreadyExists_wrapped", IsAlreadyExists, wrapped, true},
+		{"IsAlreadyExists_notFound", IsAlreadyExists, notFound, false},
+		{"IsNotFound", IsNotFound, notFound, true},
+		{"IsNotFound_exists", IsNotFound, exists, false},
+		{"IsUnauthorized", IsUnauthorized, unauthorized, true},
+		{"IsUnauthorized_notFound", IsUnauthorized, notFound, false},
+		{"IsNotFound_plainError", IsNotFound, errors.New("does not exist"), false},
+		{"IsNotFound_nil", IsNotFound, nil, false},
+	}
+	for _, tt := range tests {
+		if got := tt.f(tt.err); got != tt.match {
+			t.Errorf("%s: expected %t; got %t", tt.name, tt.match, got)
+		}
+	}
+}
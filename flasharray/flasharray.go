@@ -8,24 +8,27 @@
 // and returns the data into types defined within the library.
 // This is not designed to be a standalone program.
 // It is just meant to provide functions and communication within another program
+//
+// The create and set operations take their attributes as data, either an
+// options struct like *CreateHostOptions, which is validated before the
+// request is sent, or a map of the REST parameters of the operation.
 package flasharray
 
 import (
 	"bytes"
-	"crypto/tls"
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
-	"net/http/cookiejar"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 )
 
 // supportedRestVersions is used to negotiate the API version to use
-var supportedRestVersions = [...]string{"1.0", "1.1", "1.2", "1.3", "1.4", "1.5", "1.6", "1.7", "1.8", "1.9", "1.10", "1.11", "1.12", "1.13", "1.14", "1.15", "1.16"}
+var supportedRestVersions = [...]string{"1.0", "1.1", "1.2", "1.3", "1.4", "1.5", "1.6", "1.7", "1.8", "1.9", "1.10", "1.11", "1.12", "1.13", "1.14", "1.15", "1.16", "1.17", "1.18", "1.19"}
 
 // Client struct represents a Pure Storage FlashArray and exposes administrative APIs.
 type Client struct {
@@ -37,7 +40,22 @@ type Client struct {
 	UserAgent     string
 	RequestKwargs map[string]string
 
-	client *http.Client
+	// AutoReestablishSession controls whether a request that fails because
+	// the array expired the REST session is replayed once after a new
+	// session has been established.  NewClient enables it.
+	AutoReestablishSession bool
+
+	// RetryPolicy decides whether a request that failed with a transient
+	// error is sent again.  New sets DefaultRetryPolicy; nil disables retries.
+	RetryPolicy RetryPolicy
+
+	client        *http.Client
+	ctx           context.Context
+	session       *session
+	logger        Logger
+	requestHooks  []RequestHook
+	responseHooks []ResponseHook
+	guard         *Guard
 
 	Array            *ArrayService
 	Volumes          *VolumeService
@@ -56,6 +74,7 @@ type Client struct {
 	Snmp             *SnmpService
 	Cert             *CertService
 	SMTP             *SMTPService
+	RecycleBin       *RecycleBinService
 }
 
 // Type supported is used for retrieving the support API versions from the Flash Array
@@ -96,27 +115,32 @@ type auth struct {
 // A bool used to set whether SSL host verification should be performed.
 //
 // ssl_cert
-// Path to SSL certificate or CA Bundle file. Ignored if verify_https=False.
+// A bool used to set whether the CA Bundle file named by request_kwargs["ssl_cert"]
+// is used to verify the array. Ignored if verify_https=False.
 //
 // user_agent
 // String to be used as the HTTP User-Agent for requests.
 //
 // request_kwargs
 // A map of keyword arguments that we will pass into the the call.
+//
+// Use NewClientWithTLSConfig to pin the array certificate, or to present a
+// client certificate to the array.  New offers all the settings of the client
+// as options.
 func NewClient(target string, username string, password string, apiToken string,
 	restVersion string, verifyHTTPS bool, sslCert bool,
 	userAgent string, requestKwargs map[string]string) (*Client, error) {
 
-	//log.Printf("[debug] flasharray.NewClient: checking auth paramters")
-	if apiToken == "" && (username == "" && password == "") {
-		err := errors.New("[error] Must specify API token or both username and password")
-		return nil, err
-	}
+	return NewClientWithContext(context.Background(), target, username, password, apiToken,
+		restVersion, verifyHTTPS, sslCert, userAgent, requestKwargs)
+}
 
-	if apiToken != "" && (username != "" && password != "") {
-		err := errors.New("specify only API token or both username and password")
-		return nil, err
-	}
+// NewClientWithContext is the same as NewClient, but the REST version negotiation
+// and the session authentication are bound to ctx.  The returned Client does not
+// keep ctx; use WithContext to bind a context to subsequent API calls.
+func NewClientWithContext(ctx context.Context, target string, username string, password string, apiToken string,
+	restVersion string, verifyHTTPS bool, sslCert bool,
+	userAgent string, requestKwargs map[string]string) (*Client, error) {
 
 	//log.Printf("[debug] flasharray.NewClient: checking request_kwargs")
 	if requestKwargs == nil {
@@ -125,52 +149,135 @@ func NewClient(target string, username string, password string, apiToken string,
 
 	_, ok := requestKwargs["verify"]
 	if !ok {
-		if sslCert && verifyHTTPS {
-			requestKwargs["verify"] = "false"
-		} else {
-			requestKwargs["verify"] = "true"
+		requestKwargs["verify"] = strconv.FormatBool(verifyHTTPS)
+	}
+
+	tlsConfig := &TLSConfig{VerifyHTTPS: verifyHTTPS}
+	if verifyHTTPS && sslCert {
+		if requestKwargs["ssl_cert"] == "" {
+			err := &PureError{Reason: "[error] Must specify request_kwargs[\"ssl_cert\"] when ssl_cert is set"}
+			return nil, err
 		}
+		tlsConfig.CAFile = requestKwargs["ssl_cert"]
 	}
 
-	//log.Printf("[debug] flasharray.NewClient: checking rest_version")
+	return NewClientWithTLSConfig(ctx, target, username, password, apiToken,
+		restVersion, tlsConfig, userAgent, requestKwargs)
+}
+
+// NewClientWithTLSConfig is the same as NewClientWithContext, but the verification
+// of the array certificate and the client certificates presented to the array
+// are described by tlsConfig.  A nil tlsConfig does not verify the array.
+func NewClientWithTLSConfig(ctx context.Context, target string, username string, password string, apiToken string,
+	restVersion string, tlsConfig *TLSConfig, userAgent string, requestKwargs map[string]string) (*Client, error) {
+
+	if tlsConfig == nil {
+		tlsConfig = &TLSConfig{}
+	}
+	c, err := NewWithContext(ctx, target,
+		WithUsernamePassword(username, password),
+		WithAPIToken(apiToken),
+		WithRestVersion(restVersion),
+		WithTLSConfig(tlsConfig),
+		WithUserAgent(userAgent),
+	)
+	if err != nil {
+		return nil, err
+	}
+	c.RequestKwargs = requestKwargs
+
+	return c, nil
+}
+
+// New returns a Client used to call the administrative functions of the
+// array target, configured by opts.  Either WithAPIToken or
+// WithUsernamePassword is required.
+//
+//	c, err := flasharray.New("flasharray.example.com",
+//		flasharray.WithAPIToken(apiToken),
+//		flasharray.WithTimeout(30*time.Second),
+//	)
+func New(target string, opts ...Option) (*Client, error) {
+	return NewWithContext(context.Background(), target, opts...)
+}
+
+// NewWithContext is the same as New, but the REST version negotiation and the
+// session authentication are bound to ctx.
+func NewWithContext(ctx context.Context, target string, opts ...Option) (*Client, error) {
+
+	cfg := &config{autoReestablishSession: true, retryPolicy: DefaultRetryPolicy()}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	//log.Printf("[debug] flasharray.NewClient: checking auth paramters")
+	if cfg.apiToken == "" && (cfg.username == "" && cfg.password == "") {
+		err := &PureError{Reason: "[error] Must specify API token or both username and password"}
+		return nil, err
+	}
+
+	if cfg.apiToken != "" && (cfg.username != "" && cfg.password != "") {
+		err := &PureError{Reason: "specify only API token or both username and password"}
+		return nil, err
+	}
+
+	if cfg.guard != nil {
+		if err := cfg.guard.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	httpClient, err := cfg.newHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{Target: target, Username: cfg.username, Password: cfg.password, APIToken: cfg.apiToken, UserAgent: cfg.userAgent}
+	c.AutoReestablishSession = cfg.autoReestablishSession
+	c.RetryPolicy = cfg.retryPolicy
+	c.client = httpClient
+	c.session = &session{}
+	c.logger = cfg.logger
+	c.requestHooks = cfg.requestHooks
+	c.responseHooks = cfg.responseHooks
+	c.guard = cfg.guard
+
+	c.logf("[debug] flasharray.NewClient: checking rest_version")
+	restVersion := cfg.restVersion
 	if restVersion != "" {
-		err := checkRestVersion(restVersion, target)
+		err := checkRestVersion(ctx, httpClient, restVersion, target)
 		if err != nil {
 			return nil, err
 		}
 	} else {
-		r, err := chooseRestVersion(target)
+		r, err := chooseRestVersion(ctx, httpClient, target)
 		if err != nil {
 			return nil, err
 		}
 		restVersion = r
 	}
+	c.RestVersion = restVersion
+	c.logf("[debug] flasharray.NewClient: Rest Version: %s", restVersion)
 
-	//log.Printf("[debug] flasharray.NewClient: Rest Vesrion: %s", restVersion)
-
-	//log.Printf("[debug] flasharray.NewClient: creating client")
-	cookieJar, _ := cookiejar.New(nil)
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-	}
-	c := &Client{Target: target, Username: username, Password: password, APIToken: apiToken, RestVersion: restVersion, RequestKwargs: requestKwargs}
-	c.client = &http.Client{Transport: tr, Jar: cookieJar}
-
-	//log.Printf("[debug] flasharray.NewClient: Authenticating REST session")
-	if apiToken == "" {
-		c.getAPIToken()
+	c.logf("[debug] flasharray.NewClient: Authenticating REST session")
+	if c.APIToken == "" {
+		if err := c.getAPIToken(ctx); err != nil {
+			return nil, err
+		}
 	}
 
-	authURL := c.formatPath("auth/session")
-	data := map[string]string{"api_token": c.APIToken}
-	jsonValue, _ := json.Marshal(data)
-	_, err := c.client.Post(authURL, "application/json", bytes.NewBuffer(jsonValue))
-	if err != nil {
+	if err := c.startSession(ctx); err != nil {
 		return nil, err
 	}
-	//log.Printf("[debug] Session Auth Response Code: %d", resp.StatusCode)
-	//log.Printf("[debug] flasharray.NewClient: REST session created.")
+	c.logf("[debug] flasharray.NewClient: REST session created.")
+
+	c.initServices()
 
+	return c, nil
+}
+
+// initServices binds the API services to the client.
+func (c *Client) initServices() {
 	c.Array = &ArrayService{client: c}
 	c.Volumes = &VolumeService{client: c}
 	c.Hosts = &HostService{client: c}
@@ -188,8 +295,32 @@ func NewClient(target string, username string, password string, apiToken string,
 	c.Snmp = &SnmpService{client: c}
 	c.Cert = &CertService{client: c}
 	c.SMTP = &SMTPService{client: c}
+	c.RecycleBin = &RecycleBinService{client: c}
+}
 
-	return c, err
+// WithContext returns a shallow copy of the client whose requests are bound to ctx.
+// Every service method called through the returned client, i.e.
+// c.WithContext(ctx).Volumes.CreateVolume("vol", size), will be canceled
+// when ctx is canceled or its deadline expires.  The copy shares the
+// HTTP client and REST session of the original.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	if ctx == nil {
+		panic("nil context")
+	}
+	c2 := new(Client)
+	*c2 = *c
+	c2.ctx = ctx
+	c2.initServices()
+	return c2
+}
+
+// Context returns the context bound to the client.  The returned context is
+// always non-nil; it defaults to the background context.
+func (c *Client) Context() context.Context {
+	if c.ctx != nil {
+		return c.ctx
+	}
+	return context.Background()
 }
 
 // NewRequest builds and returns a new HTTP request object.
@@ -208,7 +339,21 @@ func NewClient(target string, username string, password string, apiToken string,
 // The data body to be passed in the HTTP request. This will be converted to JSON,
 // then added to the request as bytes.
 //
+// If data has a Validate method, like the option structs of the create and
+// set operations, i.e. *CreateHostOptions, it is called first and its error
+// is returned without building the request.
+//
+// The request is bound to the context of the client, see WithContext.
 func (c *Client) NewRequest(method string, path string, params map[string]string, data interface{}) (*http.Request, error) {
+	return c.NewRequestWithContext(c.Context(), method, path, params, data)
+}
+
+// NewRequestWithContext is the same as NewRequest, but binds the request to ctx.
+func (c *Client) NewRequestWithContext(ctx context.Context, method string, path string, params map[string]string, data interface{}) (*http.Request, error) {
+
+	if err := validateData(data); err != nil {
+		return nil, err
+	}
 
 	var fpath string
 	if strings.HasPrefix(path, "http") {
@@ -251,27 +396,65 @@ func (c *Client) NewRequest(method string, path string, params map[string]string
 		req.Header.Add("User-Agent", c.UserAgent)
 	}
 
-	return req, err
+	return req.WithContext(ctx), err
 }
 
 // Do is the client function that performs the HTTP request.
-// req	The HTTP request object to be executed.
+// req	The HTTP request object to be executed.  The request is canceled when
+// its context is done.
 // v	The data object that will be populated and returned. i.e. Volume struct
 // reestablish_session	A bool that states if the session should be reestablished prior to execution.
-//			This functionality is NOT implemented yet.  By default the Go HTTP library
-//			does not set a timeout, I need to set this implicitly.
-//			However, the array will timeout the session after 30 minutes.
+//
+// The array will timeout the session after 30 minutes.  If AutoReestablishSession
+// is set, a request rejected because of an expired session is replayed once
+// after a new session has been established.  Requests failing with transient
+// errors are retried according to RetryPolicy.  Destructive requests are
+// checked by the guard of the client, see WithGuard; with a dry run, the
+// requests changing the array are not sent and Do returns a nil response.
 func (c *Client) Do(req *http.Request, v interface{}, reestablishSession bool) (*http.Response, error) {
-	resp, err := c.client.Do(req)
+	if c.guard != nil {
+		if skip, err := c.guard.check(c, req); err != nil || skip {
+			return nil, err
+		}
+	}
+
+	var gen uint64
+	if c.session != nil {
+		gen = c.session.current()
+	}
+	if reestablishSession {
+		if c.session == nil {
+			return nil, &PureError{Reason: "[error] Client does not have a REST session to reestablish"}
+		}
+		if err := c.reestablishSession(req.Context(), gen); err != nil {
+			return nil, err
+		}
+		gen = c.session.current()
+	}
+
+	resp, err := c.sendWithRetry(req)
 	if err != nil {
-		fmt.Println("Do request failed")
+		c.logf("[debug] Do request failed: %s", err)
 		return nil, err
 	}
+
+	if c.AutoReestablishSession && c.session != nil && sessionExpired(req, resp) {
+		drainBody(resp.Body)
+		if err := c.reestablishSession(req.Context(), gen); err != nil {
+			return nil, err
+		}
+		r, err := replayRequest(req)
+		if err != nil {
+			return nil, err
+		}
+		resp, err = c.sendWithRetry(r)
+		if err != nil {
+			return nil, err
+		}
+	}
 	defer resp.Body.Close()
-	//log.Printf("[debug] URL: %s ", req.URL.String())
-	//log.Printf("[debug] Response code: %v", resp.Status)
 
-	if err := validateResponse(resp); err != nil {
+	if err := c.validateResponse(resp); err != nil {
 		return resp, err
 	}
 
@@ -280,6 +463,32 @@ func (c *Client) Do(req *http.Request, v interface{}, reestablishSession bool) (
 
 }
 
+// send performs the HTTP request with the HTTP client of c, calling the
+// request and response hooks.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	if c.UserAgent != "" && req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+	for _, hook := range c.requestHooks {
+		hook(req)
+	}
+	resp, err := c.client.Do(req)
+	if err == nil {
+		c.logf("[debug] %s %s: %s", req.Method, req.URL.String(), resp.Status)
+	}
+	for _, hook := range c.responseHooks {
+		hook(req, resp, err)
+	}
+	return resp, err
+}
+
+// logf logs a debug message if the client has a logger.
+func (c *Client) logf(format string, v ...interface{}) {
+	if c.logger != nil {
+		c.logger.Printf(format, v...)
+	}
+}
+
 // decodeResponse function reads the http response body into an interface.
 func decodeResponse(r *http.Response, v interface{}) error {
 	if v == nil {
@@ -293,29 +502,29 @@ func decodeResponse(r *http.Response, v interface{}) error {
 }
 
 // validateResponse checks that the http response is within the 200 range.
-// Some functionality needs to be added here to check for some specific errors,
-// and probably add the equivlents to PureError and PureHTTPError from the Python
-// REST client.
-func validateResponse(r *http.Response) error {
-	if c := r.StatusCode; 200 <= c && c <= 299 {
+// Otherwise a PureHTTPError describing the failed request is returned.
+func (c *Client) validateResponse(r *http.Response) error {
+	if code := r.StatusCode; 200 <= code && code <= 299 {
 		return nil
 	}
 
 	bodyBytes, _ := ioutil.ReadAll(r.Body)
-	bodyString := string(bodyBytes)
-	return fmt.Errorf("Response code: %d, ResponeBody: %s", r.StatusCode, bodyString)
+	return newPureHTTPError(r, c.RestVersion, bodyBytes)
 }
 
 // checkRestVersion will check that the specified rest_version is supported
 // by the Flash Array, and the library.
-func checkRestVersion(v string, t string) error {
+func checkRestVersion(ctx context.Context, c *http.Client, v string, t string) error {
 
 	checkURL, err := url.Parse("https://" + t + "/api/api_version")
 	if err != nil {
 		return err
 	}
 	s := &supported{}
-	err = getJSON(checkURL.String(), s)
+	err = getJSON(ctx, c, checkURL.String(), s)
+	if err != nil {
+		return err
+	}
 
 	var arraySupported bool
 	for _, n := range s.Versions {
@@ -324,7 +533,7 @@ func checkRestVersion(v string, t string) error {
 		}
 	}
 	if !arraySupported {
-		err := errors.New("[error] Array is incompatible with REST API version " + v)
+		err := &PureError{Reason: "[error] Array is incompatible with REST API version " + v}
 		return err
 	}
 
@@ -335,7 +544,7 @@ func checkRestVersion(v string, t string) error {
 		}
 	}
 	if !librarySupported {
-		err := errors.New("[error] Library is incompatible with REST API version " + v)
+		err := &PureError{Reason: "[error] Library is incompatible with REST API version " + v}
 		return err
 	}
 	return nil
@@ -343,14 +552,14 @@ func checkRestVersion(v string, t string) error {
 
 // chooseRestVersion will negotiate the highest REST API version supported by
 // the library and the flash array
-func chooseRestVersion(t string) (string, error) {
+func chooseRestVersion(ctx context.Context, c *http.Client, t string) (string, error) {
 
 	checkURL, err := url.Parse("https://" + t + "/api/api_version")
 	if err != nil {
 		return "", err
 	}
 	s := &supported{}
-	err = getJSON(checkURL.String(), s)
+	err = getJSON(ctx, c, checkURL.String(), s)
 	if err != nil {
 		return "", err
 	}
@@ -362,13 +571,12 @@ func chooseRestVersion(t string) (string, error) {
 			}
 		}
 	}
-	err = errors.New("[error] Array is incompatible with all supported REST API versions")
-	return "", err
+	return "", &PureError{Reason: "[error] Array is incompatible with all supported REST API versions"}
 }
 
 // getApiToken retrieved the API token for the given user.  The API token
 // is then used for all http authentication.
-func (c *Client) getAPIToken() error {
+func (c *Client) getAPIToken(ctx context.Context) error {
 
 	authURL, err := url.Parse(c.formatPath("auth/apitoken"))
 	if err != nil {
@@ -377,20 +585,24 @@ func (c *Client) getAPIToken() error {
 
 	data := map[string]string{"username": c.Username, "password": c.Password}
 	jsonValue, _ := json.Marshal(data)
-	fmt.Println(bytes.NewBuffer(jsonValue))
 	req, err := http.NewRequest("POST", authURL.String(), bytes.NewBuffer(jsonValue))
+	if err != nil {
+		return err
+	}
 	req.Header.Add("content-type", "application/json; charset=utf-8")
 	req.Header.Add("Accept", "application/json")
 	req.Header.Set("Content-Type", "application/json")
 
-	r, err := c.client.Do(req)
+	r, err := c.send(req.WithContext(ctx))
 	if err != nil {
 		return err
 	}
 	defer r.Body.Close()
+	if err := c.validateResponse(r); err != nil {
+		return err
+	}
 	t := &auth{}
 	err = json.NewDecoder(r.Body).Decode(t)
-	fmt.Println(t)
 	c.APIToken = t.Token
 
 	return err
@@ -404,14 +616,17 @@ func (c *Client) formatPath(path string) string {
 
 // getJSON is just a helper function that creates and retrieves information
 // from the flash array before the actual session is established.
-// Right now, its just grabbing the supported API versions.  I should
-// probably find a more graceful way to accomplish this.
-func getJSON(uri string, target interface{}) error {
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-	}
-	var c = &http.Client{Timeout: 10 * time.Second, Transport: tr}
-	r, err := c.Get(uri)
+// Right now, its just grabbing the supported API versions.  The request is
+// sent with c, so that the same TLS settings are used as for the session.
+func getJSON(ctx context.Context, c *http.Client, uri string, target interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequest("GET", uri, nil)
+	if err != nil {
+		return err
+	}
+	r, err := c.Do(req.WithContext(ctx))
 	if err != nil {
 		return err
 	}
@@ -1,8 +1,11 @@
 package flasharray
 
 import (
+	"context"
 	"os"
 	"testing"
+
+	"github.com/devans10/go-purestorage/flasharray/flasharraytest"
 )
 
 func testAccPreChecks(t *testing.T) {
@@ -44,11 +47,69 @@ func testAccGenerateClient(t *testing.T) *Client {
 	return c
 }
 
+// testAccClient returns a client of the array set in PURE_TARGET if PURE_ACC
+// is set, and of a fake array otherwise, so that the acceptance tests of the
+// storage services also run offline.
+func testAccClient(t *testing.T) *Client {
+	if os.Getenv("PURE_ACC") != "" {
+		testAccPreChecks(t)
+		return testAccGenerateClient(t)
+	}
+	_, c := testFakeArray(t)
+	return c
+}
+
+// testFakeArray starts a fake array and returns it with a client of the array.
+func testFakeArray(t *testing.T) (*flasharraytest.Server, *Client) {
+	s := flasharraytest.NewServer()
+	t.Cleanup(s.Close)
+
+	c, err := New(s.Target(), WithAPIToken(s.APIToken), WithHTTPClient(s.Client()))
+	if err != nil {
+		t.Fatalf("error setting up client of the fake array: %s", err)
+	}
+	return s, c
+}
+
 func TestAccClient(t *testing.T) {
 	testAccPreChecks(t)
 	testAccGenerateClient(t)
 }
 
+func TestChooseRestVersion(t *testing.T) {
+	older := []string{"1.0", "1.1", "1.2", "1.3", "1.4", "1.5", "1.6", "1.7", "1.8", "1.9", "1.10", "1.11", "1.12", "1.13", "1.14", "1.15", "1.16"}
+	tests := []struct {
+		versions []string
+		expected string
+	}{
+		// Arrays older than 1.17 still negotiate their latest version
+		{older, "1.16"},
+		{[]string{"1.11", "1.12", "1.13", "1.14"}, "1.14"},
+		{append(older, "1.17"), "1.17"},
+		{append(older, "1.17", "1.18", "1.19"), "1.19"},
+		// Versions newer than those of the library are not used
+		{append(older, "1.17", "1.18", "1.19", "1.20", "1.21"), "1.19"},
+	}
+	for _, tt := range tests {
+		s := flasharraytest.NewServer()
+		s.Versions = tt.versions
+		v, err := chooseRestVersion(context.Background(), s.Client(), s.Target())
+		s.Close()
+		if err != nil {
+			t.Errorf("error negotiating the REST version of %v: %s", tt.versions, err)
+		} else if v != tt.expected {
+			t.Errorf("expected the REST version %s to be negotiated with %v; got %s", tt.expected, tt.versions, v)
+		}
+	}
+
+	s := flasharraytest.NewServer()
+	defer s.Close()
+	s.Versions = []string{"2.0"}
+	if _, err := chooseRestVersion(context.Background(), s.Client(), s.Target()); err == nil {
+		t.Errorf("An Error was NOT raised for an array without a supported REST version")
+	}
+}
+
 // Test that a NewClient call with no authentication returns an error
 func TestNewClientNoAuth(t *testing.T) {
 
@@ -86,3 +147,58 @@ func TestNewRequest(t *testing.T) {
 		t.Errorf("Malformed URL returned by NewRequest. Expected: https://flasharray.example.com/api/1.0/array; Got: %s", req.URL.String())
 	}
 }
+
+// Test that NewRequestWithContext binds the context to the request
+func TestNewRequestWithContext(t *testing.T) {
+
+	c := &Client{Target: "flasharray.example.com", APIToken: "apitoken", RestVersion: "1.0"}
+
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "value")
+	req, err := c.NewRequestWithContext(ctx, "GET", "array", nil, nil)
+	if err != nil {
+		t.Fatalf("NewRequestWithContext function call returned error: %s", err)
+	}
+
+	if req.Context() != ctx {
+		t.Errorf("Request context was not set by NewRequestWithContext")
+	}
+}
+
+// Test that WithContext returns a copy of the client whose requests carry the context
+func TestWithContext(t *testing.T) {
+
+	c := &Client{Target: "flasharray.example.com", APIToken: "apitoken", RestVersion: "1.0"}
+	c.initServices()
+
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "value")
+	c2 := c.WithContext(ctx)
+
+	if c.Context() != context.Background() {
+		t.Errorf("WithContext modified the context of the original client")
+	}
+	if c2.Volumes.client != c2 {
+		t.Errorf("WithContext did not rebind the services to the new client")
+	}
+
+	req, err := c2.NewRequest("GET", "array", nil, nil)
+	if err != nil {
+		t.Fatalf("NewRequest function call returned error: %s", err)
+	}
+	if req.Context() != ctx {
+		t.Errorf("Request context was not set from the client context")
+	}
+}
+
+// Test that a NewClientWithContext call with a canceled context returns an error
+func TestNewClientWithContextCanceled(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := NewClientWithContext(ctx, "flasharray.example.com", "", "", "api_token", "", false, false, "", nil)
+	if err == nil {
+		t.Errorf("An Error was NOT raised when the context was canceled")
+	}
+}
@@ -0,0 +1,290 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package flasharraytest
+
+import (
+	"strings"
+	"time"
+)
+
+// purityVersion is the Purity version reported by the array.
+const purityVersion = "5.3.0"
+
+// Bounds of the replication bandwidth limits, in bytes per second.
+const (
+	minReplicationLimit = 1 << 20
+	maxReplicationLimit = 4 << 30
+)
+
+// replicationTypes are the types of array connections.
+var replicationTypes = []string{"async-replication", "sync-replication"}
+
+// remoteArray is an array the fake array can connect to.
+type remoteArray struct {
+	name              string
+	id                string
+	managementAddress string
+	connectionKey     string
+}
+
+type arrayConnection struct {
+	remote             *remoteArray
+	types              []string
+	replicationAddress string
+	defaultLimit       *int
+	windowLimit        *int
+	window             *throttleWindow
+}
+
+type throttleWindow struct {
+	start int
+	end   int
+}
+
+// AddRemoteArray registers an array the fake array can connect to, at
+// managementAddress with connectionKey.
+func (s *Server) AddRemoteArray(name string, managementAddress string, connectionKey string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.remotes[managementAddress] = &remoteArray{name: name, id: newID(), managementAddress: managementAddress, connectionKey: connectionKey}
+}
+
+func (s *Server) array(r *request) (interface{}, *apiError) {
+	parts := strings.SplitN(r.path, "/", 2)
+	switch {
+	case r.path == "" && r.method == "GET":
+		if boolParam(r.query, "connection_key") {
+			return map[string]string{"connection_key": s.ConnectionKey}, nil
+		}
+		if r.query.Get("action") == "monitor" || boolParam(r.query, "space") {
+			m := s.arrayMetricsView(r.query)
+			if historical(r.query) {
+				return s.history(r.query, m)
+			}
+			return []map[string]interface{}{m}, nil
+		}
+		return map[string]string{"array_name": s.ArrayName, "id": s.arrayID, "version": purityVersion, "revision": "201911211709+c5fd46f"}, nil
+	case parts[0] != "connection":
+		return nil, methodNotAllowedError()
+	case len(parts) == 1 && r.method == "GET":
+		return s.listArrayConnections(r)
+	case len(parts) == 1 && r.method == "POST":
+		return s.connectArray(r)
+	case len(parts) == 2 && r.method == "PUT":
+		return s.throttleArrayConnection(parts[1], r)
+	case len(parts) == 2 && r.method == "DELETE":
+		return s.disconnectArray(parts[1])
+	}
+	return nil, methodNotAllowedError()
+}
+
+// lookupConnection returns the connection to the array name.
+func (s *Server) lookupConnection(name string) (*arrayConnection, *apiError) {
+	for _, c := range s.connections {
+		if c.remote.name == name {
+			return c, nil
+		}
+	}
+	return nil, notExistError("Array connection", name)
+}
+
+// connectedFor reports whether the array is connected to the array name
+// with the replication type t.
+func (s *Server) connectedFor(name string, t string) bool {
+	c, err := s.lookupConnection(name)
+	return err == nil && contains(c.types, t)
+}
+
+func (s *Server) connectionView(c *arrayConnection) map[string]interface{} {
+	return map[string]interface{}{
+		"array_name":          c.remote.name,
+		"id":                  c.remote.id,
+		"version":             purityVersion,
+		"connected":           true,
+		"throttled":           c.defaultLimit != nil || c.windowLimit != nil,
+		"management_address":  c.remote.managementAddress,
+		"replication_address": c.replicationAddress,
+		"type":                append([]string{}, c.types...),
+	}
+}
+
+func (s *Server) throttleView(c *arrayConnection) map[string]interface{} {
+	m := map[string]interface{}{
+		"array_name":    c.remote.name,
+		"default_limit": nil,
+		"window_limit":  nil,
+		"window":        nil,
+	}
+	if c.defaultLimit != nil {
+		m["default_limit"] = *c.defaultLimit
+	}
+	if c.windowLimit != nil {
+		m["window_limit"] = *c.windowLimit
+	}
+	if c.window != nil {
+		m["window"] = map[string]int{"start": c.window.start, "end": c.window.end}
+	}
+	return m
+}
+
+func (s *Server) listArrayConnections(r *request) (interface{}, *apiError) {
+	l := []map[string]interface{}{}
+	for _, c := range s.connections {
+		if boolParam(r.query, "throttle") {
+			l = append(l, s.throttleView(c))
+		} else {
+			l = append(l, s.connectionView(c))
+		}
+	}
+	return l, nil
+}
+
+func (s *Server) connectArray(r *request) (interface{}, *apiError) {
+	address, err := r.data.str("management_address")
+	if err != nil {
+		return nil, err
+	}
+	key, err := r.data.str("connection_key")
+	if err != nil {
+		return nil, err
+	}
+	types, ok, err := r.data.list("type")
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		types = []string{"async-replication"}
+	}
+	for _, t := range types {
+		if !contains(replicationTypes, t) {
+			return nil, invalidParamError("type")
+		}
+	}
+	replicationAddress, err := r.data.str("replication_address")
+	if err != nil {
+		return nil, err
+	}
+
+	remote, ok := s.remotes[address]
+	if !ok {
+		return nil, errorf(address, "Could not connect to array at %s.", address)
+	}
+	if key != remote.connectionKey {
+		return nil, errorf("connection_key", "Connection key is invalid.")
+	}
+	if _, err := s.lookupConnection(remote.name); err == nil {
+		return nil, errorf(remote.name, "Array is already connected.")
+	}
+	if replicationAddress == "" {
+		replicationAddress = address
+	}
+
+	c := &arrayConnection{remote: remote, types: types, replicationAddress: replicationAddress}
+	s.connections = append(s.connections, c)
+	return s.connectionView(c), nil
+}
+
+// limit returns the replication limit parameter key, and whether it is set.
+// A null value removes the limit.
+func limit(data params, key string) (*int, bool, *apiError) {
+	if !data.has(key) {
+		return nil, false, nil
+	}
+	n, ok, err := data.integer(key)
+	if err != nil || !ok {
+		return nil, true, err
+	}
+	if n < minReplicationLimit || n > maxReplicationLimit {
+		return nil, false, errorf(key, "Replication limit must be between %d and %d bytes per second.", minReplicationLimit, maxReplicationLimit)
+	}
+	return &n, true, nil
+}
+
+func (s *Server) throttleArrayConnection(name string, r *request) (interface{}, *apiError) {
+	c, err := s.lookupConnection(name)
+	if err != nil {
+		return nil, err
+	}
+	defaultLimit, setDefault, err := limit(r.data, "default_limit")
+	if err != nil {
+		return nil, err
+	}
+	windowLimit, setWindowLimit, err := limit(r.data, "window_limit")
+	if err != nil {
+		return nil, err
+	}
+
+	window := c.window
+	if r.data.has("window") {
+		window = nil
+		if w, ok := r.data["window"].(map[string]interface{}); ok {
+			start, _, err := params(w).integer("start")
+			if err != nil {
+				return nil, err
+			}
+			end, _, err := params(w).integer("end")
+			if err != nil {
+				return nil, err
+			}
+			window = &throttleWindow{start: start, end: end}
+		} else if r.data["window"] != nil {
+			return nil, invalidParamError("window")
+		}
+	}
+	if window != nil {
+		day, hour := int(24*time.Hour/time.Millisecond), int(time.Hour/time.Millisecond)
+		for _, t := range []int{window.start, window.end} {
+			if t < 0 || t >= day || t%hour != 0 {
+				return nil, errorf("window", "Window times must be on the hour.")
+			}
+		}
+		if window.start == window.end {
+			return nil, errorf("window", "Window must not start and end at the same time.")
+		}
+	}
+
+	if !setDefault {
+		defaultLimit = c.defaultLimit
+	}
+	if !setWindowLimit {
+		windowLimit = c.windowLimit
+	}
+	if (windowLimit != nil) != (window != nil) {
+		return nil, errorf("window", "A window limit requires a window, and a window requires a window limit.")
+	}
+	c.defaultLimit, c.windowLimit, c.window = defaultLimit, windowLimit, window
+	return s.throttleView(c), nil
+}
+
+func (s *Server) disconnectArray(name string) (interface{}, *apiError) {
+	c, err := s.lookupConnection(name)
+	if err != nil {
+		return nil, err
+	}
+	for _, pg := range s.pgroups {
+		if contains(pg.targets, name) {
+			return nil, errorf(name, "Array is a target of protection group %s.", pg.name)
+		}
+	}
+	for _, p := range s.pods {
+		if p.stretchedTo(name) {
+			return nil, errorf(name, "Pod %s is stretched to the array.", p.name)
+		}
+	}
+	for _, l := range s.replicaLinks {
+		if l.remote == name {
+			return nil, errorf(name, "Pod %s has a replica link to the array.", l.pod.name)
+		}
+	}
+
+	var connections []*arrayConnection
+	for _, e := range s.connections {
+		if e != c {
+			connections = append(connections, e)
+		}
+	}
+	s.connections = connections
+	return s.connectionView(c), nil
+}
@@ -0,0 +1,138 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package flasharraytest
+
+import (
+	"sort"
+	"strings"
+)
+
+// driveCapacity is the capacity of the drives of the array, in bytes.
+const driveCapacity = 3 << 40
+
+// defaultHardware are the hardware components of the array.
+var defaultHardware = []string{"CH0", "CH0.BAY0", "CH0.BAY1", "CH0.BAY2", "CH0.BAY3", "CT0", "CT0.FAN0", "CT0.PWR0", "CT1", "CT1.FAN0", "CT1.PWR0"}
+
+// alert is an open alert message of the array.
+type alert struct {
+	id            int
+	event         string
+	severity      string
+	componentType string
+	componentName string
+	opened        string
+}
+
+// SetHardwareStatus sets the status of the hardware component name, i.e. to
+// "critical".  Drives are the components of the bays of the chassis, i.e.
+// CH0.BAY0.  It panics if the component does not exist.
+func (s *Server) SetHardwareStatus(name string, status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.hardware[name]; !ok {
+		panic("flasharraytest: hardware component " + name + " does not exist")
+	}
+	s.hardware[name] = status
+}
+
+// AddAlert opens an alert message of the given severity about a component
+// of the array, and returns its ID.
+func (s *Server) AddAlert(event string, severity string, componentType string, componentName string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.alertID++
+	s.alerts = append(s.alerts, &alert{id: s.alertID, event: event, severity: severity, componentType: componentType, componentName: componentName, opened: s.now().Format(timeFormat)})
+	return s.alertID
+}
+
+func (s *Server) hardwareComponent(r *request) (interface{}, *apiError) {
+	if r.method != "GET" {
+		return nil, methodNotAllowedError()
+	}
+	if r.path != "" {
+		if _, ok := s.hardware[r.path]; !ok {
+			return nil, notExistError("Hardware component", r.path)
+		}
+		return s.hardwareView(r.path), nil
+	}
+	l := []map[string]interface{}{}
+	for _, name := range s.sortedHardware() {
+		l = append(l, s.hardwareView(name))
+	}
+	return l, nil
+}
+
+func (s *Server) hardwareView(name string) map[string]interface{} {
+	return map[string]interface{}{
+		"name":        name,
+		"status":      s.hardware[name],
+		"identify":    "off",
+		"index":       0,
+		"slot":        nil,
+		"speed":       nil,
+		"temperature": nil,
+		"voltage":     nil,
+		"details":     nil,
+	}
+}
+
+func (s *Server) sortedHardware() []string {
+	var names []string
+	for name := range s.hardware {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *Server) drive(r *request) (interface{}, *apiError) {
+	if r.method != "GET" {
+		return nil, methodNotAllowedError()
+	}
+	l := []map[string]interface{}{}
+	for _, name := range s.sortedHardware() {
+		if !strings.Contains(name, ".BAY") || (r.path != "" && r.path != name) {
+			continue
+		}
+		l = append(l, map[string]interface{}{
+			"name":     name,
+			"status":   s.hardware[name],
+			"type":     "SSD",
+			"protocol": "NVMe",
+			"capacity": driveCapacity,
+			"details":  nil,
+		})
+	}
+	if r.path != "" {
+		if len(l) == 0 {
+			return nil, notExistError("Drive", r.path)
+		}
+		return l[0], nil
+	}
+	return l, nil
+}
+
+func (s *Server) message(r *request) (interface{}, *apiError) {
+	if r.method != "GET" || r.path != "" {
+		return nil, methodNotAllowedError()
+	}
+	l := []map[string]interface{}{}
+	for _, a := range s.alerts {
+		l = append(l, map[string]interface{}{
+			"id":               a.id,
+			"category":         "array",
+			"code":             a.id,
+			"current_severity": a.severity,
+			"event":            a.event,
+			"component_type":   a.componentType,
+			"component_name":   a.componentName,
+			"opened":           a.opened,
+			"details":          "",
+			"expected":         nil,
+			"actual":           nil,
+		})
+	}
+	return l, nil
+}
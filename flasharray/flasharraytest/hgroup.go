@@ -0,0 +1,363 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package flasharraytest
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+type hgroup struct {
+	name  string
+	hosts []*host
+	// conns are the shared connections of the host group, by LUN.
+	conns map[*volume]int
+}
+
+func (s *Server) hgroup(r *request) (interface{}, *apiError) {
+	parts := strings.SplitN(r.path, "/", 3)
+	switch {
+	case r.path == "" && r.method == "GET":
+		return s.listHgroups(r)
+	case r.path == "":
+		return nil, methodNotAllowedError()
+	case len(parts) == 1 && r.method == "GET":
+		return s.getHgroup(parts[0], r)
+	case len(parts) == 1 && r.method == "POST":
+		return s.createHgroup(parts[0], r)
+	case len(parts) == 1 && r.method == "PUT":
+		return s.setHgroup(parts[0], r)
+	case len(parts) == 1 && r.method == "DELETE":
+		return s.deleteHgroup(parts[0])
+	case len(parts) == 2 && parts[1] == "volume" && r.method == "GET":
+		return s.listHgroupConnections(parts[0])
+	case len(parts) == 3 && parts[1] == "volume" && r.method == "POST":
+		return s.connectHgroup(parts[0], parts[2], r)
+	case len(parts) == 3 && parts[1] == "volume" && r.method == "DELETE":
+		return s.disconnectHgroup(parts[0], parts[2])
+	case len(parts) == 3 && parts[1] == "pgroup" && r.method == "POST":
+		return s.addHgroupToPgroup(parts[0], parts[2])
+	case len(parts) == 3 && parts[1] == "pgroup" && r.method == "DELETE":
+		return s.removeHgroupFromPgroup(parts[0], parts[2])
+	}
+	return nil, methodNotAllowedError()
+}
+
+func (s *Server) lookupHgroup(name string) (*hgroup, *apiError) {
+	g, ok := s.hgroups[name]
+	if !ok {
+		return nil, notExistError("Host group", name)
+	}
+	return g, nil
+}
+
+func (s *Server) sortedHgroups() []*hgroup {
+	var names []string
+	for name := range s.hgroups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	l := make([]*hgroup, 0, len(names))
+	for _, name := range names {
+		l = append(l, s.hgroups[name])
+	}
+	return l
+}
+
+func (s *Server) hgroupView(g *hgroup, q url.Values) map[string]interface{} {
+	m := map[string]interface{}{"name": g.name}
+	switch {
+	case q.Get("action") == "monitor":
+		m["time"] = s.now().Format(timeFormat)
+		for _, k := range []string{"reads_per_sec", "writes_per_sec", "input_per_sec", "output_per_sec", "usec_per_read_op", "usec_per_write_op", "san_usec_per_read_op", "san_usec_per_write_op", "queue_depth"} {
+			m[k] = 0
+		}
+	case boolParam(q, "space"):
+		m["volumes"] = 0
+		m["snapshots"] = 0
+		m["total"] = 0
+		m["data_reduction"] = 1.0
+		m["total_reduction"] = 1.0
+		m["thin_provisioning"] = 1.0
+	default:
+		m["hosts"] = hostNames(g.hosts)
+	}
+	return m
+}
+
+func (s *Server) listHgroups(r *request) (interface{}, *apiError) {
+	l := []map[string]interface{}{}
+	for _, g := range s.sortedHgroups() {
+		if selected(r.query, g.name) {
+			l = append(l, s.hgroupView(g, r.query))
+		}
+	}
+	return l, nil
+}
+
+func (s *Server) getHgroup(name string, r *request) (interface{}, *apiError) {
+	g, err := s.lookupHgroup(name)
+	if err != nil {
+		return nil, err
+	}
+	if historical(r.query) {
+		return s.history(r.query, s.hgroupView(g, r.query))
+	}
+	return s.hgroupView(g, r.query), nil
+}
+
+func (s *Server) createHgroup(name string, r *request) (interface{}, *apiError) {
+	if err := checkName("host group", name); err != nil {
+		return nil, err
+	}
+	if _, ok := s.hgroups[name]; ok {
+		return nil, existsError("Host group", name)
+	}
+	hosts, _, err := r.data.list("hostlist")
+	if err != nil {
+		return nil, err
+	}
+	g := &hgroup{name: name, conns: map[*volume]int{}}
+	members, err := s.hgroupMembers(g, nil, hosts)
+	if err != nil {
+		return nil, err
+	}
+	s.hgroups[name] = g
+	s.setHgroupMembers(g, members)
+	return s.hgroupView(g, nil), nil
+}
+
+// hgroupMembers returns current after adding the hosts add, validating that
+// they can join g.
+func (s *Server) hgroupMembers(g *hgroup, current []*host, add []string) ([]*host, *apiError) {
+	members := append([]*host{}, current...)
+	for _, name := range add {
+		h, err := s.lookupHost(name)
+		if err != nil {
+			return nil, err
+		}
+		if h.hgroup != nil && h.hgroup != g {
+			return nil, errorf(name, "Host is already a member of host group %s.", h.hgroup.name)
+		}
+		for v := range g.conns {
+			if _, ok := h.conns[v]; ok {
+				return nil, errorf(name, "Host already has a private connection to volume %s.", v.name)
+			}
+		}
+		if !containsHost(members, h) {
+			members = append(members, h)
+		}
+	}
+	return members, nil
+}
+
+func (s *Server) setHgroupMembers(g *hgroup, members []*host) {
+	for _, h := range g.hosts {
+		h.hgroup = nil
+	}
+	for _, h := range members {
+		h.hgroup = g
+	}
+	g.hosts = members
+}
+
+func (s *Server) setHgroup(name string, r *request) (interface{}, *apiError) {
+	g, err := s.lookupHgroup(name)
+	if err != nil {
+		return nil, err
+	}
+
+	members := g.hosts
+	if hosts, ok, err := r.data.list("hostlist"); err != nil {
+		return nil, err
+	} else if ok {
+		if members, err = s.hgroupMembers(g, nil, hosts); err != nil {
+			return nil, err
+		}
+	}
+	add, _, err := r.data.list("addhostlist")
+	if err != nil {
+		return nil, err
+	}
+	if members, err = s.hgroupMembers(g, members, add); err != nil {
+		return nil, err
+	}
+	rem, _, err := r.data.list("remhostlist")
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range rem {
+		h, err := s.lookupHost(name)
+		if err != nil {
+			return nil, err
+		}
+		if !containsHost(members, h) {
+			return nil, errorf(name, "Host is not a member of host group %s.", g.name)
+		}
+		members = removeHost(members, h)
+	}
+
+	newName, err := r.data.str("name")
+	if err != nil {
+		return nil, err
+	}
+	if newName != "" && newName != g.name {
+		if err := checkName("host group", newName); err != nil {
+			return nil, err
+		}
+		if _, ok := s.hgroups[newName]; ok {
+			return nil, existsError("Host group", newName)
+		}
+		delete(s.hgroups, g.name)
+		g.name = newName
+		s.hgroups[newName] = g
+	}
+	s.setHgroupMembers(g, members)
+	return s.hgroupView(g, nil), nil
+}
+
+func (s *Server) deleteHgroup(name string) (interface{}, *apiError) {
+	g, err := s.lookupHgroup(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(g.hosts) > 0 {
+		return nil, errorf(name, "Host group is not empty.")
+	}
+	if len(g.conns) > 0 {
+		return nil, errorf(name, "Host group has shared volume connections.")
+	}
+	for _, pg := range s.pgroups {
+		pg.hgroups = removeHgroup(pg.hgroups, g)
+	}
+	delete(s.hgroups, name)
+	return map[string]string{"name": name}, nil
+}
+
+func (s *Server) connectHgroup(name string, volume string, r *request) (interface{}, *apiError) {
+	g, err := s.lookupHgroup(name)
+	if err != nil {
+		return nil, err
+	}
+	v, err := s.lookupVolume(volume, false)
+	if err != nil {
+		return nil, err
+	}
+	if v.snapshot {
+		return nil, errorf(volume, "Snapshots cannot be connected to host groups.")
+	}
+	if _, ok := g.conns[v]; ok {
+		return nil, errorf(volume, "Connection already exists.")
+	}
+	used := map[int]bool{}
+	for _, lun := range g.conns {
+		used[lun] = true
+	}
+	for _, h := range g.hosts {
+		if _, ok := h.conns[v]; ok {
+			return nil, errorf(volume, "Volume is already connected to host %s.", h.name)
+		}
+		for _, lun := range h.conns {
+			used[lun] = true
+		}
+	}
+	l, err := lun(r.data, used)
+	if err != nil {
+		return nil, err
+	}
+	g.conns[v] = l
+	return map[string]interface{}{"name": g.name, "vol": v.name, "lun": l}, nil
+}
+
+func (s *Server) disconnectHgroup(name string, volume string) (interface{}, *apiError) {
+	g, err := s.lookupHgroup(name)
+	if err != nil {
+		return nil, err
+	}
+	v, err := s.lookupVolume(volume, false)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := g.conns[v]; !ok {
+		return nil, errorf(volume, "Connection does not exist.")
+	}
+	delete(g.conns, v)
+	return map[string]string{"name": g.name, "vol": v.name}, nil
+}
+
+func (s *Server) listHgroupConnections(name string) (interface{}, *apiError) {
+	g, err := s.lookupHgroup(name)
+	if err != nil {
+		return nil, err
+	}
+	l := []map[string]interface{}{}
+	for _, v := range sortedConnections(g.conns) {
+		l = append(l, map[string]interface{}{"name": g.name, "vol": v.name, "lun": g.conns[v]})
+	}
+	return l, nil
+}
+
+func (s *Server) addHgroupToPgroup(name string, pgroup string) (interface{}, *apiError) {
+	g, err := s.lookupHgroup(name)
+	if err != nil {
+		return nil, err
+	}
+	pg, err := s.lookupPgroup(pgroup, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := pg.checkMembers("hgroups"); err != nil {
+		return nil, err
+	}
+	if containsHgroup(pg.hgroups, g) {
+		return nil, errorf(name, "Host group is already a member of protection group %s.", pgroup)
+	}
+	pg.hgroups = append(pg.hgroups, g)
+	return map[string]string{"name": g.name, "protection_group": pg.name}, nil
+}
+
+func (s *Server) removeHgroupFromPgroup(name string, pgroup string) (interface{}, *apiError) {
+	g, err := s.lookupHgroup(name)
+	if err != nil {
+		return nil, err
+	}
+	pg, err := s.lookupPgroup(pgroup, false)
+	if err != nil {
+		return nil, err
+	}
+	if !containsHgroup(pg.hgroups, g) {
+		return nil, errorf(name, "Host group is not a member of protection group %s.", pgroup)
+	}
+	pg.hgroups = removeHgroup(pg.hgroups, g)
+	return map[string]string{"name": g.name, "protection_group": pg.name}, nil
+}
+
+func containsHgroup(l []*hgroup, g *hgroup) bool {
+	for _, e := range l {
+		if e == g {
+			return true
+		}
+	}
+	return false
+}
+
+func removeHgroup(l []*hgroup, g *hgroup) []*hgroup {
+	r := l[:0:0]
+	for _, e := range l {
+		if e != g {
+			r = append(r, e)
+		}
+	}
+	return r
+}
+
+func hgroupNames(l []*hgroup) []string {
+	names := []string{}
+	for _, g := range l {
+		names = append(names, g.name)
+	}
+	return names
+}
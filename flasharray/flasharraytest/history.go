@@ -0,0 +1,90 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package flasharraytest
+
+import (
+	"net/url"
+	"time"
+)
+
+// arrayCapacity is the usable capacity of the array, in bytes.
+const arrayCapacity = 100 << 40
+
+// historicalWindows are the windows of the historical parameter, with the
+// interval of their samples.
+var historicalWindows = map[string][2]time.Duration{
+	"1h":  {time.Hour, 30 * time.Second},
+	"3h":  {3 * time.Hour, 30 * time.Second},
+	"24h": {24 * time.Hour, 5 * time.Minute},
+	"7d":  {7 * 24 * time.Hour, 30 * time.Minute},
+	"30d": {30 * 24 * time.Hour, 2 * time.Hour},
+	"90d": {90 * 24 * time.Hour, 8 * time.Hour},
+	"1y":  {365 * 24 * time.Hour, 24 * time.Hour},
+}
+
+// historical reports whether q requests the samples of a historical window.
+func historical(q url.Values) bool {
+	return q.Get("historical") != ""
+}
+
+// history returns the samples of the metrics of view over the historical
+// window of q.  The metrics do not change over time.
+func (s *Server) history(q url.Values, view map[string]interface{}) ([]map[string]interface{}, *apiError) {
+	if q.Get("action") != "monitor" && !boolParam(q, "space") {
+		return nil, errorf("historical", "historical requires action=monitor or space=true.")
+	}
+	w, ok := historicalWindows[q.Get("historical")]
+	if !ok {
+		return nil, errorf("historical", "Invalid value for historical: %s.", q.Get("historical"))
+	}
+	window, interval := w[0], w[1]
+
+	now := s.now().Truncate(interval)
+	l := []map[string]interface{}{}
+	for t := now.Add(interval - window); !t.After(now); t = t.Add(interval) {
+		m := map[string]interface{}{"time": t.Format(timeFormat)}
+		for k, v := range view {
+			if k != "time" {
+				m[k] = v
+			}
+		}
+		l = append(l, m)
+	}
+	return l, nil
+}
+
+// arrayMetricsView returns the performance or space metrics of the array.
+func (s *Server) arrayMetricsView(q url.Values) map[string]interface{} {
+	m := map[string]interface{}{"hostname": s.ArrayName, "time": s.now().Format(timeFormat)}
+	if q.Get("action") == "monitor" {
+		reads, writes := 0, 0
+		for _, v := range s.volumes {
+			reads += v.readsPerSec
+			writes += v.writesPerSec
+		}
+		m["reads_per_sec"] = reads
+		m["writes_per_sec"] = writes
+		for _, k := range []string{"input_per_sec", "output_per_sec", "usec_per_read_op", "usec_per_write_op", "san_usec_per_read_op", "san_usec_per_write_op", "queue_depth"} {
+			m[k] = 0
+		}
+		return m
+	}
+
+	provisioned := 0
+	for _, v := range s.volumes {
+		if !v.snapshot && !v.destroyed {
+			provisioned += v.size
+		}
+	}
+	m["capacity"] = arrayCapacity
+	m["provisioned"] = provisioned
+	for _, k := range []string{"volumes", "snapshots", "shared_space", "system", "total"} {
+		m[k] = 0
+	}
+	m["data_reduction"] = 1.0
+	m["total_reduction"] = 1.0
+	m["thin_provisioning"] = 1.0
+	return m
+}
@@ -0,0 +1,480 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package flasharraytest
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	minLun = 1
+	maxLun = 16383
+)
+
+type host struct {
+	name           string
+	wwn            []string
+	iqn            []string
+	nqn            []string
+	personality    string
+	preferredArray []string
+	hostUser       string
+	hostPassword   string
+	targetUser     string
+	targetPassword string
+	hgroup         *hgroup
+	// conns are the private connections of the host, by LUN.
+	conns map[*volume]int
+}
+
+// initiators describes the validation of the initiator ports of hosts.
+var initiators = []struct {
+	kind   string
+	key    string
+	regexp *regexp.Regexp
+	get    func(h *host) *[]string
+}{
+	{"WWN", "wwnlist", regexp.MustCompile(`^[0-9A-F]{16}$`), func(h *host) *[]string { return &h.wwn }},
+	{"IQN", "iqnlist", regexp.MustCompile(`^(iqn\.\d{4}-\d{2}\.\S+|eui\.[0-9a-fA-F]{16})$`), func(h *host) *[]string { return &h.iqn }},
+	{"NQN", "nqnlist", regexp.MustCompile(`^nqn\.\d{4}-\d{2}\.\S+$`), func(h *host) *[]string { return &h.nqn }},
+}
+
+func (s *Server) host(r *request) (interface{}, *apiError) {
+	parts := strings.SplitN(r.path, "/", 3)
+	switch {
+	case r.path == "" && r.method == "GET":
+		return s.listHosts(r)
+	case r.path == "":
+		return nil, methodNotAllowedError()
+	case len(parts) == 1 && r.method == "GET":
+		return s.getHost(parts[0], r)
+	case len(parts) == 1 && r.method == "POST":
+		return s.createHost(parts[0], r)
+	case len(parts) == 1 && r.method == "PUT":
+		return s.setHost(parts[0], r)
+	case len(parts) == 1 && r.method == "DELETE":
+		return s.deleteHost(parts[0])
+	case len(parts) == 2 && parts[1] == "volume" && r.method == "GET":
+		return s.listHostConnections(parts[0], r)
+	case len(parts) == 3 && parts[1] == "volume" && r.method == "POST":
+		return s.connectHost(parts[0], parts[2], r)
+	case len(parts) == 3 && parts[1] == "volume" && r.method == "DELETE":
+		return s.disconnectHost(parts[0], parts[2])
+	case len(parts) == 3 && parts[1] == "pgroup" && r.method == "POST":
+		return s.addHostToPgroup(parts[0], parts[2])
+	case len(parts) == 3 && parts[1] == "pgroup" && r.method == "DELETE":
+		return s.removeHostFromPgroup(parts[0], parts[2])
+	}
+	return nil, methodNotAllowedError()
+}
+
+func (s *Server) lookupHost(name string) (*host, *apiError) {
+	h, ok := s.hosts[name]
+	if !ok {
+		return nil, notExistError("Host", name)
+	}
+	return h, nil
+}
+
+func (s *Server) sortedHosts() []*host {
+	var names []string
+	for name := range s.hosts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	l := make([]*host, 0, len(names))
+	for _, name := range names {
+		l = append(l, s.hosts[name])
+	}
+	return l
+}
+
+func (s *Server) hostView(h *host, q url.Values) map[string]interface{} {
+	m := map[string]interface{}{"name": h.name}
+	switch {
+	case q.Get("action") == "monitor":
+		m["time"] = s.now().Format(timeFormat)
+		for _, k := range []string{"reads_per_sec", "writes_per_sec", "input_per_sec", "output_per_sec", "usec_per_read_op", "usec_per_write_op", "san_usec_per_read_op", "san_usec_per_write_op", "queue_depth"} {
+			m[k] = 0
+		}
+	case boolParam(q, "space"):
+		m["volumes"] = 0
+		m["snapshots"] = 0
+		m["total"] = 0
+		m["data_reduction"] = 1.0
+		m["total_reduction"] = 1.0
+		m["thin_provisioning"] = 1.0
+	case boolParam(q, "personality"):
+		m["personality"] = nullable(h.personality)
+	case boolParam(q, "chap"):
+		m["host_user"] = nullable(h.hostUser)
+		m["host_password"] = nil
+		if h.hostPassword != "" {
+			m["host_password"] = "****"
+		}
+		m["target_user"] = nullable(h.targetUser)
+		m["target_password"] = nil
+		if h.targetPassword != "" {
+			m["target_password"] = "****"
+		}
+	case boolParam(q, "preferred_array"):
+		m["preferred_array"] = append([]string{}, h.preferredArray...)
+	default:
+		m["wwn"] = append([]string{}, h.wwn...)
+		m["iqn"] = append([]string{}, h.iqn...)
+		m["nqn"] = append([]string{}, h.nqn...)
+		m["hgroup"] = nil
+		if h.hgroup != nil {
+			m["hgroup"] = h.hgroup.name
+		}
+	}
+	return m
+}
+
+func nullable(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func (s *Server) listHosts(r *request) (interface{}, *apiError) {
+	l := []map[string]interface{}{}
+	for _, h := range s.sortedHosts() {
+		if selected(r.query, h.name) {
+			l = append(l, s.hostView(h, r.query))
+		}
+	}
+	return l, nil
+}
+
+func (s *Server) getHost(name string, r *request) (interface{}, *apiError) {
+	h, err := s.lookupHost(name)
+	if err != nil {
+		return nil, err
+	}
+	if historical(r.query) {
+		return s.history(r.query, s.hostView(h, r.query))
+	}
+	return s.hostView(h, r.query), nil
+}
+
+func (s *Server) createHost(name string, r *request) (interface{}, *apiError) {
+	if err := checkName("host", name); err != nil {
+		return nil, err
+	}
+	if _, ok := s.hosts[name]; ok {
+		return nil, existsError("Host", name)
+	}
+	h := &host{name: name, conns: map[*volume]int{}}
+	if err := s.updateHost(h, r.data); err != nil {
+		return nil, err
+	}
+	s.hosts[name] = h
+	return s.hostView(h, nil), nil
+}
+
+func (s *Server) setHost(name string, r *request) (interface{}, *apiError) {
+	h, err := s.lookupHost(name)
+	if err != nil {
+		return nil, err
+	}
+	// Validate the changes on a copy, so that a rejected request
+	// leaves the host unchanged.
+	updated := *h
+	if err := s.updateHost(&updated, r.data); err != nil {
+		return nil, err
+	}
+
+	newName, err := r.data.str("name")
+	if err != nil {
+		return nil, err
+	}
+	if newName != "" && newName != h.name {
+		if err := checkName("host", newName); err != nil {
+			return nil, err
+		}
+		if _, ok := s.hosts[newName]; ok {
+			return nil, existsError("Host", newName)
+		}
+		delete(s.hosts, h.name)
+		updated.name = newName
+		s.hosts[newName] = h
+	}
+	*h = updated
+	return s.hostView(h, nil), nil
+}
+
+// updateHost applies the initiator, personality, CHAP and preferred array
+// parameters of a request to h.
+func (s *Server) updateHost(h *host, data params) *apiError {
+	for _, i := range initiators {
+		ports := i.get(h)
+		l, set, err := data.list(i.key)
+		if err != nil {
+			return err
+		}
+		if set {
+			*ports = nil
+		}
+		add, _, err := data.list("add" + i.key)
+		if err != nil {
+			return err
+		}
+		for _, port := range append(l, add...) {
+			if i.kind == "WWN" {
+				port = strings.ToUpper(strings.Replace(port, ":", "", -1))
+			}
+			if !i.regexp.MatchString(port) {
+				return errorf(port, "Invalid %s.", i.kind)
+			}
+			for _, other := range s.hosts {
+				if other.name != h.name && contains(*i.get(other), port) {
+					return errorf(port, "The specified %s is already in use by host %s.", i.kind, other.name)
+				}
+			}
+			if !contains(*ports, port) {
+				*ports = append(*ports, port)
+			}
+		}
+		rem, _, err := data.list("rem" + i.key)
+		if err != nil {
+			return err
+		}
+		for _, port := range rem {
+			if i.kind == "WWN" {
+				port = strings.ToUpper(strings.Replace(port, ":", "", -1))
+			}
+			if !contains(*ports, port) {
+				return errorf(port, "The %s is not assigned to host %s.", i.kind, h.name)
+			}
+			*ports = remove(*ports, port)
+		}
+	}
+
+	if data.has("personality") {
+		personality, err := data.str("personality")
+		if err != nil {
+			return err
+		}
+		switch personality {
+		case "", "aix", "esxi", "hitachi-vsp", "hpux", "oracle-vm-server", "solaris", "vms":
+			h.personality = personality
+		default:
+			return invalidParamError("personality")
+		}
+	}
+
+	for key, field := range map[string]*string{"host_user": &h.hostUser, "host_password": &h.hostPassword, "target_user": &h.targetUser, "target_password": &h.targetPassword} {
+		if data.has(key) {
+			v, err := data.str(key)
+			if err != nil {
+				return err
+			}
+			*field = v
+		}
+	}
+
+	if arrays, ok, err := data.list("preferred_array"); err != nil {
+		return err
+	} else if ok {
+		h.preferredArray = arrays
+	}
+	return nil
+}
+
+func (s *Server) deleteHost(name string) (interface{}, *apiError) {
+	h, err := s.lookupHost(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(h.conns) > 0 {
+		return nil, errorf(name, "Host has private volume connections.")
+	}
+	if h.hgroup != nil {
+		return nil, errorf(name, "Host is a member of host group %s.", h.hgroup.name)
+	}
+	for _, pg := range s.pgroups {
+		pg.hosts = removeHost(pg.hosts, h)
+	}
+	delete(s.hosts, name)
+	return map[string]string{"name": name}, nil
+}
+
+// usedLuns returns the LUNs in use by the private and shared connections of h.
+func usedLuns(h *host) map[int]bool {
+	luns := map[int]bool{}
+	for _, lun := range h.conns {
+		luns[lun] = true
+	}
+	if h.hgroup != nil {
+		for _, lun := range h.hgroup.conns {
+			luns[lun] = true
+		}
+	}
+	return luns
+}
+
+// lun returns the LUN requested by a connection request, or the lowest LUN
+// not in use.
+func lun(data params, used map[int]bool) (int, *apiError) {
+	lun, ok, err := data.integer("lun")
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		for lun = minLun; used[lun]; lun++ {
+		}
+	}
+	if lun < minLun || lun > maxLun {
+		return 0, errorf("lun", "LUN must be between %d and %d.", minLun, maxLun)
+	}
+	if used[lun] {
+		return 0, errorf("lun", "LUN %d is already in use.", lun)
+	}
+	return lun, nil
+}
+
+func (s *Server) connectHost(name string, volume string, r *request) (interface{}, *apiError) {
+	h, err := s.lookupHost(name)
+	if err != nil {
+		return nil, err
+	}
+	v, err := s.lookupVolume(volume, false)
+	if err != nil {
+		return nil, err
+	}
+	if v.snapshot {
+		return nil, errorf(volume, "Snapshots cannot be connected to hosts.")
+	}
+	if _, ok := h.conns[v]; ok {
+		return nil, errorf(volume, "Connection already exists.")
+	}
+	if h.hgroup != nil {
+		if _, ok := h.hgroup.conns[v]; ok {
+			return nil, errorf(volume, "Volume is already connected to host group %s.", h.hgroup.name)
+		}
+	}
+	l, err := lun(r.data, usedLuns(h))
+	if err != nil {
+		return nil, err
+	}
+	h.conns[v] = l
+	return map[string]interface{}{"name": h.name, "vol": v.name, "lun": l}, nil
+}
+
+func (s *Server) disconnectHost(name string, volume string) (interface{}, *apiError) {
+	h, err := s.lookupHost(name)
+	if err != nil {
+		return nil, err
+	}
+	v, err := s.lookupVolume(volume, false)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := h.conns[v]; !ok {
+		return nil, errorf(volume, "Connection does not exist.")
+	}
+	delete(h.conns, v)
+	return map[string]string{"name": h.name, "vol": v.name}, nil
+}
+
+func (s *Server) listHostConnections(name string, r *request) (interface{}, *apiError) {
+	h, err := s.lookupHost(name)
+	if err != nil {
+		return nil, err
+	}
+	private, shared := boolParam(r.query, "private"), boolParam(r.query, "shared")
+	if !private && !shared {
+		private, shared = true, true
+	}
+
+	l := []map[string]interface{}{}
+	if private {
+		for _, v := range sortedConnections(h.conns) {
+			l = append(l, map[string]interface{}{"name": h.name, "vol": v.name, "lun": h.conns[v], "hgroup": nil})
+		}
+	}
+	if shared && h.hgroup != nil {
+		for _, v := range sortedConnections(h.hgroup.conns) {
+			l = append(l, map[string]interface{}{"name": h.name, "vol": v.name, "lun": h.hgroup.conns[v], "hgroup": h.hgroup.name})
+		}
+	}
+	return l, nil
+}
+
+func sortedConnections(conns map[*volume]int) []*volume {
+	l := make([]*volume, 0, len(conns))
+	for v := range conns {
+		l = append(l, v)
+	}
+	sort.Slice(l, func(i, j int) bool { return l[i].name < l[j].name })
+	return l
+}
+
+func (s *Server) addHostToPgroup(name string, pgroup string) (interface{}, *apiError) {
+	h, err := s.lookupHost(name)
+	if err != nil {
+		return nil, err
+	}
+	pg, err := s.lookupPgroup(pgroup, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := pg.checkMembers("hosts"); err != nil {
+		return nil, err
+	}
+	if containsHost(pg.hosts, h) {
+		return nil, errorf(name, "Host is already a member of protection group %s.", pgroup)
+	}
+	pg.hosts = append(pg.hosts, h)
+	return map[string]string{"name": h.name, "protection_group": pg.name}, nil
+}
+
+func (s *Server) removeHostFromPgroup(name string, pgroup string) (interface{}, *apiError) {
+	h, err := s.lookupHost(name)
+	if err != nil {
+		return nil, err
+	}
+	pg, err := s.lookupPgroup(pgroup, false)
+	if err != nil {
+		return nil, err
+	}
+	if !containsHost(pg.hosts, h) {
+		return nil, errorf(name, "Host is not a member of protection group %s.", pgroup)
+	}
+	pg.hosts = removeHost(pg.hosts, h)
+	return map[string]string{"name": h.name, "protection_group": pg.name}, nil
+}
+
+func containsHost(l []*host, h *host) bool {
+	for _, e := range l {
+		if e == h {
+			return true
+		}
+	}
+	return false
+}
+
+func removeHost(l []*host, h *host) []*host {
+	r := l[:0:0]
+	for _, e := range l {
+		if e != h {
+			r = append(r, e)
+		}
+	}
+	return r
+}
+
+func hostNames(l []*host) []string {
+	names := []string{}
+	for _, h := range l {
+		names = append(names, h.name)
+	}
+	return names
+}
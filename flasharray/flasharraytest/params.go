@@ -0,0 +1,154 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package flasharraytest
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// params is the JSON body of a request.
+type params map[string]interface{}
+
+func (p params) has(key string) bool {
+	_, ok := p[key]
+	return ok
+}
+
+// str returns the string parameter key, or "" if it is missing.
+func (p params) str(key string) (string, *apiError) {
+	v, ok := p[key]
+	if !ok || v == nil {
+		return "", nil
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", invalidParamError(key)
+	}
+	return s, nil
+}
+
+// boolean returns the boolean parameter key, or false if it is missing.
+func (p params) boolean(key string) (bool, *apiError) {
+	v, ok := p[key]
+	if !ok || v == nil {
+		return false, nil
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, invalidParamError(key)
+	}
+	return b, nil
+}
+
+// integer returns the integer parameter key, and whether it is set.
+func (p params) integer(key string) (int, bool, *apiError) {
+	v, ok := p[key]
+	if !ok || v == nil {
+		return 0, false, nil
+	}
+	f, ok := v.(float64)
+	if !ok || f != float64(int(f)) {
+		return 0, false, invalidParamError(key)
+	}
+	return int(f), true, nil
+}
+
+// size returns the size parameter key, which is a number of bytes or a
+// string like "10G", and whether it is set.
+func (p params) size(key string) (int, bool, *apiError) {
+	v, ok := p[key]
+	if !ok || v == nil {
+		return 0, false, nil
+	}
+	if s, ok := v.(string); ok {
+		size, err := parseSize(s)
+		if err != nil {
+			return 0, false, invalidParamError(key)
+		}
+		return size, true, nil
+	}
+	return p.integer(key)
+}
+
+// list returns the list parameter key, and whether it is set.
+func (p params) list(key string) ([]string, bool, *apiError) {
+	v, ok := p[key]
+	if !ok || v == nil {
+		return nil, false, nil
+	}
+	if s, ok := v.(string); ok {
+		return []string{s}, true, nil
+	}
+	items, ok := v.([]interface{})
+	if !ok {
+		return nil, false, invalidParamError(key)
+	}
+	l := make([]string, 0, len(items))
+	for _, item := range items {
+		s, ok := item.(string)
+		if !ok {
+			return nil, false, invalidParamError(key)
+		}
+		l = append(l, s)
+	}
+	return l, true, nil
+}
+
+func invalidParamError(key string) *apiError {
+	return errorf(key, "Invalid value for parameter %s.", key)
+}
+
+// sizeUnits are the suffixes of sizes passed as strings.
+var sizeUnits = map[byte]int{'K': 1 << 10, 'M': 1 << 20, 'G': 1 << 30, 'T': 1 << 40, 'P': 1 << 50}
+
+func parseSize(s string) (int, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	unit := 1
+	if n := len(s); n > 0 {
+		if u, ok := sizeUnits[s[n-1]]; ok {
+			unit = u
+			s = s[:n-1]
+		}
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, err
+	}
+	return n * unit, nil
+}
+
+// nameRegexp matches the names of the objects of the array.
+var nameRegexp = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]{0,62}$`)
+
+// checkName validates the name of an object, without its container prefix.
+func checkName(kind string, name string) *apiError {
+	if !nameRegexp.MatchString(name) {
+		return errorf(name, "Invalid %s name. Names must be 1-63 characters long, begin with a letter or number, and contain only letters, numbers, '-' and '_'.", kind)
+	}
+	return nil
+}
+
+// contains reports whether l contains s.
+func contains(l []string, s string) bool {
+	for _, e := range l {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+// remove returns l without s.
+func remove(l []string, s string) []string {
+	r := l[:0:0]
+	for _, e := range l {
+		if e != s {
+			r = append(r, e)
+		}
+	}
+	return r
+}
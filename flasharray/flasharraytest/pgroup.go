@@ -0,0 +1,834 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package flasharraytest
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// secondsPerDay is the period of the daily snapshot and replication schedules.
+const secondsPerDay = 86400
+
+// minFrequency is the minimum snapshot and replication frequency, in seconds.
+const minFrequency = 300
+
+// secondsPerHour is the granularity of the times of day of the schedules.
+const secondsPerHour = 3600
+
+type pgroup struct {
+	eradication
+
+	name    string
+	hosts   []*host
+	hgroups []*hgroup
+	volumes []*volume
+	targets []string
+
+	snapEnabled        bool
+	snapFrequency      int
+	snapAt             *int
+	replicateEnabled   bool
+	replicateFrequency int
+	replicateAt        *int
+	replicateBlackout  *blackout
+
+	allFor       int
+	perDay       int
+	days         int
+	targetAllFor int
+	targetPerDay int
+	targetDays   int
+
+	// snapshots is the number of snapshots taken without a suffix.
+	snapshots int
+}
+
+// blackout is a period of the day, in seconds since midnight, during which
+// replication is suspended.
+type blackout struct {
+	start int
+	end   int
+}
+
+type pgroupSnapshot struct {
+	eradication
+
+	name    string
+	source  string
+	created time.Time
+	// size is the size of the volume snapshots.
+	size int
+	// sent is when the snapshot was sent to targets, or zero if it was
+	// not.  It is transferring for SnapshotTransferTime after.
+	sent    time.Time
+	targets []string
+}
+
+func (s *Server) pgroup(r *request) (interface{}, *apiError) {
+	parts := strings.SplitN(r.path, "/", 2)
+	switch {
+	case r.path == "" && r.method == "GET":
+		return s.listPgroups(r)
+	case r.path == "" && r.method == "POST":
+		return s.createPgroupSnapshots(r)
+	case r.path == "" || len(parts) > 1:
+		return nil, methodNotAllowedError()
+	case s.pgroupSnapshots[r.path] != nil && r.method == "PUT":
+		return s.recoverPgroupSnapshot(r.path, r)
+	case s.pgroupSnapshots[r.path] != nil && r.method == "DELETE":
+		return s.deletePgroupSnapshot(r.path, r)
+	case r.method == "GET":
+		return s.getPgroup(r.path, r)
+	case r.method == "POST":
+		return s.createPgroup(r.path, r)
+	case r.method == "PUT":
+		return s.setPgroup(r.path, r)
+	case r.method == "DELETE":
+		return s.deletePgroup(r.path, r)
+	}
+	return nil, methodNotAllowedError()
+}
+
+func (s *Server) lookupPgroup(name string, pending bool) (*pgroup, *apiError) {
+	pg, ok := s.pgroups[name]
+	if !ok || (pg.destroyed && !pending) {
+		return nil, notExistError("Protection group", name)
+	}
+	return pg, nil
+}
+
+// checkMembers validates that members of the given kind can be added to pg,
+// which can only protect one kind of objects.
+func (pg *pgroup) checkMembers(kind string) *apiError {
+	switch {
+	case kind != "hosts" && len(pg.hosts) > 0:
+		return errorf(pg.name, "Protection group already contains hosts.")
+	case kind != "hgroups" && len(pg.hgroups) > 0:
+		return errorf(pg.name, "Protection group already contains host groups.")
+	case kind != "volumes" && len(pg.volumes) > 0:
+		return errorf(pg.name, "Protection group already contains volumes.")
+	}
+	return nil
+}
+
+func (s *Server) pgroupView(pg *pgroup, q url.Values) map[string]interface{} {
+	m := map[string]interface{}{"name": pg.name}
+	switch {
+	case boolParam(q, "space"):
+		m["snapshots"] = 0
+		return m
+	case boolParam(q, "schedule"):
+		s.addPgroupSchedule(pg, m)
+		return m
+	case boolParam(q, "retention"):
+		s.addPgroupRetention(pg, m)
+		return m
+	}
+
+	m["source"] = s.ArrayName
+	m["hosts"] = hostNames(pg.hosts)
+	m["hgroups"] = hgroupNames(pg.hgroups)
+	m["volumes"] = volumeNames(pg.volumes)
+	targets := []map[string]interface{}{}
+	for _, t := range pg.targets {
+		targets = append(targets, map[string]interface{}{"name": t, "allowed": true})
+	}
+	m["targets"] = targets
+	if pg.destroyed {
+		m["time_remaining"] = s.timeRemaining(&pg.eradication)
+	}
+	return m
+}
+
+func (s *Server) addPgroupSchedule(pg *pgroup, m map[string]interface{}) {
+	m["snap_enabled"] = pg.snapEnabled
+	m["snap_frequency"] = pg.snapFrequency
+	m["snap_at"] = pg.snapAt
+	m["replicate_enabled"] = pg.replicateEnabled
+	m["replicate_frequency"] = pg.replicateFrequency
+	m["replicate_at"] = pg.replicateAt
+	m["replicate_blackout"] = nil
+	if pg.replicateBlackout != nil {
+		m["replicate_blackout"] = map[string]int{"start": pg.replicateBlackout.start, "end": pg.replicateBlackout.end}
+	}
+}
+
+func (s *Server) addPgroupRetention(pg *pgroup, m map[string]interface{}) {
+	m["all_for"] = pg.allFor
+	m["per_day"] = pg.perDay
+	m["days"] = pg.days
+	m["target_all_for"] = pg.targetAllFor
+	m["target_per_day"] = pg.targetPerDay
+	m["target_days"] = pg.targetDays
+}
+
+func (s *Server) listPgroups(r *request) (interface{}, *apiError) {
+	if boolParam(r.query, "snap") {
+		return s.listPgroupSnapshots(r)
+	}
+	var names []string
+	for name, pg := range s.pgroups {
+		if listed(r.query, &pg.eradication) && selected(r.query, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	l := []map[string]interface{}{}
+	for _, name := range names {
+		l = append(l, s.pgroupView(s.pgroups[name], r.query))
+	}
+	return l, nil
+}
+
+func (s *Server) getPgroup(name string, r *request) (interface{}, *apiError) {
+	pg, err := s.lookupPgroup(name, boolParam(r.query, "pending"))
+	if err != nil {
+		return nil, err
+	}
+	return s.pgroupView(pg, r.query), nil
+}
+
+func (s *Server) createPgroup(name string, r *request) (interface{}, *apiError) {
+	base, err := s.checkContainer(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkName("protection group", base); err != nil {
+		return nil, err
+	}
+	if _, ok := s.pgroups[name]; ok {
+		return nil, existsError("Protection group", name)
+	}
+
+	pg := &pgroup{
+		name:               name,
+		snapFrequency:      3600,
+		replicateFrequency: 14400,
+		allFor:             secondsPerDay,
+		perDay:             4,
+		days:               7,
+		targetAllFor:       secondsPerDay,
+		targetPerDay:       4,
+		targetDays:         7,
+	}
+	if err := s.updatePgroupMembers(pg, r.data); err != nil {
+		return nil, err
+	}
+	s.pgroups[name] = pg
+	return s.pgroupView(pg, nil), nil
+}
+
+// updatePgroupMembers applies the member and target list parameters of a
+// request to pg.
+func (s *Server) updatePgroupMembers(pg *pgroup, data params) *apiError {
+	updated := *pg
+	for _, kind := range []string{"host", "hgroup", "vol", "target"} {
+		l, set, err := data.list(kind + "list")
+		if err != nil {
+			return err
+		}
+		add, _, err := data.list("add" + kind + "list")
+		if err != nil {
+			return err
+		}
+		rem, _, err := data.list("rem" + kind + "list")
+		if err != nil {
+			return err
+		}
+		if !set && len(add) == 0 && len(rem) == 0 {
+			continue
+		}
+		if err := s.updatePgroupList(&updated, kind, set, l, add, rem); err != nil {
+			return err
+		}
+	}
+	if len(updated.hosts) > 0 && len(updated.hgroups)+len(updated.volumes) > 0 ||
+		len(updated.hgroups) > 0 && len(updated.volumes) > 0 {
+		return errorf(pg.name, "A protection group can only contain hosts, host groups or volumes.")
+	}
+	*pg = updated
+	return nil
+}
+
+func (s *Server) updatePgroupList(pg *pgroup, kind string, set bool, l []string, add []string, rem []string) *apiError {
+	switch kind {
+	case "host":
+		if set {
+			pg.hosts = nil
+		}
+		for _, name := range append(l, add...) {
+			h, err := s.lookupHost(name)
+			if err != nil {
+				return err
+			}
+			if !containsHost(pg.hosts, h) {
+				pg.hosts = append(pg.hosts, h)
+			}
+		}
+		for _, name := range rem {
+			h, err := s.lookupHost(name)
+			if err != nil {
+				return err
+			}
+			pg.hosts = removeHost(pg.hosts, h)
+		}
+	case "hgroup":
+		if set {
+			pg.hgroups = nil
+		}
+		for _, name := range append(l, add...) {
+			g, err := s.lookupHgroup(name)
+			if err != nil {
+				return err
+			}
+			if !containsHgroup(pg.hgroups, g) {
+				pg.hgroups = append(pg.hgroups, g)
+			}
+		}
+		for _, name := range rem {
+			g, err := s.lookupHgroup(name)
+			if err != nil {
+				return err
+			}
+			pg.hgroups = removeHgroup(pg.hgroups, g)
+		}
+	case "vol":
+		if set {
+			pg.volumes = nil
+		}
+		for _, name := range append(l, add...) {
+			v, err := s.lookupVolume(name, false)
+			if err != nil {
+				return err
+			}
+			if v.snapshot {
+				return errorf(name, "Snapshots cannot be added to protection groups.")
+			}
+			if !containsVolume(pg.volumes, v) {
+				pg.volumes = append(pg.volumes, v)
+			}
+		}
+		for _, name := range rem {
+			v, err := s.lookupVolume(name, true)
+			if err != nil {
+				return err
+			}
+			pg.volumes = removeVolume(pg.volumes, v)
+		}
+	case "target":
+		if set {
+			pg.targets = nil
+		}
+		for _, name := range append(l, add...) {
+			if name == s.ArrayName {
+				return errorf(name, "An array cannot be a target of its own protection groups.")
+			}
+			if !s.connectedFor(name, "async-replication") {
+				return errorf(name, "Array %s is not connected for asynchronous replication.", name)
+			}
+			if !contains(pg.targets, name) {
+				pg.targets = append(pg.targets, name)
+			}
+		}
+		for _, name := range rem {
+			pg.targets = remove(pg.targets, name)
+		}
+	}
+	return nil
+}
+
+func (s *Server) setPgroup(name string, r *request) (interface{}, *apiError) {
+	action, err := r.data.str("action")
+	if err != nil {
+		return nil, err
+	}
+	if action == "recover" {
+		pg, err := s.lookupPgroup(name, true)
+		if err != nil {
+			return nil, err
+		}
+		if !pg.destroyed {
+			return nil, errorf(name, "Protection group is not destroyed.")
+		}
+		pg.recover()
+		for _, snap := range s.snapshotsOfPgroup(pg) {
+			snap.recover()
+		}
+		return map[string]string{"name": pg.name}, nil
+	}
+	if action != "" {
+		return nil, invalidParamError("action")
+	}
+
+	pg, err := s.lookupPgroup(name, false)
+	if err != nil {
+		return nil, err
+	}
+	updated := *pg
+	if err := s.updatePgroupMembers(&updated, r.data); err != nil {
+		return nil, err
+	}
+	if err := updatePgroupSchedule(&updated, r.data); err != nil {
+		return nil, err
+	}
+
+	newName, err := r.data.str("name")
+	if err != nil {
+		return nil, err
+	}
+	if newName != "" && newName != pg.name {
+		base, err := s.checkContainer(newName)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkName("protection group", base); err != nil {
+			return nil, err
+		}
+		if _, ok := s.pgroups[newName]; ok {
+			return nil, existsError("Protection group", newName)
+		}
+		s.renamePgroup(pg, newName)
+		updated.name = newName
+	}
+	*pg = updated
+
+	m := s.pgroupView(pg, nil)
+	s.addPgroupSchedule(pg, m)
+	s.addPgroupRetention(pg, m)
+	return m, nil
+}
+
+// renamePgroup renames pg and its snapshots.
+func (s *Server) renamePgroup(pg *pgroup, name string) {
+	for _, snap := range s.snapshotsOfPgroup(pg) {
+		var volumes []*volume
+		for _, v := range s.volumes {
+			if v.snapshot && strings.HasPrefix(v.name, snap.name+".") {
+				volumes = append(volumes, v)
+			}
+		}
+		for _, v := range volumes {
+			delete(s.volumes, v.name)
+			v.name = name + strings.TrimPrefix(v.name, pg.name)
+			s.volumes[v.name] = v
+		}
+		delete(s.pgroupSnapshots, snap.name)
+		snap.name = name + strings.TrimPrefix(snap.name, pg.name)
+		snap.source = name
+		s.pgroupSnapshots[snap.name] = snap
+	}
+	delete(s.pgroups, pg.name)
+	s.pgroups[name] = pg
+}
+
+// updatePgroupSchedule applies the schedule and retention parameters of a
+// request to pg.
+func updatePgroupSchedule(pg *pgroup, data params) *apiError {
+	for key, field := range map[string]*bool{"snap_enabled": &pg.snapEnabled, "replicate_enabled": &pg.replicateEnabled} {
+		if data.has(key) {
+			v, err := data.boolean(key)
+			if err != nil {
+				return err
+			}
+			*field = v
+		}
+	}
+
+	for key, field := range map[string]*int{"snap_frequency": &pg.snapFrequency, "replicate_frequency": &pg.replicateFrequency} {
+		v, ok, err := data.integer(key)
+		if err != nil {
+			return err
+		}
+		if ok {
+			if v < minFrequency {
+				return errorf(key, "Frequency must be at least %d seconds.", minFrequency)
+			}
+			*field = v
+		}
+	}
+
+	for key, field := range map[string]**int{"snap_at": &pg.snapAt, "replicate_at": &pg.replicateAt} {
+		if !data.has(key) {
+			continue
+		}
+		v, ok, err := data.integer(key)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			*field = nil
+			continue
+		}
+		if v < 0 || v >= secondsPerDay {
+			return errorf(key, "Time of day must be between 0 and %d seconds.", secondsPerDay-1)
+		}
+		if v%secondsPerHour != 0 {
+			return errorf(key, "Time of day must be on the hour.")
+		}
+		*field = &v
+	}
+	if pg.snapAt != nil && pg.snapFrequency%secondsPerDay != 0 {
+		return errorf("snap_at", "snap_at can only be set with a frequency of whole days.")
+	}
+	if pg.replicateAt != nil && pg.replicateFrequency%secondsPerDay != 0 {
+		return errorf("replicate_at", "replicate_at can only be set with a frequency of whole days.")
+	}
+
+	if data.has("replicate_blackout") {
+		switch b := data["replicate_blackout"].(type) {
+		case nil:
+			pg.replicateBlackout = nil
+		case map[string]interface{}:
+			blackout, err := parseBlackout(b)
+			if err != nil {
+				return err
+			}
+			pg.replicateBlackout = blackout
+		case []interface{}:
+			pg.replicateBlackout = nil
+			if len(b) > 1 {
+				return invalidParamError("replicate_blackout")
+			}
+			if len(b) == 1 {
+				m, ok := b[0].(map[string]interface{})
+				if !ok {
+					return invalidParamError("replicate_blackout")
+				}
+				blackout, err := parseBlackout(m)
+				if err != nil {
+					return err
+				}
+				pg.replicateBlackout = blackout
+			}
+		default:
+			return invalidParamError("replicate_blackout")
+		}
+	}
+
+	for key, field := range map[string]*int{"all_for": &pg.allFor, "per_day": &pg.perDay, "days": &pg.days,
+		"target_all_for": &pg.targetAllFor, "target_per_day": &pg.targetPerDay, "target_days": &pg.targetDays} {
+		v, ok, err := data.integer(key)
+		if err != nil {
+			return err
+		}
+		if ok {
+			if v < 0 {
+				return errorf(key, "Retention must not be negative.")
+			}
+			*field = v
+		}
+	}
+	return nil
+}
+
+func parseBlackout(m map[string]interface{}) (*blackout, *apiError) {
+	p := params(m)
+	start, ok1, err := p.integer("start")
+	if err != nil {
+		return nil, invalidParamError("replicate_blackout")
+	}
+	end, ok2, err := p.integer("end")
+	if err != nil {
+		return nil, invalidParamError("replicate_blackout")
+	}
+	if !ok1 || !ok2 || start < 0 || start >= secondsPerDay || end < 0 || end >= secondsPerDay {
+		return nil, invalidParamError("replicate_blackout")
+	}
+	if start%secondsPerHour != 0 || end%secondsPerHour != 0 {
+		return nil, errorf("replicate_blackout", "Blackout times must be on the hour.")
+	}
+	if start == end {
+		return nil, nil
+	}
+	return &blackout{start: start, end: end}, nil
+}
+
+func (s *Server) deletePgroup(name string, r *request) (interface{}, *apiError) {
+	eradicate, err := r.data.boolean("eradicate")
+	if err != nil {
+		return nil, err
+	}
+	pg, err := s.lookupPgroup(name, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if eradicate {
+		if !pg.destroyed {
+			return nil, errorf(name, "Protection group has not been destroyed.")
+		}
+		for _, snap := range s.snapshotsOfPgroup(pg) {
+			s.eradicatePgroupSnapshot(snap)
+		}
+		delete(s.pgroups, name)
+		return map[string]string{"name": name}, nil
+	}
+
+	if pg.destroyed {
+		return nil, errorf(name, "Protection group has already been destroyed.")
+	}
+	now := s.now()
+	pg.destroy(now)
+	for _, snap := range s.snapshotsOfPgroup(pg) {
+		if !snap.destroyed {
+			snap.destroy(now)
+		}
+	}
+	return map[string]string{"name": name}, nil
+}
+
+// snapshotsOfPgroup returns the snapshots of pg, including the destroyed ones.
+func (s *Server) snapshotsOfPgroup(pg *pgroup) []*pgroupSnapshot {
+	var snaps []*pgroupSnapshot
+	for _, snap := range s.pgroupSnapshots {
+		if snap.source == pg.name {
+			snaps = append(snaps, snap)
+		}
+	}
+	return snaps
+}
+
+// eradicatePgroupSnapshot removes snap and the volume snapshots it contains.
+func (s *Server) eradicatePgroupSnapshot(snap *pgroupSnapshot) {
+	for _, v := range s.volumesOfPgroupSnapshot(snap) {
+		delete(s.volumes, v.name)
+	}
+	delete(s.pgroupSnapshots, snap.name)
+}
+
+// protectedVolumes returns the volumes protected by pg: its volumes, or the
+// volumes connected to its hosts and host groups.
+func (pg *pgroup) protectedVolumes() []*volume {
+	var l []*volume
+	add := func(v *volume) {
+		if !containsVolume(l, v) {
+			l = append(l, v)
+		}
+	}
+	for _, v := range pg.volumes {
+		add(v)
+	}
+	for _, h := range pg.hosts {
+		for _, v := range sortedConnections(h.conns) {
+			add(v)
+		}
+		if h.hgroup != nil {
+			for _, v := range sortedConnections(h.hgroup.conns) {
+				add(v)
+			}
+		}
+	}
+	for _, g := range pg.hgroups {
+		for _, v := range sortedConnections(g.conns) {
+			add(v)
+		}
+	}
+	return l
+}
+
+func (s *Server) createPgroupSnapshots(r *request) (interface{}, *apiError) {
+	snap, err := r.data.boolean("snap")
+	if err != nil {
+		return nil, err
+	}
+	action, err := r.data.str("action")
+	if err != nil {
+		return nil, err
+	}
+	if !snap && action != "send" {
+		return nil, errorf("", "Missing parameter snap.")
+	}
+	sources, _, err := r.data.list("source")
+	if err != nil {
+		return nil, err
+	}
+	if len(sources) == 0 {
+		return nil, errorf("source", "Missing parameter source.")
+	}
+	suffix, err := r.data.str("suffix")
+	if err != nil {
+		return nil, err
+	}
+	if suffix != "" {
+		if err := checkName("suffix", suffix); err != nil {
+			return nil, err
+		}
+	}
+
+	var pgroups []*pgroup
+	for _, source := range sources {
+		pg, err := s.lookupPgroup(source, false)
+		if err != nil {
+			return nil, err
+		}
+		if action == "send" && len(pg.targets) == 0 {
+			return nil, errorf(source, "Protection group does not have any targets.")
+		}
+		if suffix != "" {
+			if _, ok := s.pgroupSnapshots[source+"."+suffix]; ok {
+				return nil, existsError("Protection group snapshot", source+"."+suffix)
+			}
+		}
+		pgroups = append(pgroups, pg)
+	}
+
+	l := []map[string]interface{}{}
+	for _, pg := range pgroups {
+		name := pg.name + "." + suffix
+		if suffix == "" {
+			for {
+				pg.snapshots++
+				name = fmt.Sprintf("%s.%d", pg.name, pg.snapshots)
+				if _, ok := s.pgroupSnapshots[name]; !ok {
+					break
+				}
+			}
+		}
+		snap := &pgroupSnapshot{name: name, source: pg.name, created: s.now()}
+		if action == "send" {
+			snap.sent = snap.created
+			snap.targets = append([]string{}, pg.targets...)
+		}
+		s.pgroupSnapshots[name] = snap
+		for _, v := range pg.protectedVolumes() {
+			s.newSnapshot(v, name+"."+v.name)
+			snap.size += v.size
+		}
+		l = append(l, s.pgroupSnapshotView(snap, nil))
+	}
+	return l, nil
+}
+
+// transferProgress returns the fraction of snap transferred to its targets.
+func (s *Server) transferProgress(snap *pgroupSnapshot) float64 {
+	elapsed := s.now().Sub(snap.sent)
+	if elapsed >= s.SnapshotTransferTime {
+		return 1
+	}
+	return float64(elapsed) / float64(s.SnapshotTransferTime)
+}
+
+func (s *Server) pgroupSnapshotView(snap *pgroupSnapshot, q url.Values) map[string]interface{} {
+	m := map[string]interface{}{
+		"name":    snap.name,
+		"source":  snap.source,
+		"created": snap.created.Format(timeFormat),
+	}
+	if boolParam(q, "transfer") {
+		m["started"], m["completed"], m["progress"] = nil, nil, nil
+		m["data_transferred"], m["physical_bytes_written"] = nil, nil
+		if !snap.sent.IsZero() {
+			progress := s.transferProgress(snap)
+			m["started"] = snap.sent.Format(timeFormat)
+			m["progress"] = progress
+			m["data_transferred"] = int(progress * float64(snap.size))
+			m["physical_bytes_written"] = int(progress * float64(snap.size))
+			if progress == 1 {
+				m["completed"] = snap.sent.Add(s.SnapshotTransferTime).Format(timeFormat)
+			}
+		}
+	}
+	if snap.destroyed {
+		m["time_remaining"] = s.timeRemaining(&snap.eradication)
+	}
+	return m
+}
+
+func (s *Server) listPgroupSnapshots(r *request) (interface{}, *apiError) {
+	target := r.query.Get("on")
+	if target != "" {
+		if _, err := s.lookupConnection(target); err != nil {
+			return nil, err
+		}
+	}
+
+	var names []string
+	for name, snap := range s.pgroupSnapshots {
+		if target != "" && (!contains(snap.targets, target) || s.transferProgress(snap) < 1) {
+			continue
+		}
+		if listed(r.query, &snap.eradication) && (selected(r.query, name) || selected(r.query, snap.source)) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	l := []map[string]interface{}{}
+	for _, name := range names {
+		m := s.pgroupSnapshotView(s.pgroupSnapshots[name], r.query)
+		if target != "" {
+			// On the target, snapshots are named after the source array.
+			m["name"] = s.ArrayName + ":" + name
+			m["source"] = s.ArrayName + ":" + s.pgroupSnapshots[name].source
+		}
+		l = append(l, m)
+	}
+	return l, nil
+}
+
+// volumesOfPgroupSnapshot returns the volume snapshots of snap.
+func (s *Server) volumesOfPgroupSnapshot(snap *pgroupSnapshot) []*volume {
+	var volumes []*volume
+	for name, v := range s.volumes {
+		if v.snapshot && strings.HasPrefix(name, snap.name+".") {
+			volumes = append(volumes, v)
+		}
+	}
+	return volumes
+}
+
+func (s *Server) recoverPgroupSnapshot(name string, r *request) (interface{}, *apiError) {
+	action, err := r.data.str("action")
+	if err != nil {
+		return nil, err
+	}
+	if action != "recover" {
+		return nil, invalidParamError("action")
+	}
+	snap := s.pgroupSnapshots[name]
+	if !snap.destroyed {
+		return nil, errorf(name, "Protection group snapshot is not destroyed.")
+	}
+	if _, err := s.lookupPgroup(snap.source, false); err != nil {
+		return nil, errorf(name, "Protection group %s is destroyed.", snap.source)
+	}
+	snap.recover()
+	for _, v := range s.volumesOfPgroupSnapshot(snap) {
+		v.recover()
+	}
+	return s.pgroupSnapshotView(snap, nil), nil
+}
+
+func (s *Server) deletePgroupSnapshot(name string, r *request) (interface{}, *apiError) {
+	eradicate, err := r.data.boolean("eradicate")
+	if err != nil {
+		return nil, err
+	}
+	snap := s.pgroupSnapshots[name]
+	if eradicate {
+		if !snap.destroyed {
+			return nil, errorf(name, "Protection group snapshot has not been destroyed.")
+		}
+		s.eradicatePgroupSnapshot(snap)
+		return map[string]string{"name": name}, nil
+	}
+
+	if snap.destroyed {
+		return nil, errorf(name, "Protection group snapshot has already been destroyed.")
+	}
+	now := s.now()
+	snap.destroy(now)
+	for _, v := range s.volumesOfPgroupSnapshot(snap) {
+		if !v.destroyed {
+			v.destroy(now)
+		}
+	}
+	return s.pgroupSnapshotView(snap, nil), nil
+}
@@ -0,0 +1,635 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package flasharraytest
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// undoDemoteSuffix is appended to the name of a pod to name the undo pod
+// created when it is demoted.
+const undoDemoteSuffix = ".undo-demote"
+
+type pod struct {
+	eradication
+
+	name               string
+	source             string
+	arrays             []*podArray
+	failoverPreference []string
+	promotionStatus    string
+	requestedPromotion string
+}
+
+// podArray is an array a pod is stretched to.
+type podArray struct {
+	name           string
+	id             string
+	status         string
+	mediatorStatus string
+	frozenAt       time.Time
+	// stretched is when the pod was stretched to the array, which is
+	// resyncing for PodResyncTime after.
+	stretched time.Time
+}
+
+// replicaLink is an ActiveDR replica link from a pod of the array to a pod
+// of a remote array.
+type replicaLink struct {
+	pod       *pod
+	remote    string
+	remotePod string
+	paused    bool
+}
+
+// SetPodArrayStatus sets the status of the pod name on the array, and the
+// status of the mediator as seen by the array, i.e. to simulate the loss of
+// a peer array.  The array is frozen at the current time when it is set
+// offline.  It panics if the pod is not stretched to the array.
+func (s *Server) SetPodArrayStatus(name string, array string, status string, mediatorStatus string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.pods[name]
+	if !ok || !p.stretchedTo(array) {
+		panic("flasharraytest: pod " + name + " is not stretched to array " + array)
+	}
+	for _, a := range p.arrays {
+		if a.name == array {
+			a.status, a.mediatorStatus = status, mediatorStatus
+			a.frozenAt = time.Time{}
+			if status == "offline" {
+				a.frozenAt = s.now()
+			}
+			a.stretched = time.Time{}
+		}
+	}
+}
+
+func (s *Server) pod(r *request) (interface{}, *apiError) {
+	parts := strings.SplitN(r.path, "/", 3)
+	switch {
+	case r.path == "" && r.method == "GET":
+		return s.listPods(r)
+	case r.path == "":
+		return nil, methodNotAllowedError()
+	case r.path == "replica-link" && r.method == "GET":
+		return s.listReplicaLinks(r)
+	case len(parts) == 3 && parts[1] == "replica-link" && r.method == "POST":
+		return s.createReplicaLink(parts[0], parts[2], r)
+	case len(parts) == 3 && parts[1] == "replica-link" && r.method == "PUT":
+		return s.setReplicaLink(parts[0], parts[2], r)
+	case len(parts) == 3 && parts[1] == "replica-link" && r.method == "DELETE":
+		return s.deleteReplicaLink(parts[0], parts[2], r)
+	case len(parts) == 1 && r.method == "GET":
+		return s.getPod(parts[0], r)
+	case len(parts) == 1 && r.method == "POST":
+		return s.createPod(parts[0], r)
+	case len(parts) == 1 && r.method == "PUT":
+		return s.setPod(parts[0], r)
+	case len(parts) == 1 && r.method == "DELETE":
+		return s.deletePod(parts[0], r)
+	case len(parts) == 3 && parts[1] == "array" && r.method == "POST":
+		return s.stretchPod(parts[0], parts[2])
+	case len(parts) == 3 && parts[1] == "array" && r.method == "DELETE":
+		return s.unstretchPod(parts[0], parts[2])
+	}
+	return nil, methodNotAllowedError()
+}
+
+// checkContainer validates that the pod of an object exists, and returns the
+// name of the object without its pod prefix.
+func (s *Server) checkContainer(name string) (string, *apiError) {
+	i := strings.Index(name, "::")
+	if i < 0 {
+		return name, nil
+	}
+	if p, ok := s.pods[name[:i]]; !ok || p.destroyed {
+		return "", notExistError("Pod", name[:i])
+	}
+	return name[i+2:], nil
+}
+
+func (s *Server) lookupPod(name string, pending bool) (*pod, *apiError) {
+	p, ok := s.pods[name]
+	if !ok || (p.destroyed && !pending) {
+		return nil, notExistError("Pod", name)
+	}
+	return p, nil
+}
+
+func (s *Server) podView(p *pod) map[string]interface{} {
+	arrays := []map[string]interface{}{}
+	for _, a := range p.arrays {
+		status, progress := a.status, interface{}(nil)
+		if status == "online" && !a.stretched.IsZero() {
+			if elapsed := s.now().Sub(a.stretched); elapsed < s.PodResyncTime {
+				status, progress = "resyncing", float64(elapsed)/float64(s.PodResyncTime)
+			}
+		}
+		var frozenAt interface{}
+		if !a.frozenAt.IsZero() {
+			frozenAt = a.frozenAt.UTC().Format(timeFormat)
+		}
+		arrays = append(arrays, map[string]interface{}{
+			"name":            a.name,
+			"array_id":        a.id,
+			"status":          status,
+			"progress":        progress,
+			"frozen_at":       frozenAt,
+			"mediator_status": a.mediatorStatus,
+		})
+	}
+	m := map[string]interface{}{
+		"name":                      p.name,
+		"source":                    nullable(p.source),
+		"arrays":                    arrays,
+		"failover_preference":       append([]string{}, p.failoverPreference...),
+		"promotion_status":          p.promotionStatus,
+		"requested_promotion_state": p.requestedPromotion,
+	}
+	if p.destroyed {
+		m["time_remaining"] = s.timeRemaining(&p.eradication)
+	}
+	return m
+}
+
+// podMembers returns the volumes and protection groups in p,
+// including the destroyed ones.
+func (s *Server) podMembers(p *pod) (volumes []*volume, pgroups []*pgroup) {
+	prefix := p.name + "::"
+	for name, v := range s.volumes {
+		if strings.HasPrefix(name, prefix) {
+			volumes = append(volumes, v)
+		}
+	}
+	for name, pg := range s.pgroups {
+		if strings.HasPrefix(name, prefix) {
+			pgroups = append(pgroups, pg)
+		}
+	}
+	return volumes, pgroups
+}
+
+func (s *Server) listPods(r *request) (interface{}, *apiError) {
+	var names []string
+	for name, p := range s.pods {
+		if listed(r.query, &p.eradication) && selected(r.query, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	l := []map[string]interface{}{}
+	for _, name := range names {
+		l = append(l, s.podView(s.pods[name]))
+	}
+	return l, nil
+}
+
+func (s *Server) getPod(name string, r *request) (interface{}, *apiError) {
+	p, err := s.lookupPod(name, boolParam(r.query, "pending"))
+	if err != nil {
+		return nil, err
+	}
+	return s.podView(p), nil
+}
+
+func (s *Server) createPod(name string, r *request) (interface{}, *apiError) {
+	if err := checkName("pod", name); err != nil {
+		return nil, err
+	}
+	if _, ok := s.pods[name]; ok {
+		return nil, existsError("Pod", name)
+	}
+	source, err := r.data.str("source")
+	if err != nil {
+		return nil, err
+	}
+	var src *pod
+	if source != "" {
+		if src, err = s.lookupPod(source, false); err != nil {
+			return nil, err
+		}
+	}
+	preference, _, err := r.data.list("failover_preference")
+	if err != nil {
+		return nil, err
+	}
+
+	p := &pod{
+		name:               name,
+		source:             source,
+		arrays:             []*podArray{{name: s.ArrayName, id: s.arrayID, status: "online", mediatorStatus: "online"}},
+		failoverPreference: preference,
+		promotionStatus:    "promoted",
+		requestedPromotion: "promoted",
+	}
+	s.pods[name] = p
+	if src != nil {
+		volumes, _ := s.podMembers(src)
+		for _, v := range volumes {
+			if !v.destroyed && !v.snapshot {
+				s.newVolume(name+strings.TrimPrefix(v.name, src.name), v.size, v.name)
+			}
+		}
+	}
+	return s.podView(p), nil
+}
+
+func (s *Server) setPod(name string, r *request) (interface{}, *apiError) {
+	action, err := r.data.str("action")
+	if err != nil {
+		return nil, err
+	}
+	if action == "recover" {
+		p, err := s.lookupPod(name, true)
+		if err != nil {
+			return nil, err
+		}
+		if !p.destroyed {
+			return nil, errorf(name, "Pod is not destroyed.")
+		}
+		p.recover()
+		return map[string]string{"name": p.name}, nil
+	}
+	if action != "" {
+		return nil, invalidParamError("action")
+	}
+
+	p, err := s.lookupPod(name, false)
+	if err != nil {
+		return nil, err
+	}
+	if state, err := r.data.str("requested_promotion_state"); err != nil {
+		return nil, err
+	} else if state != "" {
+		if err := s.setPromotion(p, state, r.data); err != nil {
+			return nil, err
+		}
+	}
+	if preference, ok, err := r.data.list("failover_preference"); err != nil {
+		return nil, err
+	} else if ok {
+		for _, a := range preference {
+			if !p.stretchedTo(a) {
+				return nil, errorf(a, "Pod is not stretched to array %s.", a)
+			}
+		}
+		p.failoverPreference = preference
+	}
+
+	newName, err := r.data.str("name")
+	if err != nil {
+		return nil, err
+	}
+	if newName != "" && newName != p.name {
+		if err := checkName("pod", newName); err != nil {
+			return nil, err
+		}
+		if _, ok := s.pods[newName]; ok {
+			return nil, existsError("Pod", newName)
+		}
+		s.renamePod(p, newName)
+	}
+	return s.podView(p), nil
+}
+
+// renamePod renames p and the volumes and protection groups in it.
+func (s *Server) renamePod(p *pod, name string) {
+	volumes, pgroups := s.podMembers(p)
+	for _, v := range volumes {
+		delete(s.volumes, v.name)
+	}
+	for _, v := range volumes {
+		v.name = name + strings.TrimPrefix(v.name, p.name)
+		if strings.HasPrefix(v.source, p.name+"::") {
+			v.source = name + strings.TrimPrefix(v.source, p.name)
+		}
+		s.volumes[v.name] = v
+	}
+	for _, pg := range pgroups {
+		delete(s.pgroups, pg.name)
+	}
+	for _, pg := range pgroups {
+		pg.name = name + strings.TrimPrefix(pg.name, p.name)
+		s.pgroups[pg.name] = pg
+	}
+	for n, g := range s.vgroups {
+		if strings.HasPrefix(n, p.name+"::") {
+			delete(s.vgroups, n)
+			g.name = name + strings.TrimPrefix(n, p.name)
+			s.vgroups[g.name] = g
+		}
+	}
+	delete(s.pods, p.name)
+	p.name = name
+	s.pods[name] = p
+}
+
+func (p *pod) stretchedTo(array string) bool {
+	for _, a := range p.arrays {
+		if a.name == array {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) deletePod(name string, r *request) (interface{}, *apiError) {
+	eradicate, err := r.data.boolean("eradicate")
+	if err != nil {
+		return nil, err
+	}
+	p, err := s.lookupPod(name, true)
+	if err != nil {
+		return nil, err
+	}
+	volumes, pgroups := s.podMembers(p)
+
+	if eradicate {
+		if !p.destroyed {
+			return nil, errorf(name, "Pod has not been destroyed.")
+		}
+		s.eradicatePod(p)
+		return map[string]string{"name": name}, nil
+	}
+
+	if p.destroyed {
+		return nil, errorf(name, "Pod has already been destroyed.")
+	}
+	if len(p.arrays) > 1 {
+		return nil, errorf(name, "Pod is stretched to other arrays.")
+	}
+	if s.podReplicaLink(p) != nil {
+		return nil, errorf(name, "Pod has a replica link.")
+	}
+	for _, v := range volumes {
+		if !v.destroyed {
+			return nil, errorf(name, "Pod contains volumes.")
+		}
+	}
+	for _, pg := range pgroups {
+		if !pg.destroyed {
+			return nil, errorf(name, "Pod contains protection groups.")
+		}
+	}
+	p.destroy(s.now())
+	return map[string]string{"name": name}, nil
+}
+
+func (s *Server) stretchPod(name string, array string) (interface{}, *apiError) {
+	p, err := s.lookupPod(name, false)
+	if err != nil {
+		return nil, err
+	}
+	if p.stretchedTo(array) {
+		return nil, errorf(array, "Pod is already stretched to array %s.", array)
+	}
+	if !s.connectedFor(array, "sync-replication") {
+		return nil, errorf(array, "Array %s is not connected for synchronous replication.", array)
+	}
+	p.arrays = append(p.arrays, &podArray{name: array, id: newID(), status: "online", mediatorStatus: "online", stretched: s.now()})
+	return s.podView(p), nil
+}
+
+func (s *Server) unstretchPod(name string, array string) (interface{}, *apiError) {
+	p, err := s.lookupPod(name, false)
+	if err != nil {
+		return nil, err
+	}
+	if !p.stretchedTo(array) {
+		return nil, errorf(array, "Pod is not stretched to array %s.", array)
+	}
+	if len(p.arrays) == 1 {
+		return nil, errorf(array, "Cannot remove the last array of a pod.")
+	}
+	var arrays []*podArray
+	for _, a := range p.arrays {
+		if a.name != array {
+			arrays = append(arrays, a)
+		}
+	}
+	p.arrays = arrays
+	p.failoverPreference = remove(p.failoverPreference, array)
+	return s.podView(p), nil
+}
+
+// eradicatePod eradicates the destroyed pod p and the objects in it.
+func (s *Server) eradicatePod(p *pod) {
+	volumes, pgroups := s.podMembers(p)
+	for _, v := range volumes {
+		s.eradicateVolume(v)
+	}
+	for _, pg := range pgroups {
+		for _, snap := range s.snapshotsOfPgroup(pg) {
+			s.eradicatePgroupSnapshot(snap)
+		}
+		delete(s.pgroups, pg.name)
+	}
+	delete(s.pods, p.name)
+}
+
+// setPromotion promotes or demotes p.  The promotion completes immediately.
+func (s *Server) setPromotion(p *pod, state string, data params) *apiError {
+	quiesce, err := data.boolean("quiesce")
+	if err != nil {
+		return err
+	}
+	skipQuiesce, err := data.boolean("skip_quiesce")
+	if err != nil {
+		return err
+	}
+	promoteFrom, err := data.str("promote_from")
+	if err != nil {
+		return err
+	}
+
+	switch state {
+	case "promoted":
+		if quiesce || skipQuiesce {
+			return errorf("quiesce", "Quiesce options are only valid when demoting a pod.")
+		}
+		if promoteFrom != "" {
+			if p.promotionStatus != "demoted" {
+				return errorf(p.name, "Pod is not demoted.")
+			}
+			undo, err := s.lookupPod(promoteFrom, true)
+			if err != nil {
+				return err
+			}
+			if undo.name != p.name+undoDemoteSuffix || !undo.destroyed {
+				return errorf(promoteFrom, "Pod %s is not an undo pod of pod %s.", promoteFrom, p.name)
+			}
+			s.restorePod(p, undo)
+		}
+	case "demoted":
+		if promoteFrom != "" {
+			return errorf("promote_from", "promote_from is only valid when promoting a pod.")
+		}
+		if p.promotionStatus == "demoted" {
+			break
+		}
+		if len(p.arrays) > 1 {
+			return errorf(p.name, "Pod is stretched to other arrays.")
+		}
+		switch {
+		case quiesce && skipQuiesce:
+			return errorf("quiesce", "quiesce and skip_quiesce cannot be specified together.")
+		case (quiesce || skipQuiesce) && s.podReplicaLink(p) == nil:
+			return errorf(p.name, "Pod is not the source of a replica link.")
+		case !quiesce && !skipQuiesce && s.podReplicaLink(p) != nil:
+			return errorf(p.name, "Pod is the source of a replica link. Specify quiesce or skip_quiesce.")
+		}
+		s.newUndoPod(p)
+	default:
+		return invalidParamError("requested_promotion_state")
+	}
+	p.promotionStatus, p.requestedPromotion = state, state
+	return nil
+}
+
+// newUndoPod replaces the undo pod of p with a destroyed copy of its volumes.
+func (s *Server) newUndoPod(p *pod) {
+	name := p.name + undoDemoteSuffix
+	if undo, ok := s.pods[name]; ok {
+		s.eradicatePod(undo)
+	}
+	undo := &pod{
+		name:               name,
+		arrays:             []*podArray{{name: s.ArrayName, id: s.arrayID, status: "online", mediatorStatus: "online"}},
+		promotionStatus:    "demoted",
+		requestedPromotion: "demoted",
+	}
+	s.pods[name] = undo
+	volumes, _ := s.podMembers(p)
+	for _, v := range volumes {
+		if !v.destroyed && !v.snapshot {
+			s.newVolume(name+strings.TrimPrefix(v.name, p.name), v.size, v.name)
+		}
+	}
+	undo.destroy(s.now())
+}
+
+// restorePod restores the sizes of the volumes of p from its undo pod.
+func (s *Server) restorePod(p *pod, undo *pod) {
+	volumes, _ := s.podMembers(undo)
+	for _, u := range volumes {
+		if v, ok := s.volumes[p.name+strings.TrimPrefix(u.name, undo.name)]; ok && !v.destroyed {
+			v.size = u.size
+		}
+	}
+}
+
+// podReplicaLink returns the replica link of p, or nil if it has none.
+func (s *Server) podReplicaLink(p *pod) *replicaLink {
+	for _, l := range s.replicaLinks {
+		if l.pod == p {
+			return l
+		}
+	}
+	return nil
+}
+
+func (s *Server) replicaLinkView(l *replicaLink) map[string]interface{} {
+	status := "replicating"
+	if l.paused {
+		status = "paused"
+	}
+	return map[string]interface{}{
+		"local_pod_name":  l.pod.name,
+		"remote_pod_name": l.remotePod,
+		"remote_names":    []string{l.remote},
+		"direction":       "outbound",
+		"status":          status,
+		"paused":          l.paused,
+		"recovery_point":  s.now().UnixNano() / int64(time.Millisecond),
+		"lag":             0,
+	}
+}
+
+// lookupReplicaLink returns the replica link from the pod name to remotePod
+// on the remote array.
+func (s *Server) lookupReplicaLink(name string, remotePod string, data params) (*replicaLink, *apiError) {
+	remote, err := data.str("remote")
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range s.replicaLinks {
+		if l.pod.name == name && l.remotePod == remotePod && l.remote == remote {
+			return l, nil
+		}
+	}
+	return nil, notExistError("Replica link", name+":"+remote+":"+remotePod)
+}
+
+func (s *Server) listReplicaLinks(r *request) (interface{}, *apiError) {
+	l := []map[string]interface{}{}
+	for _, link := range s.replicaLinks {
+		if selected(r.query, link.pod.name) {
+			l = append(l, s.replicaLinkView(link))
+		}
+	}
+	return l, nil
+}
+
+func (s *Server) createReplicaLink(name string, remotePod string, r *request) (interface{}, *apiError) {
+	p, err := s.lookupPod(name, false)
+	if err != nil {
+		return nil, err
+	}
+	remote, err := r.data.str("remote")
+	if err != nil {
+		return nil, err
+	}
+	if err := checkName("pod", remotePod); err != nil {
+		return nil, err
+	}
+	if !s.connectedFor(remote, "async-replication") {
+		return nil, errorf(remote, "Array %s is not connected for asynchronous replication.", remote)
+	}
+	if p.promotionStatus != "promoted" {
+		return nil, errorf(name, "Pod must be promoted to be the source of a replica link.")
+	}
+	if s.podReplicaLink(p) != nil {
+		return nil, errorf(name, "Pod already has a replica link.")
+	}
+
+	l := &replicaLink{pod: p, remote: remote, remotePod: remotePod}
+	s.replicaLinks = append(s.replicaLinks, l)
+	return s.replicaLinkView(l), nil
+}
+
+func (s *Server) setReplicaLink(name string, remotePod string, r *request) (interface{}, *apiError) {
+	l, err := s.lookupReplicaLink(name, remotePod, r.data)
+	if err != nil {
+		return nil, err
+	}
+	if !r.data.has("paused") {
+		return s.replicaLinkView(l), nil
+	}
+	paused, err := r.data.boolean("paused")
+	if err != nil {
+		return nil, err
+	}
+	l.paused = paused
+	return s.replicaLinkView(l), nil
+}
+
+func (s *Server) deleteReplicaLink(name string, remotePod string, r *request) (interface{}, *apiError) {
+	l, err := s.lookupReplicaLink(name, remotePod, r.data)
+	if err != nil {
+		return nil, err
+	}
+	var links []*replicaLink
+	for _, e := range s.replicaLinks {
+		if e != l {
+			links = append(links, e)
+		}
+	}
+	s.replicaLinks = links
+	return s.replicaLinkView(l), nil
+}
@@ -0,0 +1,71 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package flasharraytest
+
+// Bounds of the QoS limits of volumes and volume groups.
+const (
+	minBandwidthLimit = 1 << 20
+	maxBandwidthLimit = 512 << 30
+	minIopsLimit      = 100
+	maxIopsLimit      = 100000000
+)
+
+// qos holds the QoS limits of a volume or volume group; nil is unlimited.
+type qos struct {
+	bandwidthLimit *int
+	iopsLimit      *int
+}
+
+// hasQoS reports whether a request sets QoS limits.
+func hasQoS(data params) bool {
+	return data.has("bandwidth_limit") || data.has("iops_limit")
+}
+
+// updateQoS returns q updated with the QoS limits of a request.  A null or
+// empty limit removes it.
+func updateQoS(q qos, data params) (qos, *apiError) {
+	if data.has("bandwidth_limit") {
+		q.bandwidthLimit = nil
+		if s, ok := data["bandwidth_limit"].(string); !ok || s != "" {
+			n, ok, err := data.size("bandwidth_limit")
+			if err != nil {
+				return q, err
+			}
+			if ok {
+				if n < minBandwidthLimit || n > maxBandwidthLimit {
+					return q, errorf("bandwidth_limit", "Bandwidth limit must be between 1M and 512G.")
+				}
+				q.bandwidthLimit = &n
+			}
+		}
+	}
+	if data.has("iops_limit") {
+		q.iopsLimit = nil
+		if s, ok := data["iops_limit"].(string); !ok || s != "" {
+			n, ok, err := data.integer("iops_limit")
+			if err != nil {
+				return q, err
+			}
+			if ok {
+				if n < minIopsLimit || n > maxIopsLimit {
+					return q, errorf("iops_limit", "IOPS limit must be between 100 and 100M.")
+				}
+				q.iopsLimit = &n
+			}
+		}
+	}
+	return q, nil
+}
+
+func qosView(name string, q qos) map[string]interface{} {
+	m := map[string]interface{}{"name": name, "bandwidth_limit": nil, "iops_limit": nil}
+	if q.bandwidthLimit != nil {
+		m["bandwidth_limit"] = *q.bandwidthLimit
+	}
+	if q.iopsLimit != nil {
+		m["iops_limit"] = *q.iopsLimit
+	}
+	return m
+}
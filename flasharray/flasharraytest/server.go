@@ -0,0 +1,422 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+// Package flasharraytest provides an in-memory FlashArray for testing code
+// that uses the flasharray package without a connection to an array.
+//
+// The fake array implements the api_version and session endpoints, the array
+// connection endpoints, and the volume, host, host group, protection group,
+// pod, pod replica link and volume group endpoints of the REST 1.x API.
+// Remote arrays it can connect to are registered with AddRemoteArray, and the
+// status of stretched pods is set with SetPodArrayStatus.  The performance
+// and space metrics of the array, volumes, hosts and host groups are served
+// over historical windows, with the I/O of volumes set with SetVolumeIO.
+// The status of hardware components is set with SetHardwareStatus, and open
+// alert messages are added with AddAlert.
+// Objects are kept in memory, requests are validated, and errors are
+// returned with the status codes and bodies of an array.
+//
+//	s := flasharraytest.NewServer()
+//	defer s.Close()
+//
+//	c, err := flasharray.New(s.Target(),
+//		flasharray.WithAPIToken(s.APIToken),
+//		flasharray.WithHTTPClient(s.Client()))
+package flasharraytest
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// timeFormat is the format of the timestamps returned by the array.
+const timeFormat = "2006-01-02T15:04:05Z"
+
+// eradicationDelay is how long destroyed objects can be recovered.
+const eradicationDelay = 24 * time.Hour
+
+// sessionCookie is the name of the cookie holding the REST session.
+const sessionCookie = "session"
+
+// Server is a fake FlashArray served by an httptest.Server over TLS.  Its
+// configuration must not be changed once the server is started, except for
+// Now which can be replaced between requests.
+type Server struct {
+	*httptest.Server
+
+	// Username and Password are the credentials accepted by auth/apitoken.
+	Username string
+	Password string
+	// APIToken is the API token accepted by auth/session.
+	APIToken string
+	// ArrayName is the name of the array.
+	ArrayName string
+	// ConnectionKey is the key remote arrays use to connect to the array.
+	ConnectionKey string
+	// Versions are the REST API versions supported by the array.
+	Versions []string
+	// PodResyncTime is how long pods are resyncing on the arrays they
+	// are stretched to, before they are online.
+	PodResyncTime time.Duration
+	// SnapshotTransferTime is how long protection group snapshots are
+	// transferring to the targets they are sent to.
+	SnapshotTransferTime time.Duration
+	// Now returns the time of the array clock.  Tests can replace it to
+	// let time pass, i.e. for destroyed objects.
+	Now func() time.Time
+
+	mu              sync.Mutex
+	arrayID         string
+	sessions        map[string]bool
+	serials         int
+	volumes         map[string]*volume
+	hosts           map[string]*host
+	hgroups         map[string]*hgroup
+	pgroups         map[string]*pgroup
+	pgroupSnapshots map[string]*pgroupSnapshot
+	pods            map[string]*pod
+	vgroups         map[string]*vgroup
+	remotes         map[string]*remoteArray
+	connections     []*arrayConnection
+	replicaLinks    []*replicaLink
+	hardware        map[string]string
+	alerts          []*alert
+	alertID         int
+}
+
+// NewServer starts and returns a new fake array.  The caller should call
+// Close when finished, to shut it down.
+func NewServer() *Server {
+	s := NewUnstartedServer()
+	s.StartTLS()
+	return s
+}
+
+// NewUnstartedServer returns a new fake array but doesn't start it, so that
+// its configuration can be changed.  Call StartTLS to start it.
+func NewUnstartedServer() *Server {
+	s := &Server{
+		Username:        "pureuser",
+		Password:        "pureuser",
+		APIToken:        newID(),
+		ArrayName:       "flasharray",
+		ConnectionKey:   newID(),
+		Versions:        []string{"1.0", "1.1", "1.2", "1.3", "1.4", "1.5", "1.6", "1.7", "1.8", "1.9", "1.10", "1.11", "1.12", "1.13", "1.14", "1.15", "1.16", "1.17", "1.18", "1.19"},
+		Now:             time.Now,
+		arrayID:         newID(),
+		sessions:        map[string]bool{},
+		volumes:         map[string]*volume{},
+		hosts:           map[string]*host{},
+		hgroups:         map[string]*hgroup{},
+		pgroups:         map[string]*pgroup{},
+		pgroupSnapshots: map[string]*pgroupSnapshot{},
+		pods:            map[string]*pod{},
+		vgroups:         map[string]*vgroup{},
+		remotes:         map[string]*remoteArray{},
+		hardware:        map[string]string{},
+	}
+	for _, name := range defaultHardware {
+		s.hardware[name] = "ok"
+	}
+	s.Server = httptest.NewUnstartedServer(s)
+	return s
+}
+
+// Target returns the address of the array, as expected by flasharray.New.
+func (s *Server) Target() string {
+	return s.Listener.Addr().String()
+}
+
+// ExpireSessions ends the REST sessions started so far, like the array does
+// with sessions that have been idle for 30 minutes.
+func (s *Server) ExpireSessions() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions = map[string]bool{}
+}
+
+// request is a request for one of the REST resources of the array.
+type request struct {
+	method string
+	// path is the path after the resource type, i.e. "vol1/pgroup/pg1"
+	// for volume/vol1/pgroup/pg1.
+	path  string
+	query url.Values
+	data  params
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/api/api_version" {
+		writeJSON(w, http.StatusOK, map[string][]string{"version": s.Versions})
+		return
+	}
+
+	p := strings.TrimPrefix(r.URL.Path, "/api/")
+	i := strings.Index(p, "/")
+	if p == r.URL.Path || i < 0 {
+		writeError(w, notFoundError())
+		return
+	}
+	version, p := p[:i], p[i+1:]
+	if !s.supports(version) {
+		writeError(w, &apiError{status: http.StatusNotFound, msg: fmt.Sprintf("REST API version %s is not supported.", version)})
+		return
+	}
+
+	data := params{}
+	if r.Body != nil {
+		body, _ := ioutil.ReadAll(r.Body)
+		if len(bytes.TrimSpace(body)) > 0 {
+			if err := json.Unmarshal(body, &data); err != nil {
+				writeError(w, errorf("", "Invalid JSON in request body."))
+				return
+			}
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch p {
+	case "auth/apitoken":
+		s.serve(w, r.Method, "POST", func() (interface{}, *apiError) { return s.createAPIToken(data) })
+		return
+	case "auth/session":
+		s.serveSession(w, r, data)
+		return
+	}
+
+	if c, err := r.Cookie(sessionCookie); err != nil || !s.sessions[c.Value] {
+		writeError(w, &apiError{status: http.StatusUnauthorized, msg: "Session has expired."})
+		return
+	}
+
+	req := &request{method: r.Method, query: r.URL.Query(), data: data}
+	resource := p
+	if i := strings.Index(p, "/"); i >= 0 {
+		resource, req.path = p[:i], p[i+1:]
+	}
+
+	var v interface{}
+	var err *apiError
+	switch resource {
+	case "array":
+		v, err = s.array(req)
+	case "volume":
+		v, err = s.volume(req)
+	case "host":
+		v, err = s.host(req)
+	case "hgroup":
+		v, err = s.hgroup(req)
+	case "pgroup":
+		v, err = s.pgroup(req)
+	case "pod":
+		v, err = s.pod(req)
+	case "vgroup":
+		v, err = s.vgroup(req)
+	case "hardware":
+		v, err = s.hardwareComponent(req)
+	case "drive":
+		v, err = s.drive(req)
+	case "message":
+		v, err = s.message(req)
+	default:
+		err = notFoundError()
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, v)
+}
+
+// serve responds with the result of f if the request method is allowed.
+func (s *Server) serve(w http.ResponseWriter, method string, allowed string, f func() (interface{}, *apiError)) {
+	if method != allowed {
+		writeError(w, methodNotAllowedError())
+		return
+	}
+	v, err := f()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, v)
+}
+
+func (s *Server) supports(version string) bool {
+	for _, v := range s.Versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) createAPIToken(data params) (interface{}, *apiError) {
+	username, err := data.str("username")
+	if err != nil {
+		return nil, err
+	}
+	password, err := data.str("password")
+	if err != nil {
+		return nil, err
+	}
+	if username != s.Username || password != s.Password {
+		return nil, errorf("", "invalid credentials")
+	}
+	return map[string]string{"api_token": s.APIToken}, nil
+}
+
+func (s *Server) serveSession(w http.ResponseWriter, r *http.Request, data params) {
+	switch r.Method {
+	case "POST":
+		token, err := data.str("api_token")
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if token != s.APIToken {
+			writeError(w, errorf("", "invalid credentials"))
+			return
+		}
+		id := newID()
+		s.sessions[id] = true
+		http.SetCookie(w, &http.Cookie{Name: sessionCookie, Value: id, Path: "/", Secure: true, HttpOnly: true})
+		writeJSON(w, http.StatusOK, map[string]string{"username": s.Username})
+	case "DELETE":
+		if c, err := r.Cookie(sessionCookie); err == nil {
+			delete(s.sessions, c.Value)
+		}
+		writeJSON(w, http.StatusOK, map[string]string{})
+	default:
+		writeError(w, methodNotAllowedError())
+	}
+}
+
+// now returns the time of the array clock.
+func (s *Server) now() time.Time {
+	return s.Now().UTC()
+}
+
+// newSerial returns a new volume serial number.
+func (s *Server) newSerial() string {
+	s.serials++
+	return strings.ToUpper(strings.Replace(s.arrayID, "-", "", -1)[:16]) + fmt.Sprintf("%08X", s.serials)
+}
+
+// eradication is the destroyed state of an object that can be recovered.
+type eradication struct {
+	destroyed   bool
+	destroyedAt time.Time
+}
+
+func (e *eradication) destroy(now time.Time) {
+	e.destroyed = true
+	e.destroyedAt = now
+}
+
+func (e *eradication) recover() {
+	e.destroyed = false
+	e.destroyedAt = time.Time{}
+}
+
+// timeRemaining returns the seconds left until the array eradicates e.
+func (s *Server) timeRemaining(e *eradication) int {
+	remaining := eradicationDelay - s.now().Sub(e.destroyedAt)
+	if remaining < 0 {
+		return 0
+	}
+	return int(remaining / time.Second)
+}
+
+// listed reports whether an object is listed given the pending and
+// pending_only parameters of the request.
+func listed(q url.Values, e *eradication) bool {
+	switch {
+	case boolParam(q, "pending_only"):
+		return e.destroyed
+	case boolParam(q, "pending"):
+		return true
+	}
+	return !e.destroyed
+}
+
+// selected reports whether name is selected by the names parameter of the request.
+func selected(q url.Values, name string) bool {
+	names := q.Get("names")
+	if names == "" {
+		return true
+	}
+	for _, n := range strings.Split(names, ",") {
+		if strings.TrimSpace(n) == name {
+			return true
+		}
+	}
+	return false
+}
+
+func boolParam(q url.Values, key string) bool {
+	return strings.EqualFold(q.Get(key), "true")
+}
+
+// apiError is an error response of the array.
+type apiError struct {
+	status int
+	ctx    string
+	msg    string
+}
+
+func errorf(ctx string, format string, a ...interface{}) *apiError {
+	return &apiError{status: http.StatusBadRequest, ctx: ctx, msg: fmt.Sprintf(format, a...)}
+}
+
+func notExistError(kind string, name string) *apiError {
+	return errorf(name, "%s does not exist.", kind)
+}
+
+func existsError(kind string, name string) *apiError {
+	return errorf(name, "%s already exists.", kind)
+}
+
+func notFoundError() *apiError {
+	return &apiError{status: http.StatusNotFound, msg: "Not found."}
+}
+
+func methodNotAllowedError() *apiError {
+	return &apiError{status: http.StatusMethodNotAllowed, msg: "Method not allowed."}
+}
+
+func writeError(w http.ResponseWriter, err *apiError) {
+	body := []map[string]interface{}{{"msg": err.msg}}
+	if err.ctx != "" {
+		body[0]["ctx"] = err.ctx
+	}
+	writeJSON(w, err.status, body)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// newID returns a random UUID.
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	h := hex.EncodeToString(b)
+	return h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
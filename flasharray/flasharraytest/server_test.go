@@ -0,0 +1,257 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package flasharraytest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/devans10/go-purestorage/flasharray"
+	"github.com/devans10/go-purestorage/flasharray/flasharraytest"
+)
+
+const testSize = 1024000000
+
+func testServer(t *testing.T, opts ...flasharray.Option) (*flasharraytest.Server, *flasharray.Client) {
+	s := flasharraytest.NewServer()
+	t.Cleanup(s.Close)
+
+	opts = append([]flasharray.Option{flasharray.WithAPIToken(s.APIToken), flasharray.WithHTTPClient(s.Client())}, opts...)
+	c, err := flasharray.New(s.Target(), opts...)
+	if err != nil {
+		t.Fatalf("error setting up client: %s", err)
+	}
+	return s, c
+}
+
+func TestServerAuthentication(t *testing.T) {
+	s, c := testServer(t, flasharray.WithSessionReestablishment(false))
+
+	if _, err := flasharray.New(s.Target(), flasharray.WithAPIToken("invalid"), flasharray.WithHTTPClient(s.Client())); err == nil {
+		t.Errorf("An Error was NOT raised for an invalid API token")
+	}
+	if _, err := flasharray.New(s.Target(), flasharray.WithUsernamePassword(s.Username, s.Password), flasharray.WithHTTPClient(s.Client())); err != nil {
+		t.Errorf("error setting up client with username and password: %s", err)
+	}
+
+	s.ExpireSessions()
+	if _, err := c.Volumes.ListVolumes(nil); !flasharray.IsUnauthorized(err) {
+		t.Errorf("expected an unauthorized error after the session expired; got %v", err)
+	}
+}
+
+func TestServerVolumeValidation(t *testing.T) {
+	_, c := testServer(t)
+
+	if _, err := c.Volumes.CreateVolume("-invalid", testSize); err == nil {
+		t.Errorf("An Error was NOT raised for an invalid volume name")
+	}
+	if _, err := c.Volumes.CreateVolume("vol1", 1000); err == nil {
+		t.Errorf("An Error was NOT raised for an invalid volume size")
+	}
+	if _, err := c.Volumes.CreateVolume("vol1", testSize); err != nil {
+		t.Fatalf("error creating volume: %s", err)
+	}
+	if _, err := c.Volumes.CreateVolume("vol1", testSize); !flasharray.IsAlreadyExists(err) {
+		t.Errorf("expected an already exists error; got %v", err)
+	}
+	if _, err := c.Volumes.GetVolume("vol2", nil); !flasharray.IsNotFound(err) {
+		t.Errorf("expected a not found error; got %v", err)
+	}
+	if _, err := c.Volumes.SetVolume("vol1", map[string]interface{}{"size": testSize / 2}); err == nil {
+		t.Errorf("An Error was NOT raised for an implicit truncation")
+	}
+	if _, err := c.Volumes.EradicateVolume("vol1"); err == nil {
+		t.Errorf("An Error was NOT raised when eradicating a volume that is not destroyed")
+	}
+}
+
+func TestServerDestroyedVolumes(t *testing.T) {
+	s, c := testServer(t)
+	now := time.Now()
+	s.Now = func() time.Time { return now }
+
+	c.Volumes.CreateVolume("vol1", testSize)
+	c.Volumes.CreateSnapshot("vol1", "snap1")
+	if _, err := c.Volumes.DeleteVolume("vol1"); err != nil {
+		t.Fatalf("error destroying volume: %s", err)
+	}
+	now = now.Add(time.Hour)
+
+	if l, _ := c.Volumes.ListVolumes(nil); len(l) != 0 {
+		t.Errorf("expected destroyed volumes not to be listed; got %+v", l)
+	}
+	if _, err := c.Volumes.GetVolume("vol1", nil); !flasharray.IsNotFound(err) {
+		t.Errorf("expected a not found error for a destroyed volume; got %v", err)
+	}
+	l, err := c.Volumes.ListVolumes(map[string]string{"pending_only": "true", "snap": "true"})
+	if err != nil || len(l) != 1 || l[0].Name != "vol1.snap1" {
+		t.Errorf("expected the snapshot of the destroyed volume to be destroyed; got %+v, %v", l, err)
+	}
+
+	if _, err := c.Volumes.RecoverVolume("vol1"); err != nil {
+		t.Fatalf("error recovering volume: %s", err)
+	}
+	if l, _ := c.Volumes.ListVolumes(map[string]string{"snap": "true"}); len(l) != 1 {
+		t.Errorf("expected the snapshot to be recovered with the volume; got %+v", l)
+	}
+}
+
+func TestServerConnections(t *testing.T) {
+	_, c := testServer(t)
+
+	c.Volumes.CreateVolume("vol1", testSize)
+	c.Volumes.CreateVolume("vol2", testSize)
+	c.Hosts.CreateHost("host1", nil)
+	c.Hosts.CreateHost("host2", nil)
+	c.Hostgroups.CreateHostgroup("hgroup1", map[string][]string{"hostlist": {"host1"}})
+
+	if _, err := c.Hosts.CreateHost("host3", map[string][]string{"wwnlist": {"not a wwn"}}); err == nil {
+		t.Errorf("An Error was NOT raised for an invalid WWN")
+	}
+	if _, err := c.Hostgroups.CreateHostgroup("hgroup2", map[string][]string{"hostlist": {"host1"}}); err == nil {
+		t.Errorf("An Error was NOT raised when adding a host to a second host group")
+	}
+
+	shared, err := c.Hostgroups.ConnectHostgroup("hgroup1", "vol1", nil)
+	if err != nil || shared.Lun != 1 {
+		t.Fatalf("expected vol1 to be connected with LUN 1; got %+v, %v", shared, err)
+	}
+	if _, err := c.Hosts.ConnectHost("host1", "vol2", map[string]int{"lun": 1}); err == nil {
+		t.Errorf("An Error was NOT raised for a LUN in use by the host group")
+	}
+	private, err := c.Hosts.ConnectHost("host1", "vol2", nil)
+	if err != nil || private.Lun != 2 {
+		t.Fatalf("expected vol2 to be connected with LUN 2; got %+v, %v", private, err)
+	}
+
+	conns, err := c.Hosts.ListHostConnections("host1", nil)
+	if err != nil || len(conns) != 2 {
+		t.Errorf("expected 2 connections; got %+v, %v", conns, err)
+	}
+	if _, err := c.Volumes.DeleteVolume("vol1"); err == nil {
+		t.Errorf("An Error was NOT raised when destroying a connected volume")
+	}
+	if _, err := c.Hosts.DeleteHost("host1"); err == nil {
+		t.Errorf("An Error was NOT raised when deleting a connected host")
+	}
+}
+
+func TestServerPgroups(t *testing.T) {
+	_, c := testServer(t)
+
+	c.Volumes.CreateVolume("vol1", testSize)
+	c.Hosts.CreateHost("host1", nil)
+	c.Protectiongroups.CreateProtectiongroup("pgroup1", map[string][]string{"vollist": {"vol1"}})
+
+	if _, err := c.Hosts.AddHost("host1", "pgroup1"); err == nil {
+		t.Errorf("An Error was NOT raised when adding a host to a protection group of volumes")
+	}
+	if _, err := c.Protectiongroups.SetProtectiongroup("pgroup1", map[string]int{"snap_frequency": 60}); err == nil {
+		t.Errorf("An Error was NOT raised for an invalid snapshot frequency")
+	}
+
+	snap, err := c.Protectiongroups.CreatePgroupSnapshot("pgroup1")
+	if err != nil || snap.Name != "pgroup1.1" || snap.Source != "pgroup1" {
+		t.Fatalf("expected snapshot pgroup1.1; got %+v, %v", snap, err)
+	}
+	if _, err := c.Volumes.GetVolume("pgroup1.1.vol1", nil); err != nil {
+		t.Errorf("error getting the volume snapshot of the protection group snapshot: %s", err)
+	}
+	if _, err := c.Protectiongroups.SendPgroupSnapshot("pgroup1"); err == nil {
+		t.Errorf("An Error was NOT raised when sending a snapshot of a protection group without targets")
+	}
+}
+
+func TestServerPodsAndVgroups(t *testing.T) {
+	_, c := testServer(t)
+
+	if _, err := c.Volumes.CreateVolume("pod1::vol1", testSize); !flasharray.IsNotFound(err) {
+		t.Errorf("expected a not found error for a volume in a missing pod; got %v", err)
+	}
+	c.Pods.CreatePod("pod1", nil)
+	if _, err := c.Volumes.CreateVolume("pod1::vol1", testSize); err != nil {
+		t.Fatalf("error creating volume in pod: %s", err)
+	}
+	if _, err := c.Pods.DeletePod("pod1"); err == nil {
+		t.Errorf("An Error was NOT raised when destroying a pod with volumes")
+	}
+	if _, err := c.Pods.RenamePod("pod1", "pod2"); err != nil {
+		t.Fatalf("error renaming pod: %s", err)
+	}
+	if _, err := c.Volumes.GetVolume("pod2::vol1", nil); err != nil {
+		t.Errorf("expected the volume to be renamed with the pod: %s", err)
+	}
+
+	c.Vgroups.CreateVgroup("vgroup1")
+	c.Volumes.CreateVolume("vgroup1/vol1", testSize)
+	g, err := c.Vgroups.GetVgroup("vgroup1")
+	if err != nil || len(g.Volumes) != 1 || g.Volumes[0] != "vgroup1/vol1" {
+		t.Fatalf("expected vgroup1 to contain vgroup1/vol1; got %+v, %v", g, err)
+	}
+	if _, err := c.Vgroups.DestroyVgroup("vgroup1"); err != nil {
+		t.Fatalf("error destroying volume group: %s", err)
+	}
+	if _, err := c.Volumes.GetVolume("vgroup1/vol1", nil); !flasharray.IsNotFound(err) {
+		t.Errorf("expected the volume to be destroyed with the volume group; got %v", err)
+	}
+}
+
+func TestServerArrayConnections(t *testing.T) {
+	s, c := testServer(t)
+	s.AddRemoteArray("remote1", "10.0.0.2", "remote-key")
+
+	c.Protectiongroups.CreateProtectiongroup("pgroup1", nil)
+	c.Pods.CreatePod("pod1", nil)
+	if _, err := c.Protectiongroups.SetProtectiongroup("pgroup1", map[string][]string{"targetlist": {"remote1"}}); err == nil {
+		t.Errorf("An Error was NOT raised when adding a target that is not connected")
+	}
+	if _, err := c.Array.ConnectArray("10.0.0.3", "remote-key", nil, ""); err == nil {
+		t.Errorf("An Error was NOT raised when connecting an unknown array")
+	}
+	if _, err := c.Array.ConnectArray("10.0.0.2", "remote-key", []string{flasharray.ReplicationTypeAsync}, ""); err != nil {
+		t.Fatalf("error connecting array: %s", err)
+	}
+	if _, err := c.Array.ConnectArray("10.0.0.2", "remote-key", nil, ""); err == nil {
+		t.Errorf("An Error was NOT raised when connecting an array twice")
+	}
+	if _, err := c.Pods.ConnectPod("pod1", "remote1"); err == nil {
+		t.Errorf("An Error was NOT raised when stretching a pod to an array connected for asynchronous replication")
+	}
+	if _, err := c.Protectiongroups.SetProtectiongroup("pgroup1", map[string][]string{"targetlist": {"remote1"}}); err != nil {
+		t.Fatalf("error adding target: %s", err)
+	}
+	if _, err := c.Array.DisconnectArray("remote1"); err == nil {
+		t.Errorf("An Error was NOT raised when disconnecting the target of a protection group")
+	}
+}
+
+func TestServerPodPromotion(t *testing.T) {
+	s, c := testServer(t)
+	s.AddRemoteArray("remote1", "10.0.0.2", "remote-key")
+	c.Array.ConnectArray("10.0.0.2", "remote-key", []string{flasharray.ReplicationTypeAsync}, "")
+
+	c.Pods.CreatePod("pod1", nil)
+	c.Volumes.CreateVolume("pod1::vol1", testSize)
+	if _, err := c.Pods.DemotePod("pod1", ""); err != nil {
+		t.Fatalf("error demoting pod: %s", err)
+	}
+	if _, err := c.Volumes.GetVolume("pod1.undo-demote::vol1", map[string]string{"pending": "true"}); err != nil {
+		t.Errorf("expected the undo pod to hold a copy of the volume: %s", err)
+	}
+	if _, err := c.Pods.CreatePodReplicaLink("pod1", "remote1", "pod1-dr"); err == nil {
+		t.Errorf("An Error was NOT raised when linking a demoted pod")
+	}
+	if _, err := c.Pods.PromotePod("pod1", ""); err != nil {
+		t.Fatalf("error promoting pod: %s", err)
+	}
+	if _, err := c.Pods.CreatePodReplicaLink("pod1", "remote1", "pod1-dr"); err != nil {
+		t.Fatalf("error creating replica link: %s", err)
+	}
+	c.Volumes.DeleteVolume("pod1::vol1")
+	if _, err := c.Pods.DeletePod("pod1"); err == nil {
+		t.Errorf("An Error was NOT raised when destroying a pod with a replica link")
+	}
+}
@@ -0,0 +1,229 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package flasharraytest
+
+import (
+	"sort"
+	"strings"
+)
+
+type vgroup struct {
+	eradication
+
+	name string
+	qos  qos
+}
+
+func (s *Server) vgroup(r *request) (interface{}, *apiError) {
+	switch {
+	case r.path == "" && r.method == "GET":
+		return s.listVgroups(r)
+	case r.path == "" || strings.Contains(r.path, "/"):
+		return nil, methodNotAllowedError()
+	case r.method == "GET":
+		return s.getVgroup(r.path, r)
+	case r.method == "POST":
+		return s.createVgroup(r.path)
+	case r.method == "PUT":
+		return s.setVgroup(r.path, r)
+	case r.method == "DELETE":
+		return s.deleteVgroup(r.path, r)
+	}
+	return nil, methodNotAllowedError()
+}
+
+func (s *Server) lookupVgroup(name string, pending bool) (*vgroup, *apiError) {
+	g, ok := s.vgroups[name]
+	if !ok || (g.destroyed && !pending) {
+		return nil, notExistError("Volume group", name)
+	}
+	return g, nil
+}
+
+// vgroupVolumes returns the volumes in g, including the destroyed ones.
+func (s *Server) vgroupVolumes(g *vgroup) []*volume {
+	var l []*volume
+	for name, v := range s.volumes {
+		if strings.HasPrefix(name, g.name+"/") {
+			l = append(l, v)
+		}
+	}
+	sort.Slice(l, func(i, j int) bool { return l[i].name < l[j].name })
+	return l
+}
+
+func (s *Server) vgroupView(g *vgroup) map[string]interface{} {
+	volumes := []string{}
+	for _, v := range s.vgroupVolumes(g) {
+		if !v.destroyed && !v.snapshot {
+			volumes = append(volumes, v.name)
+		}
+	}
+	m := map[string]interface{}{"name": g.name, "volumes": volumes}
+	if g.destroyed {
+		m["time_remaining"] = s.timeRemaining(&g.eradication)
+	}
+	return m
+}
+
+func (s *Server) listVgroups(r *request) (interface{}, *apiError) {
+	var names []string
+	for name, g := range s.vgroups {
+		if listed(r.query, &g.eradication) && selected(r.query, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	l := []map[string]interface{}{}
+	for _, name := range names {
+		if boolParam(r.query, "qos") {
+			l = append(l, qosView(name, s.vgroups[name].qos))
+		} else {
+			l = append(l, s.vgroupView(s.vgroups[name]))
+		}
+	}
+	return l, nil
+}
+
+func (s *Server) getVgroup(name string, r *request) (interface{}, *apiError) {
+	g, err := s.lookupVgroup(name, boolParam(r.query, "pending"))
+	if err != nil {
+		return nil, err
+	}
+	if boolParam(r.query, "qos") {
+		return qosView(g.name, g.qos), nil
+	}
+	return s.vgroupView(g), nil
+}
+
+func (s *Server) createVgroup(name string) (interface{}, *apiError) {
+	base, err := s.checkContainer(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkName("volume group", base); err != nil {
+		return nil, err
+	}
+	if _, ok := s.vgroups[name]; ok {
+		return nil, existsError("Volume group", name)
+	}
+	g := &vgroup{name: name}
+	s.vgroups[name] = g
+	return s.vgroupView(g), nil
+}
+
+func (s *Server) setVgroup(name string, r *request) (interface{}, *apiError) {
+	action, err := r.data.str("action")
+	if err != nil {
+		return nil, err
+	}
+	if action == "recover" {
+		g, err := s.lookupVgroup(name, true)
+		if err != nil {
+			return nil, err
+		}
+		if !g.destroyed {
+			return nil, errorf(name, "Volume group is not destroyed.")
+		}
+		destroyedAt := g.destroyedAt
+		g.recover()
+		// Recover the volumes destroyed with the volume group.
+		for _, v := range s.vgroupVolumes(g) {
+			if v.destroyed && v.destroyedAt.Equal(destroyedAt) {
+				v.recover()
+			}
+		}
+		return s.vgroupView(g), nil
+	}
+	if action != "" {
+		return nil, invalidParamError("action")
+	}
+
+	g, err := s.lookupVgroup(name, false)
+	if err != nil {
+		return nil, err
+	}
+	limits, err := updateQoS(g.qos, r.data)
+	if err != nil {
+		return nil, err
+	}
+	newName, err := r.data.str("name")
+	if err != nil {
+		return nil, err
+	}
+	if newName != "" && newName != g.name {
+		base, err := s.checkContainer(newName)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkName("volume group", base); err != nil {
+			return nil, err
+		}
+		if _, ok := s.vgroups[newName]; ok {
+			return nil, existsError("Volume group", newName)
+		}
+		volumes := s.vgroupVolumes(g)
+		for _, v := range volumes {
+			delete(s.volumes, v.name)
+		}
+		for _, v := range volumes {
+			v.name = newName + strings.TrimPrefix(v.name, g.name)
+			if strings.HasPrefix(v.source, g.name+"/") {
+				v.source = newName + strings.TrimPrefix(v.source, g.name)
+			}
+			s.volumes[v.name] = v
+		}
+		delete(s.vgroups, g.name)
+		g.name = newName
+		s.vgroups[newName] = g
+	}
+	g.qos = limits
+	if hasQoS(r.data) {
+		return qosView(g.name, g.qos), nil
+	}
+	return s.vgroupView(g), nil
+}
+
+func (s *Server) deleteVgroup(name string, r *request) (interface{}, *apiError) {
+	eradicate, err := r.data.boolean("eradicate")
+	if err != nil {
+		return nil, err
+	}
+	g, err := s.lookupVgroup(name, true)
+	if err != nil {
+		return nil, err
+	}
+	volumes := s.vgroupVolumes(g)
+
+	if eradicate {
+		if !g.destroyed {
+			return nil, errorf(name, "Volume group has not been destroyed.")
+		}
+		for _, v := range volumes {
+			s.eradicateVolume(v)
+		}
+		delete(s.vgroups, name)
+		return map[string]string{"name": name}, nil
+	}
+
+	if g.destroyed {
+		return nil, errorf(name, "Volume group has already been destroyed.")
+	}
+	for _, v := range volumes {
+		if !v.destroyed && s.volumeConnected(v) {
+			return nil, errorf(v.name, "Volume has host connections.")
+		}
+	}
+	// Destroying a volume group destroys the volumes in it.
+	now := s.now()
+	g.destroy(now)
+	for _, v := range volumes {
+		if !v.destroyed {
+			v.destroy(now)
+		}
+	}
+	return s.vgroupView(g), nil
+}
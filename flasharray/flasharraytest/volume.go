@@ -0,0 +1,738 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package flasharraytest
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	minVolumeSize = 1 << 20
+	maxVolumeSize = 4 << 50
+)
+
+// volume is a volume or a volume snapshot.
+type volume struct {
+	eradication
+
+	name     string
+	size     int
+	source   string
+	serial   string
+	created  time.Time
+	snapshot bool
+	// snapshots is the number of snapshots taken without a suffix.
+	snapshots int
+	qos       qos
+	// readsPerSec and writesPerSec are the I/O reported by the monitor.
+	readsPerSec  int
+	writesPerSec int
+}
+
+// SetVolumeIO sets the reads and writes per second reported for the volume
+// name by the monitor, i.e. to simulate a volume in use.  It panics if the
+// volume does not exist.
+func (s *Server) SetVolumeIO(name string, readsPerSec int, writesPerSec int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.volumes[name]
+	if !ok {
+		panic("flasharraytest: volume " + name + " does not exist")
+	}
+	v.readsPerSec, v.writesPerSec = readsPerSec, writesPerSec
+}
+
+func (s *Server) volume(r *request) (interface{}, *apiError) {
+	if r.path == "" {
+		switch r.method {
+		case "GET":
+			return s.listVolumes(r)
+		case "POST":
+			return s.createVolumeSnapshots(r)
+		}
+		return nil, methodNotAllowedError()
+	}
+
+	name, sub, arg := s.splitVolumePath(r.path)
+	switch {
+	case sub == "" && r.method == "GET":
+		return s.getVolume(name, r)
+	case sub == "" && r.method == "POST":
+		return s.createVolume(name, r)
+	case sub == "" && r.method == "PUT":
+		return s.setVolume(name, r)
+	case sub == "" && r.method == "DELETE":
+		return s.deleteVolume(name, r)
+	case sub == "pgroup" && r.method == "POST":
+		return s.addVolumeToPgroup(name, arg)
+	case sub == "pgroup" && r.method == "DELETE":
+		return s.removeVolumeFromPgroup(name, arg)
+	case sub == "host" && r.method == "GET":
+		return s.listVolumeHostConnections(name)
+	case sub == "hgroup" && r.method == "GET":
+		return s.listVolumeHgroupConnections(name)
+	case sub == "diff" && r.method == "GET":
+		return s.volumeDiff(name, r)
+	}
+	return nil, methodNotAllowedError()
+}
+
+// splitVolumePath splits the path of a volume request into the volume name,
+// the sub-resource and its argument.  Volume names contain a '/' when the
+// volume is in a volume group.
+func (s *Server) splitVolumePath(path string) (string, string, string) {
+	if _, ok := s.volumes[path]; ok {
+		return path, "", ""
+	}
+	parts := strings.Split(path, "/")
+	n := len(parts)
+	if n >= 3 && parts[n-2] == "pgroup" {
+		return strings.Join(parts[:n-2], "/"), "pgroup", parts[n-1]
+	}
+	if n >= 2 {
+		switch parts[n-1] {
+		case "host", "hgroup", "diff":
+			return strings.Join(parts[:n-1], "/"), parts[n-1], ""
+		}
+	}
+	return path, "", ""
+}
+
+// lookupVolume returns the volume or snapshot name.  Destroyed volumes are
+// only returned if pending is set.
+func (s *Server) lookupVolume(name string, pending bool) (*volume, *apiError) {
+	v, ok := s.volumes[name]
+	if !ok || (v.destroyed && !pending) {
+		if strings.Contains(name, ".") {
+			return nil, notExistError("Snapshot", name)
+		}
+		return nil, notExistError("Volume", name)
+	}
+	return v, nil
+}
+
+// checkVolumeName validates the name of a new volume, and that its pod and
+// volume group exist.
+func (s *Server) checkVolumeName(name string) *apiError {
+	base, err := s.checkContainer(name)
+	if err != nil {
+		return err
+	}
+	if i := strings.Index(base, "/"); i >= 0 {
+		vgroup := name[:len(name)-len(base)+i]
+		if g, ok := s.vgroups[vgroup]; !ok || g.destroyed {
+			return notExistError("Volume group", vgroup)
+		}
+		base = base[i+1:]
+	}
+	return checkName("volume", base)
+}
+
+func checkVolumeSize(size int) *apiError {
+	if size < minVolumeSize || size > maxVolumeSize {
+		return errorf("size", "Volume size must be between 1M and 4P.")
+	}
+	if size%512 != 0 {
+		return errorf("size", "Volume size must be a multiple of 512 bytes.")
+	}
+	return nil
+}
+
+func (s *Server) newVolume(name string, size int, source string) *volume {
+	v := &volume{name: name, size: size, source: source, serial: s.newSerial(), created: s.now()}
+	s.volumes[name] = v
+	return v
+}
+
+// newSnapshot takes a snapshot of v with the given suffix.
+func (s *Server) newSnapshot(v *volume, name string) *volume {
+	snap := s.newVolume(name, v.size, v.name)
+	snap.snapshot = true
+	return snap
+}
+
+// snapshotsOf returns the snapshots of volume v, including the destroyed ones.
+func (s *Server) snapshotsOf(v *volume) []*volume {
+	var snaps []*volume
+	for _, snap := range s.volumes {
+		if snap.snapshot && snap.source == v.name && strings.HasPrefix(snap.name, v.name+".") {
+			snaps = append(snaps, snap)
+		}
+	}
+	return snaps
+}
+
+func (s *Server) volumeView(v *volume, q url.Values) map[string]interface{} {
+	if q.Get("action") == "monitor" {
+		return map[string]interface{}{
+			"name":                  v.name,
+			"time":                  s.now().Format(timeFormat),
+			"reads_per_sec":         v.readsPerSec,
+			"writes_per_sec":        v.writesPerSec,
+			"input_per_sec":         0,
+			"output_per_sec":        0,
+			"usec_per_read_op":      0,
+			"usec_per_write_op":     0,
+			"san_usec_per_read_op":  0,
+			"san_usec_per_write_op": 0,
+			"queue_depth":           0,
+		}
+	}
+	if boolParam(q, "qos") {
+		return qosView(v.name, v.qos)
+	}
+	if boolParam(q, "space") {
+		return map[string]interface{}{
+			"name":              v.name,
+			"size":              v.size,
+			"volumes":           0,
+			"snapshots":         0,
+			"shared_space":      nil,
+			"system":            nil,
+			"total":             0,
+			"data_reduction":    1.0,
+			"total_reduction":   1.0,
+			"thin_provisioning": 1.0,
+		}
+	}
+
+	m := map[string]interface{}{
+		"name":    v.name,
+		"size":    v.size,
+		"serial":  v.serial,
+		"created": v.created.Format(timeFormat),
+		"source":  nil,
+	}
+	if v.source != "" {
+		m["source"] = v.source
+	}
+	if v.destroyed {
+		m["time_remaining"] = s.timeRemaining(&v.eradication)
+	}
+	return m
+}
+
+func (s *Server) listVolumes(r *request) (interface{}, *apiError) {
+	snap := boolParam(r.query, "snap")
+	var pgroupSnapshots []*pgroupSnapshot
+	if l := r.query.Get("pgrouplist"); l != "" {
+		for _, name := range strings.Split(l, ",") {
+			ps, ok := s.pgroupSnapshots[name]
+			if !ok {
+				return nil, notExistError("Protection group snapshot", name)
+			}
+			pgroupSnapshots = append(pgroupSnapshots, ps)
+		}
+	}
+	var names []string
+	for name, v := range s.volumes {
+		if v.snapshot == snap && listed(r.query, &v.eradication) && selected(r.query, name) && inPgroupSnapshots(pgroupSnapshots, v) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	l := []map[string]interface{}{}
+	for _, name := range names {
+		l = append(l, s.volumeView(s.volumes[name], r.query))
+	}
+	return l, nil
+}
+
+// listSnapshotsOf lists the snapshots of volume v.
+func (s *Server) listSnapshotsOf(v *volume, r *request) []map[string]interface{} {
+	snaps := s.snapshotsOf(v)
+	sort.Slice(snaps, func(i, j int) bool { return snaps[i].name < snaps[j].name })
+
+	l := []map[string]interface{}{}
+	for _, snap := range snaps {
+		if listed(r.query, &snap.eradication) {
+			l = append(l, s.volumeView(snap, r.query))
+		}
+	}
+	return l
+}
+
+// inPgroupSnapshots reports whether the volume snapshot v is in one of the
+// protection group snapshots l, or whether l is empty.
+func inPgroupSnapshots(l []*pgroupSnapshot, v *volume) bool {
+	if len(l) == 0 {
+		return true
+	}
+	for _, ps := range l {
+		if strings.HasPrefix(v.name, ps.name+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) getVolume(name string, r *request) (interface{}, *apiError) {
+	v, err := s.lookupVolume(name, boolParam(r.query, "pending"))
+	if err != nil {
+		return nil, err
+	}
+	if boolParam(r.query, "snap") && !v.snapshot {
+		return s.listSnapshotsOf(v, r), nil
+	}
+	if historical(r.query) {
+		return s.history(r.query, s.volumeView(v, r.query))
+	}
+	if r.query.Get("action") == "monitor" {
+		return []map[string]interface{}{s.volumeView(v, r.query)}, nil
+	}
+	return s.volumeView(v, r.query), nil
+}
+
+func (s *Server) createVolume(name string, r *request) (interface{}, *apiError) {
+	if err := s.checkVolumeName(name); err != nil {
+		return nil, err
+	}
+	if r.data.has("source") {
+		return s.copyVolume(name, r)
+	}
+	if _, ok := s.volumes[name]; ok {
+		return nil, existsError("Volume", name)
+	}
+
+	pe, err := r.data.boolean("protocol_endpoint")
+	if err != nil {
+		return nil, err
+	}
+	if pe {
+		return s.volumeView(s.newVolume(name, 0, ""), nil), nil
+	}
+
+	size, ok, err := r.data.size("size")
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errorf(name, "Missing parameter size.")
+	}
+	if err := checkVolumeSize(size); err != nil {
+		return nil, err
+	}
+	return s.volumeView(s.newVolume(name, size, ""), nil), nil
+}
+
+func (s *Server) copyVolume(name string, r *request) (interface{}, *apiError) {
+	source, err := r.data.str("source")
+	if err != nil {
+		return nil, err
+	}
+	overwrite, err := r.data.boolean("overwrite")
+	if err != nil {
+		return nil, err
+	}
+	src, err := s.lookupVolume(source, false)
+	if err != nil {
+		return nil, err
+	}
+	origin := src.name
+	if src.snapshot {
+		origin = src.source
+	}
+
+	if v, ok := s.volumes[name]; ok {
+		if !overwrite || v.destroyed || v.snapshot {
+			return nil, existsError("Volume", name)
+		}
+		v.size = src.size
+		v.source = origin
+		return s.volumeView(v, nil), nil
+	}
+	return s.volumeView(s.newVolume(name, src.size, origin), nil), nil
+}
+
+func (s *Server) createVolumeSnapshots(r *request) (interface{}, *apiError) {
+	snap, err := r.data.boolean("snap")
+	if err != nil {
+		return nil, err
+	}
+	if !snap {
+		return nil, errorf("", "Missing parameter snap.")
+	}
+	sources, _, err := r.data.list("source")
+	if err != nil {
+		return nil, err
+	}
+	if len(sources) == 0 {
+		return nil, errorf("source", "Missing parameter source.")
+	}
+	suffix, err := r.data.str("suffix")
+	if err != nil {
+		return nil, err
+	}
+	if suffix != "" {
+		if err := checkName("suffix", suffix); err != nil {
+			return nil, err
+		}
+	}
+
+	var volumes []*volume
+	for _, source := range sources {
+		v, err := s.lookupVolume(source, false)
+		if err != nil {
+			return nil, err
+		}
+		if v.snapshot {
+			return nil, errorf(source, "Cannot snapshot a snapshot.")
+		}
+		if suffix != "" {
+			if _, ok := s.volumes[source+"."+suffix]; ok {
+				return nil, existsError("Snapshot", source+"."+suffix)
+			}
+		}
+		volumes = append(volumes, v)
+	}
+
+	l := []map[string]interface{}{}
+	for _, v := range volumes {
+		name := v.name + "." + suffix
+		if suffix == "" {
+			for {
+				v.snapshots++
+				name = fmt.Sprintf("%s.%d", v.name, v.snapshots)
+				if _, ok := s.volumes[name]; !ok {
+					break
+				}
+			}
+		}
+		l = append(l, s.volumeView(s.newSnapshot(v, name), nil))
+	}
+	return l, nil
+}
+
+func (s *Server) setVolume(name string, r *request) (interface{}, *apiError) {
+	action, err := r.data.str("action")
+	if err != nil {
+		return nil, err
+	}
+	if action == "recover" {
+		return s.recoverVolume(name)
+	}
+	if action != "" {
+		return nil, invalidParamError("action")
+	}
+
+	v, err := s.lookupVolume(name, true)
+	if err != nil {
+		return nil, err
+	}
+	if v.destroyed {
+		return nil, errorf(name, "Volume has been destroyed.")
+	}
+	limits, err := updateQoS(v.qos, r.data)
+	if err != nil {
+		return nil, err
+	}
+	if hasQoS(r.data) && v.snapshot {
+		return nil, errorf(name, "QoS limits cannot be set on snapshots.")
+	}
+
+	if size, ok, err := r.data.size("size"); err != nil {
+		return nil, err
+	} else if ok {
+		if v.snapshot {
+			return nil, errorf(name, "Snapshots cannot be resized.")
+		}
+		truncate, err := r.data.boolean("truncate")
+		if err != nil {
+			return nil, err
+		}
+		if err := checkVolumeSize(size); err != nil {
+			return nil, err
+		}
+		if size < v.size && !truncate {
+			return nil, errorf(name, "Implicit truncation not permitted.")
+		}
+		v.size = size
+	}
+
+	newName := ""
+	if n, err := r.data.str("name"); err != nil {
+		return nil, err
+	} else if n != "" {
+		newName = n
+	}
+	if r.data.has("container") {
+		container, err := r.data.str("container")
+		if err != nil {
+			return nil, err
+		}
+		newName = containerPrefix(container) + baseName(v.name)
+	}
+	if newName != "" && newName != v.name {
+		if v.snapshot {
+			return nil, errorf(name, "Snapshots cannot be renamed.")
+		}
+		if err := s.checkVolumeName(newName); err != nil {
+			return nil, err
+		}
+		if _, ok := s.volumes[newName]; ok {
+			return nil, existsError("Volume", newName)
+		}
+		s.renameVolume(v, newName)
+	}
+	v.qos = limits
+	if hasQoS(r.data) {
+		return qosView(v.name, v.qos), nil
+	}
+	return s.volumeView(v, nil), nil
+}
+
+// renameVolume renames v and its snapshots.
+func (s *Server) renameVolume(v *volume, name string) {
+	snaps := s.snapshotsOf(v)
+	for _, snap := range s.volumes {
+		if snap.snapshot && snap.source == v.name {
+			snap.source = name
+		}
+	}
+	for _, snap := range snaps {
+		delete(s.volumes, snap.name)
+		snap.name = name + strings.TrimPrefix(snap.name, v.name)
+		s.volumes[snap.name] = snap
+	}
+	delete(s.volumes, v.name)
+	v.name = name
+	s.volumes[name] = v
+}
+
+// containerPrefix returns the prefix of the names of the volumes in container,
+// which is a pod or a volume group.  An empty container is the array itself.
+func containerPrefix(container string) string {
+	switch {
+	case container == "":
+		return ""
+	case strings.HasSuffix(container, "::"):
+		return container
+	}
+	return container + "/"
+}
+
+// baseName returns name without its pod and volume group prefix.
+func baseName(name string) string {
+	if i := strings.LastIndex(name, "::"); i >= 0 {
+		name = name[i+2:]
+	}
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+func (s *Server) recoverVolume(name string) (interface{}, *apiError) {
+	v, err := s.lookupVolume(name, true)
+	if err != nil {
+		return nil, err
+	}
+	if !v.destroyed {
+		return nil, errorf(name, "Volume is not destroyed.")
+	}
+	if _, err := s.checkContainer(name); err != nil {
+		return nil, err
+	}
+	v.recover()
+	for _, snap := range s.snapshotsOf(v) {
+		snap.recover()
+	}
+	return map[string]string{"name": v.name}, nil
+}
+
+func (s *Server) deleteVolume(name string, r *request) (interface{}, *apiError) {
+	eradicate, err := r.data.boolean("eradicate")
+	if err != nil {
+		return nil, err
+	}
+	v, err := s.lookupVolume(name, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if eradicate {
+		if !v.destroyed {
+			return nil, errorf(name, "Volume has not been destroyed.")
+		}
+		s.eradicateVolume(v)
+		return map[string]string{"name": v.name}, nil
+	}
+
+	if v.destroyed {
+		return nil, errorf(name, "Volume has already been destroyed.")
+	}
+	if s.volumeConnected(v) {
+		return nil, errorf(name, "Volume has host connections.")
+	}
+	now := s.now()
+	v.destroy(now)
+	for _, snap := range s.snapshotsOf(v) {
+		if !snap.destroyed {
+			snap.destroy(now)
+		}
+	}
+	return map[string]string{"name": v.name}, nil
+}
+
+// eradicateVolume removes v and its snapshots from the array.
+func (s *Server) eradicateVolume(v *volume) {
+	for _, snap := range s.snapshotsOf(v) {
+		delete(s.volumes, snap.name)
+	}
+	delete(s.volumes, v.name)
+	for _, pg := range s.pgroups {
+		pg.volumes = removeVolume(pg.volumes, v)
+	}
+}
+
+// volumeConnected reports whether v is connected to a host or host group.
+func (s *Server) volumeConnected(v *volume) bool {
+	for _, h := range s.hosts {
+		if _, ok := h.conns[v]; ok {
+			return true
+		}
+	}
+	for _, g := range s.hgroups {
+		if _, ok := g.conns[v]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) addVolumeToPgroup(name string, pgroup string) (interface{}, *apiError) {
+	v, err := s.lookupVolume(name, false)
+	if err != nil {
+		return nil, err
+	}
+	if v.snapshot {
+		return nil, errorf(name, "Snapshots cannot be added to protection groups.")
+	}
+	pg, err := s.lookupPgroup(pgroup, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := pg.checkMembers("volumes"); err != nil {
+		return nil, err
+	}
+	if containsVolume(pg.volumes, v) {
+		return nil, errorf(name, "Volume is already a member of protection group %s.", pgroup)
+	}
+	pg.volumes = append(pg.volumes, v)
+	return map[string]string{"name": v.name, "protection_group": pg.name}, nil
+}
+
+func (s *Server) removeVolumeFromPgroup(name string, pgroup string) (interface{}, *apiError) {
+	v, err := s.lookupVolume(name, false)
+	if err != nil {
+		return nil, err
+	}
+	pg, err := s.lookupPgroup(pgroup, false)
+	if err != nil {
+		return nil, err
+	}
+	if !containsVolume(pg.volumes, v) {
+		return nil, errorf(name, "Volume is not a member of protection group %s.", pgroup)
+	}
+	pg.volumes = removeVolume(pg.volumes, v)
+	return map[string]string{"name": v.name, "protection_group": pg.name}, nil
+}
+
+func (s *Server) listVolumeHostConnections(name string) (interface{}, *apiError) {
+	v, err := s.lookupVolume(name, false)
+	if err != nil {
+		return nil, err
+	}
+	l := []map[string]interface{}{}
+	for _, h := range s.sortedHosts() {
+		if lun, ok := h.conns[v]; ok {
+			l = append(l, map[string]interface{}{"name": v.name, "host": h.name, "lun": lun, "size": v.size})
+		}
+	}
+	return l, nil
+}
+
+func (s *Server) listVolumeHgroupConnections(name string) (interface{}, *apiError) {
+	v, err := s.lookupVolume(name, false)
+	if err != nil {
+		return nil, err
+	}
+	l := []map[string]interface{}{}
+	for _, g := range s.sortedHgroups() {
+		if lun, ok := g.conns[v]; ok {
+			l = append(l, map[string]interface{}{"name": v.name, "hgroup": g.name, "lun": lun, "size": v.size})
+		}
+	}
+	return l, nil
+}
+
+// volumeDiff returns the blocks of the volume that differ from the base
+// volume.  The fake array does not store data, so the whole requested range
+// is returned.
+func (s *Server) volumeDiff(name string, r *request) (interface{}, *apiError) {
+	v, err := s.lookupVolume(name, false)
+	if err != nil {
+		return nil, err
+	}
+	if base := r.query.Get("base"); base != "" {
+		if _, err := s.lookupVolume(base, false); err != nil {
+			return nil, err
+		}
+	}
+
+	offset, length := 0, v.size
+	if o := r.query.Get("offset"); o != "" {
+		n, err := parseSize(o)
+		if err != nil || n < 0 || n > v.size {
+			return nil, invalidParamError("offset")
+		}
+		offset, length = n, v.size-n
+	}
+	if l := r.query.Get("length"); l != "" {
+		n, err := parseSize(l)
+		if err != nil || n < 0 || offset+n > v.size {
+			return nil, invalidParamError("length")
+		}
+		length = n
+	}
+
+	l := []map[string]int{}
+	if length > 0 {
+		l = append(l, map[string]int{"offset": offset, "length": length})
+	}
+	return l, nil
+}
+
+func containsVolume(l []*volume, v *volume) bool {
+	for _, e := range l {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
+
+func removeVolume(l []*volume, v *volume) []*volume {
+	r := l[:0:0]
+	for _, e := range l {
+		if e != v {
+			r = append(r, e)
+		}
+	}
+	return r
+}
+
+func volumeNames(l []*volume) []string {
+	names := []string{}
+	for _, v := range l {
+		names = append(names, v.name)
+	}
+	return names
+}
@@ -0,0 +1,293 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package flasharray
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Operations checked by a Guard
+const (
+	GuardDestroy    = "destroy"
+	GuardEradicate  = "eradicate"
+	GuardDelete     = "delete"
+	GuardDisconnect = "disconnect"
+)
+
+// Guard struct for the safety checks of the destructive requests of a
+// Client, set with WithGuard.  The checks apply to every request sent with
+// Client.Do, whichever service method builds it: destroying or eradicating
+// volumes, snapshots, protection groups, pods and volume groups, deleting
+// hosts and host groups, and disconnecting volumes from them.
+type Guard struct {
+	// ProtectedNames are patterns of the names of objects which are never
+	// destroyed, eradicated, deleted or disconnected, as of
+	// RecycleBinFilter.Names, i.e. "prod-*".  A volume disconnected from a
+	// host is protected if either name matches.
+	ProtectedNames []string
+
+	// Confirm is called with each operation allowed by the other checks,
+	// and refuses it by returning false, i.e. to prompt the user
+	Confirm func(op *GuardedOperation) bool
+
+	// MinDestroyedAge refuses to eradicate objects destroyed for less than
+	// this long, with an eradication delay of EradicationDelay,
+	// DefaultEradicationDelay if zero
+	MinDestroyedAge  time.Duration
+	EradicationDelay time.Duration
+
+	// RefuseRecentIO refuses to disconnect volumes with reads or writes
+	// reported by MonitorVolume, currently, or over the RecentIOHistory
+	// period if set, one of HistoricalWindows, i.e. "1h"
+	RefuseRecentIO  bool
+	RecentIOHistory string
+
+	// DryRun logs the requests changing the array, all but GET requests,
+	// instead of sending them; the service methods return empty objects.
+	// The guarded operations are checked first.
+	DryRun bool
+	// Logger logs the dry run requests, the logger of the client if nil
+	Logger Logger
+}
+
+// GuardedOperation struct for a destructive request checked by a Guard
+type GuardedOperation struct {
+	// Op is the operation, i.e. GuardEradicate
+	Op string
+	// Kind is the kind of the object, one of the kinds of destroyed objects,
+	// i.e. DestroyedVolume, or "host" or "hgroup"
+	Kind string
+	Name string
+	// Volume is the volume disconnected from the host or host group Name
+	Volume string
+
+	Method string
+	URL    string
+}
+
+func (op *GuardedOperation) String() string {
+	if op.Op == GuardDisconnect {
+		return fmt.Sprintf("%s volume %s from %s %s", op.Op, op.Volume, op.Kind, op.Name)
+	}
+	return fmt.Sprintf("%s %s %s", op.Op, op.Kind, op.Name)
+}
+
+// Validate checks the patterns and durations of the guard.
+func (g *Guard) Validate() error {
+	for _, n := range g.ProtectedNames {
+		if _, err := matchName(n, ""); err != nil || n == "" {
+			return &PureError{Reason: fmt.Sprintf("[error] Invalid protected name pattern %q", n)}
+		}
+	}
+	if g.MinDestroyedAge < 0 || g.EradicationDelay < 0 {
+		return &PureError{Reason: "[error] Durations of guard must not be negative"}
+	}
+	if g.RecentIOHistory != "" {
+		return validateHistorical(g.RecentIOHistory)
+	}
+	return nil
+}
+
+// check refuses req with an error if it is a guarded operation failing a
+// check, and reports whether the request must not be sent, for a dry run.
+func (g *Guard) check(c *Client, req *http.Request) (bool, error) {
+	op, err := guardedOperation(req)
+	if err != nil {
+		return false, err
+	}
+	if op != nil {
+		if err := g.checkOperation(c.unguarded(), op); err != nil {
+			return false, err
+		}
+	}
+	if !g.DryRun || req.Method == "GET" {
+		return false, nil
+	}
+
+	body := ""
+	if req.GetBody != nil {
+		r, err := req.GetBody()
+		if err != nil {
+			return false, err
+		}
+		b, _ := ioutil.ReadAll(r)
+		r.Close()
+		body = " " + string(b)
+	}
+	logger := g.Logger
+	if logger == nil {
+		logger = c.logger
+	}
+	if logger != nil {
+		logger.Printf("[info] dry run: %s %s%s", req.Method, req.URL.String(), body)
+	}
+	return true, nil
+}
+
+func (g *Guard) checkOperation(c *Client, op *GuardedOperation) error {
+	refuse := func(format string, v ...interface{}) error {
+		return &PureError{Reason: fmt.Sprintf("[error] Guard refused to %s: %s", op, fmt.Sprintf(format, v...))}
+	}
+
+	for _, n := range g.ProtectedNames {
+		for _, name := range []string{op.Name, op.Volume} {
+			if ok, _ := matchName(n, name); ok && name != "" {
+				return refuse("%s is protected", name)
+			}
+		}
+	}
+
+	if op.Op == GuardEradicate && g.MinDestroyedAge > 0 {
+		timeRemaining, err := destroyedTimeRemaining(c, op)
+		if err != nil {
+			return err
+		}
+		if timeRemaining != nil {
+			delay := g.EradicationDelay
+			if delay == 0 {
+				delay = DefaultEradicationDelay
+			}
+			o := DestroyedObject{TimeRemaining: time.Duration(*timeRemaining) * time.Second}
+			if age := o.DestroyedFor(delay); age < g.MinDestroyedAge {
+				return refuse("destroyed for %s, less than %s", age, g.MinDestroyedAge)
+			}
+		}
+	}
+
+	if op.Op == GuardDisconnect && g.RefuseRecentIO {
+		params := map[string]string{}
+		if g.RecentIOHistory != "" {
+			params["historical"] = g.RecentIOHistory
+		}
+		l, err := c.Volumes.MonitorVolume(op.Volume, params)
+		if err != nil {
+			return err
+		}
+		for _, v := range l {
+			if (v.ReadsPerSec != nil && *v.ReadsPerSec > 0) || (v.WritesPerSec != nil && *v.WritesPerSec > 0) {
+				return refuse("volume %s has recent I/O", op.Volume)
+			}
+		}
+	}
+
+	if g.Confirm != nil && !g.Confirm(op) {
+		return refuse("not confirmed")
+	}
+	return nil
+}
+
+// guardedOperation returns the operation of req, or nil if it is not guarded.
+func guardedOperation(req *http.Request) (*GuardedOperation, error) {
+	if req.Method != "DELETE" {
+		return nil, nil
+	}
+	// The path of the request is /api/<version>/<path>
+	parts := strings.SplitN(strings.TrimPrefix(req.URL.Path, "/"), "/", 4)
+	if len(parts) < 4 || parts[0] != "api" || parts[3] == "" {
+		return nil, nil
+	}
+	kind, name := parts[2], parts[3]
+	op := &GuardedOperation{Kind: kind, Name: name, Method: req.Method, URL: req.URL.String()}
+
+	switch kind {
+	case "host", "hgroup":
+		op.Op = GuardDelete
+		if p := strings.SplitN(name, "/", 3); len(p) == 3 && p[1] == "volume" {
+			op.Op, op.Name, op.Volume = GuardDisconnect, p[0], p[2]
+		} else if len(p) > 1 {
+			return nil, nil
+		}
+		return op, nil
+	case "volume", "pgroup":
+		if strings.Contains(name, ".") {
+			op.Kind += "-snapshot"
+		}
+	case "pod", "vgroup":
+	default:
+		return nil, nil
+	}
+	// Other paths, i.e. volume/<volume>/pgroup/<pgroup>, remove the object
+	// from another, apart from the volumes of volume groups
+	if n := strings.Count(name, "/"); n > 1 || (n == 1 && kind != "volume") {
+		return nil, nil
+	}
+
+	op.Op = GuardDestroy
+	if req.GetBody != nil {
+		r, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		var data map[string]interface{}
+		json.NewDecoder(r).Decode(&data)
+		r.Close()
+		if data["eradicate"] == true {
+			op.Op = GuardEradicate
+		}
+	}
+	return op, nil
+}
+
+// destroyedTimeRemaining returns how long the destroyed object of op can be
+// recovered, or nil if it is not destroyed.
+func destroyedTimeRemaining(c *Client, op *GuardedOperation) (*int, error) {
+	pending := map[string]string{"pending": "true"}
+	switch op.Kind {
+	case DestroyedVolume, DestroyedVolumeSnapshot:
+		v, err := c.Volumes.GetVolume(op.Name, pending)
+		if err != nil {
+			return nil, err
+		}
+		return v.TimeRemaining, nil
+	case DestroyedPgroup:
+		pg, err := c.Protectiongroups.GetProtectiongroup(op.Name, pending)
+		if err != nil {
+			return nil, err
+		}
+		return pg.TimeRemaining, nil
+	case DestroyedPgroupSnapshot:
+		l, err := c.Protectiongroups.ListPgroupSnapshots(map[string]string{"names": op.Name, "pending": "true"})
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range l {
+			if s.Name == op.Name {
+				return s.TimeRemaining, nil
+			}
+		}
+		return nil, nil
+	case DestroyedPod:
+		p, err := c.Pods.GetPod(op.Name, pending)
+		if err != nil {
+			return nil, err
+		}
+		return p.TimeRemaining, nil
+	}
+
+	req, err := c.NewRequest("GET", "vgroup/"+op.Name, pending, nil)
+	if err != nil {
+		return nil, err
+	}
+	g := &Vgroup{}
+	if _, err = c.Do(req, g, false); err != nil {
+		return nil, err
+	}
+	return g.TimeRemaining, nil
+}
+
+// unguarded returns a copy of the client without guard, to send the requests
+// of the checks.
+func (c *Client) unguarded() *Client {
+	c2 := new(Client)
+	*c2 = *c
+	c2.guard = nil
+	c2.initServices()
+	return c2
+}
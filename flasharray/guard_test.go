@@ -0,0 +1,132 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package flasharray
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devans10/go-purestorage/flasharray/flasharraytest"
+)
+
+func testGuardedClient(t *testing.T, s *flasharraytest.Server, g *Guard) *Client {
+	c, err := New(s.Target(), WithAPIToken(s.APIToken), WithHTTPClient(s.Client()), WithGuard(g))
+	if err != nil {
+		t.Fatalf("error setting up guarded client of the fake array: %s", err)
+	}
+	return c
+}
+
+func TestGuard(t *testing.T) {
+	s, c := testFakeArray(t)
+	now := time.Now()
+	s.Now = func() time.Time { return now }
+
+	c.Volumes.CreateVolume("prod-db", testvolsize)
+	c.Volumes.CreateVolume("vol1", testvolsize)
+	c.Volumes.CreateVolume("vol2", testvolsize)
+	c.Hosts.CreateHost("host1", nil)
+	c.Hosts.CreateHost("host2", nil)
+	c.Hosts.ConnectHost("host1", "vol1", nil)
+	c.Hosts.ConnectHost("host1", "prod-db", nil)
+	c.Protectiongroups.CreateProtectiongroup("pgroup1", map[string][]string{"vollist": {"vol1"}})
+
+	var ops []string
+	confirm := true
+	g := &Guard{
+		ProtectedNames:  []string{"prod-*"},
+		MinDestroyedAge: time.Hour,
+		RefuseRecentIO:  true,
+		Confirm: func(op *GuardedOperation) bool {
+			ops = append(ops, op.String())
+			return confirm
+		},
+	}
+	gc := testGuardedClient(t, s, g)
+
+	t.Run("ProtectedNames", func(t *testing.T) {
+		if _, err := gc.Volumes.DeleteVolume("prod-db"); err == nil {
+			t.Errorf("An Error was NOT raised for destroying a protected volume")
+		}
+		if _, err := gc.Hosts.DisconnectHost("host1", "prod-db"); err == nil {
+			t.Errorf("An Error was NOT raised for disconnecting a protected volume")
+		}
+		if _, err := c.Volumes.GetVolume("prod-db", nil); err != nil {
+			t.Errorf("expected prod-db not to be destroyed: %s", err)
+		}
+		if len(ops) != 0 {
+			t.Errorf("expected no confirmation of refused operations; got %v", ops)
+		}
+	})
+
+	t.Run("MinDestroyedAge", func(t *testing.T) {
+		if _, err := gc.Volumes.DeleteVolume("vol2"); err != nil {
+			t.Fatalf("error destroying vol2: %s", err)
+		}
+		if _, err := gc.Volumes.EradicateVolume("vol2"); err == nil {
+			t.Errorf("An Error was NOT raised for eradicating a volume destroyed just now")
+		}
+		now = now.Add(2 * time.Hour)
+		if _, err := gc.Volumes.EradicateVolume("vol2"); err != nil {
+			t.Errorf("error eradicating vol2 destroyed for 2 hours: %s", err)
+		}
+	})
+
+	t.Run("RefuseRecentIO", func(t *testing.T) {
+		s.SetVolumeIO("vol1", 0, 100)
+		if _, err := gc.Hosts.DisconnectHost("host1", "vol1"); err == nil {
+			t.Errorf("An Error was NOT raised for disconnecting a volume with I/O")
+		}
+		s.SetVolumeIO("vol1", 0, 0)
+		if _, err := gc.Hosts.DisconnectHost("host1", "vol1"); err != nil {
+			t.Errorf("error disconnecting idle volume vol1: %s", err)
+		}
+	})
+
+	t.Run("Confirm", func(t *testing.T) {
+		confirm = false
+		if _, err := gc.Hosts.DeleteHost("host2"); err == nil {
+			t.Errorf("An Error was NOT raised for an operation not confirmed")
+		}
+		// Removing a volume from a protection group is not guarded
+		if _, err := gc.Volumes.RemoveVolume("vol1", "pgroup1"); err != nil {
+			t.Errorf("error removing vol1 from pgroup1: %s", err)
+		}
+		expected := []string{"destroy volume vol2", "eradicate volume vol2", "disconnect volume vol1 from host host1", "delete host host2"}
+		if strings.Join(ops, ", ") != strings.Join(expected, ", ") {
+			t.Errorf("expected confirmations %v; got %v", expected, ops)
+		}
+	})
+
+	t.Run("DryRun", func(t *testing.T) {
+		var buf bytes.Buffer
+		dc := testGuardedClient(t, s, &Guard{ProtectedNames: []string{"prod-*"}, DryRun: true, Logger: log.New(&buf, "", 0)})
+		if _, err := dc.Hosts.DeleteHost("host2"); err != nil {
+			t.Fatalf("error deleting host2 in a dry run: %s", err)
+		}
+		if _, err := dc.Volumes.DeleteVolume("prod-db"); err == nil {
+			t.Errorf("An Error was NOT raised for destroying a protected volume in a dry run")
+		}
+		if _, err := c.Hosts.GetHost("host2", nil); err != nil {
+			t.Errorf("expected host2 not to be deleted by a dry run: %s", err)
+		}
+		if !strings.Contains(buf.String(), "dry run: DELETE https://") || !strings.Contains(buf.String(), "/host/host2") {
+			t.Errorf("expected the request to be logged; got %q", buf.String())
+		}
+	})
+
+	if _, err := New(s.Target(), WithAPIToken(s.APIToken), WithHTTPClient(s.Client()), WithGuard(&Guard{ProtectedNames: []string{"["}})); err == nil {
+		t.Errorf("An Error was NOT raised for an invalid protected name pattern")
+	}
+	if _, err := New(s.Target(), WithAPIToken(s.APIToken), WithHTTPClient(s.Client()), WithGuard(&Guard{RefuseRecentIO: true, RecentIOHistory: "2h"})); err == nil {
+		t.Errorf("An Error was NOT raised for an invalid recent I/O history window")
+	}
+	if err := (&Guard{RecentIOHistory: "1h"}).Validate(); err != nil {
+		t.Errorf("unexpected error for a valid recent I/O history window: %s", err)
+	}
+}
@@ -4,12 +4,39 @@
 
 package flasharray
 
+// Status of the hardware components and drives
+const (
+	HardwareStatusOK           = "ok"
+	HardwareStatusCritical     = "critical"
+	HardwareStatusDegraded     = "degraded"
+	HardwareStatusNotInstalled = "not_installed"
+	HardwareStatusUnknown      = "unknown"
+)
+
 // Drive struct for data returned by array
 type Drive struct {
-	Name string `json:"name"`
+	Name     string `json:"name"`
+	Status   string `json:"status,omitempty"`
+	Type     string `json:"type,omitempty"`
+	Protocol string `json:"protocol,omitempty"`
+	Capacity int    `json:"capacity,omitempty"`
+	Details  string `json:"details,omitempty"`
 }
 
 // Component struct for data returned by arrayl
 type Component struct {
-	Name string `json:"name"`
+	Name     string `json:"name"`
+	Status   string `json:"status,omitempty"`
+	Identify string `json:"identify,omitempty"`
+	Index    *int   `json:"index,omitempty"`
+	Slot     *int   `json:"slot,omitempty"`
+	Model    string `json:"model,omitempty"`
+	Serial   string `json:"serial,omitempty"`
+	Details  string `json:"details,omitempty"`
+
+	// Speed is in bits per second, Temperature in degrees Celsius and
+	// Voltage in volts, for the components reporting them
+	Speed       *int `json:"speed,omitempty"`
+	Temperature *int `json:"temperature,omitempty"`
+	Voltage     *int `json:"voltage,omitempty"`
 }
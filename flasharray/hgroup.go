@@ -18,6 +18,10 @@ func (h *HostgroupService) ConnectHostgroup(hgroup string, volume string, data i
 
 	path := fmt.Sprintf("hgroup/%s/volume/%s", hgroup, volume)
 	req, err := h.client.NewRequest("POST", path, nil, data)
+	if err != nil {
+		return nil, err
+	}
+
 	m := &ConnectedVolume{}
 	_, err = h.client.Do(req, m, false)
 	if err != nil {
@@ -96,6 +100,20 @@ func (h *HostgroupService) GetHostgroup(name string, params map[string]string) (
 	return m, err
 }
 
+// GetHostgroupPerformanceHistory returns the performance samples of the host
+// group over the historical window, one of HistoricalWindows, or the current
+// sample if historical is empty
+func (h *HostgroupService) GetHostgroupPerformanceHistory(name string, historical string) (PerformanceHistory, error) {
+	return h.client.getPerformanceHistory(fmt.Sprintf("hgroup/%s", name), historical)
+}
+
+// GetHostgroupSpaceHistory returns the space samples of the host group over
+// the historical window, one of HistoricalWindows, or the current sample if
+// historical is empty
+func (h *HostgroupService) GetHostgroupSpaceHistory(name string, historical string) (SpaceHistory, error) {
+	return h.client.getSpaceHistory(fmt.Sprintf("hgroup/%s", name), historical)
+}
+
 // AddHostgroup adds a hostgroup to a Protection Group
 func (h *HostgroupService) AddHostgroup(hgroup string, pgroup string) (*HostgroupPgroup, error) {
 
@@ -47,3 +47,37 @@ type HostgroupConnection struct {
 	Vol  string `json:"vol,omitempty"`
 	Lun  int    `json:"lun,omitempty"`
 }
+
+// CreateHostgroupOptions struct for the attributes of a host group created
+// with CreateHostgroup
+type CreateHostgroupOptions struct {
+	// Hosts are the member hosts of the host group
+	Hosts []string `json:"hostlist,omitempty"`
+}
+
+// Validate checks the member hosts.
+func (o *CreateHostgroupOptions) Validate() error {
+	return validateNames("host", o.Hosts)
+}
+
+// SetHostgroupOptions struct for the changes of a host group made with
+// SetHostgroup.  Nil fields are left unchanged.
+type SetHostgroupOptions struct {
+	// Name renames the host group
+	Name string `json:"name,omitempty"`
+	// Hosts replaces the member hosts; AddHosts and RemHosts add and remove
+	// members instead
+	Hosts    *[]string `json:"hostlist,omitempty"`
+	AddHosts []string  `json:"addhostlist,omitempty"`
+	RemHosts []string  `json:"remhostlist,omitempty"`
+}
+
+// Validate checks the name and the changes of the member hosts.
+func (o *SetHostgroupOptions) Validate() error {
+	if o.Name != "" {
+		if err := validateName("host group", o.Name); err != nil {
+			return err
+		}
+	}
+	return validateListChange("host", o.Hosts, o.AddHosts, o.RemHosts)
+}
@@ -11,8 +11,7 @@ import (
 const testAccHostgroupName = "testAcchgroup"
 
 func TestAccHostgroups(t *testing.T) {
-	testAccPreChecks(t)
-	c := testAccGenerateClient(t)
+	c := testAccClient(t)
 
 	testhost1 := "testacchgrouphost1"
 	testhost2 := "testacchgrouphost2"
@@ -216,3 +215,29 @@ func testAccDeleteHostgroup(c *Client) func(t *testing.T) {
 		}
 	}
 }
+
+func TestHostgroupOptionsValidate(t *testing.T) {
+	hosts := []string{"host1"}
+	valid := []validator{
+		&CreateHostgroupOptions{Hosts: hosts},
+		&SetHostgroupOptions{Name: "hgroup2", Hosts: &hosts},
+		&SetHostgroupOptions{AddHosts: hosts, RemHosts: []string{"host2"}},
+	}
+	for _, o := range valid {
+		if err := o.Validate(); err != nil {
+			t.Errorf("unexpected error for options %+v: %s", o, err)
+		}
+	}
+
+	invalid := []validator{
+		&CreateHostgroupOptions{Hosts: []string{"host1", "host1"}},
+		&SetHostgroupOptions{Name: "hgroup 2"},
+		&SetHostgroupOptions{Hosts: &hosts, RemHosts: []string{"host2"}},
+		&SetHostgroupOptions{AddHosts: hosts, RemHosts: hosts},
+	}
+	for _, o := range invalid {
+		if err := o.Validate(); err == nil {
+			t.Errorf("An Error was NOT raised for options %+v", o)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package flasharray
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+)
+
+// getPerformanceHistory returns the performance samples of the object at
+// path, over the historical window, or the current sample if it is empty.
+func (c *Client) getPerformanceHistory(path string, historical string) (PerformanceHistory, error) {
+	h := PerformanceHistory{}
+	if err := c.getHistory(path, map[string]string{"action": "monitor"}, historical, &h); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(h, func(i, j int) bool { return h[i].Time.Before(h[j].Time) })
+	return h, nil
+}
+
+// getSpaceHistory returns the space samples of the object at path, over the
+// historical window, or the current sample if it is empty.
+func (c *Client) getSpaceHistory(path string, historical string) (SpaceHistory, error) {
+	h := SpaceHistory{}
+	if err := c.getHistory(path, map[string]string{"space": "true"}, historical, &h); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(h, func(i, j int) bool { return h[i].Time.Before(h[j].Time) })
+	return h, nil
+}
+
+// getHistory decodes the samples of the object at path into the list
+// samples.  The array returns either a sample or a list of samples.
+func (c *Client) getHistory(path string, params map[string]string, historical string, samples interface{}) error {
+	if historical != "" {
+		if err := validateHistorical(historical); err != nil {
+			return err
+		}
+		params["historical"] = historical
+	}
+	req, err := c.NewRequest("GET", path, params, nil)
+	if err != nil {
+		return err
+	}
+	m := json.RawMessage{}
+	if _, err = c.Do(req, &m, false); err != nil {
+		return err
+	}
+
+	b := bytes.TrimSpace(m)
+	if len(b) > 0 && b[0] == '{' {
+		b = append(append([]byte{'['}, b...), ']')
+	}
+	return json.Unmarshal(b, samples)
+}
@@ -0,0 +1,162 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package flasharray
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// PerformanceSample struct for a sample of the performance metrics of the
+// array, a volume, a host or a host group, returned with the action=monitor
+// flag
+type PerformanceSample struct {
+	// Name is the name of the object, or the host name of the array
+	Name string    `json:"name,omitempty"`
+	Time time.Time `json:"time"`
+
+	ReadsPerSec  int `json:"reads_per_sec"`
+	WritesPerSec int `json:"writes_per_sec"`
+	// InputPerSec and OutputPerSec are the bytes written and read per second
+	InputPerSec       int `json:"input_per_sec"`
+	OutputPerSec      int `json:"output_per_sec"`
+	UsecPerReadOp     int `json:"usec_per_read_op"`
+	UsecPerWriteOp    int `json:"usec_per_write_op"`
+	SanUsecPerReadOp  int `json:"san_usec_per_read_op"`
+	SanUsecPerWriteOp int `json:"san_usec_per_write_op"`
+	QueueDepth        int `json:"queue_depth"`
+}
+
+// IOPS returns the reads and writes per second of the sample.
+func (s PerformanceSample) IOPS() int {
+	return s.ReadsPerSec + s.WritesPerSec
+}
+
+// Bandwidth returns the bytes read and written per second of the sample.
+func (s PerformanceSample) Bandwidth() int {
+	return s.InputPerSec + s.OutputPerSec
+}
+
+// SpaceSample struct for a sample of the space metrics of the array, a
+// volume, a host or a host group, returned with the space=true flag.  Sizes
+// are in bytes.
+type SpaceSample struct {
+	// Name is the name of the object, or the host name of the array
+	Name string    `json:"name,omitempty"`
+	Time time.Time `json:"time"`
+
+	// Capacity is the usable capacity of the array
+	Capacity int `json:"capacity"`
+	// Size is the provisioned size of a volume, and Provisioned of the array
+	Size        int `json:"size"`
+	Provisioned int `json:"provisioned"`
+
+	Volumes          int     `json:"volumes"`
+	Snapshots        int     `json:"snapshots"`
+	SharedSpace      int     `json:"shared_space"`
+	System           int     `json:"system"`
+	Total            int     `json:"total"`
+	DataReduction    float64 `json:"data_reduction"`
+	TotalReduction   float64 `json:"total_reduction"`
+	ThinProvisioning float64 `json:"thin_provisioning"`
+}
+
+// PerformanceHistory is a list of performance samples, in time order
+type PerformanceHistory []PerformanceSample
+
+// Series returns the time series of the metric of the samples, i.e.
+//
+//	iops := h.Series(func(s flasharray.PerformanceSample) float64 { return float64(s.IOPS()) })
+func (h PerformanceHistory) Series(metric func(s PerformanceSample) float64) Series {
+	l := make(Series, len(h))
+	for i, s := range h {
+		l[i] = Point{Time: s.Time, Value: metric(s)}
+	}
+	return l
+}
+
+// SpaceHistory is a list of space samples, in time order
+type SpaceHistory []SpaceSample
+
+// Series returns the time series of the metric of the samples, i.e.
+//
+//	used := h.Series(func(s flasharray.SpaceSample) float64 { return float64(s.Total) })
+func (h SpaceHistory) Series(metric func(s SpaceSample) float64) Series {
+	l := make(Series, len(h))
+	for i, s := range h {
+		l[i] = Point{Time: s.Time, Value: metric(s)}
+	}
+	return l
+}
+
+// Point struct for a value of a time series
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+// Series is a time series of the values of a metric, in time order.  The
+// statistics of an empty series are 0.
+type Series []Point
+
+// Average returns the mean of the values of the series.
+func (s Series) Average() float64 {
+	if len(s) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, p := range s {
+		sum += p.Value
+	}
+	return sum / float64(len(s))
+}
+
+// Min returns the smallest value of the series.
+func (s Series) Min() float64 {
+	return s.Percentile(0)
+}
+
+// Max returns the largest value of the series.
+func (s Series) Max() float64 {
+	return s.Percentile(100)
+}
+
+// Percentile returns the p-th percentile of the values of the series, p
+// between 0 and 100, interpolated between the closest values.
+func (s Series) Percentile(p float64) float64 {
+	if len(s) == 0 {
+		return 0
+	}
+	values := make([]float64, len(s))
+	for i, pt := range s {
+		values[i] = pt.Value
+	}
+	sort.Float64s(values)
+
+	p = math.Max(0, math.Min(100, p))
+	rank := p / 100 * float64(len(values)-1)
+	i := int(rank)
+	if i == len(values)-1 {
+		return values[i]
+	}
+	return values[i] + (rank-float64(i))*(values[i+1]-values[i])
+}
+
+// Rate returns the change per second of the values of the series, between
+// each point and the previous one, at the time of the point, i.e. the
+// growth of the space used.  Points not later than the previous one are
+// skipped.
+func (s Series) Rate() Series {
+	l := Series{}
+	for i := 1; i < len(s); i++ {
+		d := s[i].Time.Sub(s[i-1].Time).Seconds()
+		if d <= 0 {
+			continue
+		}
+		l = append(l, Point{Time: s[i].Time, Value: (s[i].Value - s[i-1].Value) / d})
+	}
+	return l
+}
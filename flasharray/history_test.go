@@ -0,0 +1,111 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package flasharray
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestHistory(t *testing.T) {
+	s, c := testFakeArray(t)
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	s.Now = func() time.Time { return now }
+
+	c.Volumes.CreateVolume("vol1", testvolsize)
+	c.Hosts.CreateHost("host1", nil)
+	c.Hostgroups.CreateHostgroup("hgroup1", nil)
+	s.SetVolumeIO("vol1", 10, 20)
+
+	t.Run("Array", func(t *testing.T) {
+		h, err := c.Array.GetPerformanceHistory("1h")
+		if err != nil {
+			t.Fatalf("error getting array performance history: %s", err)
+		}
+		if len(h) != 120 || !h[len(h)-1].Time.Equal(now) || h[0].Time.After(h[1].Time) {
+			t.Errorf("expected 120 samples up to %s; got %d", now, len(h))
+		}
+		if h[0].IOPS() != 30 {
+			t.Errorf("expected 30 IOPS; got %d", h[0].IOPS())
+		}
+		sh, err := c.Array.GetSpaceHistory("")
+		if err != nil {
+			t.Fatalf("error getting array space: %s", err)
+		}
+		if len(sh) != 1 || sh[0].Capacity == 0 || sh[0].Provisioned != testvolsize {
+			t.Errorf("expected the current space sample of the array; got %+v", sh)
+		}
+	})
+
+	t.Run("Volume", func(t *testing.T) {
+		h, err := c.Volumes.GetVolumePerformanceHistory("vol1", "24h")
+		if err != nil {
+			t.Fatalf("error getting volume performance history: %s", err)
+		}
+		if len(h) != 288 || h[0].Name != "vol1" || h[0].WritesPerSec != 20 {
+			t.Errorf("expected 288 samples of vol1 with 20 writes/s; got %d", len(h))
+		}
+		sh, err := c.Volumes.GetVolumeSpaceHistory("vol1", "7d")
+		if err != nil {
+			t.Fatalf("error getting volume space history: %s", err)
+		}
+		if len(sh) != 336 || sh[0].Size != testvolsize {
+			t.Errorf("expected 336 samples of the size of vol1; got %d", len(sh))
+		}
+	})
+
+	t.Run("Host", func(t *testing.T) {
+		if _, err := c.Hosts.GetHostPerformanceHistory("host1", "3h"); err != nil {
+			t.Errorf("error getting host performance history: %s", err)
+		}
+		h, err := c.Hosts.GetHostSpaceHistory("host1", "")
+		if err != nil || len(h) != 1 {
+			t.Errorf("expected the current space sample of host1; got %v, %v", h, err)
+		}
+		if _, err := c.Hostgroups.GetHostgroupPerformanceHistory("hgroup1", "30d"); err != nil {
+			t.Errorf("error getting host group performance history: %s", err)
+		}
+		if _, err := c.Hostgroups.GetHostgroupSpaceHistory("hgroup1", "1y"); err != nil {
+			t.Errorf("error getting host group space history: %s", err)
+		}
+	})
+
+	if _, err := c.Volumes.GetVolumePerformanceHistory("vol1", "2h"); err == nil {
+		t.Errorf("An Error was NOT raised for an invalid historical window")
+	}
+}
+
+func TestSeries(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	h := SpaceHistory{}
+	for i, total := range []int{100, 400, 200, 300} {
+		h = append(h, SpaceSample{Time: start.Add(time.Duration(i) * time.Minute), Total: total})
+	}
+	s := h.Series(func(s SpaceSample) float64 { return float64(s.Total) })
+
+	if s.Average() != 250 {
+		t.Errorf("expected average 250; got %f", s.Average())
+	}
+	if s.Min() != 100 || s.Max() != 400 {
+		t.Errorf("expected min 100 and max 400; got %f and %f", s.Min(), s.Max())
+	}
+	if p := s.Percentile(50); p != 250 {
+		t.Errorf("expected median 250; got %f", p)
+	}
+	if p := s.Percentile(90); math.Abs(p-370) > 1e-9 {
+		t.Errorf("expected 90th percentile 370; got %f", p)
+	}
+
+	r := s.Rate()
+	if len(r) != 3 || r[0].Value != 5 || r[1].Value != -200.0/60 || !r[2].Time.Equal(h[3].Time) {
+		t.Errorf("expected rates per second 5, -3.33 and 1.67; got %v", r)
+	}
+
+	var empty Series
+	if empty.Average() != 0 || empty.Percentile(50) != 0 || len(empty.Rate()) != 0 {
+		t.Errorf("expected statistics of an empty series to be 0")
+	}
+}
@@ -18,6 +18,10 @@ func (h *HostService) ConnectHost(host string, volume string, data interface{})
 
 	path := fmt.Sprintf("host/%s/volume/%s", host, volume)
 	req, err := h.client.NewRequest("POST", path, nil, data)
+	if err != nil {
+		return nil, err
+	}
+
 	m := &ConnectedVolume{}
 	_, err = h.client.Do(req, m, false)
 	if err != nil {
@@ -95,6 +99,20 @@ func (h *HostService) GetHost(name string, params map[string]string) (*Host, err
 	return m, err
 }
 
+// GetHostPerformanceHistory returns the performance samples of the host over
+// the historical window, one of HistoricalWindows, or the current sample if
+// historical is empty
+func (h *HostService) GetHostPerformanceHistory(name string, historical string) (PerformanceHistory, error) {
+	return h.client.getPerformanceHistory(fmt.Sprintf("host/%s", name), historical)
+}
+
+// GetHostSpaceHistory returns the space samples of the host over the
+// historical window, one of HistoricalWindows, or the current sample if
+// historical is empty
+func (h *HostService) GetHostSpaceHistory(name string, historical string) (SpaceHistory, error) {
+	return h.client.getSpaceHistory(fmt.Sprintf("host/%s", name), historical)
+}
+
 // AddHost adds a host to a protection group
 func (h *HostService) AddHost(host string, pgroup string) (*HostPgroup, error) {
 
@@ -4,6 +4,12 @@
 
 package flasharray
 
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
 // Host struct for the host object returned from the array
 type Host struct {
 	Name           string   `json:"name,omitempty"`
@@ -57,3 +63,202 @@ type HostPgroup struct {
 	Name   string `json:"name,omitempty"`
 	Pgroup string `json:"protection_group,omitempty"`
 }
+
+// Host personalities
+const (
+	HostPersonalityAIX            = "aix"
+	HostPersonalityESXi           = "esxi"
+	HostPersonalityHitachiVSP     = "hitachi-vsp"
+	HostPersonalityHPUX           = "hpux"
+	HostPersonalityOracleVMServer = "oracle-vm-server"
+	HostPersonalitySolaris        = "solaris"
+	HostPersonalityVMS            = "vms"
+)
+
+var hostPersonalities = []string{HostPersonalityAIX, HostPersonalityESXi, HostPersonalityHitachiVSP, HostPersonalityHPUX, HostPersonalityOracleVMServer, HostPersonalitySolaris, HostPersonalityVMS}
+
+// Minimum and maximum LUN of a volume connected to a host or host group
+const (
+	MinLun = 1
+	MaxLun = 16383
+)
+
+var (
+	wwnRegexp = regexp.MustCompile(`^[0-9a-fA-F]{16}$`)
+	iqnRegexp = regexp.MustCompile(`^(iqn\.\d{4}-\d{2}\.\S+|eui\.[0-9a-fA-F]{16})$`)
+	nqnRegexp = regexp.MustCompile(`^nqn\.\d{4}-\d{2}\.\S+$`)
+)
+
+// CreateHostOptions struct for the attributes of a host created with
+// CreateHost
+type CreateHostOptions struct {
+	// Wwns, Iqns and Nqns are the Fibre Channel WWNs, with or without ':',
+	// and the iSCSI IQNs or EUIs and NVMe NQNs of the host
+	Wwns []string `json:"wwnlist,omitempty"`
+	Iqns []string `json:"iqnlist,omitempty"`
+	Nqns []string `json:"nqnlist,omitempty"`
+	// Personality is the host personality, i.e. HostPersonalityESXi
+	Personality string `json:"personality,omitempty"`
+	// PreferredArrays are the arrays of a stretched pod the host prefers
+	PreferredArrays []string `json:"preferred_array,omitempty"`
+}
+
+// Validate checks the initiators, personality and preferred arrays.
+func (o *CreateHostOptions) Validate() error {
+	if err := validateInitiators(o.Wwns, o.Iqns, o.Nqns); err != nil {
+		return err
+	}
+	if err := validatePersonality(o.Personality); err != nil {
+		return err
+	}
+	return validateNames("preferred array", o.PreferredArrays)
+}
+
+// CHAPCredentials struct for the CHAP credentials of a host.  The host
+// credentials authenticate the host to the array, and the target
+// credentials the array to the host.  Empty credentials are removed.
+type CHAPCredentials struct {
+	HostUser       string `json:"host_user"`
+	HostPassword   string `json:"host_password"`
+	TargetUser     string `json:"target_user"`
+	TargetPassword string `json:"target_password"`
+}
+
+// Validate checks that the passwords are 12-255 characters, and different.
+func (c *CHAPCredentials) Validate() error {
+	for _, p := range []struct{ name, user, password string }{
+		{"host", c.HostUser, c.HostPassword},
+		{"target", c.TargetUser, c.TargetPassword},
+	} {
+		if p.user != "" && p.password == "" {
+			return &PureError{Reason: fmt.Sprintf("[error] CHAP %s user requires a %s password", p.name, p.name)}
+		}
+		if p.password != "" && (len(p.password) < 12 || len(p.password) > 255) {
+			return &PureError{Reason: fmt.Sprintf("[error] CHAP %s password must be 12-255 characters", p.name)}
+		}
+	}
+	if c.HostPassword != "" && c.HostPassword == c.TargetPassword {
+		return &PureError{Reason: "[error] CHAP host and target passwords must be different"}
+	}
+	return nil
+}
+
+// SetHostOptions struct for the changes of a host made with SetHost.  Nil
+// fields are left unchanged.
+type SetHostOptions struct {
+	// Name renames the host
+	Name string `json:"name,omitempty"`
+
+	// Wwns, Iqns and Nqns replace the initiators of the host; the Add and
+	// Rem lists add and remove initiators instead
+	Wwns    *[]string `json:"wwnlist,omitempty"`
+	AddWwns []string  `json:"addwwnlist,omitempty"`
+	RemWwns []string  `json:"remwwnlist,omitempty"`
+	Iqns    *[]string `json:"iqnlist,omitempty"`
+	AddIqns []string  `json:"addiqnlist,omitempty"`
+	RemIqns []string  `json:"remiqnlist,omitempty"`
+	Nqns    *[]string `json:"nqnlist,omitempty"`
+	AddNqns []string  `json:"addnqnlist,omitempty"`
+	RemNqns []string  `json:"remnqnlist,omitempty"`
+
+	// Personality sets the host personality, or removes it if empty
+	Personality *string `json:"personality,omitempty"`
+	// PreferredArrays replaces the preferred arrays of the host
+	PreferredArrays *[]string `json:"preferred_array,omitempty"`
+	// CHAPCredentials replaces the CHAP credentials of the host
+	*CHAPCredentials
+}
+
+// Validate checks the name, initiators, personality, preferred arrays and
+// CHAP credentials.
+func (o *SetHostOptions) Validate() error {
+	if o.Name != "" {
+		if err := validateName("host", o.Name); err != nil {
+			return err
+		}
+	}
+	for _, l := range []struct {
+		kind     string
+		set      *[]string
+		add, rem []string
+	}{
+		{"WWN", o.Wwns, o.AddWwns, o.RemWwns},
+		{"IQN", o.Iqns, o.AddIqns, o.RemIqns},
+		{"NQN", o.Nqns, o.AddNqns, o.RemNqns},
+	} {
+		if err := validateListChange(l.kind, l.set, l.add, l.rem); err != nil {
+			return err
+		}
+	}
+	deref := func(l *[]string) []string {
+		if l == nil {
+			return nil
+		}
+		return *l
+	}
+	if err := validateInitiators(append(deref(o.Wwns), o.AddWwns...), append(deref(o.Iqns), o.AddIqns...), append(deref(o.Nqns), o.AddNqns...)); err != nil {
+		return err
+	}
+	if o.Personality != nil && *o.Personality != "" {
+		if err := validatePersonality(*o.Personality); err != nil {
+			return err
+		}
+	}
+	if err := validateNames("preferred array", deref(o.PreferredArrays)); err != nil {
+		return err
+	}
+	if o.CHAPCredentials != nil {
+		return o.CHAPCredentials.Validate()
+	}
+	return nil
+}
+
+// ConnectVolumeOptions struct for the connection of a volume to a host or
+// host group, made with ConnectHost or ConnectHostgroup
+type ConnectVolumeOptions struct {
+	// Lun is the LUN of the volume, chosen by the array if zero
+	Lun int `json:"lun,omitempty"`
+}
+
+// Validate checks that the LUN is between MinLun and MaxLun.
+func (o *ConnectVolumeOptions) Validate() error {
+	if o.Lun != 0 && (o.Lun < MinLun || o.Lun > MaxLun) {
+		return &PureError{Reason: fmt.Sprintf("[error] LUN %d must be between %d and %d", o.Lun, MinLun, MaxLun)}
+	}
+	return nil
+}
+
+// validateInitiators checks the formats of the initiators of a host.
+func validateInitiators(wwns []string, iqns []string, nqns []string) error {
+	for _, l := range []struct {
+		kind   string
+		ports  []string
+		regexp *regexp.Regexp
+	}{
+		{"WWN", wwns, wwnRegexp},
+		{"IQN", iqns, iqnRegexp},
+		{"NQN", nqns, nqnRegexp},
+	} {
+		var ports []string
+		for _, port := range l.ports {
+			if l.kind == "WWN" {
+				port = strings.ToUpper(strings.Replace(port, ":", "", -1))
+			}
+			if !l.regexp.MatchString(port) {
+				return &PureError{Reason: fmt.Sprintf("[error] Invalid %s %q", l.kind, port)}
+			}
+			ports = append(ports, port)
+		}
+		if err := validateNames(l.kind, ports); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validatePersonality(personality string) error {
+	if personality != "" && !contains(hostPersonalities, personality) {
+		return &PureError{Reason: fmt.Sprintf("[error] Invalid host personality %s, must be one of %s", personality, strings.Join(hostPersonalities, ", "))}
+	}
+	return nil
+}
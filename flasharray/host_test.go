@@ -11,8 +11,7 @@ import (
 const testAccHostName = "testAcchost"
 
 func TestAccHosts(t *testing.T) {
-	testAccPreChecks(t)
-	c := testAccGenerateClient(t)
+	c := testAccClient(t)
 
 	testvol := "testacchostvol1"
 	testpgroup := "testacchostpgroup"
@@ -196,3 +195,94 @@ func testAccDeleteHost(c *Client) func(t *testing.T) {
 		}
 	}
 }
+
+func TestHostOptions(t *testing.T) {
+	_, c := testFakeArray(t)
+	c.Volumes.CreateVolume("vol1", testvolsize)
+
+	h, err := c.Hosts.CreateHost("host1", &CreateHostOptions{Wwns: []string{"00:00:99:99:00:00:99:99"}, Personality: HostPersonalityESXi})
+	if err != nil {
+		t.Fatalf("error creating host1: %s", err)
+	}
+	if len(h.Wwn) != 1 || h.Wwn[0] != "0000999900009999" {
+		t.Fatalf("expected the WWN of host1; got %+v", h)
+	}
+	if h, err = c.Hosts.GetHost("host1", map[string]string{"personality": "true"}); err != nil || h.Personality != HostPersonalityESXi {
+		t.Fatalf("expected the personality of host1; got %+v, %v", h, err)
+	}
+
+	none := ""
+	chap := &CHAPCredentials{HostUser: "host1", HostPassword: "host-password1"}
+	if h, err = c.Hosts.SetHost("host1", &SetHostOptions{AddIqns: []string{"iqn.2020-01.com.example:host1"}, Personality: &none, CHAPCredentials: chap}); err != nil {
+		t.Fatalf("error setting host1: %s", err)
+	}
+	if len(h.Iqn) != 1 || len(h.Wwn) != 1 {
+		t.Fatalf("expected the IQN to be added; got %+v", h)
+	}
+	if h, err = c.Hosts.GetHost("host1", map[string]string{"personality": "true"}); err != nil || h.Personality != "" {
+		t.Fatalf("expected the personality of host1 to be removed; got %+v, %v", h, err)
+	}
+
+	cv, err := c.Hosts.ConnectHost("host1", "vol1", &ConnectVolumeOptions{Lun: 10})
+	if err != nil || cv.Lun != 10 {
+		t.Fatalf("expected vol1 to be connected with LUN 10; got %+v, %v", cv, err)
+	}
+
+	// Invalid options are rejected before they are sent, passed by pointer
+	// or by value
+	errs := make(map[string]error)
+	_, errs["WWN"] = c.Hosts.CreateHost("host2", &CreateHostOptions{Wwns: []string{"0000"}})
+	_, errs["personality"] = c.Hosts.CreateHost("host2", CreateHostOptions{Personality: "windows"})
+	_, errs["LUN"] = c.Hosts.ConnectHost("host1", "vol1", &ConnectVolumeOptions{Lun: MaxLun + 1})
+	for name, err := range errs {
+		if _, ok := err.(*PureError); !ok {
+			t.Errorf("expected a client-side error for the invalid %s; got %v", name, err)
+		}
+	}
+	if _, err := c.Hosts.GetHost("host2", nil); err == nil {
+		t.Errorf("expected host2 not to be created")
+	}
+
+	// A nil pointer sends a null body, as before the options were validated
+	if _, err := c.Hosts.CreateHost("host3", (*CreateHostOptions)(nil)); err != nil {
+		t.Errorf("error creating host3 with nil options: %s", err)
+	}
+	if _, err := c.Hosts.SetHost("host3", (*SetHostOptions)(nil)); err != nil {
+		t.Errorf("error setting host3 with nil options: %s", err)
+	}
+}
+
+func TestHostOptionsValidate(t *testing.T) {
+	list := func(l ...string) *[]string { return &l }
+	valid := []validator{
+		&CreateHostOptions{},
+		&CreateHostOptions{Iqns: []string{"iqn.2020-01.com.example:host1", "eui.0123456789abcdef"}, Nqns: []string{"nqn.2014-08.org.nvmexpress:host1"}, PreferredArrays: []string{"array1"}},
+		&SetHostOptions{Name: "host2", Wwns: list(), RemIqns: []string{"iqn.2020-01.com.example:host1"}},
+		&SetHostOptions{CHAPCredentials: &CHAPCredentials{}},
+		&SetHostOptions{CHAPCredentials: &CHAPCredentials{TargetUser: "array", TargetPassword: "target-password"}},
+	}
+	for _, o := range valid {
+		if err := o.Validate(); err != nil {
+			t.Errorf("unexpected error for options %+v: %s", o, err)
+		}
+	}
+
+	invalid := []validator{
+		&CreateHostOptions{Iqns: []string{"host1"}},
+		&CreateHostOptions{Nqns: []string{"nqn.host1"}},
+		&CreateHostOptions{Wwns: []string{"0000999900009999", "00:00:99:99:00:00:99:99"}},
+		&CreateHostOptions{PreferredArrays: []string{""}},
+		&SetHostOptions{Name: "-host"},
+		&SetHostOptions{Wwns: list(), AddWwns: []string{"0000999900009999"}},
+		&SetHostOptions{AddNqns: []string{"nqn.2014-08.org:a"}, RemNqns: []string{"nqn.2014-08.org:a"}},
+		&SetHostOptions{CHAPCredentials: &CHAPCredentials{HostUser: "host1"}},
+		&SetHostOptions{CHAPCredentials: &CHAPCredentials{HostPassword: "short"}},
+		&SetHostOptions{CHAPCredentials: &CHAPCredentials{HostPassword: "same-password", TargetPassword: "same-password"}},
+		&ConnectVolumeOptions{Lun: -1},
+	}
+	for _, o := range invalid {
+		if err := o.Validate(); err == nil {
+			t.Errorf("An Error was NOT raised for options %+v", o)
+		}
+	}
+}
@@ -22,7 +22,7 @@ func (a *MessageService) ListMessages(params map[string]string) ([]Message, erro
 	}
 
 	m := []Message{}
-	if _, err = a.client.Do(req, m, false); err != nil {
+	if _, err = a.client.Do(req, &m, false); err != nil {
 		return nil, err
 	}
 
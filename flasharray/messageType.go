@@ -4,6 +4,13 @@
 
 package flasharray
 
+// Severities of the alert messages
+const (
+	AlertSeverityInfo     = "info"
+	AlertSeverityWarning  = "warning"
+	AlertSeverityCritical = "critical"
+)
+
 // Message struct for the object returned by the array
 type Message struct {
 	ComponentName string `json:"component_name,omitempty"`
@@ -13,4 +20,11 @@ type Message struct {
 	ID            int    `json:"id,omitempty"`
 	Opened        string `json:"opened,omitempty"`
 	User          string `json:"user,omitempty"`
+
+	// Fields of the alert messages, listed with the open=true flag
+	Category        string `json:"category,omitempty"`
+	Code            int    `json:"code,omitempty"`
+	CurrentSeverity string `json:"current_severity,omitempty"`
+	Expected        string `json:"expected,omitempty"`
+	Actual          string `json:"actual,omitempty"`
 }
@@ -17,3 +17,15 @@ func TestAccListMessages(t *testing.T) {
 		t.Fatalf("error listing messages: %s", err)
 	}
 }
+
+func TestListMessages(t *testing.T) {
+	c := testResponseClient(t, "message", `[{"id": 1, "event": "failure", "component_type": "hardware", "component_name": "ct0.eth0"}]`)
+
+	l, err := c.Messages.ListMessages(nil)
+	if err != nil {
+		t.Fatalf("error listing messages: %s", err)
+	}
+	if len(l) != 1 || l[0].ID != 1 || l[0].ComponentName != "ct0.eth0" {
+		t.Errorf("expected the message 1 of ct0.eth0; got %+v", l)
+	}
+}
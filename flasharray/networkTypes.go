@@ -4,6 +4,11 @@
 
 package flasharray
 
+import (
+	"fmt"
+	"net"
+)
+
 // NetworkInterface struct for object returned by array
 type NetworkInterface struct {
 	Name     string   `json:"name,omitempty"`
@@ -43,3 +48,116 @@ type Port struct {
 	Iqn      string `json:"iqn"`
 	Wwn      string `json:"wwn"`
 }
+
+// Minimum and maximum MTU of network interfaces and subnets
+const (
+	MinMtu = 1280
+	MaxMtu = 9216
+)
+
+// MaxNameservers is the maximum number of DNS servers of the array
+const MaxNameservers = 3
+
+// SetNetworkInterfaceOptions struct for the changes of a network interface
+// made with SetNetworkInterface.  Nil fields are left unchanged.
+type SetNetworkInterfaceOptions struct {
+	// Address is the IP address, with or without a prefix length, i.e.
+	// "10.0.0.10/24"
+	Address string `json:"address,omitempty"`
+	Netmask string `json:"netmask,omitempty"`
+	Gateway string `json:"gateway,omitempty"`
+	Mtu     int    `json:"mtu,omitempty"`
+	Enabled *bool  `json:"enabled,omitempty"`
+}
+
+// Validate checks the addresses and the MTU.
+func (o *SetNetworkInterfaceOptions) Validate() error {
+	if o.Address != "" && net.ParseIP(o.Address) == nil {
+		if _, _, err := net.ParseCIDR(o.Address); err != nil {
+			return &PureError{Reason: fmt.Sprintf("[error] Invalid address %q", o.Address)}
+		}
+	}
+	if err := validateIP("netmask", o.Netmask); err != nil {
+		return err
+	}
+	if err := validateIP("gateway", o.Gateway); err != nil {
+		return err
+	}
+	return validateMtu(o.Mtu)
+}
+
+// SetSubnetOptions struct for the changes of a subnet made with SetSubnet.
+// Nil fields are left unchanged.
+type SetSubnetOptions struct {
+	// Name renames the subnet
+	Name string `json:"name,omitempty"`
+	// Prefix is the network of the subnet, i.e. "10.0.0.0/24"
+	Prefix  string `json:"prefix,omitempty"`
+	Gateway string `json:"gateway,omitempty"`
+	Mtu     int    `json:"mtu,omitempty"`
+	// Vlan is the VLAN ID of the subnet, 0 for none
+	Vlan    *int  `json:"vlan,omitempty"`
+	Enabled *bool `json:"enabled,omitempty"`
+}
+
+// Validate checks the name, prefix, gateway, MTU and VLAN ID.
+func (o *SetSubnetOptions) Validate() error {
+	if o.Name != "" {
+		if err := validateName("subnet", o.Name); err != nil {
+			return err
+		}
+	}
+	if o.Prefix != "" {
+		if _, _, err := net.ParseCIDR(o.Prefix); err != nil {
+			return &PureError{Reason: fmt.Sprintf("[error] Invalid subnet prefix %q", o.Prefix)}
+		}
+	}
+	if err := validateIP("gateway", o.Gateway); err != nil {
+		return err
+	}
+	if o.Vlan != nil && (*o.Vlan < 0 || *o.Vlan > 4094) {
+		return &PureError{Reason: fmt.Sprintf("[error] VLAN ID %d must be between 0 and 4094", *o.Vlan)}
+	}
+	return validateMtu(o.Mtu)
+}
+
+// SetDNSOptions struct for the changes of the DNS settings made with SetDNS.
+// Nil fields are left unchanged.
+type SetDNSOptions struct {
+	Domain *string `json:"domain,omitempty"`
+	// Nameservers are the IP addresses of the DNS servers, at most
+	// MaxNameservers
+	Nameservers *[]string `json:"nameservers,omitempty"`
+}
+
+// Validate checks the addresses of the DNS servers.
+func (o *SetDNSOptions) Validate() error {
+	if o.Nameservers == nil {
+		return nil
+	}
+	if len(*o.Nameservers) > MaxNameservers {
+		return &PureError{Reason: fmt.Sprintf("[error] At most %d DNS servers can be set", MaxNameservers)}
+	}
+	for _, ns := range *o.Nameservers {
+		if net.ParseIP(ns) == nil {
+			return &PureError{Reason: fmt.Sprintf("[error] Invalid DNS server address %q", ns)}
+		}
+	}
+	return nil
+}
+
+// validateIP checks that address, if set, is an IP address.
+func validateIP(name string, address string) error {
+	if address != "" && net.ParseIP(address) == nil {
+		return &PureError{Reason: fmt.Sprintf("[error] Invalid %s %q", name, address)}
+	}
+	return nil
+}
+
+// validateMtu checks that mtu, if set, is between MinMtu and MaxMtu.
+func validateMtu(mtu int) error {
+	if mtu != 0 && (mtu < MinMtu || mtu > MaxMtu) {
+		return &PureError{Reason: fmt.Sprintf("[error] MTU %d must be between %d and %d", mtu, MinMtu, MaxMtu)}
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package flasharray
+
+import (
+	"testing"
+)
+
+func TestNetworkOptionsValidate(t *testing.T) {
+	vlan := func(n int) *int { return &n }
+	valid := []validator{
+		&SetNetworkInterfaceOptions{Address: "10.0.0.10", Netmask: "255.255.255.0", Gateway: "10.0.0.1", Mtu: 9000},
+		&SetNetworkInterfaceOptions{Address: "fd00::10/64"},
+		&SetSubnetOptions{Name: "subnet2", Prefix: "10.0.0.0/24", Vlan: vlan(0), Mtu: MinMtu},
+		&SetDNSOptions{Nameservers: &[]string{"10.0.0.2", "10.0.0.3"}},
+	}
+	for _, o := range valid {
+		if err := o.Validate(); err != nil {
+			t.Errorf("unexpected error for options %+v: %s", o, err)
+		}
+	}
+
+	invalid := []validator{
+		&SetNetworkInterfaceOptions{Address: "10.0.0.300"},
+		&SetNetworkInterfaceOptions{Gateway: "gateway"},
+		&SetNetworkInterfaceOptions{Mtu: MaxMtu + 1},
+		&SetSubnetOptions{Prefix: "10.0.0.0"},
+		&SetSubnetOptions{Vlan: vlan(4095)},
+		&SetDNSOptions{Nameservers: &[]string{"dns.example.com"}},
+		&SetDNSOptions{Nameservers: &[]string{"10.0.0.2", "10.0.0.3", "10.0.0.4", "10.0.0.5"}},
+	}
+	for _, o := range invalid {
+		if err := o.Validate(); err == nil {
+			t.Errorf("An Error was NOT raised for options %+v", o)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package flasharray
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"time"
+)
+
+// Logger is used by the client to log debug messages.  It is satisfied by *log.Logger.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// RequestHook is called with every request before it is sent to the array.
+type RequestHook func(req *http.Request)
+
+// ResponseHook is called with every request after the array responded, or
+// with the error returned by the HTTP client.
+type ResponseHook func(req *http.Request, resp *http.Response, err error)
+
+// Option configures a Client created with New.
+type Option func(*config)
+
+// config holds the settings applied by the options passed to New.
+type config struct {
+	username    string
+	password    string
+	apiToken    string
+	restVersion string
+	userAgent   string
+
+	tlsConfig          *TLSConfig
+	insecureSkipVerify bool
+	httpClient         *http.Client
+	transport          http.RoundTripper
+	timeout            time.Duration
+	proxy              func(*http.Request) (*url.URL, error)
+
+	autoReestablishSession bool
+	retryPolicy            RetryPolicy
+	logger                 Logger
+	requestHooks           []RequestHook
+	responseHooks          []ResponseHook
+	guard                  *Guard
+}
+
+// WithAPIToken authenticates the REST session with an API token.
+func WithAPIToken(apiToken string) Option {
+	return func(c *config) {
+		c.apiToken = apiToken
+	}
+}
+
+// WithUsernamePassword authenticates the REST session with the API token of
+// the given user, which is retrieved from the array before the session is started.
+func WithUsernamePassword(username string, password string) Option {
+	return func(c *config) {
+		c.username = username
+		c.password = password
+	}
+}
+
+// WithRestVersion sets the REST API version of the session.  By default the
+// version is negotiated between the library and the array.
+func WithRestVersion(restVersion string) Option {
+	return func(c *config) {
+		c.restVersion = restVersion
+	}
+}
+
+// WithUserAgent sets the HTTP User-Agent of the requests.
+func WithUserAgent(userAgent string) Option {
+	return func(c *config) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithTLSConfig sets the verification of the array certificate and the client
+// certificates presented to the array.  By default the certificate of the
+// array is verified with the system roots.  It is ignored if the HTTP client
+// or transport is set with WithHTTPClient or WithTransport.
+func WithTLSConfig(tlsConfig *TLSConfig) Option {
+	return func(c *config) {
+		c.tlsConfig = tlsConfig
+	}
+}
+
+// WithInsecureSkipVerify disables the verification of the certificate chain
+// and host name of the array, which is then open to man-in-the-middle attacks.
+// The Fingerprint of the TLSConfig is still checked.
+func WithInsecureSkipVerify() Option {
+	return func(c *config) {
+		c.insecureSkipVerify = true
+	}
+}
+
+// WithHTTPClient sets the HTTP client used to send the requests.  The client
+// is copied; a cookie jar is added to the copy if it does not have one, since
+// the array keeps the REST session in a cookie.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *config) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithTransport sets the transport of the HTTP client used to send the requests.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *config) {
+		c.transport = transport
+	}
+}
+
+// WithTimeout sets the time limit of every request sent to the array,
+// including reading the response body.  Zero means no timeout.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.timeout = timeout
+	}
+}
+
+// WithProxy sets the function returning the proxy for a request, i.e.
+// http.ProxyFromEnvironment or http.ProxyURL(u).  It is ignored if the HTTP
+// client or transport is set with WithHTTPClient or WithTransport.
+func WithProxy(proxy func(*http.Request) (*url.URL, error)) Option {
+	return func(c *config) {
+		c.proxy = proxy
+	}
+}
+
+// WithSessionReestablishment sets Client.AutoReestablishSession.  It is enabled by default.
+func WithSessionReestablishment(enabled bool) Option {
+	return func(c *config) {
+		c.autoReestablishSession = enabled
+	}
+}
+
+// WithRetryPolicy sets Client.RetryPolicy.  By default DefaultRetryPolicy is
+// used; nil disables retries.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *config) {
+		c.retryPolicy = policy
+	}
+}
+
+// WithLogger sets the logger of the client debug messages.  By default nothing is logged.
+func WithLogger(logger Logger) Option {
+	return func(c *config) {
+		c.logger = logger
+	}
+}
+
+// WithRequestHook adds a hook called with every request before it is sent.
+func WithRequestHook(hook RequestHook) Option {
+	return func(c *config) {
+		c.requestHooks = append(c.requestHooks, hook)
+	}
+}
+
+// WithResponseHook adds a hook called with every request after it was sent.
+func WithResponseHook(hook ResponseHook) Option {
+	return func(c *config) {
+		c.responseHooks = append(c.responseHooks, hook)
+	}
+}
+
+// WithGuard sets the safety checks of the destructive requests of the client.
+// By default no request is checked.
+func WithGuard(guard *Guard) Option {
+	return func(c *config) {
+		c.guard = guard
+	}
+}
+
+// newHTTPClient returns the HTTP client described by the configuration.
+func (c *config) newHTTPClient() (*http.Client, error) {
+	httpClient := &http.Client{}
+	if c.httpClient != nil {
+		*httpClient = *c.httpClient
+	}
+
+	if c.transport != nil {
+		httpClient.Transport = c.transport
+	} else if c.httpClient == nil {
+		tlsConfig := c.tlsConfig
+		if tlsConfig == nil {
+			tlsConfig = &TLSConfig{VerifyHTTPS: true}
+		}
+		cfg, err := tlsConfig.Config()
+		if err != nil {
+			return nil, err
+		}
+		if c.insecureSkipVerify {
+			cfg.InsecureSkipVerify = true
+		}
+		httpClient.Transport = &http.Transport{
+			TLSClientConfig: cfg,
+			Proxy:           c.proxy,
+		}
+	}
+
+	if c.timeout != 0 {
+		httpClient.Timeout = c.timeout
+	}
+	if httpClient.Jar == nil {
+		cookieJar, _ := cookiejar.New(nil)
+		httpClient.Jar = cookieJar
+	}
+
+	return httpClient, nil
+}
@@ -0,0 +1,113 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package flasharray
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewNoAuth(t *testing.T) {
+	if _, err := New("target"); err == nil {
+		t.Errorf("An Error was NOT raised when no authentication methods provided")
+	}
+	if _, err := New("target", WithAPIToken("api_token"), WithUsernamePassword("username", "password")); err == nil {
+		t.Errorf("An Error was NOT raised when All authentication methods were provided")
+	}
+}
+
+func TestNewWithOptions(t *testing.T) {
+	s := newTestSessionServer(t)
+
+	var buf bytes.Buffer
+	var userAgents []string
+	var responses int
+	c, err := New(s.target(),
+		WithAPIToken("apitoken"),
+		WithInsecureSkipVerify(),
+		WithRestVersion("1.16"),
+		WithUserAgent("go-purestorage-test"),
+		WithTimeout(10*time.Second),
+		WithSessionReestablishment(false),
+		WithLogger(log.New(&buf, "", 0)),
+		WithRequestHook(func(req *http.Request) {
+			userAgents = append(userAgents, req.Header.Get("User-Agent"))
+		}),
+		WithResponseHook(func(req *http.Request, resp *http.Response, err error) {
+			if err == nil {
+				responses++
+			}
+		}),
+	)
+	if err != nil {
+		t.Fatalf("error setting up client: %s", err)
+	}
+
+	if c.RestVersion != "1.16" {
+		t.Errorf("expected: 1.16; got %s", c.RestVersion)
+	}
+	if c.AutoReestablishSession {
+		t.Errorf("WithSessionReestablishment(false) did not disable AutoReestablishSession")
+	}
+	if c.client.Timeout != 10*time.Second {
+		t.Errorf("expected timeout: 10s; got %s", c.client.Timeout)
+	}
+
+	if _, err := c.Volumes.CreateVolume("vol1", 1024); err != nil {
+		t.Fatalf("error creating volume: %s", err)
+	}
+	if len(userAgents) != 2 || responses != 2 {
+		t.Fatalf("expected the hooks to be called for 2 requests; got %d and %d", len(userAgents), responses)
+	}
+	for _, ua := range userAgents {
+		if ua != "go-purestorage-test" {
+			t.Errorf("expected User-Agent: go-purestorage-test; got %s", ua)
+		}
+	}
+	if buf.Len() == 0 {
+		t.Errorf("nothing was logged to the logger")
+	}
+}
+
+func TestNewVerifiesArray(t *testing.T) {
+	s := newTestSessionServer(t)
+
+	if _, err := New(s.target(), WithAPIToken("apitoken")); err == nil {
+		t.Errorf("An Error was NOT raised when the array certificate is not trusted")
+	}
+	if _, err := New(s.target(), WithAPIToken("apitoken"), WithTLSConfig(nil)); err == nil {
+		t.Errorf("An Error was NOT raised when the array certificate is not trusted with a nil TLSConfig")
+	}
+	if _, err := New(s.target(), WithAPIToken("apitoken"), WithInsecureSkipVerify()); err != nil {
+		t.Errorf("error setting up client without verification: %s", err)
+	}
+	tlsConfig := &TLSConfig{Fingerprint: strings.Repeat("00", 32)}
+	if _, err := New(s.target(), WithAPIToken("apitoken"), WithTLSConfig(tlsConfig), WithInsecureSkipVerify()); err == nil {
+		t.Errorf("An Error was NOT raised when the fingerprint does not match without verification")
+	}
+}
+
+func TestNewWithHTTPClient(t *testing.T) {
+	s := newTestSessionServer(t)
+
+	httpClient := s.Client()
+	c, err := New(s.target(), WithAPIToken("apitoken"), WithHTTPClient(httpClient))
+	if err != nil {
+		t.Fatalf("error setting up client: %s", err)
+	}
+	if httpClient.Jar != nil {
+		t.Errorf("WithHTTPClient modified the HTTP client")
+	}
+	if c.client.Jar == nil {
+		t.Errorf("WithHTTPClient did not add a cookie jar to the copy of the HTTP client")
+	}
+	if _, err := c.Volumes.CreateVolume("vol1", 1024); err != nil {
+		t.Fatalf("error creating volume: %s", err)
+	}
+}
@@ -64,9 +64,19 @@ func (p *ProtectiongroupService) SendPgroupSnapshot(pgroup string) ([]Protection
 
 // CreatePgroupSnapshots creates Protection Group snapshots for multiple Protection groups.
 func (p *ProtectiongroupService) CreatePgroupSnapshots(pgroups []string) ([]ProtectiongroupSnapshot, error) {
+	return p.CreatePgroupSnapshotsWithSuffix(pgroups, "")
+}
+
+// CreatePgroupSnapshotsWithSuffix creates Protection Group snapshots for
+// multiple Protection groups, named with suffix.  An empty suffix lets the
+// array number the snapshots.
+func (p *ProtectiongroupService) CreatePgroupSnapshotsWithSuffix(pgroups []string, suffix string) ([]ProtectiongroupSnapshot, error) {
 	data := make(map[string]interface{})
 	data["snap"] = true
 	data["source"] = pgroups
+	if suffix != "" {
+		data["suffix"] = suffix
+	}
 	req, err := p.client.NewRequest("POST", "pgroup", nil, data)
 	if err != nil {
 		return nil, err
@@ -238,3 +248,276 @@ func (p *ProtectiongroupService) SetProtectiongroup(name string, data interface{
 
 	return m, err
 }
+
+// ListPgroupSnapshots lists the protection group snapshots of the array.
+// The names parameter selects snapshots by snapshot or protection group name.
+func (p *ProtectiongroupService) ListPgroupSnapshots(params map[string]string) ([]ProtectiongroupSnapshot, error) {
+
+	q := map[string]string{"snap": "true"}
+	for k, v := range params {
+		q[k] = v
+	}
+	req, err := p.client.NewRequest("GET", "pgroup", q, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	m := []ProtectiongroupSnapshot{}
+	_, err = p.client.Do(req, &m, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, err
+}
+
+// ListPgroupSnapshotTransfers lists the protection group snapshots with the
+// progress of their transfer to the targets of their protection group
+func (p *ProtectiongroupService) ListPgroupSnapshotTransfers(params map[string]string) ([]ProtectiongroupSnapshot, error) {
+
+	q := map[string]string{"transfer": "true"}
+	for k, v := range params {
+		q[k] = v
+	}
+	return p.ListPgroupSnapshots(q)
+}
+
+// ListTargetPgroupSnapshots lists the snapshots of the protection groups of
+// the array that have been replicated to the target array.  On the target,
+// they are named after the source array, i.e. "source:pgroup1.1".
+func (p *ProtectiongroupService) ListTargetPgroupSnapshots(target string, params map[string]string) ([]ProtectiongroupSnapshot, error) {
+
+	q := map[string]string{"on": target}
+	for k, v := range params {
+		q[k] = v
+	}
+	return p.ListPgroupSnapshots(q)
+}
+
+// ListPgroupSnapshotVolumes lists the volume snapshots of a protection group
+// snapshot, named after the protection group snapshot, i.e. "pgroup1.1.vol1".
+// See ProtectiongroupSnapshot.VolumeName.
+func (p *ProtectiongroupService) ListPgroupSnapshotVolumes(snapshot string) ([]Volume, error) {
+
+	params := map[string]string{"snap": "true", "pgrouplist": snapshot}
+	m, err := p.client.Volumes.ListVolumes(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, err
+}
+
+// RestoreVolumeFromPgroupSnapshot copies the snapshot of volume in a protection
+// group snapshot to the volume target.  target is created, or overwritten if
+// overwrite is set; restore a volume in place with target set to volume.
+func (p *ProtectiongroupService) RestoreVolumeFromPgroupSnapshot(snapshot string, volume string, target string, overwrite bool) (*Volume, error) {
+
+	source := fmt.Sprintf("%s.%s", snapshot, volume)
+	m, err := p.client.Volumes.CopyVolume(target, source, overwrite)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, err
+}
+
+// RestorePgroupSnapshot copies every volume snapshot of a protection group
+// snapshot.  rename returns the name of the volume to restore each volume to;
+// if it is nil, the volumes are restored in place and overwrite must be set.
+// The volumes restored before an error are returned with it.
+func (p *ProtectiongroupService) RestorePgroupSnapshot(snapshot string, rename func(volume string) string, overwrite bool) ([]Volume, error) {
+
+	snaps, err := p.ListPgroupSnapshots(map[string]string{"names": snapshot})
+	if err != nil {
+		return nil, err
+	}
+	var snap *ProtectiongroupSnapshot
+	for i := range snaps {
+		if snaps[i].Name == snapshot {
+			snap = &snaps[i]
+		}
+	}
+	if snap == nil {
+		return nil, &PureError{Reason: fmt.Sprintf("[error] Protection group snapshot %s does not exist", snapshot)}
+	}
+	volumes, err := p.ListPgroupSnapshotVolumes(snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	m := []Volume{}
+	for _, v := range volumes {
+		name := snap.VolumeName(v.Name)
+		target := name
+		if rename != nil {
+			target = rename(name)
+		}
+		restored, err := p.RestoreVolumeFromPgroupSnapshot(snapshot, name, target, overwrite)
+		if err != nil {
+			return m, err
+		}
+		m = append(m, *restored)
+	}
+
+	return m, nil
+}
+
+// DestroyPgroupSnapshot destroys a protection group snapshot and its volume
+// snapshots.  It can be recovered until it is eradicated.
+func (p *ProtectiongroupService) DestroyPgroupSnapshot(snapshot string) (*ProtectiongroupSnapshot, error) {
+
+	path := fmt.Sprintf("pgroup/%s", snapshot)
+	req, err := p.client.NewRequest("DELETE", path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &ProtectiongroupSnapshot{}
+	_, err = p.client.Do(req, m, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, err
+}
+
+// RecoverPgroupSnapshot recovers a destroyed protection group snapshot
+func (p *ProtectiongroupService) RecoverPgroupSnapshot(snapshot string) (*ProtectiongroupSnapshot, error) {
+
+	path := fmt.Sprintf("pgroup/%s", snapshot)
+	data := map[string]string{"action": "recover"}
+	req, err := p.client.NewRequest("PUT", path, nil, data)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &ProtectiongroupSnapshot{}
+	_, err = p.client.Do(req, m, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, err
+}
+
+// EradicatePgroupSnapshot eradicates a destroyed protection group snapshot
+func (p *ProtectiongroupService) EradicatePgroupSnapshot(snapshot string) (*ProtectiongroupSnapshot, error) {
+
+	path := fmt.Sprintf("pgroup/%s", snapshot)
+	data := map[string]bool{"eradicate": true}
+	req, err := p.client.NewRequest("DELETE", path, nil, data)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &ProtectiongroupSnapshot{}
+	_, err = p.client.Do(req, m, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, err
+}
+
+// GetPgroupSchedule gets the snapshot and replication schedule of a protection group
+func (p *ProtectiongroupService) GetPgroupSchedule(name string) (*ProtectiongroupSchedule, error) {
+
+	path := fmt.Sprintf("pgroup/%s", name)
+	params := map[string]string{"schedule": "true"}
+	req, err := p.client.NewRequest("GET", path, params, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &ProtectiongroupSchedule{}
+	_, err = p.client.Do(req, m, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, err
+}
+
+// SetPgroupSchedule replaces the schedule of a protection group with
+// schedule, which is validated first
+func (p *ProtectiongroupService) SetPgroupSchedule(name string, schedule ProtectiongroupSchedule) (*ProtectiongroupSchedule, error) {
+
+	if err := schedule.Validate(); err != nil {
+		return nil, err
+	}
+	path := fmt.Sprintf("pgroup/%s", name)
+	req, err := p.client.NewRequest("PUT", path, nil, schedule)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &ProtectiongroupSchedule{}
+	_, err = p.client.Do(req, m, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, err
+}
+
+// GetPgroupRetention gets the snapshot retention of a protection group
+func (p *ProtectiongroupService) GetPgroupRetention(name string) (*ProtectiongroupRetention, error) {
+
+	path := fmt.Sprintf("pgroup/%s", name)
+	params := map[string]string{"retention": "true"}
+	req, err := p.client.NewRequest("GET", path, params, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &ProtectiongroupRetention{}
+	_, err = p.client.Do(req, m, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, err
+}
+
+// SetPgroupRetention replaces the snapshot retention of a protection group
+// with retention, which is validated first
+func (p *ProtectiongroupService) SetPgroupRetention(name string, retention ProtectiongroupRetention) (*ProtectiongroupRetention, error) {
+
+	if err := retention.Validate(); err != nil {
+		return nil, err
+	}
+	path := fmt.Sprintf("pgroup/%s", name)
+	req, err := p.client.NewRequest("PUT", path, nil, retention)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &ProtectiongroupRetention{}
+	_, err = p.client.Do(req, m, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, err
+}
+
+// GetPgroupPolicy gets the schedule, retention and replication targets of a protection group
+func (p *ProtectiongroupService) GetPgroupPolicy(name string) (*ProtectiongroupPolicy, error) {
+
+	pg, err := p.GetProtectiongroup(name, nil)
+	if err != nil {
+		return nil, err
+	}
+	schedule, err := p.GetPgroupSchedule(name)
+	if err != nil {
+		return nil, err
+	}
+	retention, err := p.GetPgroupRetention(name)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &ProtectiongroupPolicy{Name: pg.Name, Schedule: *schedule, Retention: *retention, Targets: pg.TargetList()}
+	return m, nil
+}
@@ -4,6 +4,13 @@
 
 package flasharray
 
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
 // Protectiongroup struct for object returned by array
 type Protectiongroup struct {
 	Name               string                   `json:"name,omitempty"`
@@ -26,6 +33,9 @@ type Protectiongroup struct {
 	TargetAllfor       int                      `json:"target_all_for,omitempty"`
 	TargetDays         int                      `json:"target_days,omitempty"`
 	TargetPerDay       int                      `json:"target_per_day,omitempty"`
+
+	// TimeRemaining is returned for destroyed protection groups, in seconds
+	TimeRemaining *int `json:"time_remaining,omitempty"`
 }
 
 // ProtectiongroupSnapshot struct for object returned by array
@@ -33,4 +43,459 @@ type ProtectiongroupSnapshot struct {
 	Source  string `json:"source"`
 	Name    string `json:"name"`
 	Created string `json:"created"`
+
+	// TimeRemaining is returned for destroyed snapshots, in seconds
+	TimeRemaining *int `json:"time_remaining,omitempty"`
+
+	// Transfer details returned with the transfer=true flag.  They are
+	// null for snapshots that have not been sent to a target.
+	Started              string   `json:"started,omitempty"`
+	Completed            string   `json:"completed,omitempty"`
+	Progress             *float64 `json:"progress,omitempty"`
+	DataTransferred      *int     `json:"data_transferred,omitempty"`
+	PhysicalBytesWritten *int     `json:"physical_bytes_written,omitempty"`
+}
+
+// Transferred reports whether the snapshot has been completely sent to its
+// targets.  It is only meaningful for snapshots listed with the transfer flag.
+func (s *ProtectiongroupSnapshot) Transferred() bool {
+	return s.Completed != ""
+}
+
+// Suffix returns the suffix of the snapshot, i.e. "1" for "pgroup1.1".
+func (s *ProtectiongroupSnapshot) Suffix() string {
+	return strings.TrimPrefix(s.Name, s.Source+".")
+}
+
+// VolumeName returns the name of the volume the snapshot volume snap of the
+// protection group snapshot was taken of, i.e. "vol1" for "pgroup1.1.vol1".
+func (s *ProtectiongroupSnapshot) VolumeName(snap string) string {
+	return strings.TrimPrefix(snap, s.Name+".")
+}
+
+// MinPgroupFrequency is the minimum snapshot and replication frequency of a protection group
+const MinPgroupFrequency = 5 * time.Minute
+
+// ProtectiongroupTarget struct for a replication target of a protection group.
+// Allowed reports whether the target array allows the replication.
+type ProtectiongroupTarget struct {
+	Name    string `json:"name"`
+	Allowed bool   `json:"allowed"`
+}
+
+// TargetList returns the replication targets of the protection group
+func (pg *Protectiongroup) TargetList() []ProtectiongroupTarget {
+	targets := []ProtectiongroupTarget{}
+	for _, t := range pg.Targets {
+		name, _ := t["name"].(string)
+		allowed, _ := t["allowed"].(bool)
+		targets = append(targets, ProtectiongroupTarget{Name: name, Allowed: allowed})
+	}
+	return targets
+}
+
+// BlackoutWindow is the daily period during which the replication of a
+// protection group is suspended.  Start and End are times of day after
+// midnight, on the hour.  A window ending before it starts spans midnight.
+type BlackoutWindow struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// Validate checks that the window starts and ends on the hour, within a day.
+func (w *BlackoutWindow) Validate() error {
+	for _, t := range []time.Duration{w.Start, w.End} {
+		if err := validateTimeOfDay("Blackout window time", t); err != nil {
+			return err
+		}
+	}
+	if w.Start == w.End {
+		return &PureError{Reason: "[error] Blackout window must not start and end at the same time"}
+	}
+	return nil
+}
+
+// ProtectiongroupSchedule is the snapshot and replication schedule of a
+// protection group.  SnapAt and ReplicateAt are the times of day after
+// midnight to take and replicate snapshots at; they can only be set with
+// frequencies of whole days.
+type ProtectiongroupSchedule struct {
+	SnapEnabled        bool
+	SnapFrequency      time.Duration
+	SnapAt             *time.Duration
+	ReplicateEnabled   bool
+	ReplicateFrequency time.Duration
+	ReplicateAt        *time.Duration
+	ReplicateBlackout  *BlackoutWindow
+}
+
+// pgroupSchedule is the representation of a ProtectiongroupSchedule in the
+// REST API, in seconds.
+type pgroupSchedule struct {
+	SnapEnabled        bool            `json:"snap_enabled"`
+	SnapFrequency      int64           `json:"snap_frequency"`
+	SnapAt             *int64          `json:"snap_at"`
+	ReplicateEnabled   bool            `json:"replicate_enabled"`
+	ReplicateFrequency int64           `json:"replicate_frequency"`
+	ReplicateAt        *int64          `json:"replicate_at"`
+	ReplicateBlackout  json.RawMessage `json:"replicate_blackout,omitempty"`
+}
+
+type pgroupBlackout struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+// MarshalJSON encodes the schedule in seconds, as expected by the array.
+func (s ProtectiongroupSchedule) MarshalJSON() ([]byte, error) {
+	m := pgroupSchedule{
+		SnapEnabled:        s.SnapEnabled,
+		SnapFrequency:      seconds(s.SnapFrequency),
+		SnapAt:             optionalSeconds(s.SnapAt),
+		ReplicateEnabled:   s.ReplicateEnabled,
+		ReplicateFrequency: seconds(s.ReplicateFrequency),
+		ReplicateAt:        optionalSeconds(s.ReplicateAt),
+		ReplicateBlackout:  json.RawMessage("null"),
+	}
+	if s.ReplicateBlackout != nil {
+		b, err := json.Marshal(pgroupBlackout{Start: seconds(s.ReplicateBlackout.Start), End: seconds(s.ReplicateBlackout.End)})
+		if err != nil {
+			return nil, err
+		}
+		m.ReplicateBlackout = b
+	}
+	return json.Marshal(m)
+}
+
+// UnmarshalJSON decodes the schedule returned by the array.  The blackout
+// window is returned as an object, or as a list of at most one object.
+func (s *ProtectiongroupSchedule) UnmarshalJSON(data []byte) error {
+	var m pgroupSchedule
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	*s = ProtectiongroupSchedule{
+		SnapEnabled:        m.SnapEnabled,
+		SnapFrequency:      time.Duration(m.SnapFrequency) * time.Second,
+		SnapAt:             optionalDuration(m.SnapAt),
+		ReplicateEnabled:   m.ReplicateEnabled,
+		ReplicateFrequency: time.Duration(m.ReplicateFrequency) * time.Second,
+		ReplicateAt:        optionalDuration(m.ReplicateAt),
+	}
+
+	var blackouts []pgroupBlackout
+	if len(m.ReplicateBlackout) > 0 && m.ReplicateBlackout[0] == '[' {
+		if err := json.Unmarshal(m.ReplicateBlackout, &blackouts); err != nil {
+			return err
+		}
+	} else if len(m.ReplicateBlackout) > 0 && string(m.ReplicateBlackout) != "null" {
+		var b pgroupBlackout
+		if err := json.Unmarshal(m.ReplicateBlackout, &b); err != nil {
+			return err
+		}
+		blackouts = append(blackouts, b)
+	}
+	if len(blackouts) > 0 && blackouts[0].Start != blackouts[0].End {
+		s.ReplicateBlackout = &BlackoutWindow{
+			Start: time.Duration(blackouts[0].Start) * time.Second,
+			End:   time.Duration(blackouts[0].End) * time.Second,
+		}
+	}
+	return nil
+}
+
+// Validate checks the combinations of settings the array rejects: frequencies
+// below MinPgroupFrequency or of fractions of seconds, times of day that are
+// not on the hour or are set with frequencies of fractions of days, and
+// invalid blackout windows.
+func (s *ProtectiongroupSchedule) Validate() error {
+	for _, f := range []struct {
+		name      string
+		frequency time.Duration
+		at        *time.Duration
+	}{
+		{"Snapshot", s.SnapFrequency, s.SnapAt},
+		{"Replication", s.ReplicateFrequency, s.ReplicateAt},
+	} {
+		if f.frequency < MinPgroupFrequency || f.frequency%time.Second != 0 {
+			return &PureError{Reason: fmt.Sprintf("[error] %s frequency %s must be whole seconds of at least %s", f.name, f.frequency, MinPgroupFrequency)}
+		}
+		if f.at == nil {
+			continue
+		}
+		if f.frequency%(24*time.Hour) != 0 {
+			return &PureError{Reason: fmt.Sprintf("[error] %s time of day can only be set with a frequency of whole days, not %s", f.name, f.frequency)}
+		}
+		if err := validateTimeOfDay(f.name+" time of day", *f.at); err != nil {
+			return err
+		}
+	}
+	if s.ReplicateBlackout != nil {
+		return s.ReplicateBlackout.Validate()
+	}
+	return nil
+}
+
+// ProtectiongroupRetention is the retention of the snapshots of a protection
+// group, on the array and on its targets.  All snapshots are kept for
+// AllFor, then PerDay snapshots are kept for Days more days.
+type ProtectiongroupRetention struct {
+	AllFor       time.Duration
+	PerDay       int
+	Days         int
+	TargetAllFor time.Duration
+	TargetPerDay int
+	TargetDays   int
+}
+
+// pgroupRetention is the representation of a ProtectiongroupRetention in the
+// REST API, in seconds.
+type pgroupRetention struct {
+	AllFor       int64 `json:"all_for"`
+	PerDay       int   `json:"per_day"`
+	Days         int   `json:"days"`
+	TargetAllFor int64 `json:"target_all_for"`
+	TargetPerDay int   `json:"target_per_day"`
+	TargetDays   int   `json:"target_days"`
+}
+
+// MarshalJSON encodes the retention in seconds, as expected by the array.
+func (r ProtectiongroupRetention) MarshalJSON() ([]byte, error) {
+	return json.Marshal(pgroupRetention{
+		AllFor:       seconds(r.AllFor),
+		PerDay:       r.PerDay,
+		Days:         r.Days,
+		TargetAllFor: seconds(r.TargetAllFor),
+		TargetPerDay: r.TargetPerDay,
+		TargetDays:   r.TargetDays,
+	})
+}
+
+// UnmarshalJSON decodes the retention returned by the array.
+func (r *ProtectiongroupRetention) UnmarshalJSON(data []byte) error {
+	var m pgroupRetention
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	*r = ProtectiongroupRetention{
+		AllFor:       time.Duration(m.AllFor) * time.Second,
+		PerDay:       m.PerDay,
+		Days:         m.Days,
+		TargetAllFor: time.Duration(m.TargetAllFor) * time.Second,
+		TargetPerDay: m.TargetPerDay,
+		TargetDays:   m.TargetDays,
+	}
+	return nil
+}
+
+// Validate checks that the retention is not negative, and in whole seconds.
+func (r *ProtectiongroupRetention) Validate() error {
+	for _, d := range []time.Duration{r.AllFor, r.TargetAllFor} {
+		if d < 0 || d%time.Second != 0 {
+			return &PureError{Reason: fmt.Sprintf("[error] Retention %s must be whole seconds and not negative", d)}
+		}
+	}
+	for _, n := range []int{r.PerDay, r.Days, r.TargetPerDay, r.TargetDays} {
+		if n < 0 {
+			return &PureError{Reason: fmt.Sprintf("[error] Retention %d must not be negative", n)}
+		}
+	}
+	return nil
+}
+
+// ProtectiongroupPolicy is the protection policy of a protection group: its
+// schedule, retention and replication targets.
+type ProtectiongroupPolicy struct {
+	Name      string
+	Schedule  ProtectiongroupSchedule
+	Retention ProtectiongroupRetention
+	Targets   []ProtectiongroupTarget
+}
+
+// String summarizes the policy, i.e.
+//
+//	pgroup1: snapshots every 1h, keep all for 1d then 4 per day for 7 days;
+//	replication every 4h to remote1, blackout 08:00-17:00, keep all for 1d then 4 per day for 7 days
+func (p ProtectiongroupPolicy) String() string {
+	s, r := p.Schedule, p.Retention
+	var b strings.Builder
+	b.WriteString(p.Name + ": ")
+	if s.SnapEnabled {
+		fmt.Fprintf(&b, "snapshots every %s", formatPolicyDuration(s.SnapFrequency))
+		if s.SnapAt != nil {
+			fmt.Fprintf(&b, " at %s", formatTimeOfDay(*s.SnapAt))
+		}
+		fmt.Fprintf(&b, ", %s", formatRetention(r.AllFor, r.PerDay, r.Days))
+	} else {
+		b.WriteString("snapshots disabled")
+	}
+
+	b.WriteString("; ")
+	if !s.ReplicateEnabled || len(p.Targets) == 0 {
+		b.WriteString("replication disabled")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "replication every %s", formatPolicyDuration(s.ReplicateFrequency))
+	if s.ReplicateAt != nil {
+		fmt.Fprintf(&b, " at %s", formatTimeOfDay(*s.ReplicateAt))
+	}
+	var targets []string
+	for _, t := range p.Targets {
+		if t.Allowed {
+			targets = append(targets, t.Name)
+		} else {
+			targets = append(targets, t.Name+" (not allowed)")
+		}
+	}
+	fmt.Fprintf(&b, " to %s", strings.Join(targets, ", "))
+	if s.ReplicateBlackout != nil {
+		fmt.Fprintf(&b, ", blackout %s-%s", formatTimeOfDay(s.ReplicateBlackout.Start), formatTimeOfDay(s.ReplicateBlackout.End))
+	}
+	fmt.Fprintf(&b, ", %s", formatRetention(r.TargetAllFor, r.TargetPerDay, r.TargetDays))
+	return b.String()
+}
+
+func formatRetention(allFor time.Duration, perDay int, days int) string {
+	s := "keep all for " + formatPolicyDuration(allFor)
+	if perDay > 0 && days > 0 {
+		s += fmt.Sprintf(" then %d per day for %d days", perDay, days)
+	}
+	return s
+}
+
+// formatPolicyDuration formats d in the largest unit of days, hours, minutes
+// or seconds it is a whole number of.
+func formatPolicyDuration(d time.Duration) string {
+	for _, u := range []struct {
+		unit   time.Duration
+		suffix string
+	}{{24 * time.Hour, "d"}, {time.Hour, "h"}, {time.Minute, "m"}} {
+		if d != 0 && d%u.unit == 0 {
+			return fmt.Sprintf("%d%s", d/u.unit, u.suffix)
+		}
+	}
+	return d.String()
+}
+
+func formatTimeOfDay(t time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", t/time.Hour, t%time.Hour/time.Minute)
+}
+
+// validateTimeOfDay checks that t is on the hour within a day.
+func validateTimeOfDay(name string, t time.Duration) error {
+	if t < 0 || t >= 24*time.Hour || t%time.Hour != 0 {
+		return &PureError{Reason: fmt.Sprintf("[error] %s %s must be on the hour within a day", name, t)}
+	}
+	return nil
+}
+
+func seconds(d time.Duration) int64 {
+	return int64(d / time.Second)
+}
+
+func optionalSeconds(d *time.Duration) *int64 {
+	if d == nil {
+		return nil
+	}
+	s := seconds(*d)
+	return &s
+}
+
+func optionalDuration(s *int64) *time.Duration {
+	if s == nil {
+		return nil
+	}
+	d := time.Duration(*s) * time.Second
+	return &d
+}
+
+// CreateProtectiongroupOptions struct for the members and replication
+// targets of a protection group created with CreateProtectiongroup.  A
+// protection group has only one kind of members.
+type CreateProtectiongroupOptions struct {
+	Hosts   []string `json:"hostlist,omitempty"`
+	Hgroups []string `json:"hgrouplist,omitempty"`
+	Volumes []string `json:"vollist,omitempty"`
+	Targets []string `json:"targetlist,omitempty"`
+}
+
+// Validate checks that the members are of one kind, and the lists.
+func (o *CreateProtectiongroupOptions) Validate() error {
+	if err := validatePgroupMembers(len(o.Hosts) > 0, len(o.Hgroups) > 0, len(o.Volumes) > 0); err != nil {
+		return err
+	}
+	for kind, l := range map[string][]string{"host": o.Hosts, "host group": o.Hgroups, "volume": o.Volumes, "target": o.Targets} {
+		if err := validateNames(kind, l); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetProtectiongroupOptions struct for the changes of a protection group
+// made with SetProtectiongroup.  The lists replace the members or targets;
+// the Add and Rem lists add and remove them instead.  Nil fields are left
+// unchanged.
+type SetProtectiongroupOptions struct {
+	// Name renames the protection group
+	Name string `json:"name,omitempty"`
+
+	Hosts      *[]string `json:"hostlist,omitempty"`
+	AddHosts   []string  `json:"addhostlist,omitempty"`
+	RemHosts   []string  `json:"remhostlist,omitempty"`
+	Hgroups    *[]string `json:"hgrouplist,omitempty"`
+	AddHgroups []string  `json:"addhgrouplist,omitempty"`
+	RemHgroups []string  `json:"remhgrouplist,omitempty"`
+	Volumes    *[]string `json:"vollist,omitempty"`
+	AddVolumes []string  `json:"addvollist,omitempty"`
+	RemVolumes []string  `json:"remvollist,omitempty"`
+	Targets    *[]string `json:"targetlist,omitempty"`
+	AddTargets []string  `json:"addtargetlist,omitempty"`
+	RemTargets []string  `json:"remtargetlist,omitempty"`
+}
+
+// Validate checks the name, that the members added are of one kind, and the
+// changes of the lists.
+func (o *SetProtectiongroupOptions) Validate() error {
+	if o.Name != "" {
+		if err := validateName("protection group", o.Name); err != nil {
+			return err
+		}
+	}
+	adds := func(set *[]string, add []string) bool {
+		return (set != nil && len(*set) > 0) || len(add) > 0
+	}
+	if err := validatePgroupMembers(adds(o.Hosts, o.AddHosts), adds(o.Hgroups, o.AddHgroups), adds(o.Volumes, o.AddVolumes)); err != nil {
+		return err
+	}
+	for _, l := range []struct {
+		kind     string
+		set      *[]string
+		add, rem []string
+	}{
+		{"host", o.Hosts, o.AddHosts, o.RemHosts},
+		{"host group", o.Hgroups, o.AddHgroups, o.RemHgroups},
+		{"volume", o.Volumes, o.AddVolumes, o.RemVolumes},
+		{"target", o.Targets, o.AddTargets, o.RemTargets},
+	} {
+		if err := validateListChange(l.kind, l.set, l.add, l.rem); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validatePgroupMembers checks that at most one kind of members is added to
+// a protection group.
+func validatePgroupMembers(hosts bool, hgroups bool, volumes bool) error {
+	n := 0
+	for _, b := range []bool{hosts, hgroups, volumes} {
+		if b {
+			n++
+		}
+	}
+	if n > 1 {
+		return &PureError{Reason: "[error] A protection group can only contain hosts, host groups or volumes"}
+	}
+	return nil
 }
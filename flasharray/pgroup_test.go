@@ -6,13 +6,13 @@ package flasharray
 
 import (
 	"testing"
+	"time"
 )
 
 const testAccProtectiongroupName = "testAccpgroup"
 
 func TestAccProtectiongroups(t *testing.T) {
-	testAccPreChecks(t)
-	c := testAccGenerateClient(t)
+	c := testAccClient(t)
 
 	testhost1 := "testaccpgrouphost1"
 	testvol := "testaccpgroupvol1"
@@ -257,3 +257,320 @@ func testAccEradicateProtectiongroup(c *Client) func(t *testing.T) {
 		}
 	}
 }
+
+func TestPgroupSnapshots(t *testing.T) {
+	c := testAccClient(t)
+
+	c.Volumes.CreateVolume("testaccpgsnapvol1", 1024000000)
+	c.Volumes.CreateVolume("testaccpgsnapvol2", 1024000000)
+	vollist := map[string][]string{"vollist": {"testaccpgsnapvol1", "testaccpgsnapvol2"}}
+	if _, err := c.Protectiongroups.CreateProtectiongroup("testaccpgsnap", vollist); err != nil {
+		t.Fatalf("error creating protection group: %s", err)
+	}
+	snap, err := c.Protectiongroups.CreatePgroupSnapshot("testaccpgsnap")
+	if err != nil {
+		t.Fatalf("error creating protection group snapshot: %s", err)
+	}
+
+	t.Run("ListPgroupSnapshots", testListPgroupSnapshots(c, snap.Name))
+	t.Run("ListPgroupSnapshotVolumes", testListPgroupSnapshotVolumes(c, snap.Name))
+	t.Run("RestoreVolumeFromPgroupSnapshot", testRestoreVolumeFromPgroupSnapshot(c, snap.Name))
+	t.Run("RestorePgroupSnapshot", testRestorePgroupSnapshot(c, snap.Name))
+	t.Run("DestroyPgroupSnapshot", testDestroyPgroupSnapshot(c, snap.Name))
+
+	for _, vol := range []string{"testaccpgsnapvol1", "testaccpgsnapvol2", "testaccpgsnapvol1-restored", "testaccpgsnapvol2-restored"} {
+		c.Volumes.DeleteVolume(vol)
+		c.Volumes.EradicateVolume(vol)
+	}
+	c.Protectiongroups.DestroyProtectiongroup("testaccpgsnap")
+	c.Protectiongroups.EradicateProtectiongroup("testaccpgsnap")
+}
+
+func testListPgroupSnapshots(c *Client, snapshot string) func(*testing.T) {
+	return func(t *testing.T) {
+		snaps, err := c.Protectiongroups.ListPgroupSnapshots(map[string]string{"names": "testaccpgsnap"})
+		if err != nil {
+			t.Fatalf("error listing protection group snapshots: %s", err)
+		}
+		if len(snaps) != 1 || snaps[0].Name != snapshot || snaps[0].Source != "testaccpgsnap" {
+			t.Fatalf("expected snapshot %s; got %+v", snapshot, snaps)
+		}
+		if snaps[0].Suffix() == "" {
+			t.Errorf("expected the suffix of %s", snapshot)
+		}
+	}
+}
+
+func testListPgroupSnapshotVolumes(c *Client, snapshot string) func(*testing.T) {
+	return func(t *testing.T) {
+		volumes, err := c.Protectiongroups.ListPgroupSnapshotVolumes(snapshot)
+		if err != nil {
+			t.Fatalf("error listing protection group snapshot volumes: %s", err)
+		}
+		if len(volumes) != 2 || volumes[0].Name != snapshot+".testaccpgsnapvol1" || volumes[0].Source != "testaccpgsnapvol1" {
+			t.Fatalf("expected the snapshots of the 2 volumes; got %+v", volumes)
+		}
+	}
+}
+
+func testRestoreVolumeFromPgroupSnapshot(c *Client, snapshot string) func(*testing.T) {
+	return func(t *testing.T) {
+		c.Volumes.ExtendVolume("testaccpgsnapvol1", 2048000000)
+		if _, err := c.Protectiongroups.RestoreVolumeFromPgroupSnapshot(snapshot, "testaccpgsnapvol1", "testaccpgsnapvol1", false); err == nil {
+			t.Errorf("An Error was NOT raised when overwriting a volume without overwrite")
+		}
+		v, err := c.Protectiongroups.RestoreVolumeFromPgroupSnapshot(snapshot, "testaccpgsnapvol1", "testaccpgsnapvol1", true)
+		if err != nil {
+			t.Fatalf("error restoring volume: %s", err)
+		}
+		if v.Size != 1024000000 {
+			t.Fatalf("expected the volume to be restored to its size in the snapshot; got %d", v.Size)
+		}
+	}
+}
+
+func testRestorePgroupSnapshot(c *Client, snapshot string) func(*testing.T) {
+	return func(t *testing.T) {
+		volumes, err := c.Protectiongroups.RestorePgroupSnapshot(snapshot, func(volume string) string { return volume + "-restored" }, false)
+		if err != nil {
+			t.Fatalf("error restoring protection group snapshot: %s", err)
+		}
+		if len(volumes) != 2 || volumes[1].Name != "testaccpgsnapvol2-restored" {
+			t.Fatalf("expected 2 restored volumes; got %+v", volumes)
+		}
+		if _, err := c.Protectiongroups.RestorePgroupSnapshot("testaccpgsnap.missing", nil, true); err == nil {
+			t.Errorf("An Error was NOT raised for a missing snapshot")
+		}
+	}
+}
+
+func testDestroyPgroupSnapshot(c *Client, snapshot string) func(*testing.T) {
+	return func(t *testing.T) {
+		if _, err := c.Protectiongroups.DestroyPgroupSnapshot(snapshot); err != nil {
+			t.Fatalf("error destroying protection group snapshot: %s", err)
+		}
+		if _, err := c.Protectiongroups.RestoreVolumeFromPgroupSnapshot(snapshot, "testaccpgsnapvol1", "testaccpgsnapvol1", true); err == nil {
+			t.Errorf("An Error was NOT raised when restoring from a destroyed snapshot")
+		}
+		if _, err := c.Protectiongroups.RecoverPgroupSnapshot(snapshot); err != nil {
+			t.Fatalf("error recovering protection group snapshot: %s", err)
+		}
+		if volumes, err := c.Protectiongroups.ListPgroupSnapshotVolumes(snapshot); err != nil || len(volumes) != 2 {
+			t.Fatalf("expected the volume snapshots to be recovered; got %+v, %v", volumes, err)
+		}
+		c.Protectiongroups.DestroyPgroupSnapshot(snapshot)
+		if _, err := c.Protectiongroups.EradicatePgroupSnapshot(snapshot); err != nil {
+			t.Fatalf("error eradicating protection group snapshot: %s", err)
+		}
+		snaps, err := c.Protectiongroups.ListPgroupSnapshots(map[string]string{"names": snapshot, "pending": "true"})
+		if err != nil || len(snaps) != 0 {
+			t.Fatalf("expected the snapshot to be eradicated; got %+v, %v", snaps, err)
+		}
+	}
+}
+
+func TestPgroupSnapshotTransfers(t *testing.T) {
+	s, c := testFakeConnectedArray(t, ReplicationTypeAsync)
+	now := time.Now()
+	s.Now = func() time.Time { return now }
+	s.SnapshotTransferTime = time.Minute
+
+	c.Volumes.CreateVolume("vol1", 1024000000)
+	c.Protectiongroups.CreateProtectiongroup("pgroup1", map[string][]string{"vollist": {"vol1"}, "targetlist": {"remote1"}})
+	c.Protectiongroups.CreatePgroupSnapshot("pgroup1")
+	if _, err := c.Protectiongroups.SendPgroupSnapshot("pgroup1"); err != nil {
+		t.Fatalf("error sending protection group snapshot: %s", err)
+	}
+
+	now = now.Add(15 * time.Second)
+	snaps, err := c.Protectiongroups.ListPgroupSnapshotTransfers(nil)
+	if err != nil {
+		t.Fatalf("error listing transfers: %s", err)
+	}
+	if len(snaps) != 2 || snaps[0].Progress != nil || snaps[1].Progress == nil || *snaps[1].Progress != 0.25 || snaps[1].Transferred() {
+		t.Fatalf("expected the sent snapshot to be transferring; got %+v", snaps)
+	}
+	if snaps, err := c.Protectiongroups.ListTargetPgroupSnapshots("remote1", nil); err != nil || len(snaps) != 0 {
+		t.Fatalf("expected no snapshots on the target before the transfer completes; got %+v, %v", snaps, err)
+	}
+
+	now = now.Add(time.Minute)
+	snaps, err = c.Protectiongroups.ListPgroupSnapshotTransfers(map[string]string{"names": "pgroup1.2"})
+	if err != nil || len(snaps) != 1 || !snaps[0].Transferred() || *snaps[0].DataTransferred != 1024000000 {
+		t.Fatalf("expected the snapshot to be transferred; got %+v, %v", snaps, err)
+	}
+	snaps, err = c.Protectiongroups.ListTargetPgroupSnapshots("remote1", nil)
+	if err != nil || len(snaps) != 1 || snaps[0].Name != s.ArrayName+":pgroup1.2" {
+		t.Fatalf("expected the snapshot on the target; got %+v, %v", snaps, err)
+	}
+}
+
+func TestPgroupPolicy(t *testing.T) {
+	c := testAccClient(t)
+
+	if _, err := c.Protectiongroups.CreateProtectiongroup("testaccpgpolicy", nil); err != nil {
+		t.Fatalf("error creating protection group: %s", err)
+	}
+
+	t.Run("SetPgroupSchedule", testSetPgroupSchedule(c))
+	t.Run("SetPgroupRetention", testSetPgroupRetention(c))
+	t.Run("GetPgroupPolicy", testGetPgroupPolicy(c))
+
+	c.Protectiongroups.DestroyProtectiongroup("testaccpgpolicy")
+	c.Protectiongroups.EradicateProtectiongroup("testaccpgpolicy")
+}
+
+func testSetPgroupSchedule(c *Client) func(*testing.T) {
+	return func(t *testing.T) {
+		at := 2 * time.Hour
+		schedule := ProtectiongroupSchedule{
+			SnapEnabled:        true,
+			SnapFrequency:      24 * time.Hour,
+			SnapAt:             &at,
+			ReplicateFrequency: 4 * time.Hour,
+			ReplicateBlackout:  &BlackoutWindow{Start: 8 * time.Hour, End: 17 * time.Hour},
+		}
+		if _, err := c.Protectiongroups.SetPgroupSchedule("testaccpgpolicy", schedule); err != nil {
+			t.Fatalf("error setting schedule: %s", err)
+		}
+		s, err := c.Protectiongroups.GetPgroupSchedule("testaccpgpolicy")
+		if err != nil {
+			t.Fatalf("error getting schedule: %s", err)
+		}
+		if !s.SnapEnabled || s.SnapFrequency != 24*time.Hour || s.SnapAt == nil || *s.SnapAt != at || s.ReplicateAt != nil ||
+			s.ReplicateBlackout == nil || *s.ReplicateBlackout != *schedule.ReplicateBlackout {
+			t.Fatalf("expected schedule %+v; got %+v", schedule, s)
+		}
+
+		schedule.SnapFrequency = time.Hour
+		if _, err := c.Protectiongroups.SetPgroupSchedule("testaccpgpolicy", schedule); err == nil {
+			t.Errorf("An Error was NOT raised for a time of day with an hourly frequency")
+		}
+	}
+}
+
+func testSetPgroupRetention(c *Client) func(*testing.T) {
+	return func(t *testing.T) {
+		retention := ProtectiongroupRetention{AllFor: 2 * 24 * time.Hour, PerDay: 2, Days: 14, TargetAllFor: 24 * time.Hour, TargetPerDay: 1, TargetDays: 30}
+		r, err := c.Protectiongroups.SetPgroupRetention("testaccpgpolicy", retention)
+		if err != nil {
+			t.Fatalf("error setting retention: %s", err)
+		}
+		if *r != retention {
+			t.Fatalf("expected retention %+v; got %+v", retention, r)
+		}
+		if _, err := c.Protectiongroups.SetPgroupRetention("testaccpgpolicy", ProtectiongroupRetention{Days: -1}); err == nil {
+			t.Errorf("An Error was NOT raised for a negative retention")
+		}
+	}
+}
+
+func testGetPgroupPolicy(c *Client) func(*testing.T) {
+	return func(t *testing.T) {
+		p, err := c.Protectiongroups.GetPgroupPolicy("testaccpgpolicy")
+		if err != nil {
+			t.Fatalf("error getting policy: %s", err)
+		}
+		expected := "testaccpgpolicy: snapshots every 1d at 02:00, keep all for 2d then 2 per day for 14 days; replication disabled"
+		if p.String() != expected {
+			t.Fatalf("expected policy %q; got %q", expected, p.String())
+		}
+	}
+}
+
+func TestProtectiongroupScheduleValidate(t *testing.T) {
+	at := func(d time.Duration) *time.Duration { return &d }
+	valid := []ProtectiongroupSchedule{
+		{SnapFrequency: time.Hour, ReplicateFrequency: 4 * time.Hour},
+		{SnapFrequency: 24 * time.Hour, SnapAt: at(0), ReplicateFrequency: 48 * time.Hour, ReplicateAt: at(23 * time.Hour)},
+		{SnapFrequency: MinPgroupFrequency, ReplicateFrequency: time.Hour, ReplicateBlackout: &BlackoutWindow{Start: 22 * time.Hour, End: 6 * time.Hour}},
+	}
+	for _, schedule := range valid {
+		if err := schedule.Validate(); err != nil {
+			t.Errorf("unexpected error for schedule %+v: %s", schedule, err)
+		}
+	}
+
+	invalid := []ProtectiongroupSchedule{
+		{},
+		{SnapFrequency: time.Minute, ReplicateFrequency: time.Hour},
+		{SnapFrequency: time.Hour + time.Millisecond, ReplicateFrequency: time.Hour},
+		{SnapFrequency: time.Hour, SnapAt: at(0), ReplicateFrequency: time.Hour},
+		{SnapFrequency: 24 * time.Hour, SnapAt: at(90 * time.Minute), ReplicateFrequency: time.Hour},
+		{SnapFrequency: 24 * time.Hour, SnapAt: at(24 * time.Hour), ReplicateFrequency: time.Hour},
+		{SnapFrequency: time.Hour, ReplicateFrequency: time.Hour, ReplicateBlackout: &BlackoutWindow{Start: time.Hour, End: time.Hour}},
+		{SnapFrequency: time.Hour, ReplicateFrequency: time.Hour, ReplicateBlackout: &BlackoutWindow{Start: 0, End: 30 * time.Minute}},
+	}
+	for _, schedule := range invalid {
+		if err := schedule.Validate(); err == nil {
+			t.Errorf("An Error was NOT raised for schedule %+v", schedule)
+		}
+	}
+}
+
+func TestProtectiongroupPolicyString(t *testing.T) {
+	p := ProtectiongroupPolicy{
+		Name: "pgroup1",
+		Schedule: ProtectiongroupSchedule{
+			SnapEnabled:        true,
+			SnapFrequency:      time.Hour,
+			ReplicateEnabled:   true,
+			ReplicateFrequency: 4 * time.Hour,
+			ReplicateBlackout:  &BlackoutWindow{Start: 8 * time.Hour, End: 17 * time.Hour},
+		},
+		Retention: ProtectiongroupRetention{AllFor: 24 * time.Hour, PerDay: 4, Days: 7, TargetAllFor: 24 * time.Hour},
+		Targets:   []ProtectiongroupTarget{{Name: "remote1", Allowed: true}, {Name: "remote2"}},
+	}
+	expected := "pgroup1: snapshots every 1h, keep all for 1d then 4 per day for 7 days; " +
+		"replication every 4h to remote1, remote2 (not allowed), blackout 08:00-17:00, keep all for 1d"
+	if p.String() != expected {
+		t.Errorf("expected %q; got %q", expected, p.String())
+	}
+}
+
+func TestProtectiongroupOptions(t *testing.T) {
+	_, c := testFakeArray(t)
+	c.Volumes.CreateVolume("vol1", testvolsize)
+	c.Volumes.CreateVolume("vol2", testvolsize)
+	c.Hosts.CreateHost("host1", nil)
+
+	if _, err := c.Protectiongroups.CreateProtectiongroup("pgroup1", &CreateProtectiongroupOptions{Volumes: []string{"vol1"}}); err != nil {
+		t.Fatalf("error creating pgroup1: %s", err)
+	}
+	pg, err := c.Protectiongroups.SetProtectiongroup("pgroup1", &SetProtectiongroupOptions{AddVolumes: []string{"vol2"}, RemVolumes: []string{"vol1"}})
+	if err != nil || len(pg.Volumes) != 1 || pg.Volumes[0] != "vol2" {
+		t.Fatalf("expected vol1 to be replaced with vol2; got %+v, %v", pg, err)
+	}
+	_, err = c.Protectiongroups.SetProtectiongroup("pgroup1", &SetProtectiongroupOptions{AddHosts: []string{"host1"}, AddVolumes: []string{"vol1"}})
+	if _, ok := err.(*PureError); !ok {
+		t.Errorf("expected a client-side error for hosts and volumes; got %v", err)
+	}
+}
+
+func TestProtectiongroupOptionsValidate(t *testing.T) {
+	empty := []string{}
+	valid := []validator{
+		&CreateProtectiongroupOptions{},
+		&CreateProtectiongroupOptions{Hgroups: []string{"hgroup1"}, Targets: []string{"array2"}},
+		&SetProtectiongroupOptions{Name: "pgroup2", Volumes: &empty, AddHosts: []string{"host1"}},
+		&SetProtectiongroupOptions{RemHosts: []string{"host1"}, AddVolumes: []string{"vol1"}},
+	}
+	for _, o := range valid {
+		if err := o.Validate(); err != nil {
+			t.Errorf("unexpected error for options %+v: %s", o, err)
+		}
+	}
+
+	invalid := []validator{
+		&CreateProtectiongroupOptions{Hosts: []string{"host1"}, Volumes: []string{"vol1"}},
+		&CreateProtectiongroupOptions{Targets: []string{""}},
+		&SetProtectiongroupOptions{Name: "pgroup.2"},
+		&SetProtectiongroupOptions{Hgroups: &[]string{"hgroup1"}, AddVolumes: []string{"vol1"}},
+		&SetProtectiongroupOptions{Targets: &empty, AddTargets: []string{"array2"}},
+	}
+	for _, o := range invalid {
+		if err := o.Validate(); err == nil {
+			t.Errorf("An Error was NOT raised for options %+v", o)
+		}
+	}
+}
@@ -6,6 +6,7 @@ package flasharray
 
 import (
 	"fmt"
+	"time"
 )
 
 // PodService struct for pod API endpoints
@@ -22,7 +23,7 @@ func (p *PodService) ListPods(params map[string]string) ([]Pod, error) {
 	}
 
 	m := []Pod{}
-	if _, err = p.client.Do(req, m, false); err != nil {
+	if _, err = p.client.Do(req, &m, false); err != nil {
 		return nil, err
 	}
 
@@ -156,7 +157,7 @@ func (p *PodService) EradicatePod(pod string) (*Pod, error) {
 	return m, err
 }
 
-// DisconnectPod Disconnects a pod frp, a peer array
+// DisconnectPod Disconnects a pod from a peer array
 func (p *PodService) DisconnectPod(pod string, array string) (*Pod, error) {
 
 	path := fmt.Sprintf("pod/%s/array/%s", pod, array)
@@ -172,3 +173,199 @@ func (p *PodService) DisconnectPod(pod string, array string) (*Pod, error) {
 
 	return m, err
 }
+
+// Quiesce modes of DemotePod, for pods that are the source of a replica link
+const (
+	// DemoteQuiesce waits for the changes of the pod to be replicated
+	// to the target pod before demoting it
+	DemoteQuiesce = "quiesce"
+	// DemoteSkipQuiesce demotes the pod without waiting, and discards
+	// the changes that have not been replicated yet
+	DemoteSkipQuiesce = "skip_quiesce"
+)
+
+// PromotePod requests the promotion of a demoted pod.  If promoteFrom is set,
+// the pod is promoted from the undo pod of that name, i.e. UndoDemotePod(pod),
+// discarding the changes replicated to it since it was demoted.
+// Promotion is asynchronous; see WaitForPodPromotion.
+func (p *PodService) PromotePod(pod string, promoteFrom string) (*Pod, error) {
+
+	data := map[string]string{"requested_promotion_state": PromotionStatusPromoted}
+	if promoteFrom != "" {
+		data["promote_from"] = promoteFrom
+	}
+	m, err := p.SetPod(pod, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, err
+}
+
+// DemotePod demotes a pod.  The array keeps the content of the pod at the time
+// of the demotion in a destroyed undo pod, see UndoDemotePod.  mode must be
+// DemoteQuiesce or DemoteSkipQuiesce if the pod is the source of a replica
+// link, and empty otherwise.
+func (p *PodService) DemotePod(pod string, mode string) (*Pod, error) {
+
+	data := map[string]interface{}{"requested_promotion_state": PromotionStatusDemoted}
+	switch mode {
+	case "":
+	case DemoteQuiesce, DemoteSkipQuiesce:
+		data[mode] = true
+	default:
+		return nil, &PureError{Reason: fmt.Sprintf("[error] Invalid demote mode %q", mode)}
+	}
+	m, err := p.SetPod(pod, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, err
+}
+
+// GetUndoDemotePod gets the undo pod created when the pod was last demoted
+func (p *PodService) GetUndoDemotePod(pod string) (*Pod, error) {
+
+	params := map[string]string{"pending": "true"}
+	m, err := p.GetPod(UndoDemotePod(pod), params)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, err
+}
+
+// ListPodReplicaLinks lists the replica links of the pods
+func (p *PodService) ListPodReplicaLinks(params map[string]string) ([]PodReplicaLink, error) {
+
+	req, err := p.client.NewRequest("GET", "pod/replica-link", params, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	m := []PodReplicaLink{}
+	if _, err = p.client.Do(req, &m, false); err != nil {
+		return nil, err
+	}
+
+	return m, err
+}
+
+// CreatePodReplicaLink creates a replica link replicating the local pod to
+// remotePod on the remote array.  The arrays must be connected for
+// asynchronous replication.
+func (p *PodService) CreatePodReplicaLink(pod string, remote string, remotePod string) (*PodReplicaLink, error) {
+
+	path := fmt.Sprintf("pod/%s/replica-link/%s", pod, remotePod)
+	data := map[string]string{"remote": remote}
+	req, err := p.client.NewRequest("POST", path, nil, data)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &PodReplicaLink{}
+	if _, err = p.client.Do(req, m, false); err != nil {
+		return nil, err
+	}
+
+	return m, err
+}
+
+// setPodReplicaLink modifies the replica link from pod to remotePod on the remote array
+func (p *PodService) setPodReplicaLink(pod string, remote string, remotePod string, data map[string]interface{}) (*PodReplicaLink, error) {
+
+	path := fmt.Sprintf("pod/%s/replica-link/%s", pod, remotePod)
+	data["remote"] = remote
+	req, err := p.client.NewRequest("PUT", path, nil, data)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &PodReplicaLink{}
+	if _, err = p.client.Do(req, m, false); err != nil {
+		return nil, err
+	}
+
+	return m, err
+}
+
+// PausePodReplicaLink pauses the replication of a replica link
+func (p *PodService) PausePodReplicaLink(pod string, remote string, remotePod string) (*PodReplicaLink, error) {
+
+	data := map[string]interface{}{"paused": true}
+	m, err := p.setPodReplicaLink(pod, remote, remotePod, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, err
+}
+
+// ResumePodReplicaLink resumes the replication of a paused replica link
+func (p *PodService) ResumePodReplicaLink(pod string, remote string, remotePod string) (*PodReplicaLink, error) {
+
+	data := map[string]interface{}{"paused": false}
+	m, err := p.setPodReplicaLink(pod, remote, remotePod, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, err
+}
+
+// DeletePodReplicaLink deletes a replica link
+func (p *PodService) DeletePodReplicaLink(pod string, remote string, remotePod string) (*PodReplicaLink, error) {
+
+	path := fmt.Sprintf("pod/%s/replica-link/%s", pod, remotePod)
+	data := map[string]string{"remote": remote}
+	req, err := p.client.NewRequest("DELETE", path, nil, data)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &PodReplicaLink{}
+	if _, err = p.client.Do(req, m, false); err != nil {
+		return nil, err
+	}
+
+	return m, err
+}
+
+// WaitForPodSync polls the pod every interval until it is online on every
+// array it is stretched to, and returns it.  It returns the error of the
+// context of the client if it is done first, see Client.WithContext.
+func (p *PodService) WaitForPodSync(pod string, interval time.Duration) (*Pod, error) {
+	return p.waitForPod(pod, interval, (*Pod).Synchronized)
+}
+
+// WaitForPodPromotion polls the pod every interval until its promotion status
+// is the requested promotion state, and returns it.  It returns the error of
+// the context of the client if it is done first, see Client.WithContext.
+func (p *PodService) WaitForPodPromotion(pod string, interval time.Duration) (*Pod, error) {
+	return p.waitForPod(pod, interval, func(m *Pod) bool {
+		return m.PromotionStatus != "" && m.PromotionStatus == m.RequestedPromotionState
+	})
+}
+
+// waitForPod polls the pod every interval until done returns true for it.
+func (p *PodService) waitForPod(pod string, interval time.Duration, done func(*Pod) bool) (*Pod, error) {
+	ctx := p.client.Context()
+	for {
+		m, err := p.GetPod(pod, nil)
+		if err != nil {
+			return nil, err
+		}
+		if done(m) {
+			return m, nil
+		}
+
+		t := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return m, ctx.Err()
+		case <-t.C:
+		}
+	}
+}
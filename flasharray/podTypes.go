@@ -4,9 +4,174 @@
 
 package flasharray
 
+import (
+	"strings"
+)
+
 // Pod struct for object returned by array
 type Pod struct {
-	Name               string   `json:"name,omitempty"`
-	Source             string   `json:"source,omitempty"`
+	Name               string     `json:"name,omitempty"`
+	Source             string     `json:"source,omitempty"`
+	FailoverPreference []string   `json:"failover_preference,omitempty"`
+	Arrays             []PodArray `json:"arrays,omitempty"`
+
+	// PromotionStatus and RequestedPromotionState are returned by REST 1.19
+	// and later, for ActiveDR
+	PromotionStatus         string `json:"promotion_status,omitempty"`
+	RequestedPromotionState string `json:"requested_promotion_state,omitempty"`
+
+	// TimeRemaining is returned for destroyed pods, in seconds
+	TimeRemaining *int `json:"time_remaining,omitempty"`
+}
+
+// PodArray struct for the status of a pod on one of the arrays it is stretched to
+type PodArray struct {
+	Name           string `json:"name"`
+	ArrayID        string `json:"array_id"`
+	Status         string `json:"status"`
+	MediatorStatus string `json:"mediator_status"`
+	// FrozenAt is the time the pod was frozen on an offline array
+	FrozenAt string `json:"frozen_at,omitempty"`
+	// Progress is the fraction of the pod resynchronized to a resyncing array
+	Progress *float64 `json:"progress,omitempty"`
+}
+
+// Statuses of a pod on an array
+const (
+	PodStatusOnline    = "online"
+	PodStatusOffline   = "offline"
+	PodStatusResyncing = "resyncing"
+	PodStatusUnknown   = "unknown"
+)
+
+// Statuses of the mediator of a pod, as seen by an array
+const (
+	MediatorStatusOnline      = "online"
+	MediatorStatusUnreachable = "unreachable"
+	MediatorStatusUnknown     = "unknown"
+	MediatorStatusFlummoxed   = "flummoxed"
+)
+
+// Promotion statuses of a pod
+const (
+	PromotionStatusPromoted  = "promoted"
+	PromotionStatusDemoted   = "demoted"
+	PromotionStatusPromoting = "promoting"
+)
+
+// undoDemoteSuffix is appended to the name of a pod to name the undo pod
+// created when it is demoted.
+const undoDemoteSuffix = ".undo-demote"
+
+// UndoDemotePod returns the name of the undo pod the array creates, destroyed,
+// when the pod name is demoted.  Promoting the pod from it discards the
+// changes replicated to the pod since the demotion.
+func UndoDemotePod(name string) string {
+	return name + undoDemoteSuffix
+}
+
+// IsUndoPod reports whether name is the name of an undo pod.
+func IsUndoPod(name string) bool {
+	return strings.HasSuffix(name, undoDemoteSuffix)
+}
+
+// Synchronized reports whether the pod is online on every array it is
+// stretched to.
+func (p *Pod) Synchronized() bool {
+	if len(p.Arrays) == 0 {
+		return false
+	}
+	for _, a := range p.Arrays {
+		if a.Status != PodStatusOnline {
+			return false
+		}
+	}
+	return true
+}
+
+// Array returns the status of the pod on the array name, or nil if the pod
+// is not stretched to it.
+func (p *Pod) Array(name string) *PodArray {
+	for i := range p.Arrays {
+		if p.Arrays[i].Name == name {
+			return &p.Arrays[i]
+		}
+	}
+	return nil
+}
+
+// PodReplicaLink struct for an ActiveDR replica link between a local and a remote pod
+type PodReplicaLink struct {
+	LocalPodName  string   `json:"local_pod_name"`
+	RemotePodName string   `json:"remote_pod_name"`
+	RemoteNames   []string `json:"remote_names"`
+	Direction     string   `json:"direction"`
+	Status        string   `json:"status"`
+	Paused        bool     `json:"paused"`
+	// RecoveryPoint is the time of the latest data replicated to the
+	// target pod, in milliseconds since the epoch
+	RecoveryPoint *int64 `json:"recovery_point"`
+	// Lag is how far the target pod is behind the source pod, in milliseconds
+	Lag *int64 `json:"lag"`
+}
+
+// Directions of a pod replica link
+const (
+	ReplicaLinkOutbound = "outbound"
+	ReplicaLinkInbound  = "inbound"
+)
+
+// Statuses of a pod replica link
+const (
+	ReplicaLinkStatusReplicating = "replicating"
+	ReplicaLinkStatusBaselining  = "baselining"
+	ReplicaLinkStatusPaused      = "paused"
+	ReplicaLinkStatusQuiescing   = "quiescing"
+	ReplicaLinkStatusQuiesced    = "quiesced"
+	ReplicaLinkStatusIdle        = "idle"
+	ReplicaLinkStatusUnhealthy   = "unhealthy"
+)
+
+// CreatePodOptions struct for the attributes of a pod created with CreatePod
+type CreatePodOptions struct {
+	// Source is the pod the new pod is cloned from
+	Source string `json:"source,omitempty"`
+	// FailoverPreference are the arrays which keep the pod online first
+	// when the arrays of a stretched pod lose contact
 	FailoverPreference []string `json:"failover_preference,omitempty"`
 }
+
+// Validate checks the source pod and the failover preference.
+func (o *CreatePodOptions) Validate() error {
+	if o.Source != "" {
+		if err := validateName("pod", o.Source); err != nil {
+			return err
+		}
+	}
+	return validateNames("array", o.FailoverPreference)
+}
+
+// SetPodOptions struct for the changes of a pod made with SetPod.  Nil
+// fields are left unchanged.
+type SetPodOptions struct {
+	// Name renames the pod
+	Name string `json:"name,omitempty"`
+	// FailoverPreference replaces the failover preference of the pod, or
+	// removes it if empty
+	FailoverPreference *[]string `json:"failover_preference,omitempty"`
+	// Mediator is the mediator of the pod, i.e. "purestorage"
+	Mediator string `json:"mediator,omitempty"`
+}
+
+// Validate checks the name and the failover preference.
+func (o *SetPodOptions) Validate() error {
+	if o.Name != "" {
+		if err := validateName("pod", o.Name); err != nil {
+			return err
+		}
+	}
+	if o.FailoverPreference != nil {
+		return validateNames("array", *o.FailoverPreference)
+	}
+	return nil
+}
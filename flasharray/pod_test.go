@@ -5,15 +5,296 @@
 package flasharray
 
 import (
+	"context"
 	"testing"
+	"time"
+
+	"github.com/devans10/go-purestorage/flasharray/flasharraytest"
 )
 
 func TestAccListPods(t *testing.T) {
-	testAccPreChecks(t)
-	c := testAccGenerateClient(t)
+	c := testAccClient(t)
 
 	_, err := c.Pods.ListPods(nil)
 	if err != nil {
 		t.Fatalf("error listing pods: %s", err)
 	}
 }
+
+func TestListPods(t *testing.T) {
+	_, c := testFakeArray(t)
+
+	for _, name := range []string{"pod1", "pod2"} {
+		if _, err := c.Pods.CreatePod(name, nil); err != nil {
+			t.Fatalf("error creating pod: %s", err)
+		}
+	}
+	pods, err := c.Pods.ListPods(nil)
+	if err != nil {
+		t.Fatalf("error listing pods: %s", err)
+	}
+	if len(pods) != 2 || pods[0].Name != "pod1" || pods[1].Name != "pod2" {
+		t.Errorf("expected the pods pod1 and pod2; got %+v", pods)
+	}
+}
+
+// testFakeConnectedArray returns a fake array connected to the array remote1
+// for replicationTypes, and a client of the fake array.
+func testFakeConnectedArray(t *testing.T, replicationTypes ...string) (*flasharraytest.Server, *Client) {
+	s, c := testFakeArray(t)
+	s.AddRemoteArray("remote1", "10.0.0.2", "remote-key")
+	if _, err := c.Array.ConnectArray("10.0.0.2", "remote-key", replicationTypes, ""); err != nil {
+		t.Fatalf("error connecting array: %s", err)
+	}
+	return s, c
+}
+
+func TestPodStretch(t *testing.T) {
+	s, c := testFakeConnectedArray(t, ReplicationTypeSync)
+	now := time.Now()
+	s.Now = func() time.Time { return now }
+	s.PodResyncTime = time.Minute
+
+	if _, err := c.Pods.CreatePod("pod1", nil); err != nil {
+		t.Fatalf("error creating pod: %s", err)
+	}
+	if _, err := c.Pods.ConnectPod("pod1", "remote1"); err != nil {
+		t.Fatalf("error stretching pod: %s", err)
+	}
+
+	now = now.Add(30 * time.Second)
+	t.Run("Resyncing", testPodResyncing(c))
+	t.Run("WaitForPodSyncTimeout", testWaitForPodSyncTimeout(c))
+	now = now.Add(30 * time.Second)
+	t.Run("WaitForPodSync", testWaitForPodSync(c))
+
+	s.SetPodArrayStatus("pod1", "remote1", PodStatusOffline, MediatorStatusUnreachable)
+	t.Run("Offline", testPodOffline(c))
+}
+
+func testPodResyncing(c *Client) func(t *testing.T) {
+	return func(t *testing.T) {
+		p, err := c.Pods.GetPod("pod1", nil)
+		if err != nil {
+			t.Fatalf("error getting pod: %s", err)
+		}
+		if p.Synchronized() {
+			t.Errorf("expected the pod not to be synchronized while resyncing")
+		}
+		a := p.Array("remote1")
+		if a == nil || a.Status != PodStatusResyncing || a.Progress == nil || *a.Progress != 0.5 {
+			t.Fatalf("expected the pod to be resyncing half way to remote1; got %+v", p.Arrays)
+		}
+	}
+}
+
+func testWaitForPodSyncTimeout(c *Client) func(t *testing.T) {
+	return func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		if _, err := c.WithContext(ctx).Pods.WaitForPodSync("pod1", 10*time.Millisecond); err == nil || ctx.Err() == nil {
+			t.Fatalf("expected the wait to end with the context; got %v", err)
+		}
+	}
+}
+
+func testWaitForPodSync(c *Client) func(t *testing.T) {
+	return func(t *testing.T) {
+		p, err := c.Pods.WaitForPodSync("pod1", time.Millisecond)
+		if err != nil {
+			t.Fatalf("error waiting for pod: %s", err)
+		}
+		if len(p.Arrays) != 2 || !p.Synchronized() {
+			t.Fatalf("expected the pod to be online on both arrays; got %+v", p.Arrays)
+		}
+	}
+}
+
+func testPodOffline(c *Client) func(t *testing.T) {
+	return func(t *testing.T) {
+		p, err := c.Pods.GetPod("pod1", nil)
+		if err != nil {
+			t.Fatalf("error getting pod: %s", err)
+		}
+		a := p.Array("remote1")
+		if p.Synchronized() || a.Status != PodStatusOffline || a.MediatorStatus != MediatorStatusUnreachable || a.FrozenAt == "" {
+			t.Fatalf("expected the pod to be frozen on remote1; got %+v", a)
+		}
+	}
+}
+
+func TestPodPromotion(t *testing.T) {
+	c := testAccClient(t)
+
+	if _, err := c.Pods.CreatePod("pod1", nil); err != nil {
+		t.Fatalf("error creating pod: %s", err)
+	}
+	if _, err := c.Volumes.CreateVolume("pod1::vol1", 1024000000); err != nil {
+		t.Fatalf("error creating volume: %s", err)
+	}
+
+	t.Run("DemotePod", testDemotePod(c))
+	t.Run("GetUndoDemotePod", testGetUndoDemotePod(c))
+	t.Run("PromotePod", testPromotePod(c))
+
+	c.Volumes.DeleteVolume("pod1::vol1")
+	c.Volumes.EradicateVolume("pod1::vol1")
+	c.Pods.EradicatePod(UndoDemotePod("pod1"))
+	c.Pods.DeletePod("pod1")
+	c.Pods.EradicatePod("pod1")
+}
+
+func testDemotePod(c *Client) func(t *testing.T) {
+	return func(t *testing.T) {
+		if _, err := c.Pods.DemotePod("pod1", "invalid"); err == nil {
+			t.Errorf("An Error was NOT raised for an invalid demote mode")
+		}
+		if _, err := c.Pods.DemotePod("pod1", DemoteQuiesce); err == nil {
+			t.Errorf("An Error was NOT raised when quiescing a pod without replica link")
+		}
+		p, err := c.Pods.DemotePod("pod1", "")
+		if err != nil {
+			t.Fatalf("error demoting pod: %s", err)
+		}
+		if p.RequestedPromotionState != PromotionStatusDemoted {
+			t.Fatalf("expected the demotion of the pod to be requested; got %+v", p)
+		}
+	}
+}
+
+func testGetUndoDemotePod(c *Client) func(t *testing.T) {
+	return func(t *testing.T) {
+		p, err := c.Pods.GetUndoDemotePod("pod1")
+		if err != nil {
+			t.Fatalf("error getting undo pod: %s", err)
+		}
+		if !IsUndoPod(p.Name) || p.TimeRemaining == nil {
+			t.Fatalf("expected a destroyed undo pod; got %+v", p)
+		}
+	}
+}
+
+func testPromotePod(c *Client) func(t *testing.T) {
+	return func(t *testing.T) {
+		if _, err := c.Pods.PromotePod("pod1", "pod2"+undoDemoteSuffix); err == nil {
+			t.Errorf("An Error was NOT raised when promoting a pod from the undo pod of another pod")
+		}
+		if _, err := c.Pods.PromotePod("pod1", UndoDemotePod("pod1")); err != nil {
+			t.Fatalf("error promoting pod: %s", err)
+		}
+		p, err := c.Pods.WaitForPodPromotion("pod1", time.Second)
+		if err != nil {
+			t.Fatalf("error waiting for pod promotion: %s", err)
+		}
+		if p.PromotionStatus != PromotionStatusPromoted {
+			t.Fatalf("expected the pod to be promoted; got %+v", p)
+		}
+	}
+}
+
+func TestPodReplicaLinks(t *testing.T) {
+	_, c := testFakeConnectedArray(t, ReplicationTypeAsync)
+
+	if _, err := c.Pods.CreatePod("pod1", nil); err != nil {
+		t.Fatalf("error creating pod: %s", err)
+	}
+
+	t.Run("CreatePodReplicaLink", testCreatePodReplicaLink(c))
+	t.Run("ListPodReplicaLinks", testListPodReplicaLinks(c))
+	t.Run("PausePodReplicaLink", testPausePodReplicaLink(c))
+	t.Run("DemoteLinkedPod", testDemoteLinkedPod(c))
+	t.Run("DeletePodReplicaLink", testDeletePodReplicaLink(c))
+}
+
+func testCreatePodReplicaLink(c *Client) func(t *testing.T) {
+	return func(t *testing.T) {
+		if _, err := c.Pods.CreatePodReplicaLink("pod1", "remote2", "pod1-dr"); err == nil {
+			t.Errorf("An Error was NOT raised for an array that is not connected")
+		}
+		l, err := c.Pods.CreatePodReplicaLink("pod1", "remote1", "pod1-dr")
+		if err != nil {
+			t.Fatalf("error creating replica link: %s", err)
+		}
+		if l.LocalPodName != "pod1" || l.RemotePodName != "pod1-dr" || l.Direction != ReplicaLinkOutbound || l.Status != ReplicaLinkStatusReplicating {
+			t.Fatalf("unexpected replica link: %+v", l)
+		}
+	}
+}
+
+func testListPodReplicaLinks(c *Client) func(t *testing.T) {
+	return func(t *testing.T) {
+		l, err := c.Pods.ListPodReplicaLinks(nil)
+		if err != nil {
+			t.Fatalf("error listing replica links: %s", err)
+		}
+		if len(l) != 1 || len(l[0].RemoteNames) != 1 || l[0].RemoteNames[0] != "remote1" {
+			t.Fatalf("expected the replica link to remote1; got %+v", l)
+		}
+	}
+}
+
+func testPausePodReplicaLink(c *Client) func(t *testing.T) {
+	return func(t *testing.T) {
+		l, err := c.Pods.PausePodReplicaLink("pod1", "remote1", "pod1-dr")
+		if err != nil {
+			t.Fatalf("error pausing replica link: %s", err)
+		}
+		if !l.Paused || l.Status != ReplicaLinkStatusPaused {
+			t.Fatalf("expected the replica link to be paused; got %+v", l)
+		}
+		if l, err = c.Pods.ResumePodReplicaLink("pod1", "remote1", "pod1-dr"); err != nil {
+			t.Fatalf("error resuming replica link: %s", err)
+		}
+		if l.Paused {
+			t.Fatalf("expected the replica link to be resumed; got %+v", l)
+		}
+	}
+}
+
+func testDemoteLinkedPod(c *Client) func(t *testing.T) {
+	return func(t *testing.T) {
+		if _, err := c.Pods.DemotePod("pod1", ""); err == nil {
+			t.Errorf("An Error was NOT raised when demoting the source of a replica link without a quiesce mode")
+		}
+		if _, err := c.Pods.DemotePod("pod1", DemoteQuiesce); err != nil {
+			t.Fatalf("error demoting pod: %s", err)
+		}
+	}
+}
+
+func testDeletePodReplicaLink(c *Client) func(t *testing.T) {
+	return func(t *testing.T) {
+		if _, err := c.Array.DisconnectArray("remote1"); err == nil {
+			t.Errorf("An Error was NOT raised when disconnecting an array with replica links")
+		}
+		if _, err := c.Pods.DeletePodReplicaLink("pod1", "remote1", "pod1-dr"); err != nil {
+			t.Fatalf("error deleting replica link: %s", err)
+		}
+		if l, err := c.Pods.ListPodReplicaLinks(nil); err != nil || len(l) != 0 {
+			t.Fatalf("expected no replica links; got %+v, %v", l, err)
+		}
+	}
+}
+
+func TestPodOptionsValidate(t *testing.T) {
+	valid := []validator{
+		&CreatePodOptions{Source: "pod1", FailoverPreference: []string{"array1"}},
+		&SetPodOptions{Name: "pod2", FailoverPreference: &[]string{}},
+	}
+	for _, o := range valid {
+		if err := o.Validate(); err != nil {
+			t.Errorf("unexpected error for options %+v: %s", o, err)
+		}
+	}
+
+	invalid := []validator{
+		&CreatePodOptions{Source: "pod1::vol1"},
+		&CreatePodOptions{FailoverPreference: []string{"array1", "array1"}},
+		&SetPodOptions{Name: "pod::2"},
+	}
+	for _, o := range invalid {
+		if err := o.Validate(); err == nil {
+			t.Errorf("An Error was NOT raised for options %+v", o)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package flasharray
+
+// getQoS gets the QoS limits of the volume or volume group at path
+func getQoS(c *Client, path string) (*QoS, error) {
+
+	params := map[string]string{"qos": "true"}
+	req, err := c.NewRequest("GET", path, params, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &QoS{}
+	_, err = c.Do(req, m, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, err
+}
+
+// listQoS lists the QoS limits of the volumes or volume groups at path
+func listQoS(c *Client, path string, params map[string]string) ([]QoS, error) {
+
+	q := map[string]string{"qos": "true"}
+	for k, v := range params {
+		q[k] = v
+	}
+	req, err := c.NewRequest("GET", path, q, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	m := []QoS{}
+	_, err = c.Do(req, &m, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, err
+}
+
+// setQoS sets the QoS limits in data of the volume or volume group at path,
+// and returns the resulting limits
+func setQoS(c *Client, path string, data interface{}) (*QoS, error) {
+
+	req, err := c.NewRequest("PUT", path, nil, data)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &QoS{}
+	_, err = c.Do(req, m, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, err
+}
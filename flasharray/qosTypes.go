@@ -0,0 +1,163 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package flasharray
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Bandwidth is a bandwidth in bytes per second
+type Bandwidth int64
+
+// Units of Bandwidth
+const (
+	BytesPerSecond Bandwidth = 1
+	KBPerSecond              = 1024 * BytesPerSecond
+	MBPerSecond              = 1024 * KBPerSecond
+	GBPerSecond              = 1024 * MBPerSecond
+)
+
+// IOPS is a number of I/O operations per second
+type IOPS int64
+
+// Units of IOPS
+const (
+	KIOPS IOPS = 1000
+	MIOPS      = 1000 * KIOPS
+)
+
+// Bounds of the QoS limits of volumes and volume groups
+const (
+	MinBandwidthLimit = MBPerSecond
+	MaxBandwidthLimit = 512 * GBPerSecond
+	MinIopsLimit      = IOPS(100)
+	MaxIopsLimit      = 100 * MIOPS
+)
+
+// String formats the bandwidth in the largest unit it is a whole number of,
+// i.e. "10 MB/s".
+func (b Bandwidth) String() string {
+	for _, u := range []struct {
+		unit   Bandwidth
+		suffix string
+	}{{GBPerSecond, "GB/s"}, {MBPerSecond, "MB/s"}, {KBPerSecond, "KB/s"}} {
+		if b != 0 && b%u.unit == 0 {
+			return fmt.Sprintf("%d %s", b/u.unit, u.suffix)
+		}
+	}
+	return fmt.Sprintf("%d B/s", int64(b))
+}
+
+// String formats the IOPS in the largest unit it is a whole number of,
+// i.e. "5K IOPS".
+func (n IOPS) String() string {
+	switch {
+	case n != 0 && n%MIOPS == 0:
+		return fmt.Sprintf("%dM IOPS", n/MIOPS)
+	case n != 0 && n%KIOPS == 0:
+		return fmt.Sprintf("%dK IOPS", n/KIOPS)
+	}
+	return fmt.Sprintf("%d IOPS", int64(n))
+}
+
+// ParseBandwidth parses a bandwidth like "500K", "10M" or "1G", in binary
+// units of bytes per second, or a number of bytes per second.  The format of
+// String, i.e. "10 MB/s", is accepted.
+func ParseBandwidth(s string) (Bandwidth, error) {
+	n, err := parseQuantity(strings.TrimSuffix(strings.TrimSuffix(strings.TrimSpace(s), "/s"), "B"), 1024)
+	if err != nil {
+		return 0, &PureError{Reason: fmt.Sprintf("[error] Invalid bandwidth %q", s)}
+	}
+	return Bandwidth(n), nil
+}
+
+// ParseIOPS parses a number of IOPS like "500", "5K" or "1M", in decimal
+// units.  The format of String, i.e. "5K IOPS", is accepted.
+func ParseIOPS(s string) (IOPS, error) {
+	n, err := parseQuantity(strings.TrimSuffix(strings.TrimSpace(s), "IOPS"), 1000)
+	if err != nil {
+		return 0, &PureError{Reason: fmt.Sprintf("[error] Invalid IOPS %q", s)}
+	}
+	return IOPS(n), nil
+}
+
+// parseQuantity parses a number with an optional K, M, G or T suffix of the
+// given base, which may be separated from the number by spaces.
+func parseQuantity(s string, base int64) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	multiplier := int64(1)
+	if n := len(s); n > 0 {
+		if i := strings.IndexByte("KMGT", s[n-1]); i >= 0 {
+			for ; i >= 0; i-- {
+				multiplier *= base
+			}
+			s = strings.TrimSpace(s[:n-1])
+		}
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid quantity %q", s)
+	}
+	return n * multiplier, nil
+}
+
+// QoS struct for the QoS limits of a volume or volume group.  Zero limits
+// are unlimited.
+type QoS struct {
+	Name           string    `json:"name,omitempty"`
+	BandwidthLimit Bandwidth `json:"bandwidth_limit"`
+	IopsLimit      IOPS      `json:"iops_limit"`
+}
+
+// MarshalJSON encodes the unlimited limits as null, as expected by the array.
+func (q QoS) MarshalJSON() ([]byte, error) {
+	m := q.limits()
+	if q.Name != "" {
+		m["name"] = q.Name
+	}
+	return json.Marshal(m)
+}
+
+// limits returns the limits of q as set in a request, without the name, which
+// would rename the volume or volume group.
+func (q QoS) limits() map[string]interface{} {
+	return map[string]interface{}{
+		"bandwidth_limit": qosLimit(int64(q.BandwidthLimit)),
+		"iops_limit":      qosLimit(int64(q.IopsLimit)),
+	}
+}
+
+// Validate checks that the limits are within the ranges accepted by the array.
+func (q *QoS) Validate() error {
+	if err := validateBandwidthLimit(q.BandwidthLimit); err != nil {
+		return err
+	}
+	return validateIopsLimit(q.IopsLimit)
+}
+
+func validateBandwidthLimit(b Bandwidth) error {
+	if b != 0 && (b < MinBandwidthLimit || b > MaxBandwidthLimit) {
+		return &PureError{Reason: fmt.Sprintf("[error] Bandwidth limit %s must be between %s and %s", b, MinBandwidthLimit, MaxBandwidthLimit)}
+	}
+	return nil
+}
+
+func validateIopsLimit(n IOPS) error {
+	if n != 0 && (n < MinIopsLimit || n > MaxIopsLimit) {
+		return &PureError{Reason: fmt.Sprintf("[error] IOPS limit %s must be between %s and %s", n, MinIopsLimit, MaxIopsLimit)}
+	}
+	return nil
+}
+
+// qosLimit returns the value of a limit in a request, null if it is unlimited.
+func qosLimit(n int64) interface{} {
+	if n == 0 {
+		return nil
+	}
+	return n
+}
@@ -0,0 +1,190 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package flasharray
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestAccVolumeQoS(t *testing.T) {
+	c := testAccClient(t)
+
+	c.Volumes.CreateVolume("testaccqosvol", testvolsize)
+
+	t.Run("SetVolumeQoS", testSetVolumeQoS(c))
+	t.Run("SetVolumeIopsLimit", testSetVolumeIopsLimit(c))
+	t.Run("ListVolumeQoS", testListVolumeQoS(c))
+	t.Run("ClearVolumeQoS", testClearVolumeQoS(c))
+
+	c.Volumes.DeleteVolume("testaccqosvol")
+	c.Volumes.EradicateVolume("testaccqosvol")
+}
+
+func testSetVolumeQoS(c *Client) func(*testing.T) {
+	return func(t *testing.T) {
+		if _, err := c.Volumes.SetVolumeQoS("testaccqosvol", QoS{BandwidthLimit: 512 * KBPerSecond}); err == nil {
+			t.Errorf("An Error was NOT raised for a bandwidth limit below the minimum")
+		}
+		if _, err := c.Volumes.SetVolumeQoS("testaccqosvol", QoS{BandwidthLimit: 100 * MBPerSecond, IopsLimit: 10 * KIOPS}); err != nil {
+			t.Fatalf("error setting QoS: %s", err)
+		}
+		q, err := c.Volumes.GetVolumeQoS("testaccqosvol")
+		if err != nil {
+			t.Fatalf("error getting QoS: %s", err)
+		}
+		if q.BandwidthLimit != 100*MBPerSecond || q.IopsLimit != 10*KIOPS {
+			t.Fatalf("expected limits of 100 MB/s and 10K IOPS; got %s and %s", q.BandwidthLimit, q.IopsLimit)
+		}
+	}
+}
+
+func testSetVolumeIopsLimit(c *Client) func(*testing.T) {
+	return func(t *testing.T) {
+		q, err := c.Volumes.SetVolumeIopsLimit("testaccqosvol", 0)
+		if err != nil {
+			t.Fatalf("error setting IOPS limit: %s", err)
+		}
+		if q.BandwidthLimit != 100*MBPerSecond || q.IopsLimit != 0 {
+			t.Fatalf("expected the IOPS limit to be removed and the bandwidth limit kept; got %+v", q)
+		}
+		if _, err := c.Volumes.SetVolumeIopsLimit("testaccqosvol", MaxIopsLimit+1); err == nil {
+			t.Errorf("An Error was NOT raised for an IOPS limit above the maximum")
+		}
+	}
+}
+
+func testListVolumeQoS(c *Client) func(*testing.T) {
+	return func(t *testing.T) {
+		l, err := c.Volumes.ListVolumeQoS(map[string]string{"names": "testaccqosvol"})
+		if err != nil {
+			t.Fatalf("error listing QoS: %s", err)
+		}
+		if len(l) != 1 || l[0].Name != "testaccqosvol" || l[0].BandwidthLimit != 100*MBPerSecond {
+			t.Fatalf("expected the limits of testaccqosvol; got %+v", l)
+		}
+
+		b, err := json.Marshal(l[0])
+		if err != nil {
+			t.Fatalf("error encoding QoS: %s", err)
+		}
+		var q QoS
+		if err := json.Unmarshal(b, &q); err != nil {
+			t.Fatalf("error decoding QoS: %s", err)
+		}
+		if q != l[0] {
+			t.Fatalf("expected QoS %+v; got %+v from %s", l[0], q, b)
+		}
+
+		c.Volumes.CreateVolume("testaccqosvol2", testvolsize)
+		defer func() {
+			c.Volumes.DeleteVolume("testaccqosvol2")
+			c.Volumes.EradicateVolume("testaccqosvol2")
+		}()
+		if _, err := c.Volumes.SetVolumeQoS("testaccqosvol2", l[0]); err != nil {
+			t.Fatalf("error setting the listed QoS: %s", err)
+		}
+		if _, err := c.Volumes.GetVolume("testaccqosvol2", nil); err != nil {
+			t.Fatalf("expected testaccqosvol2 not to be renamed; got %s", err)
+		}
+	}
+}
+
+func testClearVolumeQoS(c *Client) func(*testing.T) {
+	return func(t *testing.T) {
+		if _, err := c.Volumes.ClearVolumeQoS("testaccqosvol"); err != nil {
+			t.Fatalf("error clearing QoS: %s", err)
+		}
+		q, err := c.Volumes.GetVolumeQoS("testaccqosvol")
+		if err != nil || q.BandwidthLimit != 0 || q.IopsLimit != 0 {
+			t.Fatalf("expected no limits; got %+v, %v", q, err)
+		}
+	}
+}
+
+func TestAccVgroupQoS(t *testing.T) {
+	c := testAccClient(t)
+
+	c.Vgroups.CreateVgroup("testaccqosvgroup")
+
+	if _, err := c.Vgroups.SetVgroupBandwidthLimit("testaccqosvgroup", GBPerSecond); err != nil {
+		t.Fatalf("error setting bandwidth limit: %s", err)
+	}
+	if _, err := c.Vgroups.SetVgroupIopsLimit("testaccqosvgroup", 50); err == nil {
+		t.Errorf("An Error was NOT raised for an IOPS limit below the minimum")
+	}
+	q, err := c.Vgroups.GetVgroupQoS("testaccqosvgroup")
+	if err != nil || q.BandwidthLimit != GBPerSecond || q.IopsLimit != 0 {
+		t.Fatalf("expected a bandwidth limit of 1 GB/s; got %+v, %v", q, err)
+	}
+	if _, err := c.Vgroups.ClearVgroupQoS("testaccqosvgroup"); err != nil {
+		t.Fatalf("error clearing QoS: %s", err)
+	}
+	if l, err := c.Vgroups.ListVgroupQoS(nil); err != nil || len(l) != 1 || l[0].BandwidthLimit != 0 {
+		t.Fatalf("expected no limits; got %+v, %v", l, err)
+	}
+
+	c.Vgroups.DestroyVgroup("testaccqosvgroup")
+	c.Vgroups.EradicateVgroup("testaccqosvgroup")
+}
+
+func TestQoSUnits(t *testing.T) {
+	bandwidths := map[string]Bandwidth{
+		"1048576": MBPerSecond,
+		"500K":    500 * KBPerSecond,
+		"10M":     10 * MBPerSecond,
+		"10MB/s":  10 * MBPerSecond,
+		"2g":      2 * GBPerSecond,
+	}
+	for s, expected := range bandwidths {
+		if b, err := ParseBandwidth(s); err != nil || b != expected {
+			t.Errorf("expected %s for %q; got %s, %v", expected, s, b, err)
+		}
+	}
+	iops := map[string]IOPS{"500": 500, "5K": 5 * KIOPS, "1M": MIOPS}
+	for s, expected := range iops {
+		if n, err := ParseIOPS(s); err != nil || n != expected {
+			t.Errorf("expected %s for %q; got %s, %v", expected, s, n, err)
+		}
+	}
+	for _, s := range []string{"", "M", "-1", "10X", "1.5G"} {
+		if _, err := ParseBandwidth(s); err == nil {
+			t.Errorf("An Error was NOT raised for bandwidth %q", s)
+		}
+	}
+
+	if s := (1536 * KBPerSecond).String(); s != "1536 KB/s" {
+		t.Errorf("expected 1536 KB/s; got %s", s)
+	}
+	if s := (512 * GBPerSecond).String(); s != "512 GB/s" {
+		t.Errorf("expected 512 GB/s; got %s", s)
+	}
+	if s := IOPS(2500).String(); s != "2500 IOPS" {
+		t.Errorf("expected 2500 IOPS; got %s", s)
+	}
+}
+
+func TestQoSUnitsRoundTrip(t *testing.T) {
+	for _, b := range []Bandwidth{0, 512 * BytesPerSecond, 1536 * KBPerSecond, 10 * MBPerSecond, MaxBandwidthLimit} {
+		if parsed, err := ParseBandwidth(b.String()); err != nil || parsed != b {
+			t.Errorf("expected %d for %q; got %d, %v", int64(b), b.String(), int64(parsed), err)
+		}
+	}
+	for _, n := range []IOPS{0, 2500, 5 * KIOPS, MaxIopsLimit} {
+		if parsed, err := ParseIOPS(n.String()); err != nil || parsed != n {
+			t.Errorf("expected %d for %q; got %d, %v", int64(n), n.String(), int64(parsed), err)
+		}
+	}
+	for _, s := range []string{"B/s", " MB/s", "10 XB/s"} {
+		if _, err := ParseBandwidth(s); err == nil {
+			t.Errorf("An Error was NOT raised for bandwidth %q", s)
+		}
+	}
+	for _, s := range []string{"IOPS", "K IOPS", "10 IOPS/s"} {
+		if _, err := ParseIOPS(s); err == nil {
+			t.Errorf("An Error was NOT raised for IOPS %q", s)
+		}
+	}
+}
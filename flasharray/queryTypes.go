@@ -0,0 +1,232 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package flasharray
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Historical windows of the monitor and space metrics
+var HistoricalWindows = []string{"1h", "3h", "24h", "7d", "30d", "90d", "1y"}
+
+// queryParamVersions are the REST versions the query parameters require, of
+// the parameters not supported by every version.
+var queryParamVersions = map[string]string{
+	"filter": "1.4",
+	"sort":   "1.4",
+	"limit":  "1.4",
+	"token":  "1.4",
+}
+
+// sortRegexp matches the fields of the sort parameter, descending with a '-'
+// suffix.
+var sortRegexp = regexp.MustCompile(`^[a-z][a-z0-9_.]*-?$`)
+
+// Query struct for the parameters of the list and get operations, i.e.
+// ListVolumes, converted with Params.  Zero fields are not set.
+type Query struct {
+	// Names selects the objects by name
+	Names []string
+	// Space lists the space metrics, and Monitor the performance metrics,
+	// over the Historical window if set, one of HistoricalWindows
+	Space      bool
+	Monitor    bool
+	Historical string
+	// Pending also lists the destroyed objects, and PendingOnly only lists
+	// them
+	Pending     bool
+	PendingOnly bool
+	// Filter selects the objects matching an expression, i.e.
+	// And(Eq("name", "vol*"), Gt("size", 1<<40))
+	Filter Filter
+	// Sort sorts the objects by fields, descending if suffixed with '-',
+	// i.e. "size-"
+	Sort []string
+	// Limit is the maximum number of objects listed, and Token the token of
+	// the page to list, returned with the previous one
+	Limit int
+	Token string
+}
+
+// Validate checks the combinations of parameters, and that the parameters
+// are supported by the REST version restVersion.
+func (q *Query) Validate(restVersion string) error {
+	if !restVersionAtLeast(restVersion, supportedRestVersions[0]) {
+		return &PureError{Reason: fmt.Sprintf("[error] REST version %s is not supported", restVersion)}
+	}
+	if q.Space && q.Monitor {
+		return &PureError{Reason: "[error] Query can list either space or monitor metrics"}
+	}
+	if q.Historical != "" {
+		if !q.Space && !q.Monitor {
+			return &PureError{Reason: "[error] Historical window requires space or monitor metrics"}
+		}
+		if err := validateHistorical(q.Historical); err != nil {
+			return err
+		}
+	}
+	if q.Pending && q.PendingOnly {
+		return &PureError{Reason: "[error] Query can list either pending or only pending objects"}
+	}
+	if err := validateNames("object", q.Names); err != nil {
+		return err
+	}
+	for _, f := range q.Sort {
+		if !sortRegexp.MatchString(f) {
+			return &PureError{Reason: fmt.Sprintf("[error] Invalid sort field %q", f)}
+		}
+	}
+	if q.Limit < 0 {
+		return &PureError{Reason: "[error] Query limit must not be negative"}
+	}
+
+	for k := range q.params() {
+		if min, ok := queryParamVersions[k]; ok && !restVersionAtLeast(restVersion, min) {
+			return &PureError{Reason: fmt.Sprintf("[error] Query parameter %s requires REST version %s, not %s", k, min, restVersion)}
+		}
+	}
+	return nil
+}
+
+// Params returns the parameters of the query, once validated for the REST
+// version restVersion, i.e. of Client.RestVersion.
+//
+//	params, err := (&flasharray.Query{Space: true, Sort: []string{"size-"}, Limit: 10}).Params(c.RestVersion)
+//	if err != nil {
+//		return err
+//	}
+//	volumes, err := c.Volumes.ListVolumes(params)
+func (q *Query) Params(restVersion string) (map[string]string, error) {
+	if err := q.Validate(restVersion); err != nil {
+		return nil, err
+	}
+	return q.params(), nil
+}
+
+func (q *Query) params() map[string]string {
+	p := make(map[string]string)
+	if len(q.Names) > 0 {
+		p["names"] = strings.Join(q.Names, ",")
+	}
+	if q.Space {
+		p["space"] = "true"
+	}
+	if q.Monitor {
+		p["action"] = "monitor"
+	}
+	if q.Historical != "" {
+		p["historical"] = q.Historical
+	}
+	if q.Pending {
+		p["pending"] = "true"
+	}
+	if q.PendingOnly {
+		p["pending_only"] = "true"
+	}
+	if q.Filter != "" {
+		p["filter"] = string(q.Filter)
+	}
+	if len(q.Sort) > 0 {
+		p["sort"] = strings.Join(q.Sort, ",")
+	}
+	if q.Limit > 0 {
+		p["limit"] = strconv.Itoa(q.Limit)
+	}
+	if q.Token != "" {
+		p["token"] = q.Token
+	}
+	return p
+}
+
+// Filter is a filter expression of a Query, built with Eq, Ne, Gt, Lt, And,
+// Or and Not.
+// The builders are the same as those of package pure1, and are tested with the
+// cases of internal/filtertest.
+type Filter string
+
+// Eq returns a filter selecting the objects whose field equals value; string
+// values can have '*' wildcards.
+func Eq(field string, value interface{}) Filter {
+	return Filter(field + "=" + filterValue(value))
+}
+
+// Ne returns a filter selecting the objects whose field does not equal value.
+func Ne(field string, value interface{}) Filter {
+	return Filter(field + "!=" + filterValue(value))
+}
+
+// Gt returns a filter selecting the objects whose field is greater than value.
+func Gt(field string, value interface{}) Filter {
+	return Filter(field + ">" + filterValue(value))
+}
+
+// Lt returns a filter selecting the objects whose field is less than value.
+func Lt(field string, value interface{}) Filter {
+	return Filter(field + "<" + filterValue(value))
+}
+
+// And returns a filter selecting the objects selected by every filter.
+func And(filters ...Filter) Filter {
+	return joinFilters(" and ", filters)
+}
+
+// Or returns a filter selecting the objects selected by any filter.
+func Or(filters ...Filter) Filter {
+	return joinFilters(" or ", filters)
+}
+
+// Not returns a filter selecting the objects not selected by f.
+func Not(f Filter) Filter {
+	return Filter("not(" + string(f) + ")")
+}
+
+func joinFilters(op string, filters []Filter) Filter {
+	if len(filters) == 1 {
+		return filters[0]
+	}
+	l := make([]string, len(filters))
+	for i, f := range filters {
+		l[i] = "(" + string(f) + ")"
+	}
+	return Filter(strings.Join(l, op))
+}
+
+// filterValue formats a value of a filter, quoting strings with their
+// backslashes and quotes escaped, and nil as null.
+func filterValue(value interface{}) string {
+	if value == nil {
+		return "null"
+	}
+	if s, ok := value.(string); ok {
+		return "'" + strings.Replace(strings.Replace(s, `\`, `\\`, -1), "'", `\'`, -1) + "'"
+	}
+	return fmt.Sprint(value)
+}
+
+// validateHistorical checks that window is one of HistoricalWindows.
+func validateHistorical(window string) error {
+	if !contains(HistoricalWindows, window) {
+		return &PureError{Reason: fmt.Sprintf("[error] Invalid historical window %s, must be one of %s", window, strings.Join(HistoricalWindows, ", "))}
+	}
+	return nil
+}
+
+// restVersionAtLeast reports whether the REST version v is supported by the
+// library and at least min.
+func restVersionAtLeast(v string, min string) bool {
+	i, j := -1, -1
+	for n, s := range supportedRestVersions {
+		if s == v {
+			i = n
+		}
+		if s == min {
+			j = n
+		}
+	}
+	return i >= 0 && i >= j
+}
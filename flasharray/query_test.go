@@ -0,0 +1,141 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package flasharray
+
+import (
+	"testing"
+
+	"github.com/devans10/go-purestorage/internal/filtertest"
+)
+
+func TestQueryValidate(t *testing.T) {
+	valid := map[string]*Query{
+		"Empty":      {},
+		"Space":      {Space: true, Historical: "24h"},
+		"Monitor":    {Monitor: true, Historical: "1h"},
+		"Pending":    {Names: []string{"vol1", "vol2"}, PendingOnly: true},
+		"Filter":     {Filter: And(Eq("name", "vol*"), Gt("size", 1024))},
+		"Pagination": {Sort: []string{"size-", "name"}, Limit: 10, Token: "abc"},
+	}
+	for n, q := range valid {
+		if err := q.Validate("1.19"); err != nil {
+			t.Errorf("error validating query %s: %s", n, err)
+		}
+	}
+
+	invalid := map[string]*Query{
+		"SpaceMonitor":       {Space: true, Monitor: true},
+		"HistoricalNoMetric": {Historical: "24h"},
+		"HistoricalWindow":   {Monitor: true, Historical: "2h"},
+		"Pending":            {Pending: true, PendingOnly: true},
+		"EmptyName":          {Names: []string{""}},
+		"DuplicateName":      {Names: []string{"vol1", "vol1"}},
+		"Sort":               {Sort: []string{"-size"}},
+		"Limit":              {Limit: -1},
+	}
+	for n, q := range invalid {
+		if err := q.Validate("1.19"); err == nil {
+			t.Errorf("An Error was NOT raised for query %s", n)
+		}
+	}
+
+	if err := (&Query{Sort: []string{"name"}}).Validate("1.3"); err == nil {
+		t.Errorf("An Error was NOT raised for sort with REST version 1.3")
+	}
+	if err := (&Query{Space: true}).Validate("1.3"); err != nil {
+		t.Errorf("error validating space with REST version 1.3: %s", err)
+	}
+	if err := (&Query{}).Validate("2.0"); err == nil {
+		t.Errorf("An Error was NOT raised for REST version 2.0")
+	}
+}
+
+func TestQueryParams(t *testing.T) {
+	q := &Query{
+		Names:      []string{"vol1", "vol2"},
+		Monitor:    true,
+		Historical: "7d",
+		Filter:     Or(Eq("name", "it's"), Not(Lt("size", 10))),
+		Sort:       []string{"name-"},
+		Limit:      5,
+	}
+	params, err := q.Params("1.19")
+	if err != nil {
+		t.Fatalf("error getting query params: %s", err)
+	}
+	expected := map[string]string{
+		"names":      "vol1,vol2",
+		"action":     "monitor",
+		"historical": "7d",
+		"filter":     `(name='it\'s') or (not(size<10))`,
+		"sort":       "name-",
+		"limit":      "5",
+	}
+	if len(params) != len(expected) {
+		t.Errorf("expected params %v; got %v", expected, params)
+	}
+	for k, v := range expected {
+		if params[k] != v {
+			t.Errorf("expected param %s to be %q; got %q", k, v, params[k])
+		}
+	}
+	if _, err := (&Query{Historical: "1h"}).Params("1.19"); err == nil {
+		t.Errorf("An Error was NOT raised for the params of an invalid query")
+	}
+}
+
+func TestQueryListVolumes(t *testing.T) {
+	_, c := testFakeArray(t)
+
+	c.Volumes.CreateVolume("vol1", testvolsize)
+	c.Volumes.CreateVolume("vol2", testvolsize)
+	c.Volumes.CreateVolume("vol3", testvolsize)
+	c.Volumes.DeleteVolume("vol3")
+
+	params, err := (&Query{Names: []string{"vol1", "vol3"}, Pending: true}).Params(c.RestVersion)
+	if err != nil {
+		t.Fatalf("error getting query params: %s", err)
+	}
+	l, err := c.Volumes.ListVolumes(params)
+	if err != nil {
+		t.Fatalf("error listing volumes: %s", err)
+	}
+	if len(l) != 2 || l[0].Name != "vol1" || l[1].Name != "vol3" {
+		t.Errorf("expected volumes vol1 and vol3; got %v", l)
+	}
+}
+
+// testFilter builds the filter expression e of the shared test cases.
+func testFilter(e filtertest.Expr) Filter {
+	args := make([]Filter, len(e.Args))
+	for i, a := range e.Args {
+		args[i] = testFilter(a)
+	}
+	switch e.Op {
+	case filtertest.Eq:
+		return Eq(e.Field, e.Value)
+	case filtertest.Ne:
+		return Ne(e.Field, e.Value)
+	case filtertest.Gt:
+		return Gt(e.Field, e.Value)
+	case filtertest.Lt:
+		return Lt(e.Field, e.Value)
+	case filtertest.And:
+		return And(args...)
+	case filtertest.Or:
+		return Or(args...)
+	case filtertest.Not:
+		return Not(args[0])
+	}
+	panic("unknown filter builder " + e.Op)
+}
+
+func TestFilters(t *testing.T) {
+	for _, c := range filtertest.Cases {
+		if f := testFilter(c.Expr); string(f) != c.Expected {
+			t.Errorf("expected filter %s for %s; got %s", c.Expected, c.Name, f)
+		}
+	}
+}
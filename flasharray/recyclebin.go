@@ -0,0 +1,205 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package flasharray
+
+import (
+	"sort"
+	"time"
+)
+
+// RecycleBinService struct for the destroyed objects of the array, pending
+// eradication
+type RecycleBinService struct {
+	client *Client
+}
+
+// ListDestroyedObjects lists the destroyed volumes, volume snapshots,
+// protection groups, protection group snapshots, pods and volume groups
+// selected by filter if it is not nil, containers first
+func (r *RecycleBinService) ListDestroyedObjects(filter *RecycleBinFilter) ([]DestroyedObject, error) {
+
+	if filter == nil {
+		filter = &RecycleBinFilter{}
+	}
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	objects, err := r.listDestroyedObjects()
+	if err != nil {
+		return nil, err
+	}
+
+	m := []DestroyedObject{}
+	for _, o := range objects {
+		if filter.Match(&o) {
+			m = append(m, o)
+		}
+	}
+
+	return m, nil
+}
+
+// listDestroyedObjects lists every destroyed object, containers first.
+func (r *RecycleBinService) listDestroyedObjects() ([]DestroyedObject, error) {
+	pending := map[string]string{"pending_only": "true"}
+	now := time.Now()
+	var m []DestroyedObject
+	add := func(kind string, name string, timeRemaining *int) {
+		o := DestroyedObject{Kind: kind, Name: name}
+		if timeRemaining != nil {
+			o.TimeRemaining = time.Duration(*timeRemaining) * time.Second
+		}
+		o.Eradication = now.Add(o.TimeRemaining)
+		m = append(m, o)
+	}
+
+	volumes, err := r.client.Volumes.ListVolumes(pending)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range volumes {
+		add(DestroyedVolume, v.Name, v.TimeRemaining)
+	}
+	snaps, err := r.client.Volumes.ListVolumeSnapshots("", &VolumeSnapshotFilter{PendingOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range snaps {
+		add(DestroyedVolumeSnapshot, s.Name, s.TimeRemaining)
+	}
+	pgroups, err := r.client.Protectiongroups.ListProtectiongroups(pending)
+	if err != nil {
+		return nil, err
+	}
+	for _, pg := range pgroups {
+		add(DestroyedPgroup, pg.Name, pg.TimeRemaining)
+	}
+	pgroupSnaps, err := r.client.Protectiongroups.ListPgroupSnapshots(pending)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range pgroupSnaps {
+		add(DestroyedPgroupSnapshot, s.Name, s.TimeRemaining)
+	}
+	pods, err := r.client.Pods.ListPods(pending)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range pods {
+		add(DestroyedPod, p.Name, p.TimeRemaining)
+	}
+
+	req, err := r.client.NewRequest("GET", "vgroup", pending, nil)
+	if err != nil {
+		return nil, err
+	}
+	vgroups := []Vgroup{}
+	_, err = r.client.Do(req, &vgroups, false)
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range vgroups {
+		add(DestroyedVgroup, g.Name, g.TimeRemaining)
+	}
+
+	order := make(map[string]int)
+	for i, k := range destroyedKinds {
+		order[k] = i
+	}
+	sort.SliceStable(m, func(i, j int) bool {
+		if m[i].Kind != m[j].Kind {
+			return order[m[i].Kind] < order[m[j].Kind]
+		}
+		return m[i].Name < m[j].Name
+	})
+	return m, nil
+}
+
+// RecoverDestroyedObjects recovers the destroyed objects selected by filter.
+// Objects recovered with their container, like the snapshots of a volume,
+// are not recovered again.  With dryRun, the objects are only listed.  The
+// objects recovered before an error are returned with it.
+func (r *RecycleBinService) RecoverDestroyedObjects(filter *RecycleBinFilter, dryRun bool) ([]DestroyedObject, error) {
+	return r.apply(filter, dryRun, func(o *DestroyedObject) error {
+		var err error
+		switch o.Kind {
+		case DestroyedVolume, DestroyedVolumeSnapshot:
+			_, err = r.client.Volumes.RecoverVolume(o.Name)
+		case DestroyedPgroup:
+			_, err = r.client.Protectiongroups.RecoverProtectiongroup(o.Name)
+		case DestroyedPgroupSnapshot:
+			_, err = r.client.Protectiongroups.RecoverPgroupSnapshot(o.Name)
+		case DestroyedPod:
+			_, err = r.client.Pods.RecoverPod(o.Name)
+		case DestroyedVgroup:
+			_, err = r.client.Vgroups.RecoverVgroup(o.Name)
+		}
+		return err
+	})
+}
+
+// EradicateDestroyedObjects eradicates the destroyed objects selected by
+// filter, which must select them by name; the pattern "*" selects every
+// object.  Objects eradicated with their container are not eradicated again.
+// With dryRun, the objects are only listed.  The objects eradicated before
+// an error are returned with it.
+func (r *RecycleBinService) EradicateDestroyedObjects(filter *RecycleBinFilter, dryRun bool) ([]DestroyedObject, error) {
+	if filter == nil || len(filter.Names) == 0 {
+		return nil, &PureError{Reason: "[error] Eradicating destroyed objects requires name patterns"}
+	}
+	return r.apply(filter, dryRun, func(o *DestroyedObject) error {
+		var err error
+		switch o.Kind {
+		case DestroyedVolume, DestroyedVolumeSnapshot:
+			_, err = r.client.Volumes.EradicateVolume(o.Name)
+		case DestroyedPgroup:
+			_, err = r.client.Protectiongroups.EradicateProtectiongroup(o.Name)
+		case DestroyedPgroupSnapshot:
+			_, err = r.client.Protectiongroups.EradicatePgroupSnapshot(o.Name)
+		case DestroyedPod:
+			_, err = r.client.Pods.EradicatePod(o.Name)
+		case DestroyedVgroup:
+			_, err = r.client.Vgroups.EradicateVgroup(o.Name)
+		}
+		return err
+	})
+}
+
+// apply calls action on the destroyed objects selected by filter, containers
+// first.  Once an object containing other selected objects is done, the
+// recycle bin is listed again to skip the objects done with it.
+func (r *RecycleBinService) apply(filter *RecycleBinFilter, dryRun bool, action func(*DestroyedObject) error) ([]DestroyedObject, error) {
+	selected, err := r.ListDestroyedObjects(filter)
+	if err != nil || dryRun {
+		return selected, err
+	}
+
+	var pending map[DestroyedObject]bool
+	m := []DestroyedObject{}
+	for i, o := range selected {
+		if pending == nil || pending[DestroyedObject{Kind: o.Kind, Name: o.Name}] {
+			if err := action(&o); err != nil {
+				return m, err
+			}
+		}
+		m = append(m, o)
+
+		for _, e := range selected[i+1:] {
+			if e.containedIn(o.Name) {
+				objects, err := r.listDestroyedObjects()
+				if err != nil {
+					return m, err
+				}
+				pending = make(map[DestroyedObject]bool)
+				for _, p := range objects {
+					pending[DestroyedObject{Kind: p.Kind, Name: p.Name}] = true
+				}
+				break
+			}
+		}
+	}
+
+	return m, nil
+}
@@ -0,0 +1,138 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package flasharray
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// DefaultEradicationDelay is how long destroyed objects are kept before the
+// array eradicates them, unless the eradication delay of the array is changed.
+const DefaultEradicationDelay = 24 * time.Hour
+
+// Kinds of destroyed objects
+const (
+	DestroyedVolume         = "volume"
+	DestroyedVolumeSnapshot = "volume-snapshot"
+	DestroyedPgroup         = "pgroup"
+	DestroyedPgroupSnapshot = "pgroup-snapshot"
+	DestroyedPod            = "pod"
+	DestroyedVgroup         = "vgroup"
+)
+
+// destroyedKinds are the kinds of destroyed objects, containers first.
+var destroyedKinds = []string{DestroyedPod, DestroyedVgroup, DestroyedPgroup, DestroyedVolume, DestroyedPgroupSnapshot, DestroyedVolumeSnapshot}
+
+// DestroyedObject struct for an object of the recycle bin, destroyed and
+// pending eradication
+type DestroyedObject struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// TimeRemaining is how long the object can be recovered, and Eradication
+	// when the array eradicates it, as of the time it was listed
+	TimeRemaining time.Duration `json:"time_remaining"`
+	Eradication   time.Time     `json:"eradication"`
+}
+
+// DestroyedFor returns how long the object has been destroyed, with the
+// eradication delay of the array.
+func (o *DestroyedObject) DestroyedFor(eradicationDelay time.Duration) time.Duration {
+	return eradicationDelay - o.TimeRemaining
+}
+
+// containedIn reports whether o is contained in the object named container,
+// i.e. is a snapshot of it, or a volume of a pod or volume group.
+func (o *DestroyedObject) containedIn(container string) bool {
+	for _, sep := range []string{".", "::", "/"} {
+		if strings.HasPrefix(o.Name, container+sep) {
+			return true
+		}
+	}
+	return false
+}
+
+// RecycleBinFilter struct for the selection of destroyed objects.  Zero
+// fields select every object.
+type RecycleBinFilter struct {
+	// Kinds are the kinds of the objects, i.e. DestroyedVolume
+	Kinds []string
+	// Names are patterns of the names of the objects, as of path.Match
+	// except that '*' also matches the '/' of volume group volumes, i.e.
+	// "test-*"
+	Names []string
+	// DestroyedFor selects the objects destroyed for at least this long,
+	// with an eradication delay of EradicationDelay, DefaultEradicationDelay
+	// if zero
+	DestroyedFor     time.Duration
+	EradicationDelay time.Duration
+	// ExpiringWithin selects the objects eradicated by the array within
+	// this long
+	ExpiringWithin time.Duration
+}
+
+// Validate checks the kinds, patterns and durations of the filter.
+func (f *RecycleBinFilter) Validate() error {
+	for _, k := range f.Kinds {
+		if !contains(destroyedKinds, k) {
+			return &PureError{Reason: fmt.Sprintf("[error] Invalid kind %s, must be one of %s", k, strings.Join(destroyedKinds, ", "))}
+		}
+	}
+	for _, n := range f.Names {
+		if _, err := matchName(n, ""); err != nil || n == "" {
+			return &PureError{Reason: fmt.Sprintf("[error] Invalid name pattern %q", n)}
+		}
+	}
+	if f.DestroyedFor < 0 || f.EradicationDelay < 0 || f.ExpiringWithin < 0 {
+		return &PureError{Reason: "[error] Durations of recycle bin filter must not be negative"}
+	}
+	return nil
+}
+
+// Match reports whether the destroyed object o is selected by the filter.
+func (f *RecycleBinFilter) Match(o *DestroyedObject) bool {
+	if len(f.Kinds) > 0 && !contains(f.Kinds, o.Kind) {
+		return false
+	}
+	if len(f.Names) > 0 {
+		matched := false
+		for _, n := range f.Names {
+			if ok, _ := matchName(n, o.Name); ok {
+				matched = true
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	delay := f.EradicationDelay
+	if delay == 0 {
+		delay = DefaultEradicationDelay
+	}
+	if f.DestroyedFor > 0 && o.DestroyedFor(delay) < f.DestroyedFor {
+		return false
+	}
+	if f.ExpiringWithin > 0 && o.TimeRemaining > f.ExpiringWithin {
+		return false
+	}
+	return true
+}
+
+// matchName reports whether name matches the shell pattern, in which '*'
+// matches any sequence of characters.
+func matchName(pattern string, name string) (bool, error) {
+	return path.Match(strings.Replace(pattern, "/", "\x00", -1), strings.Replace(name, "/", "\x00", -1))
+}
+
+func contains(l []string, s string) bool {
+	for _, e := range l {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package flasharray
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecycleBin(t *testing.T) {
+	s, c := testFakeArray(t)
+	now := time.Now()
+	s.Now = func() time.Time { return now }
+
+	c.Volumes.CreateVolume("vol1", testvolsize)
+	c.Volumes.CreateSnapshot("vol1", "snap1")
+	c.Volumes.CreateVolume("vol2", testvolsize)
+	c.Vgroups.CreateVgroup("vgroup1")
+	c.Volumes.CreateVolume("vgroup1/vol3", testvolsize)
+	c.Pods.CreatePod("pod1", nil)
+	c.Volumes.CreateVolume("pod1::vol4", testvolsize)
+	c.Protectiongroups.CreateProtectiongroup("pgroup1", map[string][]string{"vollist": {"vol2"}})
+	c.Protectiongroups.CreatePgroupSnapshot("pgroup1")
+
+	c.Volumes.DeleteVolume("vol2")
+	now = now.Add(2 * time.Hour)
+	c.Volumes.DeleteVolume("vol1")
+	c.Vgroups.DestroyVgroup("vgroup1")
+	c.Volumes.DeleteVolume("pod1::vol4")
+	c.Pods.DeletePod("pod1")
+	c.Protectiongroups.DestroyProtectiongroup("pgroup1")
+
+	t.Run("ListDestroyedObjects", testListDestroyedObjects(c))
+	t.Run("EradicateDryRun", testEradicateDryRun(c))
+	t.Run("RecoverDestroyedObjects", testRecoverDestroyedObjects(c))
+	t.Run("EradicateDestroyedObjects", testEradicateDestroyedObjects(c))
+}
+
+func testListDestroyedObjects(c *Client) func(t *testing.T) {
+	return func(t *testing.T) {
+		l, err := c.RecycleBin.ListDestroyedObjects(nil)
+		if err != nil {
+			t.Fatalf("error listing destroyed objects: %s", err)
+		}
+		expected := []string{"pod1", "vgroup1", "pgroup1", "pod1::vol4", "vgroup1/vol3", "vol1", "vol2", "pgroup1.1", "vol1.snap1"}
+		if len(l) != len(expected) {
+			t.Fatalf("expected %v; got %+v", expected, l)
+		}
+		for i, o := range l {
+			if o.Name != expected[i] {
+				t.Fatalf("expected %v; got %+v", expected, l)
+			}
+		}
+		if l[0].Kind != DestroyedPod || l[0].TimeRemaining != 24*time.Hour {
+			t.Fatalf("expected pod1 to be eradicated in 24h; got %+v", l[0])
+		}
+
+		filter := &RecycleBinFilter{Kinds: []string{DestroyedVolume}, DestroyedFor: time.Hour}
+		if l, err = c.RecycleBin.ListDestroyedObjects(filter); err != nil || len(l) != 1 || l[0].Name != "vol2" {
+			t.Fatalf("expected vol2, destroyed 2 hours ago; got %+v, %v", l, err)
+		}
+		filter = &RecycleBinFilter{Names: []string{"vol*"}, ExpiringWithin: 23 * time.Hour}
+		if l, err = c.RecycleBin.ListDestroyedObjects(filter); err != nil || len(l) != 1 || l[0].Name != "vol2" {
+			t.Fatalf("expected vol2, eradicated first; got %+v, %v", l, err)
+		}
+		filter = &RecycleBinFilter{Names: []string{"vgroup1*"}}
+		if l, err = c.RecycleBin.ListDestroyedObjects(filter); err != nil || len(l) != 2 || l[1].Name != "vgroup1/vol3" {
+			t.Fatalf("expected vgroup1 and its volume; got %+v, %v", l, err)
+		}
+
+		invalid := []*RecycleBinFilter{{Kinds: []string{"host"}}, {Names: []string{"["}}, {Names: []string{""}}, {DestroyedFor: -time.Hour}}
+		for _, f := range invalid {
+			if _, err := c.RecycleBin.ListDestroyedObjects(f); err == nil {
+				t.Errorf("An Error was NOT raised for filter %+v", f)
+			}
+		}
+	}
+}
+
+func testEradicateDryRun(c *Client) func(t *testing.T) {
+	return func(t *testing.T) {
+		if _, err := c.RecycleBin.EradicateDestroyedObjects(nil, true); err == nil {
+			t.Errorf("An Error was NOT raised for eradicating without name patterns")
+		}
+		l, err := c.RecycleBin.EradicateDestroyedObjects(&RecycleBinFilter{Names: []string{"*"}}, true)
+		if err != nil || len(l) != 9 {
+			t.Fatalf("expected every destroyed object; got %+v, %v", l, err)
+		}
+		if l, err = c.RecycleBin.ListDestroyedObjects(nil); err != nil || len(l) != 9 {
+			t.Fatalf("expected no object to be eradicated by a dry run; got %+v, %v", l, err)
+		}
+	}
+}
+
+func testRecoverDestroyedObjects(c *Client) func(t *testing.T) {
+	return func(t *testing.T) {
+		// Recovering a pod does not recover its volumes, recovering a volume
+		// group does.
+		l, err := c.RecycleBin.RecoverDestroyedObjects(&RecycleBinFilter{Names: []string{"pod1*", "vgroup1*"}}, false)
+		if err != nil || len(l) != 4 {
+			t.Fatalf("error recovering objects: %+v, %v", l, err)
+		}
+		for _, name := range []string{"pod1::vol4", "vgroup1/vol3"} {
+			if _, err := c.Volumes.GetVolume(name, nil); err != nil {
+				t.Errorf("expected %s to be recovered: %s", name, err)
+			}
+		}
+	}
+}
+
+func testEradicateDestroyedObjects(c *Client) func(t *testing.T) {
+	return func(t *testing.T) {
+		l, err := c.RecycleBin.EradicateDestroyedObjects(&RecycleBinFilter{Names: []string{"vol1*", "pgroup1*"}}, false)
+		if err != nil || len(l) != 4 {
+			t.Fatalf("error eradicating objects: %+v, %v", l, err)
+		}
+		if l, err = c.RecycleBin.ListDestroyedObjects(nil); err != nil || len(l) != 1 || l[0].Name != "vol2" {
+			t.Fatalf("expected vol2 to be left in the recycle bin; got %+v, %v", l, err)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package flasharray
+
+import (
+	"net/http"
+
+	"github.com/devans10/go-purestorage/internal/retry"
+)
+
+// RetryPolicy decides whether a request that failed with a transient error is
+// sent again.  Retry is called after every failed attempt with the response or
+// the error of the HTTP client, and the number of attempts made so far.  It
+// returns how long to wait before the next attempt, and false to give up.
+type RetryPolicy = retry.Policy
+
+// ExponentialBackoff is a RetryPolicy that waits exponentially longer between
+// attempts, with random jitter.
+//
+// Requests with idempotent methods (GET, HEAD, OPTIONS, PUT and DELETE) are
+// retried when the array responds with one of RetryableStatusCodes, or when the
+// request fails with a network error.  Other requests, i.e. the POST of
+// CreateVolume, are only retried when the array certainly did not process them:
+// when the connection could not be established, or when the array responded
+// with 429 Too Many Requests.  Set RetryNonIdempotent to retry them like
+// idempotent requests.
+type ExponentialBackoff = retry.ExponentialBackoff
+
+// DefaultRetryPolicy returns the retry policy used by New: 4 attempts,
+// starting with a wait of 500ms, for 429, 502, 503 and 504 responses.
+func DefaultRetryPolicy() *ExponentialBackoff {
+	return retry.Default()
+}
+
+// sendWithRetry sends req, and sends it again as long as the retry policy of
+// the client allows.
+func (c *Client) sendWithRetry(req *http.Request) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		resp, err := c.send(req)
+		if c.RetryPolicy == nil {
+			return resp, err
+		}
+		wait, ok := c.RetryPolicy.Retry(req, resp, err, attempt)
+		if !ok {
+			return resp, err
+		}
+		if resp != nil {
+			drainBody(resp.Body)
+		}
+		c.logf("[debug] %s %s: retrying in %s after attempt %d", req.Method, req.URL.String(), wait, attempt)
+		if err := retry.Sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+		if req, err = replayRequest(req); err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package flasharray
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testFlakyServer responds to the first failures requests with status.
+// 429 responses ask the client to retry immediately.
+type testFlakyServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests int
+	failures int
+	status   int
+}
+
+func newTestFlakyServer(t *testing.T, failures int, status int) *testFlakyServer {
+	s := &testFlakyServer{failures: failures, status: status}
+	s.Server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests++
+		fail := s.requests <= s.failures
+		s.mu.Unlock()
+		if fail {
+			if s.status == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", "0")
+			}
+			w.WriteHeader(s.status)
+			fmt.Fprint(w, `[{"msg": "Service unavailable."}]`)
+			return
+		}
+		fmt.Fprint(w, `{"name": "vol1", "size": 1024}`)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func testRetryClient(s *testFlakyServer, policy RetryPolicy) *Client {
+	c := &Client{Target: strings.TrimPrefix(s.URL, "https://"), RestVersion: "1.16", RetryPolicy: policy}
+	c.client = s.Client()
+	c.initServices()
+	return c
+}
+
+func testRetryPolicy() *ExponentialBackoff {
+	p := DefaultRetryPolicy()
+	p.InitialBackoff = time.Millisecond
+	p.Jitter = 0
+	return p
+}
+
+func TestRetryIdempotentRequest(t *testing.T) {
+	s := newTestFlakyServer(t, 2, http.StatusServiceUnavailable)
+	c := testRetryClient(s, testRetryPolicy())
+
+	if _, err := c.Volumes.GetVolume("vol1", nil); err != nil {
+		t.Fatalf("error getting volume: %s", err)
+	}
+	if s.requests != 3 {
+		t.Errorf("expected 3 requests; got %d", s.requests)
+	}
+}
+
+func TestRetryMaxAttempts(t *testing.T) {
+	s := newTestFlakyServer(t, 10, http.StatusServiceUnavailable)
+	c := testRetryClient(s, testRetryPolicy())
+
+	_, err := c.Volumes.GetVolume("vol1", nil)
+	var e *PureHTTPError
+	if !errors.As(err, &e) || e.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected a 503 PureHTTPError; got %v", err)
+	}
+	if s.requests != 4 {
+		t.Errorf("expected 4 requests; got %d", s.requests)
+	}
+}
+
+func TestRetryNonIdempotentRequest(t *testing.T) {
+	s := newTestFlakyServer(t, 1, http.StatusServiceUnavailable)
+	c := testRetryClient(s, testRetryPolicy())
+
+	if _, err := c.Volumes.CreateVolume("vol1", 1024); err == nil {
+		t.Fatalf("An Error was NOT raised when a POST request failed with 503")
+	}
+	if s.requests != 1 {
+		t.Errorf("expected 1 request; got %d", s.requests)
+	}
+
+	s = newTestFlakyServer(t, 1, http.StatusTooManyRequests)
+	c = testRetryClient(s, testRetryPolicy())
+	if _, err := c.Volumes.CreateVolume("vol1", 1024); err != nil {
+		t.Fatalf("error creating volume after 429 response: %s", err)
+	}
+	if s.requests != 2 {
+		t.Errorf("expected 2 requests; got %d", s.requests)
+	}
+
+	s = newTestFlakyServer(t, 1, http.StatusServiceUnavailable)
+	p := testRetryPolicy()
+	p.RetryNonIdempotent = true
+	c = testRetryClient(s, p)
+	if _, err := c.Volumes.CreateVolume("vol1", 1024); err != nil {
+		t.Fatalf("error creating volume with RetryNonIdempotent: %s", err)
+	}
+}
+
+func TestRetryDisabled(t *testing.T) {
+	s := newTestFlakyServer(t, 1, http.StatusServiceUnavailable)
+	c := testRetryClient(s, nil)
+
+	if _, err := c.Volumes.GetVolume("vol1", nil); err == nil {
+		t.Fatalf("An Error was NOT raised when retries are disabled")
+	}
+	if s.requests != 1 {
+		t.Errorf("expected 1 request; got %d", s.requests)
+	}
+}
+
+func TestRetryContextCanceled(t *testing.T) {
+	s := newTestFlakyServer(t, 10, http.StatusServiceUnavailable)
+	p := testRetryPolicy()
+	p.InitialBackoff = time.Hour
+	p.MaxBackoff = time.Hour
+	c := testRetryClient(s, p)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := c.WithContext(ctx).Volumes.GetVolume("vol1", nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded; got %v", err)
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	p := &ExponentialBackoff{MaxAttempts: 5, InitialBackoff: time.Second, MaxBackoff: 5 * time.Second, Multiplier: 2}
+	get, _ := http.NewRequest("GET", "https://flasharray.example.com/api/1.16/volume", nil)
+	post, _ := http.NewRequest("POST", "https://flasharray.example.com/api/1.16/volume/vol1", strings.NewReader("{}"))
+
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second}
+	for i, e := range expected {
+		wait, ok := p.Retry(get, nil, errors.New("connection reset"), i+1)
+		if !ok || wait != e {
+			t.Errorf("attempt %d: expected %s; got %s, %t", i+1, e, wait, ok)
+		}
+	}
+	if _, ok := p.Retry(get, nil, errors.New("connection reset"), 5); ok {
+		t.Errorf("request was retried after MaxAttempts")
+	}
+
+	if _, ok := p.Retry(post, nil, errors.New("connection reset"), 1); ok {
+		t.Errorf("POST request was retried after a network error")
+	}
+	dialErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	if _, ok := p.Retry(post, nil, dialErr, 1); !ok {
+		t.Errorf("POST request was not retried after a dial error")
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/devans10/go-purestorage/flasharray"
+)
+
+// descriptors are the shorthands of common schedules.
+var descriptors = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+	"@yearly":  "0 0 1 1 *",
+}
+
+// field is the range of a field of a schedule.
+type field struct {
+	name string
+	min  int
+	max  int
+}
+
+var fields = []field{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// Schedule is a cron schedule of the minutes a policy runs at.
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+
+	// domAny and dowAny are set when the day of month or day of week is
+	// "*"; if both are restricted, a day matching either is scheduled
+	domAny, dowAny bool
+}
+
+// ParseSchedule parses a cron schedule of five fields, minute, hour, day of
+// month, month and day of week, i.e. "0 */4 * * 1-5", or one of the
+// shorthands @hourly, @daily, @weekly, @monthly and @yearly.  The fields are
+// lists of values, ranges and steps.  Sunday is day 0 or 7.
+func ParseSchedule(spec string) (*Schedule, error) {
+	if d, ok := descriptors[strings.TrimSpace(spec)]; ok {
+		spec = d
+	}
+	f := strings.Fields(spec)
+	if len(f) != len(fields) {
+		return nil, &flasharray.PureError{Reason: fmt.Sprintf("[error] Schedule %q must have %d fields", spec, len(fields))}
+	}
+
+	bits := make([]uint64, len(fields))
+	for i, s := range f {
+		b, err := parseField(s, fields[i])
+		if err != nil {
+			return nil, &flasharray.PureError{Reason: fmt.Sprintf("[error] Invalid schedule %q: %s", spec, err)}
+		}
+		bits[i] = b
+	}
+	// Sunday is both 0 and 7
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+
+	return &Schedule{
+		minute: bits[0],
+		hour:   bits[1],
+		dom:    bits[2],
+		month:  bits[3],
+		dow:    bits[4],
+		domAny: f[2] == "*",
+		dowAny: f[4] == "*",
+	}, nil
+}
+
+// parseField parses a comma separated list of values, ranges and steps of f.
+func parseField(s string, f field) (uint64, error) {
+	var bits uint64
+	for _, e := range strings.Split(s, ",") {
+		step := 1
+		if i := strings.IndexByte(e, '/'); i >= 0 {
+			n, err := strconv.Atoi(e[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q of %s", e[i+1:], f.name)
+			}
+			step, e = n, e[:i]
+		}
+
+		min, max := f.min, f.max
+		switch i := strings.IndexByte(e, '-'); {
+		case e == "*":
+		case i >= 0:
+			var err error
+			if min, err = parseValue(e[:i], f); err != nil {
+				return 0, err
+			}
+			if max, err = parseValue(e[i+1:], f); err != nil {
+				return 0, err
+			}
+			if min > max {
+				return 0, fmt.Errorf("invalid range %q of %s", e, f.name)
+			}
+		default:
+			n, err := parseValue(e, f)
+			if err != nil {
+				return 0, err
+			}
+			min = n
+			if step == 1 {
+				max = n
+			}
+		}
+
+		for n := min; n <= max; n += step {
+			bits |= 1 << uint(n)
+		}
+	}
+	return bits, nil
+}
+
+func parseValue(s string, f field) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < f.min || n > f.max {
+		return 0, fmt.Errorf("%s %q must be between %d and %d", f.name, s, f.min, f.max)
+	}
+	return n, nil
+}
+
+// maxScheduleSearch bounds the search of the next time of a schedule that
+// never matches, like the 30th of February.
+const maxScheduleSearch = 5 * 366 * 24 * time.Hour
+
+// Next returns the first minute of the schedule after t, in the location of
+// t, or the zero time if there is none.
+func (s *Schedule) Next(t time.Time) time.Time {
+	end := t.Add(maxScheduleSearch)
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	for t.Before(end) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.matchDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// matchDay reports whether the day of t is scheduled.
+func (s *Schedule) matchDay(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domAny || s.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
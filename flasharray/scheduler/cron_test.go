@@ -0,0 +1,51 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleNext(t *testing.T) {
+	// 2020-01-01 is a Wednesday
+	from := time.Date(2020, 1, 1, 10, 30, 15, 0, time.UTC)
+	schedules := []struct {
+		spec string
+		next time.Time
+	}{
+		{"* * * * *", time.Date(2020, 1, 1, 10, 31, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2020, 1, 1, 11, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2020, 1, 1, 10, 45, 0, 0, time.UTC)},
+		{"5,40 9-17 * * *", time.Date(2020, 1, 1, 10, 40, 0, 0, time.UTC)},
+		{"0 */4 * * *", time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"30 2 * * 1-5", time.Date(2020, 1, 2, 2, 30, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2020, 1, 5, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2020, 1, 5, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 15 * 6", time.Date(2020, 1, 4, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, s := range schedules {
+		schedule, err := ParseSchedule(s.spec)
+		if err != nil {
+			t.Errorf("error parsing schedule %q: %s", s.spec, err)
+			continue
+		}
+		if next := schedule.Next(from); !next.Equal(s.next) {
+			t.Errorf("expected %s after %s for %q; got %s", s.next, from, s.spec, next)
+		}
+	}
+
+	invalid := []string{"", "@often", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "*/0 * * * *", "5-1 * * * *", "a * * * *"}
+	for _, spec := range invalid {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("An Error was NOT raised for schedule %q", spec)
+		}
+	}
+}
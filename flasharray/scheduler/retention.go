@@ -0,0 +1,95 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package scheduler
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/devans10/go-purestorage/flasharray"
+)
+
+// Retention is a grandfather-father-son retention of the snapshots of a
+// policy: the latest snapshot of each of the last Hourly hours, Daily days,
+// Weekly ISO weeks, Monthly months and Yearly years with a snapshot is kept,
+// i.e. "keep 24 hourly, 7 daily, 4 weekly" is Retention{Hourly: 24, Daily:
+// 7, Weekly: 4}.  The latest snapshot is always kept, and a zero Retention
+// keeps every snapshot.
+type Retention struct {
+	Hourly  int
+	Daily   int
+	Weekly  int
+	Monthly int
+	Yearly  int
+}
+
+// Validate checks that the counts of the retention are not negative.
+func (r *Retention) Validate() error {
+	if r.Hourly < 0 || r.Daily < 0 || r.Weekly < 0 || r.Monthly < 0 || r.Yearly < 0 {
+		return &flasharray.PureError{Reason: fmt.Sprintf("[error] Invalid retention %+v", *r)}
+	}
+	return nil
+}
+
+// IsZero reports whether the retention keeps every snapshot.
+func (r *Retention) IsZero() bool {
+	return *r == Retention{}
+}
+
+// periods returns the count of each period of the retention, with the
+// function returning the period of a time.
+func (r *Retention) periods() []struct {
+	count  int
+	period func(time.Time) int
+} {
+	return []struct {
+		count  int
+		period func(time.Time) int
+	}{
+		{r.Hourly, func(t time.Time) int { return dayOf(t)*24 + t.Hour() }},
+		{r.Daily, dayOf},
+		{r.Weekly, func(t time.Time) int { y, w := t.ISOWeek(); return y*100 + w }},
+		{r.Monthly, func(t time.Time) int { return t.Year()*100 + int(t.Month()) }},
+		{r.Yearly, func(t time.Time) int { return t.Year() }},
+	}
+}
+
+func dayOf(t time.Time) int {
+	return t.Year()*1000 + t.YearDay()
+}
+
+// Prune returns the times of times not kept by the retention, newest first.
+// The periods of the times are those of their location.
+func (r *Retention) Prune(times []time.Time) []time.Time {
+	if r.IsZero() || len(times) == 0 {
+		return nil
+	}
+	sorted := append([]time.Time{}, times...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].After(sorted[j]) })
+
+	keep := make([]bool, len(sorted))
+	keep[0] = true
+	for _, p := range r.periods() {
+		seen := make(map[int]bool)
+		for i, t := range sorted {
+			if len(seen) == p.count {
+				break
+			}
+			if k := p.period(t); !seen[k] {
+				seen[k] = true
+				keep[i] = true
+			}
+		}
+	}
+
+	var pruned []time.Time
+	for i, t := range sorted {
+		if !keep[i] {
+			pruned = append(pruned, t)
+		}
+	}
+	return pruned
+}
@@ -0,0 +1,53 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetentionPrune(t *testing.T) {
+	// A snapshot every 6 hours for 30 days, the last one on 2020-01-30
+	var times []time.Time
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 30*4; i++ {
+		times = append(times, start.Add(time.Duration(i)*6*time.Hour))
+	}
+	latest := times[len(times)-1]
+
+	retentions := []struct {
+		retention Retention
+		kept      int
+	}{
+		{Retention{}, len(times)},
+		{Retention{Hourly: 1}, 1},
+		{Retention{Hourly: 4}, 4},
+		// The 4 snapshots of the last day, and one for each of the 6 days before
+		{Retention{Hourly: 4, Daily: 7}, 10},
+		// 2020-01-27 to 2020-01-30 are in week 5, then weeks 4, 3, 2 and 1
+		{Retention{Weekly: 5}, 5},
+		{Retention{Daily: 2, Monthly: 12, Yearly: 3}, 2},
+	}
+	for _, r := range retentions {
+		pruned := r.retention.Prune(times)
+		if kept := len(times) - len(pruned); kept != r.kept {
+			t.Errorf("expected %+v to keep %d snapshots; kept %d", r.retention, r.kept, kept)
+		}
+		for _, p := range pruned {
+			if p.Equal(latest) {
+				t.Errorf("expected %+v to keep the latest snapshot", r.retention)
+			}
+		}
+	}
+
+	pruned := (&Retention{Daily: 2}).Prune(times)
+	if len(pruned) == 0 || !pruned[0].Equal(time.Date(2020, 1, 30, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected the pruned snapshots newest first; got %v", pruned)
+	}
+	if err := (&Retention{Daily: -1}).Validate(); err == nil {
+		t.Errorf("An Error was NOT raised for a negative retention")
+	}
+}
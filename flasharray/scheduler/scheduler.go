@@ -0,0 +1,346 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+// Package scheduler takes snapshots of volumes and protection groups on
+// client-side cron schedules, and prunes them with grandfather-father-son
+// retention.
+//
+// Each Policy takes snapshots of a set of volumes, or of protection groups,
+// together.  Its Pre and Post hooks run before and after the snapshots, i.e.
+// to quiesce an application for app-consistent snapshots.  The snapshots are
+// tagged with a suffix of the name of the policy and the time of the run, so
+// that the retention of a policy only ever prunes its own snapshots.
+//
+//	s, err := scheduler.New(c, nil, &scheduler.Policy{
+//		Name:      "hourly",
+//		Schedule:  "0 * * * *",
+//		Volumes:   []string{"db-data", "db-log"},
+//		Retention: scheduler.Retention{Hourly: 24, Daily: 7, Weekly: 4},
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	return s.Run(ctx)
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/devans10/go-purestorage/flasharray"
+)
+
+// Clock is the source of time of a Scheduler.  Tests can provide a clock
+// they control.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// SystemClock is the Clock of the system, used when New is given no clock.
+var SystemClock Clock = systemClock{}
+
+// suffixTimeFormat is the format of the time of a run in snapshot suffixes,
+// in UTC.
+const suffixTimeFormat = "200601021504"
+
+// policyNameRegexp matches the names of policies, which leave room for the
+// time in the snapshot suffixes of 63 characters at most.
+var policyNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9-]{0,49}$`)
+
+// Suffix returns the suffix of the snapshots taken by the policy name at t,
+// i.e. "hourly-202001021500".
+func Suffix(name string, t time.Time) string {
+	return name + "-" + t.UTC().Format(suffixTimeFormat)
+}
+
+// ParseSuffix returns the time of the snapshot suffix of the policy name, and
+// whether suffix is a suffix of the policy.
+func ParseSuffix(name string, suffix string) (time.Time, bool) {
+	if !strings.HasPrefix(suffix, name+"-") {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(suffixTimeFormat, suffix[len(name)+1:])
+	return t, err == nil
+}
+
+// Hook is run before or after the snapshots of a policy.
+type Hook func(ctx context.Context, p *Policy) error
+
+// Policy is a schedule of snapshots of a set of volumes, or of protection
+// groups, and their retention.
+type Policy struct {
+	// Name tags the snapshots of the policy.  It must be 1-50 letters,
+	// numbers and '-', starting with a letter or number.
+	Name string
+	// Schedule is a cron schedule, as parsed by ParseSchedule
+	Schedule string
+
+	// Volumes or Pgroups are the volumes or protection groups snapshotted
+	// together by the policy
+	Volumes []string
+	Pgroups []string
+
+	// Retention selects the snapshots of the policy to destroy after each
+	// snapshot, and Eradicate eradicates them
+	Retention Retention
+	Eradicate bool
+
+	// Pre is run before the snapshots; if it fails, no snapshot is taken.
+	// Post is run after the snapshots, whether they were taken or not, once
+	// Pre succeeded.
+	Pre  Hook
+	Post Hook
+}
+
+// Validate checks the name, schedule, sources and retention of the policy.
+func (p *Policy) Validate() error {
+	if !policyNameRegexp.MatchString(p.Name) {
+		return &flasharray.PureError{Reason: fmt.Sprintf("[error] Invalid policy name %q", p.Name)}
+	}
+	if _, err := ParseSchedule(p.Schedule); err != nil {
+		return err
+	}
+	if (len(p.Volumes) == 0) == (len(p.Pgroups) == 0) {
+		return &flasharray.PureError{Reason: fmt.Sprintf("[error] Policy %s must have either volumes or protection groups", p.Name)}
+	}
+	return p.Retention.Validate()
+}
+
+// Result is the result of a run of a policy.
+type Result struct {
+	Policy string
+	Time   time.Time
+	Suffix string
+	// Snapshots are the names of the snapshots taken, and Pruned the names
+	// of the snapshots destroyed by the retention
+	Snapshots []string
+	Pruned    []string
+	// Err is the first error of the run
+	Err error
+}
+
+type policy struct {
+	*Policy
+	schedule *Schedule
+}
+
+// Scheduler runs snapshot policies on an array.
+type Scheduler struct {
+	client   *flasharray.Client
+	clock    Clock
+	policies []policy
+
+	// OnRun is called with the result of each run of a policy by Run
+	OnRun func(*Result)
+}
+
+// New returns a Scheduler of the policies on the array of c, with the time
+// of clock, or of the system if clock is nil.
+func New(c *flasharray.Client, clock Clock, policies ...*Policy) (*Scheduler, error) {
+	if clock == nil {
+		clock = SystemClock
+	}
+	s := &Scheduler{client: c, clock: clock}
+	names := make(map[string]bool)
+	for _, p := range policies {
+		if err := p.Validate(); err != nil {
+			return nil, err
+		}
+		if names[p.Name] {
+			return nil, &flasharray.PureError{Reason: fmt.Sprintf("[error] Duplicate policy %s", p.Name)}
+		}
+		names[p.Name] = true
+		schedule, _ := ParseSchedule(p.Schedule)
+		s.policies = append(s.policies, policy{Policy: p, schedule: schedule})
+	}
+	return s, nil
+}
+
+// Run runs the policies on their schedules until ctx is done, and returns
+// the error of ctx.  The errors of the runs are reported to OnRun.
+func (s *Scheduler) Run(ctx context.Context) error {
+	now := s.clock.Now()
+	next := make([]time.Time, len(s.policies))
+	for i, p := range s.policies {
+		next[i] = p.schedule.Next(now)
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var due time.Time
+		for _, t := range next {
+			if !t.IsZero() && (due.IsZero() || t.Before(due)) {
+				due = t
+			}
+		}
+		if due.IsZero() {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.clock.After(due.Sub(now)):
+		}
+		now = s.clock.Now()
+		for i, p := range s.policies {
+			if next[i].IsZero() || next[i].After(now) {
+				continue
+			}
+			r := s.run(ctx, p.Policy, next[i])
+			if s.OnRun != nil {
+				s.OnRun(r)
+			}
+			next[i] = p.schedule.Next(now)
+		}
+	}
+}
+
+// RunPolicy runs the policy name now, outside of its schedule.
+func (s *Scheduler) RunPolicy(ctx context.Context, name string) (*Result, error) {
+	for _, p := range s.policies {
+		if p.Name == name {
+			r := s.run(ctx, p.Policy, s.clock.Now())
+			return r, r.Err
+		}
+	}
+	return nil, &flasharray.PureError{Reason: fmt.Sprintf("[error] Policy %s does not exist", name)}
+}
+
+// run takes the snapshots of p for the time t, and prunes its snapshots.
+func (s *Scheduler) run(ctx context.Context, p *Policy, t time.Time) *Result {
+	r := &Result{Policy: p.Name, Time: t, Suffix: Suffix(p.Name, t)}
+
+	if p.Pre != nil {
+		if err := p.Pre(ctx, p); err != nil {
+			r.Err = &flasharray.PureError{Reason: fmt.Sprintf("[error] Pre hook of policy %s failed: %v", p.Name, err)}
+			return r
+		}
+	}
+	c := s.client.WithContext(ctx)
+	r.Snapshots, r.Err = snapshot(c, p, r.Suffix)
+	if p.Post != nil {
+		if err := p.Post(ctx, p); err != nil && r.Err == nil {
+			r.Err = &flasharray.PureError{Reason: fmt.Sprintf("[error] Post hook of policy %s failed: %v", p.Name, err)}
+		}
+	}
+	// Snapshots are only pruned once new ones are taken
+	if r.Err == nil {
+		r.Pruned, r.Err = prune(c, p, t.Location())
+	}
+	return r
+}
+
+func snapshot(c *flasharray.Client, p *Policy, suffix string) ([]string, error) {
+	var names []string
+	if len(p.Volumes) > 0 {
+		snaps, err := c.Volumes.CreateSnapshots(p.Volumes, suffix)
+		if err != nil {
+			return nil, err
+		}
+		for _, snap := range snaps {
+			names = append(names, snap.Name)
+		}
+		return names, nil
+	}
+
+	snaps, err := c.Protectiongroups.CreatePgroupSnapshotsWithSuffix(p.Pgroups, suffix)
+	if err != nil {
+		return nil, err
+	}
+	for _, snap := range snaps {
+		names = append(names, snap.Name)
+	}
+	return names, nil
+}
+
+// snapshots returns the names of the snapshots of p by the Unix time of
+// their suffix.
+func snapshots(c *flasharray.Client, p *Policy) (map[int64][]string, error) {
+	m := make(map[int64][]string)
+	add := func(name string, suffix string) {
+		if t, ok := ParseSuffix(p.Name, suffix); ok {
+			m[t.Unix()] = append(m[t.Unix()], name)
+		}
+	}
+
+	filter := &flasharray.VolumeSnapshotFilter{Suffix: p.Name + "-*"}
+	for _, v := range p.Volumes {
+		snaps, err := c.Volumes.ListVolumeSnapshots(v, filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, snap := range snaps {
+			add(snap.Name, snap.Suffix())
+		}
+	}
+	for _, pg := range p.Pgroups {
+		snaps, err := c.Protectiongroups.ListPgroupSnapshots(map[string]string{"names": pg})
+		if err != nil {
+			return nil, err
+		}
+		for _, snap := range snaps {
+			if snap.Source == pg {
+				add(snap.Name, snap.Suffix())
+			}
+		}
+	}
+	return m, nil
+}
+
+// prune destroys the snapshots of p not kept by its retention, with the
+// periods of loc, and returns their names.
+func prune(c *flasharray.Client, p *Policy, loc *time.Location) ([]string, error) {
+	if p.Retention.IsZero() {
+		return nil, nil
+	}
+	snaps, err := snapshots(c, p)
+	if err != nil {
+		return nil, err
+	}
+	var times []time.Time
+	for t := range snaps {
+		times = append(times, time.Unix(t, 0).In(loc))
+	}
+
+	pruned := []string{}
+	for _, t := range p.Retention.Prune(times) {
+		for _, name := range snaps[t.Unix()] {
+			if err := destroy(c, p, name); err != nil {
+				return pruned, err
+			}
+			pruned = append(pruned, name)
+		}
+	}
+	return pruned, nil
+}
+
+func destroy(c *flasharray.Client, p *Policy, name string) error {
+	if len(p.Volumes) > 0 {
+		names := []string{name}
+		if _, err := c.Volumes.DestroyVolumeSnapshots(names); err != nil || !p.Eradicate {
+			return err
+		}
+		_, err := c.Volumes.EradicateVolumeSnapshots(names)
+		return err
+	}
+
+	if _, err := c.Protectiongroups.DestroyPgroupSnapshot(name); err != nil || !p.Eradicate {
+		return err
+	}
+	_, err := c.Protectiongroups.EradicatePgroupSnapshot(name)
+	return err
+}
@@ -0,0 +1,199 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/devans10/go-purestorage/flasharray"
+	"github.com/devans10/go-purestorage/flasharray/flasharraytest"
+)
+
+// testClock is a Clock whose timers fire at once, advancing its time.
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+func testFakeArray(t *testing.T) *flasharray.Client {
+	s := flasharraytest.NewServer()
+	t.Cleanup(s.Close)
+
+	c, err := flasharray.New(s.Target(), flasharray.WithAPIToken(s.APIToken), flasharray.WithHTTPClient(s.Client()))
+	if err != nil {
+		t.Fatalf("error setting up client of the fake array: %s", err)
+	}
+	return c
+}
+
+// testRun runs s until it reports n results.
+func testRun(t *testing.T, s *Scheduler, n int) []*Result {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var results []*Result
+	s.OnRun = func(r *Result) {
+		results = append(results, r)
+		if len(results) == n {
+			cancel()
+		}
+	}
+	if err := s.Run(ctx); err != context.Canceled {
+		t.Fatalf("expected the scheduler to run until canceled; got %v", err)
+	}
+	return results
+}
+
+func TestSchedulerVolumes(t *testing.T) {
+	c := testFakeArray(t)
+	c.Volumes.CreateVolume("db-data", 1<<30)
+	c.Volumes.CreateVolume("db-log", 1<<30)
+	// A snapshot of another policy, never pruned by hourly
+	c.Volumes.CreateSnapshot("db-data", "hourly-db-202001010000")
+
+	var hooks []string
+	pre := 0
+	p := &Policy{
+		Name:      "hourly",
+		Schedule:  "@hourly",
+		Volumes:   []string{"db-data", "db-log"},
+		Retention: Retention{Hourly: 3, Daily: 2},
+		Pre: func(ctx context.Context, p *Policy) error {
+			hooks = append(hooks, "pre")
+			if pre++; pre == 3 {
+				return errors.New("quiesce failed")
+			}
+			return nil
+		},
+		Post: func(ctx context.Context, p *Policy) error {
+			hooks = append(hooks, "post")
+			return nil
+		},
+	}
+	clock := &testClock{now: time.Date(2020, 1, 1, 0, 30, 0, 0, time.UTC)}
+	s, err := New(c, clock, p)
+	if err != nil {
+		t.Fatalf("error creating scheduler: %s", err)
+	}
+
+	// Hourly from 2020-01-01 01:00 to 2020-01-02 06:00
+	results := testRun(t, s, 30)
+	if results[0].Suffix != "hourly-202001010100" || len(results[0].Snapshots) != 2 || results[0].Snapshots[0] != "db-data.hourly-202001010100" {
+		t.Fatalf("unexpected first run: %+v", results[0])
+	}
+	if results[1].Err != nil || len(hooks) < 4 || hooks[0] != "pre" || hooks[1] != "post" {
+		t.Fatalf("expected the hooks to run around the snapshots; got %v, %v", hooks, results[1].Err)
+	}
+	if results[2].Err == nil || len(results[2].Snapshots) != 0 || hooks[4] != "pre" || hooks[5] != "pre" {
+		t.Fatalf("expected no snapshot and no post hook when the pre hook fails; got %+v, %v", results[2], hooks)
+	}
+	last := results[len(results)-1]
+	if last.Err != nil || !last.Time.Equal(time.Date(2020, 1, 2, 6, 0, 0, 0, time.UTC)) || len(last.Pruned) != 2 {
+		t.Fatalf("expected the last run to prune one snapshot of each volume; got %+v", last)
+	}
+
+	l, err := c.Volumes.ListVolumeSnapshots("", nil)
+	if err != nil {
+		t.Fatalf("error listing snapshots: %s", err)
+	}
+	expected := []string{
+		"db-data.hourly-202001012300", "db-data.hourly-202001020400", "db-data.hourly-202001020500", "db-data.hourly-202001020600",
+		"db-data.hourly-db-202001010000",
+		"db-log.hourly-202001012300", "db-log.hourly-202001020400", "db-log.hourly-202001020500", "db-log.hourly-202001020600",
+	}
+	names := flasharray.VolumeSnapshotNames(l)
+	if len(names) != len(expected) {
+		t.Fatalf("expected snapshots %v; got %v", expected, names)
+	}
+	for i := range names {
+		if names[i] != expected[i] {
+			t.Fatalf("expected snapshots %v; got %v", expected, names)
+		}
+	}
+	if l, err = c.Volumes.ListVolumeSnapshots("", &flasharray.VolumeSnapshotFilter{PendingOnly: true}); err != nil || len(l) != 2*(29-4) {
+		t.Fatalf("expected the pruned snapshots to be destroyed; got %d, %v", len(l), err)
+	}
+}
+
+func TestSchedulerPgroups(t *testing.T) {
+	c := testFakeArray(t)
+	c.Volumes.CreateVolume("vol1", 1<<30)
+	c.Protectiongroups.CreateProtectiongroup("pgroup1", map[string][]string{"vollist": {"vol1"}})
+
+	p := &Policy{Name: "daily", Schedule: "0 2 * * *", Pgroups: []string{"pgroup1"}, Retention: Retention{Daily: 2}, Eradicate: true}
+	clock := &testClock{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	s, err := New(c, clock, p)
+	if err != nil {
+		t.Fatalf("error creating scheduler: %s", err)
+	}
+	if results := testRun(t, s, 4); results[3].Err != nil || len(results[3].Pruned) != 1 || results[3].Pruned[0] != "pgroup1.daily-202001020200" {
+		t.Fatalf("expected the last run to prune the snapshot of 2020-01-02; got %+v", results[3])
+	}
+
+	l, err := c.Protectiongroups.ListPgroupSnapshots(map[string]string{"pending": "true"})
+	if err != nil {
+		t.Fatalf("error listing snapshots: %s", err)
+	}
+	if len(l) != 2 || l[0].Name != "pgroup1.daily-202001030200" || l[1].Name != "pgroup1.daily-202001040200" {
+		t.Fatalf("expected the 2 latest snapshots, the others eradicated; got %+v", l)
+	}
+
+	clock.now = time.Date(2020, 1, 4, 12, 0, 0, 0, time.UTC)
+	r, err := s.RunPolicy(context.Background(), "daily")
+	if err != nil || r.Snapshots[0] != "pgroup1.daily-202001041200" || len(r.Pruned) != 1 || r.Pruned[0] != "pgroup1.daily-202001040200" {
+		t.Fatalf("expected the run to replace the snapshot of the morning; got %+v, %v", r, err)
+	}
+	if _, err := s.RunPolicy(context.Background(), "weekly"); err == nil {
+		t.Errorf("An Error was NOT raised for a policy that does not exist")
+	}
+}
+
+func TestPolicyValidate(t *testing.T) {
+	valid := Policy{Name: "hourly", Schedule: "@hourly", Volumes: []string{"vol1"}}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("error validating policy: %s", err)
+	}
+
+	invalid := map[string]func(p *Policy){
+		"name":      func(p *Policy) { p.Name = "-hourly" },
+		"long name": func(p *Policy) { p.Name = "a123456789012345678901234567890123456789012345678901" },
+		"schedule":  func(p *Policy) { p.Schedule = "hourly" },
+		"sources":   func(p *Policy) { p.Pgroups = []string{"pgroup1"} },
+		"no source": func(p *Policy) { p.Volumes = nil },
+		"retention": func(p *Policy) { p.Retention.Weekly = -1 },
+	}
+	for name, f := range invalid {
+		p := valid
+		f(&p)
+		if err := p.Validate(); err == nil {
+			t.Errorf("An Error was NOT raised for %s", name)
+		}
+	}
+
+	if _, err := New(nil, nil, &valid, &valid); err == nil {
+		t.Errorf("An Error was NOT raised for duplicate policies")
+	}
+	if s, ok := ParseSuffix("hourly", Suffix("hourly", time.Date(2020, 1, 2, 15, 0, 0, 0, time.FixedZone("CET", 3600)))); !ok || s.Hour() != 14 {
+		t.Errorf("expected the suffix in UTC; got %s, %v", s, ok)
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package flasharray
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/devans10/go-purestorage/internal/retry"
+)
+
+// session tracks the REST session of a client.  It is shared between a
+// client and the copies returned by WithContext, so that only one of them
+// re-authenticates when the array expires the session.
+type session struct {
+	mu         sync.Mutex
+	generation uint64
+}
+
+// current returns the generation of the REST session in use.
+func (s *session) current() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.generation
+}
+
+// startSession posts the API token to auth/session.  The session cookie
+// returned by the array is stored in the cookie jar of the HTTP client.
+func (c *Client) startSession(ctx context.Context) error {
+
+	authURL := c.formatPath("auth/session")
+	data := map[string]string{"api_token": c.APIToken}
+	jsonValue, _ := json.Marshal(data)
+	req, err := http.NewRequest("POST", authURL, bytes.NewBuffer(jsonValue))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.send(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return c.validateResponse(resp)
+}
+
+// reestablishSession starts a new REST session, unless another request has
+// already done so since generation gen was observed.  For clients created
+// with a username and password, the API token is retrieved again first.
+func (c *Client) reestablishSession(ctx context.Context, gen uint64) error {
+	c.session.mu.Lock()
+	defer c.session.mu.Unlock()
+
+	if c.session.generation != gen {
+		return nil
+	}
+
+	if c.Username != "" {
+		if err := c.getAPIToken(ctx); err != nil {
+			return err
+		}
+	}
+	if err := c.startSession(ctx); err != nil {
+		return err
+	}
+	c.session.generation++
+
+	return nil
+}
+
+// sessionExpired reports whether the response of req indicates that the
+// REST session is no longer valid and the request can be replayed.
+func sessionExpired(req *http.Request, resp *http.Response) bool {
+	if resp.StatusCode != http.StatusUnauthorized {
+		return false
+	}
+	if strings.Contains(req.URL.Path, "/auth/") {
+		return false
+	}
+	return retry.Replayable(req)
+}
+
+// replayRequest returns a copy of req with a fresh body, so that it can be
+// sent again.  The cookies added to req by the cookie jar are dropped, so
+// that the new session cookie is used.
+func replayRequest(req *http.Request) (*http.Request, error) {
+	r := req.WithContext(req.Context())
+	r.Header = make(http.Header, len(req.Header))
+	for k, v := range req.Header {
+		if k != "Cookie" {
+			r.Header[k] = append([]string(nil), v...)
+		}
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		r.Body = body
+	}
+	return r, nil
+}
+
+// drainBody reads the rest of body and closes it, so the underlying
+// connection can be reused.
+func drainBody(body io.ReadCloser) {
+	io.Copy(ioutil.Discard, body)
+	body.Close()
+}
@@ -0,0 +1,173 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package flasharray
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testSessionServer is a minimal array that issues session cookies and can
+// expire them on demand.
+type testSessionServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	session  int
+	sessions int
+	tokens   int
+}
+
+func newTestSessionServer(t *testing.T) *testSessionServer {
+	s := newUnstartedTestSessionServer(t)
+	s.StartTLS()
+	return s
+}
+
+func newUnstartedTestSessionServer(t *testing.T) *testSessionServer {
+	s := &testSessionServer{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/api_version", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"version": ["1.16"]}`)
+	})
+	mux.HandleFunc("/api/1.16/auth/apitoken", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.tokens++
+		s.mu.Unlock()
+		fmt.Fprint(w, `{"api_token": "apitoken"}`)
+	})
+	mux.HandleFunc("/api/1.16/auth/session", func(w http.ResponseWriter, r *http.Request) {
+		data := map[string]string{}
+		json.NewDecoder(r.Body).Decode(&data)
+		if data["api_token"] != "apitoken" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `[{"msg": "Invalid API token."}]`)
+			return
+		}
+		s.mu.Lock()
+		s.sessions++
+		s.session = s.sessions
+		s.mu.Unlock()
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: fmt.Sprint(s.session), Path: "/"})
+		fmt.Fprint(w, `{"username": "pureuser"}`)
+	})
+	mux.HandleFunc("/api/1.16/volume/", func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie("session")
+		s.mu.Lock()
+		valid := err == nil && s.session != 0 && cookie.Value == fmt.Sprint(s.session)
+		s.mu.Unlock()
+		if !valid {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `[{"msg": "Session has expired."}]`)
+			return
+		}
+		data := map[string]int{}
+		json.NewDecoder(r.Body).Decode(&data)
+		name := strings.TrimPrefix(r.URL.Path, "/api/1.16/volume/")
+		fmt.Fprintf(w, `{"name": %q, "size": %d}`, name, data["size"])
+	})
+	s.Server = httptest.NewUnstartedServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+// testResponseClient returns a client of a test array responding to the
+// requests of path with body.
+func testResponseClient(t *testing.T, path string, body string) *Client {
+	s := newUnstartedTestSessionServer(t)
+	s.Config.Handler.(*http.ServeMux).HandleFunc("/api/1.16/"+path, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, body)
+	})
+	s.StartTLS()
+
+	c, err := New(s.target(), WithAPIToken("apitoken"), WithHTTPClient(s.Client()))
+	if err != nil {
+		t.Fatalf("error setting up client: %s", err)
+	}
+	return c
+}
+
+func (s *testSessionServer) target() string {
+	return strings.TrimPrefix(s.URL, "https://")
+}
+
+func (s *testSessionServer) expire() {
+	s.mu.Lock()
+	s.session = 0
+	s.mu.Unlock()
+}
+
+func TestSessionReestablishedOnExpiry(t *testing.T) {
+	s := newTestSessionServer(t)
+
+	c, err := NewClient(s.target(), "pureuser", "password", "", "", false, false, "", nil)
+	if err != nil {
+		t.Fatalf("error setting up client: %s", err)
+	}
+
+	s.expire()
+	v, err := c.Volumes.CreateVolume("vol1", 1024)
+	if err != nil {
+		t.Fatalf("request was not replayed after the session expired: %s", err)
+	}
+	if v.Name != "vol1" || v.Size != 1024 {
+		t.Errorf("expected: vol1 with size 1024; got %+v", v)
+	}
+	if s.sessions != 2 {
+		t.Errorf("expected 2 sessions to be started; got %d", s.sessions)
+	}
+	if s.tokens != 2 {
+		t.Errorf("expected the API token to be retrieved 2 times; got %d", s.tokens)
+	}
+}
+
+func TestSessionReestablishDisabled(t *testing.T) {
+	s := newTestSessionServer(t)
+
+	c, err := NewClient(s.target(), "", "", "apitoken", "", false, false, "", nil)
+	if err != nil {
+		t.Fatalf("error setting up client: %s", err)
+	}
+	c.AutoReestablishSession = false
+
+	s.expire()
+	if _, err := c.Volumes.CreateVolume("vol1", 1024); err == nil {
+		t.Fatalf("An Error was NOT raised when the session expired")
+	}
+	if s.sessions != 1 {
+		t.Errorf("expected 1 session to be started; got %d", s.sessions)
+	}
+}
+
+func TestSessionReestablishedOnce(t *testing.T) {
+	s := newTestSessionServer(t)
+
+	c, err := NewClient(s.target(), "", "", "apitoken", "", false, false, "", nil)
+	if err != nil {
+		t.Fatalf("error setting up client: %s", err)
+	}
+
+	s.expire()
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := c.Volumes.CreateVolume(fmt.Sprintf("vol%d", i), 1024); err != nil {
+				t.Errorf("error creating volume: %s", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if s.sessions != 2 {
+		t.Errorf("expected 2 sessions to be started; got %d", s.sessions)
+	}
+}
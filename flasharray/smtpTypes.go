@@ -4,6 +4,13 @@
 
 package flasharray
 
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
 // SMTP struct for object returned by array
 type SMTP struct {
 	Password     string `json:"password,omitempty"`
@@ -11,3 +18,43 @@ type SMTP struct {
 	RelayHost    string `json:"relay_host,omitempty"`
 	SenderDomain string `json:"sender_domain,omitempty"`
 }
+
+// SetSMTPOptions struct for the changes of the SMTP settings made with
+// SetSMTP.  Nil fields are left unchanged, empty fields are removed.
+type SetSMTPOptions struct {
+	// RelayHost is the relay host, as host or host:port
+	RelayHost    *string `json:"relay_host,omitempty"`
+	SenderDomain *string `json:"sender_domain,omitempty"`
+	Username     *string `json:"user_name,omitempty"`
+	Password     *string `json:"password,omitempty"`
+}
+
+// Validate checks the relay host and the sender domain.
+func (o *SetSMTPOptions) Validate() error {
+	if o.RelayHost != nil && *o.RelayHost != "" {
+		if err := validateHostPort("SMTP relay host", *o.RelayHost); err != nil {
+			return err
+		}
+	}
+	if o.SenderDomain != nil && strings.ContainsAny(*o.SenderDomain, "@ ") {
+		return &PureError{Reason: fmt.Sprintf("[error] Invalid sender domain %q", *o.SenderDomain)}
+	}
+	return nil
+}
+
+// validateHostPort checks an address of a server, as host or host:port.
+func validateHostPort(name string, address string) error {
+	host, port := address, ""
+	if h, p, err := net.SplitHostPort(address); err == nil {
+		host, port = h, p
+	}
+	if host == "" || strings.ContainsAny(host, "/ @") {
+		return &PureError{Reason: fmt.Sprintf("[error] Invalid %s %q", name, address)}
+	}
+	if port != "" {
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return &PureError{Reason: fmt.Sprintf("[error] Invalid port of %s %q", name, address)}
+		}
+	}
+	return nil
+}
@@ -17,3 +17,27 @@ func TestAccGetSmtp(t *testing.T) {
 		t.Fatalf("error getting Smtp: %s", err)
 	}
 }
+
+func TestSMTPOptionsValidate(t *testing.T) {
+	s := func(s string) *string { return &s }
+	valid := []validator{
+		&SetSMTPOptions{RelayHost: s("smtp.example.com:587"), SenderDomain: s("example.com")},
+		&SetSMTPOptions{RelayHost: s("")},
+	}
+	for _, o := range valid {
+		if err := o.Validate(); err != nil {
+			t.Errorf("unexpected error for options %+v: %s", o, err)
+		}
+	}
+
+	invalid := []validator{
+		&SetSMTPOptions{RelayHost: s("smtp.example.com:smtp")},
+		&SetSMTPOptions{RelayHost: s("user@smtp.example.com")},
+		&SetSMTPOptions{SenderDomain: s("array@example.com")},
+	}
+	for _, o := range invalid {
+		if err := o.Validate(); err == nil {
+			t.Errorf("An Error was NOT raised for options %+v", o)
+		}
+	}
+}
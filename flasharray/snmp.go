@@ -22,7 +22,7 @@ func (s *SnmpService) ListSnmp(params map[string]string) ([]SnmpManager, error)
 	}
 
 	m := []SnmpManager{}
-	if _, err = s.client.Do(req, m, false); err != nil {
+	if _, err = s.client.Do(req, &m, false); err != nil {
 		return nil, err
 	}
 
@@ -4,6 +4,10 @@
 
 package flasharray
 
+import (
+	"fmt"
+)
+
 // SnmpManager struct for object returned by array
 type SnmpManager struct {
 	Name              string `json:"name"`
@@ -18,3 +22,98 @@ type SnmpManager struct {
 	AuthPassphrase    string `json:"auth_passphrase"`
 	EngineID          string `json:"engine_id"`
 }
+
+// SNMP versions, notifications and protocols
+const (
+	SnmpVersion2c = "v2c"
+	SnmpVersion3  = "v3"
+
+	SnmpNotificationInform = "inform"
+	SnmpNotificationTrap   = "trap"
+
+	SnmpAuthMD5 = "MD5"
+	SnmpAuthSHA = "SHA"
+	SnmpPrivAES = "AES"
+	SnmpPrivDES = "DES"
+)
+
+// CreateSnmpOptions struct for the attributes of an SNMP manager created
+// with CreateSnmp
+type CreateSnmpOptions struct {
+	// Host is the address of the manager, as host or host:port
+	Host         string `json:"host,omitempty"`
+	Version      string `json:"version,omitempty"`
+	Notification string `json:"notification,omitempty"`
+	// Community is the community of SNMP v2c
+	Community string `json:"community,omitempty"`
+	// User, and the authentication and privacy protocols and passphrases
+	// are the credentials of SNMP v3
+	User              string `json:"user,omitempty"`
+	AuthProtocol      string `json:"auth_protocol,omitempty"`
+	AuthPassphrase    string `json:"auth_passphrase,omitempty"`
+	PrivacyProtocol   string `json:"privacy_protocol,omitempty"`
+	PrivacyPassphrase string `json:"privacy_passphrase,omitempty"`
+}
+
+// Validate checks that the host is set, and the attributes.
+func (o *CreateSnmpOptions) Validate() error {
+	if o.Host == "" {
+		return &PureError{Reason: "[error] SNMP manager host is required"}
+	}
+	return o.validate()
+}
+
+func (o *CreateSnmpOptions) validate() error {
+	if o.Host != "" {
+		if err := validateHostPort("SNMP manager host", o.Host); err != nil {
+			return err
+		}
+	}
+	for _, a := range []struct {
+		name, value string
+		values      []string
+	}{
+		{"version", o.Version, []string{SnmpVersion2c, SnmpVersion3}},
+		{"notification", o.Notification, []string{SnmpNotificationInform, SnmpNotificationTrap}},
+		{"authentication protocol", o.AuthProtocol, []string{SnmpAuthMD5, SnmpAuthSHA}},
+		{"privacy protocol", o.PrivacyProtocol, []string{SnmpPrivAES, SnmpPrivDES}},
+	} {
+		if a.value != "" && !contains(a.values, a.value) {
+			return &PureError{Reason: fmt.Sprintf("[error] Invalid SNMP %s %s, must be one of %v", a.name, a.value, a.values)}
+		}
+	}
+	if o.Version == SnmpVersion2c && (o.User != "" || o.AuthProtocol != "" || o.PrivacyProtocol != "") {
+		return &PureError{Reason: "[error] SNMP v2c managers authenticate with a community, not a user"}
+	}
+	if o.Version == SnmpVersion3 && o.Community != "" {
+		return &PureError{Reason: "[error] SNMP v3 managers authenticate with a user, not a community"}
+	}
+	if o.AuthPassphrase != "" && (len(o.AuthPassphrase) < 8 || len(o.AuthPassphrase) > 32) {
+		return &PureError{Reason: "[error] SNMP authentication passphrase must be 8-32 characters"}
+	}
+	if o.PrivacyPassphrase != "" && (len(o.PrivacyPassphrase) < 8 || len(o.PrivacyPassphrase) > 63) {
+		return &PureError{Reason: "[error] SNMP privacy passphrase must be 8-63 characters"}
+	}
+	if o.PrivacyProtocol != "" && o.AuthProtocol == "" {
+		return &PureError{Reason: "[error] SNMP privacy protocol requires an authentication protocol"}
+	}
+	return nil
+}
+
+// SetSnmpOptions struct for the changes of an SNMP manager made with
+// SetSnmp.  Empty fields are left unchanged.
+type SetSnmpOptions struct {
+	// Name renames the manager
+	Name string `json:"name,omitempty"`
+	CreateSnmpOptions
+}
+
+// Validate checks the name and the attributes.
+func (o *SetSnmpOptions) Validate() error {
+	if o.Name != "" {
+		if err := validateName("SNMP manager", o.Name); err != nil {
+			return err
+		}
+	}
+	return o.CreateSnmpOptions.validate()
+}
@@ -58,3 +58,43 @@ func testAccDeleteSnmp(name string, c *Client) func(t *testing.T) {
 		}
 	}
 }
+
+func TestListSnmp(t *testing.T) {
+	c := testResponseClient(t, "snmp", `[{"name": "localhost", "host": "localhost", "version": "v2c"}]`)
+
+	l, err := c.Snmp.ListSnmp(nil)
+	if err != nil {
+		t.Fatalf("error listing SNMP managers: %s", err)
+	}
+	if len(l) != 1 || l[0].Name != "localhost" || l[0].Version != "v2c" {
+		t.Errorf("expected the SNMP manager localhost; got %+v", l)
+	}
+}
+
+func TestSnmpOptionsValidate(t *testing.T) {
+	valid := []validator{
+		&CreateSnmpOptions{Host: "snmp.example.com:162", Version: SnmpVersion2c, Community: "public"},
+		&CreateSnmpOptions{Host: "10.0.0.5", Version: SnmpVersion3, User: "pure", AuthProtocol: SnmpAuthSHA, AuthPassphrase: "passphrase", PrivacyProtocol: SnmpPrivAES, PrivacyPassphrase: "passphrase"},
+		&SetSnmpOptions{Name: "manager2"},
+	}
+	for _, o := range valid {
+		if err := o.Validate(); err != nil {
+			t.Errorf("unexpected error for options %+v: %s", o, err)
+		}
+	}
+
+	invalid := []validator{
+		&CreateSnmpOptions{Version: SnmpVersion2c},
+		&CreateSnmpOptions{Host: "snmp.example.com:0"},
+		&CreateSnmpOptions{Host: "snmp.example.com", Version: "v1"},
+		&CreateSnmpOptions{Host: "snmp.example.com", Version: SnmpVersion2c, User: "pure"},
+		&CreateSnmpOptions{Host: "snmp.example.com", AuthProtocol: SnmpAuthMD5, AuthPassphrase: "short"},
+		&CreateSnmpOptions{Host: "snmp.example.com", PrivacyProtocol: SnmpPrivDES},
+		&SetSnmpOptions{CreateSnmpOptions: CreateSnmpOptions{Notification: "email"}},
+	}
+	for _, o := range invalid {
+		if err := o.Validate(); err == nil {
+			t.Errorf("An Error was NOT raised for options %+v", o)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package flasharray
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// TLSConfig describes how the client verifies the certificate of the array,
+// and which certificate it presents to the array.  The same settings are used
+// for the REST API version negotiation and for the REST session.
+type TLSConfig struct {
+	// VerifyHTTPS enables verification of the certificate chain and host name
+	// of the array.  If false, any certificate is accepted unless CAFile,
+	// RootCAs or Fingerprint is set.
+	VerifyHTTPS bool
+
+	// CAFile is the path to a PEM encoded CA bundle used to verify the array.
+	// Setting it enables verification, as if VerifyHTTPS were set.
+	CAFile string
+
+	// RootCAs is the set of CAs used to verify the array.  Certificates read
+	// from CAFile are added to it.  If both are empty, the system roots are used.
+	// Setting it enables verification, as if VerifyHTTPS were set.
+	RootCAs *x509.CertPool
+
+	// Fingerprint is the SHA-256 fingerprint of the array certificate, in hex
+	// with or without colons.  If set, the connection is refused unless the
+	// certificate of the array matches, regardless of VerifyHTTPS.
+	Fingerprint string
+
+	// CertFile and KeyFile are the paths to a PEM encoded client certificate
+	// and private key presented to the array.
+	CertFile string
+	KeyFile  string
+
+	// Certificates are additional client certificates presented to the array.
+	Certificates []tls.Certificate
+}
+
+// Config returns the crypto/tls configuration described by t.  A nil TLSConfig
+// returns a configuration that does not verify the array.
+func (t *TLSConfig) Config() (*tls.Config, error) {
+	if t == nil {
+		return &tls.Config{InsecureSkipVerify: true}, nil
+	}
+
+	verify := t.VerifyHTTPS || t.RootCAs != nil || t.CAFile != ""
+	cfg := &tls.Config{InsecureSkipVerify: !verify}
+
+	if t.RootCAs != nil || t.CAFile != "" {
+		pool := t.RootCAs
+		if pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if t.CAFile != "" {
+			pem, err := ioutil.ReadFile(t.CAFile)
+			if err != nil {
+				return nil, err
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, &PureError{Reason: "[error] No certificates found in CA file " + t.CAFile}
+			}
+		}
+		cfg.RootCAs = pool
+	}
+
+	if t.CertFile != "" || t.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = append(cfg.Certificates, cert)
+	}
+	cfg.Certificates = append(cfg.Certificates, t.Certificates...)
+
+	if t.Fingerprint != "" {
+		fingerprint, err := parseFingerprint(t.Fingerprint)
+		if err != nil {
+			return nil, err
+		}
+		cfg.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 {
+				return &PureError{Reason: "[error] Array did not present a certificate"}
+			}
+			sum := sha256.Sum256(rawCerts[0])
+			if !bytes.Equal(sum[:], fingerprint) {
+				return &PureError{Reason: fmt.Sprintf("[error] Array certificate fingerprint %x does not match %x", sum, fingerprint)}
+			}
+			return nil
+		}
+	}
+
+	return cfg, nil
+}
+
+// parseFingerprint decodes a SHA-256 fingerprint such as "AB:CD:..." or "abcd...".
+func parseFingerprint(s string) ([]byte, error) {
+	b, err := hex.DecodeString(strings.Replace(s, ":", "", -1))
+	if err != nil || len(b) != sha256.Size {
+		return nil, &PureError{Reason: "[error] Invalid SHA-256 certificate fingerprint " + s}
+	}
+	return b, nil
+}
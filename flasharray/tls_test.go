@@ -0,0 +1,134 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package flasharray
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testServerFingerprint(s *testSessionServer) string {
+	sum := sha256.Sum256(s.Certificate().Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+func testWriteServerCA(t *testing.T, s *testSessionServer) string {
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.Certificate().Raw})
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("error writing CA file: %s", err)
+	}
+	return path
+}
+
+func testClientCertificate(t *testing.T) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating client key: %s", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "pureuser"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("error creating client certificate: %s", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestTLSVerifyHTTPS(t *testing.T) {
+	s := newTestSessionServer(t)
+
+	if _, err := NewClient(s.target(), "", "", "apitoken", "", true, false, "", nil); err == nil {
+		t.Errorf("An Error was NOT raised when the array certificate is not trusted")
+	}
+
+	kwargs := map[string]string{"ssl_cert": testWriteServerCA(t, s)}
+	if _, err := NewClient(s.target(), "", "", "apitoken", "", true, true, "", kwargs); err != nil {
+		t.Errorf("error setting up client with CA file: %s", err)
+	}
+
+	if _, err := NewClient(s.target(), "", "", "apitoken", "", false, false, "", nil); err != nil {
+		t.Errorf("error setting up client without verification: %s", err)
+	}
+}
+
+func TestTLSRootCAs(t *testing.T) {
+	s := newTestSessionServer(t)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(s.Certificate())
+	tlsConfig := &TLSConfig{VerifyHTTPS: true, RootCAs: pool}
+	if _, err := NewClientWithTLSConfig(context.Background(), s.target(), "", "", "apitoken", "", tlsConfig, "", nil); err != nil {
+		t.Errorf("error setting up client with RootCAs: %s", err)
+	}
+
+	// A CA enables verification without VerifyHTTPS
+	tlsConfig = &TLSConfig{RootCAs: x509.NewCertPool()}
+	if _, err := NewClientWithTLSConfig(context.Background(), s.target(), "", "", "apitoken", "", tlsConfig, "", nil); err == nil {
+		t.Errorf("An Error was NOT raised when the array certificate is not signed by RootCAs")
+	}
+	tlsConfig = &TLSConfig{CAFile: testWriteServerCA(t, s)}
+	if _, err := NewClientWithTLSConfig(context.Background(), s.target(), "", "", "apitoken", "", tlsConfig, "", nil); err != nil {
+		t.Errorf("error setting up client with CAFile: %s", err)
+	}
+	cfg, err := tlsConfig.Config()
+	if err != nil || cfg.InsecureSkipVerify {
+		t.Errorf("CAFile did not enable verification: %v", err)
+	}
+}
+
+func TestTLSFingerprint(t *testing.T) {
+	s := newTestSessionServer(t)
+
+	fingerprint := testServerFingerprint(s)
+	tlsConfig := &TLSConfig{Fingerprint: strings.ToUpper(fingerprint)}
+	if _, err := NewClientWithTLSConfig(context.Background(), s.target(), "", "", "apitoken", "", tlsConfig, "", nil); err != nil {
+		t.Errorf("error setting up client with a matching fingerprint: %s", err)
+	}
+
+	tlsConfig = &TLSConfig{Fingerprint: strings.Repeat("00:", 31) + "00"}
+	if _, err := NewClientWithTLSConfig(context.Background(), s.target(), "", "", "apitoken", "", tlsConfig, "", nil); err == nil {
+		t.Errorf("An Error was NOT raised when the array certificate fingerprint does not match")
+	}
+
+	tlsConfig = &TLSConfig{Fingerprint: "not a fingerprint"}
+	if _, err := tlsConfig.Config(); err == nil {
+		t.Errorf("An Error was NOT raised for an invalid fingerprint")
+	}
+}
+
+func TestTLSClientCertificate(t *testing.T) {
+	s := newUnstartedTestSessionServer(t)
+	s.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	s.StartTLS()
+
+	if _, err := NewClientWithTLSConfig(context.Background(), s.target(), "", "", "apitoken", "", nil, "", nil); err == nil {
+		t.Errorf("An Error was NOT raised when no client certificate was presented")
+	}
+
+	tlsConfig := &TLSConfig{Certificates: []tls.Certificate{testClientCertificate(t)}}
+	if _, err := NewClientWithTLSConfig(context.Background(), s.target(), "", "", "apitoken", "", tlsConfig, "", nil); err != nil {
+		t.Errorf("error setting up client with a client certificate: %s", err)
+	}
+}
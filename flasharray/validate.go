@@ -0,0 +1,91 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package flasharray
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+)
+
+// nameRegexp matches the names of the objects of the array.
+var nameRegexp = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]{0,62}$`)
+
+// validator is implemented by the data of requests checked client-side,
+// like the option structs of the create and set operations.
+type validator interface {
+	Validate() error
+}
+
+// validateData calls the Validate method of data, passed by value or by
+// pointer, if it has one.  A nil pointer is not validated.
+func validateData(data interface{}) error {
+	rv := reflect.ValueOf(data)
+	if !rv.IsValid() || (rv.Kind() == reflect.Ptr && rv.IsNil()) {
+		return nil
+	}
+	if v, ok := data.(validator); ok {
+		return v.Validate()
+	}
+	if rv.Kind() == reflect.Ptr {
+		return nil
+	}
+	p := reflect.New(rv.Type())
+	p.Elem().Set(rv)
+	if v, ok := p.Interface().(validator); ok {
+		return v.Validate()
+	}
+	return nil
+}
+
+// validateName checks the name of a new object of the given kind, i.e. "host".
+func validateName(kind string, name string) error {
+	if !nameRegexp.MatchString(name) {
+		return &PureError{Reason: fmt.Sprintf("[error] Invalid %s name %q, names must be 1-63 letters, numbers, '-' and '_', starting with a letter or number", kind, name)}
+	}
+	return nil
+}
+
+// validateNames checks that the names of a list of the given kind are not
+// empty or duplicated.
+func validateNames(kind string, names []string) error {
+	seen := make(map[string]bool)
+	for _, n := range names {
+		if n == "" {
+			return &PureError{Reason: fmt.Sprintf("[error] Empty %s name", kind)}
+		}
+		if seen[n] {
+			return &PureError{Reason: fmt.Sprintf("[error] Duplicate %s %s", kind, n)}
+		}
+		seen[n] = true
+	}
+	return nil
+}
+
+// validateListChange checks the changes of a list of the given kind: a list
+// replaced with set is not also added to or removed from, and no name is
+// both added and removed.
+func validateListChange(kind string, set *[]string, add []string, rem []string) error {
+	if set != nil && (len(add) > 0 || len(rem) > 0) {
+		return &PureError{Reason: fmt.Sprintf("[error] The %s list can either be replaced, or added to and removed from", kind)}
+	}
+	if set != nil {
+		if err := validateNames(kind, *set); err != nil {
+			return err
+		}
+	}
+	if err := validateNames(kind, add); err != nil {
+		return err
+	}
+	if err := validateNames(kind, rem); err != nil {
+		return err
+	}
+	for _, n := range add {
+		if contains(rem, n) {
+			return &PureError{Reason: fmt.Sprintf("[error] The %s %s is both added and removed", kind, n)}
+		}
+	}
+	return nil
+}
@@ -140,3 +140,50 @@ func (v *VgroupService) SetVgroup(name string, data interface{}) (*Vgroup, error
 
 	return m, err
 }
+
+// GetVgroupQoS gets the bandwidth and IOPS limits of a volume group
+func (v *VgroupService) GetVgroupQoS(name string) (*QoS, error) {
+	return getQoS(v.client, fmt.Sprintf("vgroup/%s", name))
+}
+
+// ListVgroupQoS lists the bandwidth and IOPS limits of the volume groups
+func (v *VgroupService) ListVgroupQoS(params map[string]string) ([]QoS, error) {
+	return listQoS(v.client, "vgroup", params)
+}
+
+// SetVgroupQoS replaces the bandwidth and IOPS limits of a volume group with
+// those of qos, which are validated first.  Zero limits are removed.
+func (v *VgroupService) SetVgroupQoS(name string, qos QoS) (*QoS, error) {
+
+	if err := qos.Validate(); err != nil {
+		return nil, err
+	}
+	return setQoS(v.client, fmt.Sprintf("vgroup/%s", name), qos.limits())
+}
+
+// SetVgroupBandwidthLimit sets the bandwidth limit of a volume group, keeping its
+// IOPS limit.  A zero limit removes it.
+func (v *VgroupService) SetVgroupBandwidthLimit(name string, limit Bandwidth) (*QoS, error) {
+
+	if err := validateBandwidthLimit(limit); err != nil {
+		return nil, err
+	}
+	data := map[string]interface{}{"bandwidth_limit": qosLimit(int64(limit))}
+	return setQoS(v.client, fmt.Sprintf("vgroup/%s", name), data)
+}
+
+// SetVgroupIopsLimit sets the IOPS limit of a volume group, keeping its bandwidth
+// limit.  A zero limit removes it.
+func (v *VgroupService) SetVgroupIopsLimit(name string, limit IOPS) (*QoS, error) {
+
+	if err := validateIopsLimit(limit); err != nil {
+		return nil, err
+	}
+	data := map[string]interface{}{"iops_limit": qosLimit(int64(limit))}
+	return setQoS(v.client, fmt.Sprintf("vgroup/%s", name), data)
+}
+
+// ClearVgroupQoS removes the bandwidth and IOPS limits of a volume group
+func (v *VgroupService) ClearVgroupQoS(name string) (*QoS, error) {
+	return setQoS(v.client, fmt.Sprintf("vgroup/%s", name), QoS{})
+}
@@ -9,6 +9,9 @@ type Vgroup struct {
 	Name    string   `json:"name"`
 	Volumes []string `json:"volumes"`
 
+	// TimeRemaining is returned for destroyed volume groups, in seconds
+	TimeRemaining *int `json:"time_remaining,omitempty"`
+
 	// Metrics returned with the action=monitor flag
 	WritesPerSec      *int   `json:"writes_per_sec,omitempty"`
 	ReadsPerSec       *int   `json:"reads_per_sec,omitempty"`
@@ -220,6 +220,20 @@ func (v *VolumeService) MonitorVolume(name string, params map[string]string) ([]
 	return m, err
 }
 
+// GetVolumePerformanceHistory returns the performance samples of the volume
+// over the historical window, one of HistoricalWindows, or the current
+// sample if historical is empty
+func (v *VolumeService) GetVolumePerformanceHistory(name string, historical string) (PerformanceHistory, error) {
+	return v.client.getPerformanceHistory(fmt.Sprintf("volume/%s", name), historical)
+}
+
+// GetVolumeSpaceHistory returns the space samples of the volume over the
+// historical window, one of HistoricalWindows, or the current sample if
+// historical is empty
+func (v *VolumeService) GetVolumeSpaceHistory(name string, historical string) (SpaceHistory, error) {
+	return v.client.getSpaceHistory(fmt.Sprintf("volume/%s", name), historical)
+}
+
 // AddVolume adds a volume to a protection group
 func (v *VolumeService) AddVolume(volume string, pgroup string) (*VolumePgroup, error) {
 
@@ -351,3 +365,172 @@ func (v *VolumeService) MoveVolume(name string, container string) (*Volume, erro
 
 	return m, err
 }
+
+// GetVolumeQoS gets the bandwidth and IOPS limits of a volume
+func (v *VolumeService) GetVolumeQoS(name string) (*QoS, error) {
+	return getQoS(v.client, fmt.Sprintf("volume/%s", name))
+}
+
+// ListVolumeQoS lists the bandwidth and IOPS limits of the volumes
+func (v *VolumeService) ListVolumeQoS(params map[string]string) ([]QoS, error) {
+	return listQoS(v.client, "volume", params)
+}
+
+// SetVolumeQoS replaces the bandwidth and IOPS limits of a volume with
+// those of qos, which are validated first.  Zero limits are removed.
+func (v *VolumeService) SetVolumeQoS(name string, qos QoS) (*QoS, error) {
+
+	if err := qos.Validate(); err != nil {
+		return nil, err
+	}
+	return setQoS(v.client, fmt.Sprintf("volume/%s", name), qos.limits())
+}
+
+// SetVolumeBandwidthLimit sets the bandwidth limit of a volume, keeping its
+// IOPS limit.  A zero limit removes it.
+func (v *VolumeService) SetVolumeBandwidthLimit(name string, limit Bandwidth) (*QoS, error) {
+
+	if err := validateBandwidthLimit(limit); err != nil {
+		return nil, err
+	}
+	data := map[string]interface{}{"bandwidth_limit": qosLimit(int64(limit))}
+	return setQoS(v.client, fmt.Sprintf("volume/%s", name), data)
+}
+
+// SetVolumeIopsLimit sets the IOPS limit of a volume, keeping its bandwidth
+// limit.  A zero limit removes it.
+func (v *VolumeService) SetVolumeIopsLimit(name string, limit IOPS) (*QoS, error) {
+
+	if err := validateIopsLimit(limit); err != nil {
+		return nil, err
+	}
+	data := map[string]interface{}{"iops_limit": qosLimit(int64(limit))}
+	return setQoS(v.client, fmt.Sprintf("volume/%s", name), data)
+}
+
+// ClearVolumeQoS removes the bandwidth and IOPS limits of a volume
+func (v *VolumeService) ClearVolumeQoS(name string) (*QoS, error) {
+	return setQoS(v.client, fmt.Sprintf("volume/%s", name), QoS{})
+}
+
+// GetVolumeSnapshot gets a volume snapshot
+func (v *VolumeService) GetVolumeSnapshot(name string) (*VolumeSnapshot, error) {
+
+	if !isVolumeSnapshot(name) {
+		return nil, &PureError{Reason: fmt.Sprintf("[error] %s is not a volume snapshot", name)}
+	}
+	path := fmt.Sprintf("volume/%s", name)
+	req, err := v.client.NewRequest("GET", path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &VolumeSnapshot{}
+	_, err = v.client.Do(req, m, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, err
+}
+
+// ListVolumeSnapshots lists the snapshots of a volume, or of every volume if
+// volume is empty, selected by filter if it is not nil
+func (v *VolumeService) ListVolumeSnapshots(volume string, filter *VolumeSnapshotFilter) ([]VolumeSnapshot, error) {
+
+	if filter == nil {
+		filter = &VolumeSnapshotFilter{}
+	}
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	path := "volume"
+	if volume != "" {
+		path = fmt.Sprintf("volume/%s", volume)
+	}
+	params := map[string]string{"snap": "true"}
+	if filter.PendingOnly {
+		params["pending_only"] = "true"
+	} else if filter.Pending {
+		params["pending"] = "true"
+	}
+
+	req, err := v.client.NewRequest("GET", path, params, nil)
+	if err != nil {
+		return nil, err
+	}
+	snaps := []VolumeSnapshot{}
+	_, err = v.client.Do(req, &snaps, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var space map[string]*int
+	if filter.Space {
+		params["space"] = "true"
+		req, err := v.client.NewRequest("GET", path, params, nil)
+		if err != nil {
+			return nil, err
+		}
+		l := []VolumeSnapshot{}
+		_, err = v.client.Do(req, &l, false)
+		if err != nil {
+			return nil, err
+		}
+		space = make(map[string]*int)
+		for _, s := range l {
+			space[s.Name] = s.Space
+		}
+	}
+
+	m := []VolumeSnapshot{}
+	for _, s := range snaps {
+		if filter.Match(&s) {
+			if space != nil {
+				s.Space = space[s.Name]
+			}
+			m = append(m, s)
+		}
+	}
+
+	return m, nil
+}
+
+// DestroyVolumeSnapshots destroys volume snapshots.  They can be recovered
+// until they are eradicated.  The snapshots destroyed before an error are
+// returned with it.
+func (v *VolumeService) DestroyVolumeSnapshots(names []string) ([]string, error) {
+	return eachVolumeSnapshot(names, v.DeleteVolume)
+}
+
+// RecoverVolumeSnapshots recovers destroyed volume snapshots.  The snapshots
+// recovered before an error are returned with it.
+func (v *VolumeService) RecoverVolumeSnapshots(names []string) ([]string, error) {
+	return eachVolumeSnapshot(names, v.RecoverVolume)
+}
+
+// EradicateVolumeSnapshots eradicates destroyed volume snapshots.  The
+// snapshots eradicated before an error are returned with it.
+func (v *VolumeService) EradicateVolumeSnapshots(names []string) ([]string, error) {
+	return eachVolumeSnapshot(names, v.EradicateVolume)
+}
+
+// eachVolumeSnapshot applies f to the snapshots names, once they are all
+// checked to be snapshots so that no volume is changed by mistake.
+func eachVolumeSnapshot(names []string, f func(name string) (*Volume, error)) ([]string, error) {
+	for _, name := range names {
+		if !isVolumeSnapshot(name) {
+			return nil, &PureError{Reason: fmt.Sprintf("[error] %s is not a volume snapshot", name)}
+		}
+	}
+
+	m := []string{}
+	for _, name := range names {
+		if _, err := f(name); err != nil {
+			return m, err
+		}
+		m = append(m, name)
+	}
+
+	return m, nil
+}
@@ -4,6 +4,13 @@
 
 package flasharray
 
+import (
+	"encoding/json"
+	"path"
+	"strings"
+	"time"
+)
+
 // Volume struct for object returned by array
 type Volume struct {
 	Name    string `json:"name,omitempty"`
@@ -12,6 +19,9 @@ type Volume struct {
 	Size    int    `json:"size,omitempty"`
 	Created string `json:"created,omitempty"`
 
+	// TimeRemaining is returned for destroyed volumes, in seconds
+	TimeRemaining *int `json:"time_remaining,omitempty"`
+
 	// Metrics returned with the action=monitor flag
 	WritesPerSec      *int   `json:"writes_per_sec,omitempty"`
 	ReadsPerSec       *int   `json:"reads_per_sec,omitempty"`
@@ -59,3 +69,115 @@ type Block struct {
 	Length int `json:"length,omitempty"`
 	Offset int `json:"offset,omitempty"`
 }
+
+// VolumeSnapshot struct for a volume snapshot returned by array
+type VolumeSnapshot struct {
+	Name    string    `json:"name"`
+	Source  string    `json:"source"`
+	Serial  string    `json:"serial"`
+	Size    int       `json:"size"`
+	Created time.Time `json:"created"`
+
+	// Space is the physical space of the snapshot, in bytes, returned
+	// when listing snapshots with space
+	Space *int `json:"total,omitempty"`
+
+	// TimeRemaining is returned for destroyed snapshots, in seconds
+	TimeRemaining *int `json:"time_remaining,omitempty"`
+}
+
+// UnmarshalJSON decodes the creation time of the snapshot.
+func (s *VolumeSnapshot) UnmarshalJSON(data []byte) error {
+	type snapshot VolumeSnapshot
+	v := struct {
+		*snapshot
+		Created string `json:"created"`
+	}{snapshot: (*snapshot)(s)}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	s.Created = time.Time{}
+	if v.Created != "" {
+		t, err := time.Parse(time.RFC3339, v.Created)
+		if err != nil {
+			return err
+		}
+		s.Created = t
+	}
+	return nil
+}
+
+// Suffix returns the suffix of the snapshot, i.e. "1" for "vol1.1".
+func (s *VolumeSnapshot) Suffix() string {
+	return strings.TrimPrefix(s.Name, s.Source+".")
+}
+
+// Destroyed reports whether the snapshot is destroyed, pending eradication.
+func (s *VolumeSnapshot) Destroyed() bool {
+	return s.TimeRemaining != nil
+}
+
+// VolumeSnapshotNames returns the names of the snapshots l.
+func VolumeSnapshotNames(l []VolumeSnapshot) []string {
+	names := make([]string, len(l))
+	for i, s := range l {
+		names[i] = s.Name
+	}
+	return names
+}
+
+// isVolumeSnapshot reports whether name is the name of a volume snapshot,
+// that is the name of a volume followed by a suffix.
+func isVolumeSnapshot(name string) bool {
+	i := strings.LastIndex(name, ".")
+	return i > 0 && i < len(name)-1 && !strings.HasSuffix(name[:i], ":")
+}
+
+// snapshotVolume returns the name of the volume of the snapshot name, or name
+// if it is the name of a volume.
+func snapshotVolume(name string) string {
+	if !isVolumeSnapshot(name) {
+		return name
+	}
+	return name[:strings.LastIndex(name, ".")]
+}
+
+// VolumeSnapshotFilter struct for the selection of the snapshots listed by
+// ListVolumeSnapshots.  Zero fields select every snapshot.
+type VolumeSnapshotFilter struct {
+	// Suffix is a pattern of the suffixes of the snapshots, as of path.Match,
+	// i.e. "daily-*"
+	Suffix string
+	// CreatedAfter and CreatedBefore bound the creation time of the snapshots
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	// Pending also lists the destroyed snapshots, and PendingOnly only them
+	Pending     bool
+	PendingOnly bool
+	// Space requests the physical space of the snapshots
+	Space bool
+}
+
+// Validate checks that the suffix pattern is well formed.
+func (f *VolumeSnapshotFilter) Validate() error {
+	if _, err := path.Match(f.Suffix, ""); err != nil {
+		return &PureError{Reason: "[error] Invalid suffix pattern " + f.Suffix}
+	}
+	return nil
+}
+
+// Match reports whether the snapshot s is selected by the filter.
+func (f *VolumeSnapshotFilter) Match(s *VolumeSnapshot) bool {
+	if f.Suffix != "" {
+		if ok, _ := path.Match(f.Suffix, s.Suffix()); !ok {
+			return false
+		}
+	}
+	if !f.CreatedAfter.IsZero() && !s.Created.After(f.CreatedAfter) {
+		return false
+	}
+	if !f.CreatedBefore.IsZero() && !s.Created.Before(f.CreatedBefore) {
+		return false
+	}
+	return true
+}
@@ -7,6 +7,7 @@ package flasharray
 import (
 	"fmt"
 	"testing"
+	"time"
 )
 
 const testAccVolumeName = "testAccvolume"
@@ -17,8 +18,7 @@ const testvolresize = 2048000000
 const testpgroup = "testacchostpgroup"
 
 func TestAccVolumes(t *testing.T) {
-	testAccPreChecks(t)
-	c := testAccGenerateClient(t)
+	c := testAccClient(t)
 
 	c.Protectiongroups.CreateProtectiongroup(testpgroup, nil)
 
@@ -227,3 +227,112 @@ func testAccEradicateVolume(c *Client) func(t *testing.T) {
 		}
 	}
 }
+
+func TestVolumeSnapshots(t *testing.T) {
+	s, c := testFakeArray(t)
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.Now = func() time.Time { return now }
+
+	c.Volumes.CreateVolume("vol1", testvolsize)
+	c.Volumes.CreateVolume("vol2", testvolsize)
+	for _, suffix := range []string{"daily-1", "daily-2", "weekly-1"} {
+		if _, err := c.Volumes.CreateSnapshots([]string{"vol1", "vol2"}, suffix); err != nil {
+			t.Fatalf("error creating snapshots: %s", err)
+		}
+		now = now.Add(time.Hour)
+	}
+
+	t.Run("GetVolumeSnapshot", testGetVolumeSnapshot(c))
+	t.Run("ListVolumeSnapshots", testListVolumeSnapshots(c))
+	t.Run("DestroyVolumeSnapshots", testDestroyVolumeSnapshots(c))
+	t.Run("RecoverVolumeSnapshots", testRecoverVolumeSnapshots(c))
+	t.Run("EradicateVolumeSnapshots", testEradicateVolumeSnapshots(c))
+}
+
+func testGetVolumeSnapshot(c *Client) func(t *testing.T) {
+	return func(t *testing.T) {
+		if _, err := c.Volumes.GetVolumeSnapshot("vol1"); err == nil {
+			t.Errorf("An Error was NOT raised for a volume")
+		}
+		snap, err := c.Volumes.GetVolumeSnapshot("vol1.daily-2")
+		if err != nil {
+			t.Fatalf("error getting snapshot: %s", err)
+		}
+		created := time.Date(2020, 1, 1, 1, 0, 0, 0, time.UTC)
+		if snap.Source != "vol1" || snap.Suffix() != "daily-2" || !snap.Created.Equal(created) || snap.Size != testvolsize || snap.Destroyed() {
+			t.Fatalf("unexpected snapshot: %+v", snap)
+		}
+	}
+}
+
+func testListVolumeSnapshots(c *Client) func(t *testing.T) {
+	return func(t *testing.T) {
+		l, err := c.Volumes.ListVolumeSnapshots("vol1", nil)
+		if err != nil {
+			t.Fatalf("error listing snapshots: %s", err)
+		}
+		if len(l) != 3 || l[0].Name != "vol1.daily-1" {
+			t.Fatalf("expected the 3 snapshots of vol1; got %+v", l)
+		}
+
+		filter := &VolumeSnapshotFilter{Suffix: "daily-*", CreatedAfter: time.Date(2020, 1, 1, 0, 30, 0, 0, time.UTC), Space: true}
+		if l, err = c.Volumes.ListVolumeSnapshots("", filter); err != nil {
+			t.Fatalf("error listing snapshots: %s", err)
+		}
+		if names := VolumeSnapshotNames(l); len(names) != 2 || names[0] != "vol1.daily-2" || names[1] != "vol2.daily-2" || l[0].Space == nil {
+			t.Fatalf("expected the daily-2 snapshots with their space; got %+v", l)
+		}
+
+		if _, err := c.Volumes.ListVolumeSnapshots("", &VolumeSnapshotFilter{Suffix: "["}); err == nil {
+			t.Errorf("An Error was NOT raised for an invalid suffix pattern")
+		}
+	}
+}
+
+func testDestroyVolumeSnapshots(c *Client) func(t *testing.T) {
+	return func(t *testing.T) {
+		if _, err := c.Volumes.DestroyVolumeSnapshots([]string{"vol1.daily-1", "vol2"}); err == nil {
+			t.Errorf("An Error was NOT raised for destroying a volume")
+		}
+		l, err := c.Volumes.ListVolumeSnapshots("", &VolumeSnapshotFilter{Suffix: "daily-*"})
+		if err != nil {
+			t.Fatalf("error listing snapshots: %s", err)
+		}
+		if _, err := c.Volumes.DestroyVolumeSnapshots(VolumeSnapshotNames(l)); err != nil {
+			t.Fatalf("error destroying snapshots: %s", err)
+		}
+		if l, err = c.Volumes.ListVolumeSnapshots("vol1", &VolumeSnapshotFilter{PendingOnly: true}); err != nil || len(l) != 2 || !l[0].Destroyed() {
+			t.Fatalf("expected the 2 destroyed snapshots of vol1; got %+v, %v", l, err)
+		}
+		if l, err = c.Volumes.ListVolumeSnapshots("vol1", nil); err != nil || len(l) != 1 || l[0].Suffix() != "weekly-1" {
+			t.Fatalf("expected the weekly snapshot of vol1; got %+v, %v", l, err)
+		}
+	}
+}
+
+func testRecoverVolumeSnapshots(c *Client) func(t *testing.T) {
+	return func(t *testing.T) {
+		names, err := c.Volumes.RecoverVolumeSnapshots([]string{"vol1.daily-2", "vol1.weekly-1"})
+		if err == nil {
+			t.Errorf("An Error was NOT raised for recovering a snapshot that is not destroyed")
+		}
+		if len(names) != 1 || names[0] != "vol1.daily-2" {
+			t.Fatalf("expected vol1.daily-2 to be recovered before the error; got %v", names)
+		}
+	}
+}
+
+func testEradicateVolumeSnapshots(c *Client) func(t *testing.T) {
+	return func(t *testing.T) {
+		if _, err := c.Volumes.EradicateVolumeSnapshots([]string{"vol1.daily-1", "vol2.daily-1", "vol2.daily-2"}); err != nil {
+			t.Fatalf("error eradicating snapshots: %s", err)
+		}
+		l, err := c.Volumes.ListVolumeSnapshots("", &VolumeSnapshotFilter{Pending: true})
+		if err != nil {
+			t.Fatalf("error listing snapshots: %s", err)
+		}
+		if names := VolumeSnapshotNames(l); len(names) != 3 || names[0] != "vol1.daily-2" {
+			t.Fatalf("expected the remaining snapshots; got %v", names)
+		}
+	}
+}
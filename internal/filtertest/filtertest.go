@@ -0,0 +1,59 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+// Package filtertest holds the test cases of the filter builders of the
+// flasharray and pure1 packages, which cannot import each other, so that
+// both copies of the builders format the same expressions.
+package filtertest
+
+// Builders of the filter expressions
+const (
+	Eq  = "eq"
+	Ne  = "ne"
+	Gt  = "gt"
+	Lt  = "lt"
+	And = "and"
+	Or  = "or"
+	Not = "not"
+)
+
+// Expr struct for a filter expression, built with the builder Op from
+// Field and Value, or from the expressions Args
+type Expr struct {
+	Op    string
+	Field string
+	Value interface{}
+	Args  []Expr
+}
+
+// Case struct for a filter expression and its expected formatting
+type Case struct {
+	Name     string
+	Expr     Expr
+	Expected string
+}
+
+// Cases are the test cases of the filter builders.
+var Cases = []Case{
+	{"string", Expr{Op: Eq, Field: "name", Value: "vol*"}, `name='vol*'`},
+	{"quote", Expr{Op: Eq, Field: "name", Value: "it's"}, `name='it\'s'`},
+	{"backslash", Expr{Op: Eq, Field: "name", Value: `it\'s`}, `name='it\\\'s'`},
+	{"int", Expr{Op: Gt, Field: "size", Value: 1 << 40}, `size>1099511627776`},
+	{"float", Expr{Op: Lt, Field: "data_reduction", Value: 2.5}, `data_reduction<2.5`},
+	{"bool", Expr{Op: Eq, Field: "destroyed", Value: true}, `destroyed=true`},
+	{"null", Expr{Op: Ne, Field: "pod", Value: nil}, `pod!=null`},
+	{"not", Expr{Op: Not, Args: []Expr{{Op: Eq, Field: "name", Value: "vol*"}}}, `not(name='vol*')`},
+	{"single", Expr{Op: And, Args: []Expr{{Op: Eq, Field: "name", Value: "vol1"}}}, `name='vol1'`},
+	{"and", Expr{Op: And, Args: []Expr{
+		{Op: Eq, Field: "name", Value: "vol*"},
+		{Op: Gt, Field: "size", Value: 1024},
+	}}, `(name='vol*') and (size>1024)`},
+	{"nested", Expr{Op: Or, Args: []Expr{
+		{Op: Eq, Field: "name", Value: "it's"},
+		{Op: And, Args: []Expr{
+			{Op: Gt, Field: "size", Value: 10},
+			{Op: Not, Args: []Expr{{Op: Ne, Field: "pod", Value: nil}}},
+		}},
+	}}, `(name='it\'s') or ((size>10) and (not(pod!=null)))`},
+}
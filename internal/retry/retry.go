@@ -0,0 +1,175 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+// Package retry holds the retry policies of the flasharray and pure1
+// clients, which cannot import each other, so that both retry transient
+// errors the same way.
+package retry
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Policy decides whether a request that failed with a transient error is
+// sent again.  Retry is called after every failed attempt with the response or
+// the error of the HTTP client, and the number of attempts made so far.  It
+// returns how long to wait before the next attempt, and false to give up.
+type Policy interface {
+	Retry(req *http.Request, resp *http.Response, err error, attempt int) (time.Duration, bool)
+}
+
+// ExponentialBackoff is a Policy that waits exponentially longer between
+// attempts, with random jitter.
+//
+// Requests with idempotent methods (GET, HEAD, OPTIONS, PUT and DELETE) are
+// retried when the server responds with one of RetryableStatusCodes, or when
+// the request fails with a network error.  Other requests, i.e. the POST of
+// CreateVolume, are only retried when the server certainly did not process
+// them: when the connection could not be established, or when the server
+// responded with 429 Too Many Requests.  Set RetryNonIdempotent to retry them
+// like idempotent requests.
+type ExponentialBackoff struct {
+	// MaxAttempts is the maximum number of attempts, including the first one.
+	MaxAttempts int
+	// InitialBackoff is the wait before the second attempt.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between attempts, including waits requested
+	// by the server with a Retry-After header.
+	MaxBackoff time.Duration
+	// Multiplier is the factor the wait grows by after every attempt.
+	Multiplier float64
+	// Jitter is the fraction of the wait, between 0 and 1, that is randomized.
+	Jitter float64
+	// RetryableStatusCodes are the status codes of transient errors.
+	RetryableStatusCodes []int
+	// RetryNonIdempotent retries requests with non-idempotent methods like
+	// idempotent requests.
+	RetryNonIdempotent bool
+}
+
+// Default returns the default retry policy of the clients: 4 attempts,
+// starting with a wait of 500ms, for 429, 502, 503 and 504 responses.
+func Default() *ExponentialBackoff {
+	return &ExponentialBackoff{
+		MaxAttempts:          4,
+		InitialBackoff:       500 * time.Millisecond,
+		MaxBackoff:           30 * time.Second,
+		Multiplier:           2,
+		Jitter:               0.2,
+		RetryableStatusCodes: []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+	}
+}
+
+// Retry implements Policy.
+func (b *ExponentialBackoff) Retry(req *http.Request, resp *http.Response, err error, attempt int) (time.Duration, bool) {
+	if attempt >= b.MaxAttempts || !Replayable(req) {
+		return 0, false
+	}
+
+	idempotent := b.RetryNonIdempotent || isIdempotent(req.Method)
+	switch {
+	case err != nil:
+		if req.Context().Err() != nil {
+			return 0, false
+		}
+		if !idempotent && !isDialError(err) {
+			return 0, false
+		}
+	case resp.StatusCode == http.StatusTooManyRequests:
+		if !b.retryableStatus(resp.StatusCode) {
+			return 0, false
+		}
+	default:
+		if !idempotent || !b.retryableStatus(resp.StatusCode) {
+			return 0, false
+		}
+	}
+
+	if wait, ok := retryAfter(resp); ok {
+		return b.cap(wait), true
+	}
+	return b.backoff(attempt), true
+}
+
+// backoff returns the wait after attempt attempts.
+func (b *ExponentialBackoff) backoff(attempt int) time.Duration {
+	multiplier := b.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	wait := float64(b.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if b.Jitter > 0 {
+		wait += wait * b.Jitter * (2*rand.Float64() - 1)
+	}
+	return b.cap(time.Duration(wait))
+}
+
+func (b *ExponentialBackoff) cap(wait time.Duration) time.Duration {
+	if b.MaxBackoff > 0 && wait > b.MaxBackoff {
+		return b.MaxBackoff
+	}
+	return wait
+}
+
+func (b *ExponentialBackoff) retryableStatus(code int) bool {
+	for _, c := range b.RetryableStatusCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// isIdempotent reports whether requests with method can be safely replayed.
+func isIdempotent(method string) bool {
+	switch method {
+	case "GET", "HEAD", "OPTIONS", "PUT", "DELETE":
+		return true
+	}
+	return false
+}
+
+// Replayable reports whether the body of req can be sent again.
+func Replayable(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// isDialError reports whether err was returned before the connection to the
+// server was established, so the request was never sent.
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// retryAfter returns the wait requested by the Retry-After header of resp,
+// in seconds.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// Sleep waits for d, or until ctx is done.
+func Sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
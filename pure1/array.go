@@ -25,6 +25,24 @@ func (a *ArrayService) GetArrays(params map[string]string) ([]Array, error) {
 	return m, err
 }
 
+// Pages calls fn with the pages of the FlashArray and FlashBlade objects
+// matching params, as described in PageInfo.
+func (a *ArrayService) Pages(params map[string]string, fn func(page []Array, info PageInfo) bool) error {
+	return a.client.pages("arrays", params, func() interface{} { return &[]Array{} }, func(page interface{}, info PageInfo) bool {
+		return fn(*page.(*[]Array), info)
+	})
+}
+
+// ListAll returns the FlashArray and FlashBlade objects matching params, from
+// all the pages of the list, as described in PageInfo.
+func (a *ArrayService) ListAll(params map[string]string) ([]Array, error) {
+	m := []Array{}
+	if err := a.client.listAll("arrays", params, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // GetTags returns a list of tags on arrays
 func (a *ArrayService) GetTags(params map[string]string) ([]Tag, error) {
 	req, err := a.client.NewRequest("GET", "arrays/tags", params, nil)
@@ -41,6 +59,24 @@ func (a *ArrayService) GetTags(params map[string]string) ([]Tag, error) {
 	return m, err
 }
 
+// TagPages calls fn with the pages of the tags on arrays matching params, as
+// described in PageInfo.
+func (a *ArrayService) TagPages(params map[string]string, fn func(page []Tag, info PageInfo) bool) error {
+	return a.client.pages("arrays/tags", params, func() interface{} { return &[]Tag{} }, func(page interface{}, info PageInfo) bool {
+		return fn(*page.(*[]Tag), info)
+	})
+}
+
+// ListAllTags returns the tags on arrays matching params, from all the pages of
+// the list, as described in PageInfo.
+func (a *ArrayService) ListAllTags(params map[string]string) ([]Tag, error) {
+	m := []Tag{}
+	if err := a.client.listAll("arrays/tags", params, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // DeleteTags returns a list of tags on arrays
 // params resource_id or resource_names is required
 // both are comma-separated lists of tags to be deleted
@@ -9,11 +9,12 @@ import (
 )
 
 func TestPure1Array(t *testing.T) {
-	testAccPreChecks(t)
-	c := testAccGenerateClient(t)
+	c := testAccClient(t)
 
 	t.Run("GetArrays", testPure1GetArrays(c))
 	t.Run("GetTags", testPure1GetTags(c))
+	t.Run("ListAllArrays", testPure1ListAllArrays(c))
+	t.Run("ListAllTags", testPure1ListAllTags(c))
 }
 
 func testPure1GetArrays(c *Client) func(t *testing.T) {
@@ -33,3 +34,21 @@ func testPure1GetTags(c *Client) func(t *testing.T) {
 		}
 	}
 }
+
+func testPure1ListAllArrays(c *Client) func(t *testing.T) {
+	return func(t *testing.T) {
+		_, err := c.Arrays.ListAll(map[string]string{"limit": "1"})
+		if err != nil {
+			t.Fatalf("error listing all arrays: %s", err)
+		}
+	}
+}
+
+func testPure1ListAllTags(c *Client) func(t *testing.T) {
+	return func(t *testing.T) {
+		_, err := c.Arrays.ListAllTags(map[string]string{"limit": "1"})
+		if err != nil {
+			t.Fatalf("error listing all tags: %s", err)
+		}
+	}
+}
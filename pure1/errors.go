@@ -0,0 +1,142 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pure1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// PureError is the base error returned by the library.
+type PureError struct {
+	Reason string
+}
+
+func (e *PureError) Error() string {
+	return e.Reason
+}
+
+// PureHTTPError is returned when the Pure1 API responds with a status code
+// outside the 200 range.
+//
+// A PureHTTPError is also a PureError; errors.As will match either type.
+type PureHTTPError struct {
+	PureError
+
+	// RestVersion is the REST API version of the request
+	RestVersion string
+	// Method and URL of the failed request
+	Method string
+	URL    string
+	// Code is the HTTP status code of the response
+	Code int
+	// Text is the raw body of the response
+	Text string
+	// Errors is the list of error messages parsed from the body of the response
+	Errors []ErrorMessage
+}
+
+// ErrorMessage is a single error reported by the Pure1 API in the body of a
+// failed response.
+type ErrorMessage struct {
+	Context string `json:"context,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+func (e *PureHTTPError) Error() string {
+	msg := fmt.Sprintf("PureHTTPError status code %d returned by REST version %s at %s: %s", e.Code, e.RestVersion, e.URL, e.Reason)
+	for _, m := range e.Errors {
+		if m.Context != "" {
+			msg += fmt.Sprintf("\n%s: %s", m.Context, m.Message)
+		} else {
+			msg += fmt.Sprintf("\n%s", m.Message)
+		}
+	}
+	if len(e.Errors) == 0 && e.Text != "" {
+		msg += "\n" + e.Text
+	}
+	return msg
+}
+
+// As allows errors.As to match a PureHTTPError with a target of type **PureError.
+func (e *PureHTTPError) As(target interface{}) bool {
+	if p, ok := target.(**PureError); ok {
+		*p = &e.PureError
+		return true
+	}
+	return false
+}
+
+// hasMessage reports whether any of the error messages contains s.
+func (e *PureHTTPError) hasMessage(s string) bool {
+	for _, m := range e.Errors {
+		if strings.Contains(strings.ToLower(m.Message), s) {
+			return true
+		}
+	}
+	return false
+}
+
+// newPureHTTPError builds a PureHTTPError from the response r.  The body of the
+// response is parsed for the list of error messages, or for the OAuth error
+// returned by the token exchange.
+func newPureHTTPError(r *http.Response, restVersion string, body []byte) *PureHTTPError {
+	e := &PureHTTPError{
+		PureError:   PureError{Reason: http.StatusText(r.StatusCode)},
+		RestVersion: restVersion,
+		Code:        r.StatusCode,
+		Text:        string(body),
+	}
+	if r.Request != nil {
+		e.Method = r.Request.Method
+		e.URL = r.Request.URL.String()
+	}
+
+	var resp struct {
+		Errors           []ErrorMessage `json:"errors,omitempty"`
+		Error            string         `json:"error,omitempty"`
+		ErrorDescription string         `json:"error_description,omitempty"`
+	}
+	if err := json.Unmarshal(body, &resp); err == nil {
+		e.Errors = resp.Errors
+		if resp.Error != "" {
+			e.Errors = append(e.Errors, ErrorMessage{Context: resp.Error, Message: resp.ErrorDescription})
+		}
+	}
+	return e
+}
+
+// IsNotFound reports whether err was caused by the Pure1 API not finding the
+// requested resource.
+func IsNotFound(err error) bool {
+	var e *PureHTTPError
+	if !errors.As(err, &e) {
+		return false
+	}
+	return e.Code == http.StatusNotFound || e.hasMessage("does not exist") || e.hasMessage("not found")
+}
+
+// IsAlreadyExists reports whether err was caused by the resource to be created
+// already existing.
+func IsAlreadyExists(err error) bool {
+	var e *PureHTTPError
+	if !errors.As(err, &e) {
+		return false
+	}
+	return e.Code == http.StatusConflict || e.hasMessage("already exists")
+}
+
+// IsUnauthorized reports whether err was caused by failed authentication or
+// authorization, i.e. an expired access token.
+func IsUnauthorized(err error) bool {
+	var e *PureHTTPError
+	if !errors.As(err, &e) {
+		return false
+	}
+	return e.Code == http.StatusUnauthorized || e.Code == http.StatusForbidden
+}
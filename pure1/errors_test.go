@@ -0,0 +1,52 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pure1
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func testErrorResponse(code int, body string) *http.Response {
+	req, _ := http.NewRequest("GET", "https://api.pure1.purestorage.com/api/1.0/arrays", nil)
+	return &http.Response{
+		StatusCode: code,
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}
+}
+
+func TestPure1ValidateResponse(t *testing.T) {
+	c := &Client{RestVersion: "1.0"}
+
+	err := c.validateResponse(testErrorResponse(404, `{"errors": [{"context": "arrays", "message": "Array not found."}]}`))
+
+	var e *PureHTTPError
+	if !errors.As(fmt.Errorf("wrapped: %w", err), &e) {
+		t.Fatalf("expected a PureHTTPError; got %T", err)
+	}
+	if e.Code != 404 || e.Method != "GET" || e.RestVersion != "1.0" {
+		t.Errorf("unexpected PureHTTPError: %+v", e)
+	}
+	if len(e.Errors) != 1 || e.Errors[0].Context != "arrays" || e.Errors[0].Message != "Array not found." {
+		t.Errorf("error messages were not parsed; got %+v", e.Errors)
+	}
+	if !IsNotFound(err) || IsUnauthorized(err) || IsAlreadyExists(err) {
+		t.Errorf("error helpers did not match a 404 response")
+	}
+
+	err = c.validateResponse(testErrorResponse(401, `{"error": "invalid_grant", "error_description": "JWT expired"}`))
+	if !IsUnauthorized(err) {
+		t.Errorf("IsUnauthorized did not match a 401 response")
+	}
+	var p *PureError
+	if !errors.As(err, &p) || p.Reason != "Unauthorized" {
+		t.Errorf("PureHTTPError did not match PureError; got %+v", p)
+	}
+}
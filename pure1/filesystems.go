@@ -24,3 +24,21 @@ func (f *FilesystemService) GetFilesystems(params map[string]string) ([]Filesyst
 
 	return m, err
 }
+
+// Pages calls fn with the pages of the Filesystem objects matching params, as
+// described in PageInfo.
+func (f *FilesystemService) Pages(params map[string]string, fn func(page []Filesystem, info PageInfo) bool) error {
+	return f.client.pages("file-systems", params, func() interface{} { return &[]Filesystem{} }, func(page interface{}, info PageInfo) bool {
+		return fn(*page.(*[]Filesystem), info)
+	})
+}
+
+// ListAll returns the Filesystem objects matching params, from all the pages of
+// the list, as described in PageInfo.
+func (f *FilesystemService) ListAll(params map[string]string) ([]Filesystem, error) {
+	m := []Filesystem{}
+	if err := f.client.listAll("file-systems", params, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
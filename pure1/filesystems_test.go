@@ -9,10 +9,10 @@ import (
 )
 
 func TestPure1Filesystems(t *testing.T) {
-	testAccPreChecks(t)
-	c := testAccGenerateClient(t)
+	c := testAccClient(t)
 
 	t.Run("GetFilesystems", testPure1GetFilesystems(c))
+	t.Run("ListAllFilesystems", testPure1ListAllFilesystems(c))
 }
 
 func testPure1GetFilesystems(c *Client) func(t *testing.T) {
@@ -23,3 +23,12 @@ func testPure1GetFilesystems(c *Client) func(t *testing.T) {
 		}
 	}
 }
+
+func testPure1ListAllFilesystems(c *Client) func(t *testing.T) {
+	return func(t *testing.T) {
+		_, err := c.Filesystems.ListAll(map[string]string{"limit": "1"})
+		if err != nil {
+			t.Fatalf("error listing all filesystems: %s", err)
+		}
+	}
+}
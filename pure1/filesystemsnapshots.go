@@ -24,3 +24,21 @@ func (f *FilesystemSnapshotService) GetFilesystemSnapshots(params map[string]str
 
 	return m, err
 }
+
+// Pages calls fn with the pages of the FilesystemSnapshot objects matching
+// params, as described in PageInfo.
+func (f *FilesystemSnapshotService) Pages(params map[string]string, fn func(page []FilesystemSnapshot, info PageInfo) bool) error {
+	return f.client.pages("file-system-snapshots", params, func() interface{} { return &[]FilesystemSnapshot{} }, func(page interface{}, info PageInfo) bool {
+		return fn(*page.(*[]FilesystemSnapshot), info)
+	})
+}
+
+// ListAll returns the FilesystemSnapshot objects matching params, from all the
+// pages of the list, as described in PageInfo.
+func (f *FilesystemSnapshotService) ListAll(params map[string]string) ([]FilesystemSnapshot, error) {
+	m := []FilesystemSnapshot{}
+	if err := f.client.listAll("file-system-snapshots", params, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
@@ -9,10 +9,10 @@ import (
 )
 
 func TestPure1FilesystemSnapshots(t *testing.T) {
-	testAccPreChecks(t)
-	c := testAccGenerateClient(t)
+	c := testAccClient(t)
 
 	t.Run("GetFilesystemSnapshots", testPure1GetFilesystemSnapshots(c))
+	t.Run("ListAllFilesystemSnapshots", testPure1ListAllFilesystemSnapshots(c))
 }
 
 func testPure1GetFilesystemSnapshots(c *Client) func(t *testing.T) {
@@ -23,3 +23,12 @@ func testPure1GetFilesystemSnapshots(c *Client) func(t *testing.T) {
 		}
 	}
 }
+
+func testPure1ListAllFilesystemSnapshots(c *Client) func(t *testing.T) {
+	return func(t *testing.T) {
+		_, err := c.FilesystemSnapshots.ListAll(map[string]string{"limit": "1"})
+		if err != nil {
+			t.Fatalf("error listing all filesystem snapshots: %s", err)
+		}
+	}
+}
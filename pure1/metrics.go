@@ -29,6 +29,24 @@ func (s *MetricsService) GetMetrics(params map[string]string) ([]Metric, error)
 	return m, err
 }
 
+// Pages calls fn with the pages of the metric objects matching params, as
+// described in PageInfo.
+func (s *MetricsService) Pages(params map[string]string, fn func(page []Metric, info PageInfo) bool) error {
+	return s.client.pages("metrics", params, func() interface{} { return &[]Metric{} }, func(page interface{}, info PageInfo) bool {
+		return fn(*page.(*[]Metric), info)
+	})
+}
+
+// ListAll returns the metric objects matching params, from all the pages of the
+// list, as described in PageInfo.
+func (s *MetricsService) ListAll(params map[string]string) ([]Metric, error) {
+	m := []Metric{}
+	if err := s.client.listAll("metrics", params, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // GetMetricHistory returns a list of metric objects
 // aggregation: 'avg' or 'max'
 // endTime: in milliseconds since epoch
@@ -36,11 +54,15 @@ func (s *MetricsService) GetMetrics(params map[string]string) ([]Metric, error)
 // resolution: duration between data points, in milliseconds
 // params: ids or names, and resource_ids or resource_names are required
 func (s *MetricsService) GetMetricHistory(aggregation string, startTime int, endTime int, resolution int, params map[string]string) ([]Metric, error) {
-	params["aggregation"] = aggregation
-	params["start_time"] = strconv.Itoa(startTime)
-	params["end_time"] = strconv.Itoa(endTime)
-	params["resolution"] = strconv.Itoa(resolution)
-	req, err := s.client.NewRequest("GET", "metrics/history", params, nil)
+	p := make(map[string]string, len(params)+4)
+	for k, v := range params {
+		p[k] = v
+	}
+	p["aggregation"] = aggregation
+	p["start_time"] = strconv.Itoa(startTime)
+	p["end_time"] = strconv.Itoa(endTime)
+	p["resolution"] = strconv.Itoa(resolution)
+	req, err := s.client.NewRequest("GET", "metrics/history", p, nil)
 	if err != nil {
 		return nil, err
 	}
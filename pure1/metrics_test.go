@@ -6,13 +6,14 @@ package pure1
 
 import (
 	"testing"
+	"time"
 )
 
 func TestPure1Metrics(t *testing.T) {
-	testAccPreChecks(t)
-	c := testAccGenerateClient(t)
+	c := testAccClient(t)
 
 	t.Run("GetMetrics", testPure1GetMetrics(c))
+	t.Run("ListAllMetrics", testPure1ListAllMetrics(c))
 }
 
 func testPure1GetMetrics(c *Client) func(t *testing.T) {
@@ -23,3 +24,34 @@ func testPure1GetMetrics(c *Client) func(t *testing.T) {
 		}
 	}
 }
+
+func testPure1ListAllMetrics(c *Client) func(t *testing.T) {
+	return func(t *testing.T) {
+		_, err := c.Metrics.ListAll(map[string]string{"limit": "1"})
+		if err != nil {
+			t.Fatalf("error listing all metrics: %s", err)
+		}
+	}
+}
+
+func TestPure1GetMetricHistory(t *testing.T) {
+	s, c := testFakePure1(t)
+	s.MetricValue = func(metric string, resourceID string, ts time.Time) float64 { return 42 }
+
+	// The arguments take precedence over the same keys of params
+	params := map[string]string{"names": "array_read_iops", "resource_names": "array1", "resolution": "1000"}
+	m, err := c.Metrics.GetMetricHistory("avg", 0, 600000, 300000, params)
+	if err != nil {
+		t.Fatalf("error getting metric history: %s", err)
+	}
+	if len(m) != 1 || len(m[0].Data) != 3 {
+		t.Errorf("expected 3 data points of array_read_iops at the resolution argument; got %+v", m)
+	}
+	if len(params) != 3 || params["resolution"] != "1000" {
+		t.Errorf("expected the params not to be changed; got %v", params)
+	}
+
+	if _, err := c.Metrics.GetMetricHistory("avg", 0, 600000, 300000, nil); err == nil {
+		t.Errorf("An Error was NOT raised for a metric history without metric names")
+	}
+}
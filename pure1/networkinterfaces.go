@@ -24,3 +24,21 @@ func (n *NetworkInterfacesService) GetNetworkInterfacess(params map[string]strin
 
 	return m, err
 }
+
+// Pages calls fn with the pages of the NetworkInterface objects matching
+// params, as described in PageInfo.
+func (n *NetworkInterfacesService) Pages(params map[string]string, fn func(page []NetworkInterface, info PageInfo) bool) error {
+	return n.client.pages("network-interfaces", params, func() interface{} { return &[]NetworkInterface{} }, func(page interface{}, info PageInfo) bool {
+		return fn(*page.(*[]NetworkInterface), info)
+	})
+}
+
+// ListAll returns the NetworkInterface objects matching params, from all the
+// pages of the list, as described in PageInfo.
+func (n *NetworkInterfacesService) ListAll(params map[string]string) ([]NetworkInterface, error) {
+	m := []NetworkInterface{}
+	if err := n.client.listAll("network-interfaces", params, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
@@ -9,10 +9,10 @@ import (
 )
 
 func TestPure1NetworkInterfaces(t *testing.T) {
-	testAccPreChecks(t)
-	c := testAccGenerateClient(t)
+	c := testAccClient(t)
 
 	t.Run("GetNetworkInterfaces", testPure1GetNetworkInterfaces(c))
+	t.Run("ListAllNetworkInterfaces", testPure1ListAllNetworkInterfaces(c))
 }
 
 func testPure1GetNetworkInterfaces(c *Client) func(t *testing.T) {
@@ -23,3 +23,12 @@ func testPure1GetNetworkInterfaces(c *Client) func(t *testing.T) {
 		}
 	}
 }
+
+func testPure1ListAllNetworkInterfaces(c *Client) func(t *testing.T) {
+	return func(t *testing.T) {
+		_, err := c.NetworkInterfaces.ListAll(map[string]string{"limit": "1"})
+		if err != nil {
+			t.Fatalf("error listing all network interfaces: %s", err)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pure1
+
+import (
+	"net/http"
+	"time"
+)
+
+// DefaultBaseURL is the URL of the Pure1 API.
+const DefaultBaseURL = "https://api.pure1.purestorage.com"
+
+// Logger is used by the client to log debug messages.  It is satisfied by *log.Logger.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// Option configures a Client created with New.
+type Option func(*config)
+
+// config holds the settings applied by the options passed to New.
+type config struct {
+	restVersion string
+	baseURL     string
+	httpClient  *http.Client
+	timeout     time.Duration
+	retryPolicy RetryPolicy
+	logger      Logger
+}
+
+// WithRestVersion sets the REST API version of the client.  It defaults to 1.0.
+func WithRestVersion(restVersion string) Option {
+	return func(c *config) {
+		c.restVersion = restVersion
+	}
+}
+
+// WithBaseURL sets the URL of the Pure1 API, both for the token exchange and
+// the API calls, i.e. the URL of a pure1test.Server.  It defaults to DefaultBaseURL.
+func WithBaseURL(baseURL string) Option {
+	return func(c *config) {
+		c.baseURL = baseURL
+	}
+}
+
+// WithHTTPClient sets the HTTP client used to send the requests.  The client is copied.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *config) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithTimeout sets the time limit of every request sent to Pure1,
+// including reading the response body.  Zero means no timeout.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.timeout = timeout
+	}
+}
+
+// WithRetryPolicy sets Client.RetryPolicy.  By default DefaultRetryPolicy is
+// used; nil disables retries.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *config) {
+		c.retryPolicy = policy
+	}
+}
+
+// WithLogger sets the logger of the client debug messages.  By default nothing is logged.
+func WithLogger(logger Logger) Option {
+	return func(c *config) {
+		c.logger = logger
+	}
+}
+
+// newHTTPClient returns the HTTP client described by the configuration.
+func (c *config) newHTTPClient() *http.Client {
+	httpClient := &http.Client{}
+	if c.httpClient != nil {
+		*httpClient = *c.httpClient
+	}
+	if c.timeout != 0 {
+		httpClient.Timeout = c.timeout
+	}
+	return httpClient
+}
@@ -0,0 +1,68 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pure1
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewWithOptions(t *testing.T) {
+	s, _ := testFakePure1(t)
+
+	c, err := New(s.AppID, s.PrivateKey,
+		WithBaseURL(s.URL+"/"),
+		WithHTTPClient(s.Client()),
+		WithRestVersion("1.0"),
+		WithTimeout(10*time.Second),
+		WithRetryPolicy(nil),
+	)
+	if err != nil {
+		t.Fatalf("error setting up client: %s", err)
+	}
+	if c.BaseURL != s.URL {
+		t.Errorf("expected BaseURL: %s; got %s", s.URL, c.BaseURL)
+	}
+	if c.RetryPolicy != nil {
+		t.Errorf("WithRetryPolicy(nil) did not disable retries")
+	}
+	if c.client.Timeout != 10*time.Second {
+		t.Errorf("expected timeout: 10s; got %s", c.client.Timeout)
+	}
+
+	req, err := c.NewRequest("GET", "arrays", nil, nil)
+	if err != nil {
+		t.Fatalf("error building request: %s", err)
+	}
+	if !strings.HasPrefix(req.URL.String(), s.URL+"/api/1.0/arrays") {
+		t.Errorf("expected the request to be sent to the base URL; got %s", req.URL)
+	}
+	if _, err := c.Do(req, &[]Array{}, false); err != nil {
+		t.Errorf("error getting arrays: %s", err)
+	}
+}
+
+func TestNewDefaultBaseURL(t *testing.T) {
+	c := &Client{RestVersion: "1.0", auth: &tokenSource{token: &pure1Token{AccessToken: "token"}}}
+	req, err := c.NewRequest("GET", "arrays", nil, nil)
+	if err != nil {
+		t.Fatalf("error building request: %s", err)
+	}
+	if req.URL.String() != DefaultBaseURL+"/api/1.0/arrays" {
+		t.Errorf("expected the request to be sent to Pure1; got %s", req.URL)
+	}
+}
+
+func TestNewInvalidApp(t *testing.T) {
+	s, _ := testFakePure1(t)
+
+	if _, err := New("pure1:apikey:unknown", s.PrivateKey, WithBaseURL(s.URL), WithHTTPClient(s.Client())); err == nil {
+		t.Errorf("An Error was NOT raised for an unregistered application")
+	}
+	if _, err := New(s.AppID, testGeneratePrivateKey(t), WithBaseURL(s.URL), WithHTTPClient(s.Client())); err == nil {
+		t.Errorf("An Error was NOT raised for a JWT signed with another key")
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pure1
+
+import (
+	"reflect"
+	"strconv"
+)
+
+// PageInfo holds the pagination fields of a list returned by the Pure1 API.
+//
+// The lists of the services are paginated.  Their Pages methods, i.e.
+// ArrayService.Pages, call a function with every page of the objects
+// matching params, following the continuation tokens of the responses, until
+// the function returns false; the limit parameter sets the number of items
+// per page, and the PageInfo of the pages holds the total number of items.
+// Their ListAll methods return the objects of all the pages, or the first
+// limit objects if the limit parameter is set.
+type PageInfo struct {
+	// TotalItemCount is the number of items matching the request, in all pages.
+	TotalItemCount int
+	// ContinuationToken is passed as the continuation_token parameter to
+	// get the next page.  It is empty on the last page.
+	ContinuationToken string
+}
+
+// pages requests the list at path matching params, page after page, following
+// the continuation tokens of the responses.  Every page is decoded into the
+// value returned by newPage and passed to f with its page info, until f
+// returns false or the last page is reached.  The limit parameter sets the
+// number of items per page.
+func (c *Client) pages(path string, params map[string]string, newPage func() interface{}, f func(page interface{}, info PageInfo) bool) error {
+	p := map[string]string{}
+	for k, v := range params {
+		p[k] = v
+	}
+
+	for {
+		req, err := c.NewRequest("GET", path, p, nil)
+		if err != nil {
+			return err
+		}
+		page := newPage()
+		info, err := c.DoPage(req, page)
+		if err != nil {
+			return err
+		}
+		if !f(page, *info) || info.ContinuationToken == "" {
+			return nil
+		}
+		if info.ContinuationToken == p["continuation_token"] {
+			return &PureError{Reason: "[error] Pure1 returned the same continuation token for " + path}
+		}
+		p["continuation_token"] = info.ContinuationToken
+	}
+}
+
+// listAll sets items, a pointer to a slice, to the list at path matching
+// params, from all its pages or up to the limit parameter if set.
+func (c *Client) listAll(path string, params map[string]string, items interface{}) error {
+	limit := 0
+	if l, ok := params["limit"]; ok {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 1 {
+			return &PureError{Reason: "[error] Invalid limit " + l}
+		}
+		limit = n
+	}
+
+	all := reflect.ValueOf(items).Elem()
+	return c.pages(path, params, func() interface{} { return reflect.New(all.Type()).Interface() }, func(page interface{}, info PageInfo) bool {
+		all.Set(reflect.AppendSlice(all, reflect.ValueOf(page).Elem()))
+		if limit > 0 && all.Len() >= limit {
+			all.Set(all.Slice(0, limit))
+			return false
+		}
+		return true
+	})
+}
@@ -0,0 +1,107 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pure1
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/devans10/go-purestorage/pure1/pure1test"
+)
+
+func TestPure1DecodeResponse(t *testing.T) {
+	_, c := testFakePure1(t)
+
+	arrays, err := c.Arrays.GetArrays(nil)
+	if err != nil {
+		t.Fatalf("error getting arrays: %s", err)
+	}
+	if len(arrays) != 1 || arrays[0].Name != "array1" || arrays[0].Model != "FA-X70R3" || arrays[0].ID == "" || arrays[0].AsOf == 0 {
+		t.Errorf("expected array1 to be decoded; got %+v", arrays)
+	}
+
+	req, _ := c.NewRequest("GET", "arrays", map[string]string{"limit": "1"}, nil)
+	info, err := c.DoPage(req, &[]Array{})
+	if err != nil {
+		t.Fatalf("error getting arrays: %s", err)
+	}
+	if info.TotalItemCount != 1 || info.ContinuationToken != "" {
+		t.Errorf("expected 1 item and no continuation token; got %+v", info)
+	}
+}
+
+func TestPure1Pages(t *testing.T) {
+	s, c := testFakePure1(t)
+	for i := 0; i < 5; i++ {
+		s.Add(pure1test.Volumes, map[string]interface{}{"name": "vol" + strconv.Itoa(i)})
+	}
+
+	var pages int
+	err := c.Volumes.Pages(map[string]string{"limit": "2"}, func(page []Volume, info PageInfo) bool {
+		pages++
+		if len(page) > 2 {
+			t.Errorf("expected at most 2 volumes per page; got %d", len(page))
+		}
+		if info.TotalItemCount != 5 {
+			t.Errorf("expected total_item_count: 5; got %d", info.TotalItemCount)
+		}
+		return true
+	})
+	if err != nil {
+		t.Fatalf("error getting pages of volumes: %s", err)
+	}
+	if pages != 3 {
+		t.Errorf("expected 3 pages; got %d", pages)
+	}
+
+	pages = 0
+	c.Volumes.Pages(map[string]string{"limit": "2"}, func(page []Volume, info PageInfo) bool {
+		pages++
+		return false
+	})
+	if pages != 1 {
+		t.Errorf("expected the iteration to stop after the first page; got %d pages", pages)
+	}
+
+	volumes, err := c.Volumes.ListAll(map[string]string{"sort": "name"})
+	if err != nil {
+		t.Fatalf("error listing volumes: %s", err)
+	}
+	if len(volumes) != 5 || volumes[0].Name != "vol0" || volumes[4].Name != "vol4" {
+		t.Errorf("expected the 5 volumes in order; got %+v", volumes)
+	}
+}
+
+func TestPure1ListAllLimit(t *testing.T) {
+	s, c := testFakePure1(t)
+	for i := 0; i < 5; i++ {
+		s.Add(pure1test.Volumes, map[string]interface{}{"name": "vol" + strconv.Itoa(i)})
+	}
+
+	// The limit caps the number of volumes listed, less than the total
+	params := map[string]string{"limit": "2", "sort": "name"}
+	volumes, err := c.Volumes.ListAll(params)
+	if err != nil {
+		t.Fatalf("error listing volumes: %s", err)
+	}
+	if len(volumes) != 2 || volumes[0].Name != "vol0" || volumes[1].Name != "vol1" {
+		t.Errorf("expected the first 2 volumes; got %+v", volumes)
+	}
+	if _, ok := params["continuation_token"]; ok {
+		t.Errorf("ListAll modified the parameters")
+	}
+
+	volumes, err = c.Volumes.ListAll(map[string]string{"limit": "10"})
+	if err != nil {
+		t.Fatalf("error listing volumes: %s", err)
+	}
+	if len(volumes) != 5 {
+		t.Errorf("expected the 5 volumes with a limit over the total; got %d", len(volumes))
+	}
+
+	if _, err := c.Volumes.ListAll(map[string]string{"limit": "none"}); err == nil {
+		t.Errorf("An Error was NOT raised for an invalid limit")
+	}
+}
@@ -24,3 +24,21 @@ func (p *PodService) GetPods(params map[string]string) ([]Pod, error) {
 
 	return m, err
 }
+
+// Pages calls fn with the pages of the pod objects matching params, as
+// described in PageInfo.
+func (p *PodService) Pages(params map[string]string, fn func(page []Pod, info PageInfo) bool) error {
+	return p.client.pages("pods", params, func() interface{} { return &[]Pod{} }, func(page interface{}, info PageInfo) bool {
+		return fn(*page.(*[]Pod), info)
+	})
+}
+
+// ListAll returns the pod objects matching params, from all the pages of the
+// list, as described in PageInfo.
+func (p *PodService) ListAll(params map[string]string) ([]Pod, error) {
+	m := []Pod{}
+	if err := p.client.listAll("pods", params, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
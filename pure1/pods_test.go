@@ -9,10 +9,10 @@ import (
 )
 
 func TestPure1Pods(t *testing.T) {
-	testAccPreChecks(t)
-	c := testAccGenerateClient(t)
+	c := testAccClient(t)
 
 	t.Run("GetPods", testPure1GetPods(c))
+	t.Run("ListAllPods", testPure1ListAllPods(c))
 }
 
 func testPure1GetPods(c *Client) func(t *testing.T) {
@@ -23,3 +23,12 @@ func testPure1GetPods(c *Client) func(t *testing.T) {
 		}
 	}
 }
+
+func testPure1ListAllPods(c *Client) func(t *testing.T) {
+	return func(t *testing.T) {
+		_, err := c.Pods.ListAll(map[string]string{"limit": "1"})
+		if err != nil {
+			t.Fatalf("error listing all pods: %s", err)
+		}
+	}
+}
@@ -8,12 +8,13 @@ package pure1
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"time"
 
 	"encoding/json"
-	"errors"
 	"github.com/dgrijalva/jwt-go"
 	"net/http"
 	"net/url"
@@ -26,8 +27,17 @@ type Client struct {
 	PrivateKey  []byte
 	RestVersion string
 
+	// BaseURL is the URL of the Pure1 API, without the api or oauth2 path.
+	BaseURL string
+
+	// RetryPolicy decides whether a request that failed with a transient
+	// error is sent again.  NewClient sets DefaultRetryPolicy; nil disables retries.
+	RetryPolicy RetryPolicy
+
 	client *http.Client
-	token  *pure1Token
+	auth   *tokenSource
+	ctx    context.Context
+	logger Logger
 
 	Arrays              *ArrayService
 	Filesystems         *FilesystemService
@@ -48,30 +58,67 @@ type pure1Token struct {
 
 // NewClient creates a Client struct for calling Pure1 API endpoints
 func NewClient(appID string, privateKey []byte, restVersion string) (*Client, error) {
+	return NewClientWithContext(context.Background(), appID, privateKey, restVersion)
+}
+
+// NewClientWithContext is the same as NewClient, but the token exchange is bound
+// to ctx.  The returned Client does not keep ctx; use WithContext to bind a
+// context to subsequent API calls.
+func NewClientWithContext(ctx context.Context, appID string, privateKey []byte, restVersion string) (*Client, error) {
+	return NewWithContext(ctx, appID, privateKey, WithRestVersion(restVersion))
+}
+
+// New creates a Client for calling Pure1 API endpoints, authenticated as the
+// API application appID with privateKey, the PEM encoded RSA key registered
+// for the application.  The client is configured by opts, i.e.
+//
+//	c, err := pure1.New(appID, privateKey, pure1.WithBaseURL(s.URL), pure1.WithHTTPClient(s.Client()))
+func New(appID string, privateKey []byte, opts ...Option) (*Client, error) {
+	return NewWithContext(context.Background(), appID, privateKey, opts...)
+}
+
+// NewWithContext is the same as New, but the token exchange is bound to ctx.
+func NewWithContext(ctx context.Context, appID string, privateKey []byte, opts ...Option) (*Client, error) {
+
+	cfg := &config{retryPolicy: DefaultRetryPolicy()}
+	for _, opt := range opts {
+		opt(cfg)
+	}
 
 	if appID == "" {
-		err := errors.New("[error] Must specify an App ID")
+		err := &PureError{Reason: "[error] Must specify an App ID"}
 		return nil, err
 	}
 
 	if privateKey == nil {
-		err := errors.New("[error] Must specify a Private Key")
+		err := &PureError{Reason: "[error] Must specify a Private Key"}
 		return nil, err
 	}
 
+	restVersion := cfg.restVersion
 	if restVersion == "" {
 		restVersion = "1.0"
 	}
+	baseURL := strings.TrimSuffix(cfg.baseURL, "/")
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
 
-	c := &Client{AppID: appID, PrivateKey: privateKey, RestVersion: restVersion}
-	c.client = &http.Client{}
-	token, err := getToken(c)
-	if err != nil {
+	c := &Client{AppID: appID, PrivateKey: privateKey, RestVersion: restVersion, BaseURL: baseURL, RetryPolicy: cfg.retryPolicy}
+	c.client = cfg.newHTTPClient()
+	c.logger = cfg.logger
+	c.auth = &tokenSource{}
+	if _, _, err := c.accessToken(ctx); err != nil {
 		return nil, err
 	}
 
-	c.token = token
+	c.initServices()
 
+	return c, nil
+}
+
+// initServices binds the API services to the client.
+func (c *Client) initServices() {
 	c.Arrays = &ArrayService{client: c}
 	c.Filesystems = &FilesystemService{client: c}
 	c.FilesystemSnapshots = &FilesystemSnapshotService{client: c}
@@ -80,11 +127,33 @@ func NewClient(appID string, privateKey []byte, restVersion string) (*Client, er
 	c.Pods = &PodService{client: c}
 	c.Volumes = &VolumeService{client: c}
 	c.VolumeSnapshots = &VolumeSnapshotService{client: c}
+}
 
-	return c, nil
+// WithContext returns a shallow copy of the client whose requests are bound to ctx.
+// Every service method called through the returned client, i.e.
+// c.WithContext(ctx).Metrics.GetMetricHistory(...), will be canceled
+// when ctx is canceled or its deadline expires.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	if ctx == nil {
+		panic("nil context")
+	}
+	c2 := new(Client)
+	*c2 = *c
+	c2.ctx = ctx
+	c2.initServices()
+	return c2
+}
+
+// Context returns the context bound to the client.  The returned context is
+// always non-nil; it defaults to the background context.
+func (c *Client) Context() context.Context {
+	if c.ctx != nil {
+		return c.ctx
+	}
+	return context.Background()
 }
 
-func getToken(c *Client) (*pure1Token, error) {
+func getToken(ctx context.Context, c *Client) (*pure1Token, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
 		"iss": c.AppID,
 		"iat": time.Now().Unix(),
@@ -107,7 +176,7 @@ func getToken(c *Client) (*pure1Token, error) {
 	values.Add("subject_token", tokenStr)
 	values.Add("subject_token_type", "urn:ietf:params:oauth:token-type:jwt")
 
-	pure1URL := fmt.Sprintf("https://api.pure1.purestorage.com/oauth2/%s/token", c.RestVersion)
+	pure1URL := fmt.Sprintf("%s/oauth2/%s/token", c.baseURL(), c.RestVersion)
 	req, err := http.NewRequest(http.MethodPost, pure1URL, strings.NewReader(values.Encode()))
 	if err != nil {
 		return nil, err
@@ -116,14 +185,16 @@ func getToken(c *Client) (*pure1Token, error) {
 	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 	req.Header.Add("Accept", "application/json")
 
-	client := http.DefaultClient
-
-	resp, err := client.Do(req)
+	resp, err := c.httpClient().Do(req.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
+	if err := c.validateResponse(resp); err != nil {
+		return nil, err
+	}
+
 	responseBody, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
@@ -153,7 +224,13 @@ func getToken(c *Client) (*pure1Token, error) {
 // The data body to be passed in the HTTP request. This will be converted to JSON,
 // then added to the request as bytes.
 //
+// The request is bound to the context of the client, see WithContext.
 func (c *Client) NewRequest(method string, path string, params map[string]string, data interface{}) (*http.Request, error) {
+	return c.NewRequestWithContext(c.Context(), method, path, params, data)
+}
+
+// NewRequestWithContext is the same as NewRequest, but binds the request to ctx.
+func (c *Client) NewRequestWithContext(ctx context.Context, method string, path string, params map[string]string, data interface{}) (*http.Request, error) {
 
 	var fpath string
 	if strings.HasPrefix(path, "http") {
@@ -191,73 +268,157 @@ func (c *Client) NewRequest(method string, path string, params map[string]string
 
 	req.Header.Add("Content-Type", "application/json")
 	req.Header.Add("Accept", "application/json")
-	req.Header.Add("Authorization", `Bearer `+c.token.AccessToken)
+	if c.auth != nil {
+		token, _ := c.auth.current()
+		req.Header.Add("Authorization", `Bearer `+token)
+	}
 
-	return req, err
+	return req.WithContext(ctx), err
 }
 
 // Do is the client function that performs the HTTP request.
-// req  The HTTP request object to be executed.
+// req  The HTTP request object to be executed.  The request is canceled when
+// its context is done.
 // v    The data object that will be populated and returned. i.e. Volume struct
-// reestablish_session  A bool that states if the session should be reestablished prior to execution.
-//                      This functionality is NOT implemented yet.  By default the Go HTTP library
-//                      does not set a timeout, I need to set this implicitly.
-//                      However, the array will timeout the session after 30 minutes.
+// reestablish_session  A bool that states if a new access token should be exchanged prior to execution.
+//
+// The access token is refreshed before it expires, and a request rejected
+// because of an expired or revoked token is sent again once with a new token.
+// Requests failing with transient errors are retried according to RetryPolicy.
+// The items of the response are decoded into v; use DoPage to also get the
+// pagination fields of the response.
 func (c *Client) Do(req *http.Request, v interface{}, reestablishSession bool) (*http.Response, error) {
-	resp, err := c.client.Do(req)
+	resp, _, err := c.do(req, v, reestablishSession)
+	return resp, err
+}
+
+// DoPage is the same as Do, but also returns the pagination fields of the
+// response: the total number of items and the continuation token of the next page.
+func (c *Client) DoPage(req *http.Request, v interface{}) (*PageInfo, error) {
+	_, info, err := c.do(req, v, false)
+	return info, err
+}
+
+func (c *Client) do(req *http.Request, v interface{}, refresh bool) (*http.Response, *PageInfo, error) {
+	token, gen, err := c.accessToken(req.Context())
 	if err != nil {
-		fmt.Println("Do request failed")
-		return nil, err
+		return nil, nil, err
+	}
+	if refresh {
+		if err := c.refreshToken(req.Context(), gen); err != nil {
+			return nil, nil, err
+		}
+		if token, gen, err = c.accessToken(req.Context()); err != nil {
+			return nil, nil, err
+		}
+	}
+	authorize(req, token)
+
+	resp, err := c.sendWithRetry(req)
+	if err != nil {
+		c.logf("[debug] %s %s: request failed: %s", req.Method, req.URL.String(), err)
+		return nil, nil, err
+	}
+
+	if tokenRejected(req, resp) {
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+		if err := c.refreshToken(req.Context(), gen); err != nil {
+			return nil, nil, err
+		}
+		if token, _, err = c.accessToken(req.Context()); err != nil {
+			return nil, nil, err
+		}
+		r, err := replayRequest(req)
+		if err != nil {
+			return nil, nil, err
+		}
+		r.Header = req.Header.Clone()
+		authorize(r, token)
+		if resp, err = c.sendWithRetry(r); err != nil {
+			return nil, nil, err
+		}
 	}
 	defer resp.Body.Close()
 	//log.Printf("[debug] URL: %s ", req.URL.String())
 	//log.Printf("[debug] Response code: %v", resp.Status)
 
-	if err := validateResponse(resp); err != nil {
-		return resp, err
+	if err := c.validateResponse(resp); err != nil {
+		return resp, nil, err
 	}
 
-	err = decodeResponse(resp, v)
-	return resp, err
+	info, err := decodeResponse(resp, v)
+	return resp, info, err
 
 }
 
 // decodeResponse function reads the http response body into an interface.
-func decodeResponse(r *http.Response, v interface{}) error {
+// The body is the envelope of the Pure1 API; its items are decoded into v,
+// and its pagination fields are returned.
+func decodeResponse(r *http.Response, v interface{}) (*PageInfo, error) {
 	if v == nil {
-		return fmt.Errorf("nil interface provided to decodeResponse")
+		return nil, fmt.Errorf("nil interface provided to decodeResponse")
 	}
 
-	type response struct {
-		TotalItems        int         `json:"total_item_count,omitempty"`
-		ContinuationToken interface{} `json:"continuation_token,omitempty"`
-		Items             interface{} `json:"items,omitempty"`
+	var resp struct {
+		TotalItemCount    int             `json:"total_item_count,omitempty"`
+		ContinuationToken string          `json:"continuation_token,omitempty"`
+		Items             json.RawMessage `json:"items,omitempty"`
 	}
 
-	resp := &response{}
-	bodyBytes, _ := ioutil.ReadAll(r.Body)
-	bodyString := string(bodyBytes)
-	err := json.Unmarshal([]byte(bodyString), resp)
-	v = resp.Items
-	return err
+	err := json.NewDecoder(r.Body).Decode(&resp)
+	if err == io.EOF {
+		return &PageInfo{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	info := &PageInfo{TotalItemCount: resp.TotalItemCount, ContinuationToken: resp.ContinuationToken}
+	if len(resp.Items) > 0 && string(resp.Items) != "null" {
+		if err := json.Unmarshal(resp.Items, v); err != nil {
+			return nil, err
+		}
+	}
+	return info, nil
+}
+
+// logf logs a debug message with the logger of the client, if any.
+func (c *Client) logf(format string, v ...interface{}) {
+	if c.logger != nil {
+		c.logger.Printf(format, v...)
+	}
 }
 
 // validateResponse checks that the http response is within the 200 range.
-// Some functionality needs to be added here to check for some specific errors,
-// and probably add the equivlents to PureError and PureHTTPError from the Python
-// REST client.
-func validateResponse(r *http.Response) error {
-	if c := r.StatusCode; 200 <= c && c <= 299 {
+// Otherwise a PureHTTPError describing the failed request is returned.
+func (c *Client) validateResponse(r *http.Response) error {
+	if code := r.StatusCode; 200 <= code && code <= 299 {
 		return nil
 	}
 
 	bodyBytes, _ := ioutil.ReadAll(r.Body)
-	bodyString := string(bodyBytes)
-	return fmt.Errorf("Response code: %d, ResponeBody: %s", r.StatusCode, bodyString)
+	return newPureHTTPError(r, c.RestVersion, bodyBytes)
 }
 
 // formatPath returns the formated string to be used for the base URL in
 // all API calls
 func (c *Client) formatPath(path string) string {
-	return fmt.Sprintf("https://api.pure1.purestorage.com/api/%s/%s", c.RestVersion, path)
+	return fmt.Sprintf("%s/api/%s/%s", c.baseURL(), c.RestVersion, path)
+}
+
+// baseURL returns the URL of the Pure1 API, which defaults to DefaultBaseURL
+// for clients that were not created with New.
+func (c *Client) baseURL() string {
+	if c.BaseURL == "" {
+		return DefaultBaseURL
+	}
+	return strings.TrimSuffix(c.BaseURL, "/")
+}
+
+// httpClient returns the HTTP client used to send the requests.
+func (c *Client) httpClient() *http.Client {
+	if c.client == nil {
+		return http.DefaultClient
+	}
+	return c.client
 }
@@ -1,8 +1,15 @@
 package pure1
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
 	"os"
 	"testing"
+
+	"github.com/devans10/go-purestorage/pure1/pure1test"
 )
 
 func testAccPreChecks(t *testing.T) {
@@ -33,6 +40,40 @@ func testAccGenerateClient(t *testing.T) *Client {
 	}
 	return c
 }
+
+// testAccClient returns a client of Pure1 if PURE1_ACC is set, and of a fake
+// Pure1 API otherwise.
+func testAccClient(t *testing.T) *Client {
+	if os.Getenv("PURE1_ACC") != "" {
+		testAccPreChecks(t)
+		return testAccGenerateClient(t)
+	}
+	_, c := testFakePure1(t)
+	return c
+}
+
+// testFakePure1 starts a fake Pure1 API with an array, and returns it with a
+// client of the API.
+func testFakePure1(t *testing.T) (*pure1test.Server, *Client) {
+	s := pure1test.NewServer()
+	t.Cleanup(s.Close)
+	s.Add(pure1test.Arrays, map[string]interface{}{"name": "array1", "model": "FA-X70R3", "os": "Purity//FA", "version": "6.1.0"})
+
+	c, err := New(s.AppID, s.PrivateKey, WithBaseURL(s.URL), WithHTTPClient(s.Client()))
+	if err != nil {
+		t.Fatalf("error setting up client of the fake Pure1 API: %s", err)
+	}
+	return s, c
+}
+
+func testGeneratePrivateKey(t *testing.T) []byte {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("error generating private key: %s", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+}
+
 func TestPure1NewClient(t *testing.T) {
 	testAccPreChecks(t)
 	c := testAccGenerateClient(t)
@@ -40,3 +81,30 @@ func TestPure1NewClient(t *testing.T) {
 		t.Fatal("error setting up client")
 	}
 }
+
+func TestPure1NewRequestWithContext(t *testing.T) {
+	c := &Client{AppID: "appid", RestVersion: "1.0", auth: &tokenSource{token: &pure1Token{AccessToken: "token"}}}
+
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "value")
+	req, err := c.WithContext(ctx).NewRequest("GET", "arrays", nil, nil)
+	if err != nil {
+		t.Fatalf("NewRequest function call returned error: %s", err)
+	}
+	if req.Context() != ctx {
+		t.Errorf("Request context was not set from the client context")
+	}
+	if c.Context() != context.Background() {
+		t.Errorf("WithContext modified the context of the original client")
+	}
+}
+
+func TestPure1NewClientWithContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := NewClientWithContext(ctx, "appid", testGeneratePrivateKey(t), "")
+	if err == nil {
+		t.Errorf("An Error was NOT raised when the context was canceled")
+	}
+}
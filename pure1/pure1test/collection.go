@@ -0,0 +1,194 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pure1test
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// continuation is the position of a continuation token in a list.
+type continuation struct {
+	path   string
+	offset int
+}
+
+// Add adds items to collection, one of Arrays, Volumes, Metrics etc.  Items
+// are the JSON objects returned by the API; each must have a unique name.
+// An id is generated for the items that do not have one.  The IDs of the
+// added items are returned.
+func (s *Server) Add(collection string, items ...map[string]interface{}) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.add(collection, items...)
+}
+
+func (s *Server) add(collection string, items ...map[string]interface{}) []string {
+	if !contains(collections, collection) {
+		panic(fmt.Sprintf("pure1test: unknown collection %q", collection))
+	}
+	var ids []string
+	for _, item := range items {
+		name, _ := item["name"].(string)
+		if name == "" {
+			panic(fmt.Sprintf("pure1test: item of %s has no name", collection))
+		}
+		if s.lookup(collection, "", name) != nil {
+			panic(fmt.Sprintf("pure1test: %s %q already exists", collection, name))
+		}
+		m := map[string]interface{}{}
+		for k, v := range item {
+			m[k] = v
+		}
+		if id, _ := m["id"].(string); id == "" {
+			m["id"] = newID()
+		}
+		s.items[collection] = append(s.items[collection], m)
+		ids = append(ids, m["id"].(string))
+	}
+	return ids
+}
+
+// lookup returns the item of collection with the given ID or name, or nil.
+func (s *Server) lookup(collection string, id string, name string) map[string]interface{} {
+	for _, item := range s.items[collection] {
+		if (id != "" && item["id"] == id) || (name != "" && item["name"] == name) {
+			return item
+		}
+	}
+	return nil
+}
+
+// list serves the GET request of a collection.
+func (s *Server) list(path string, q url.Values) (interface{}, *apiError) {
+	if q.Get("filter") != "" {
+		return nil, errorf("filter", "The fake Pure1 API does not support filter.")
+	}
+	ids := splitList(q, "ids")
+	names := splitList(q, "names")
+
+	items := []interface{}{}
+	for _, item := range s.items[path] {
+		if len(ids) > 0 && !contains(ids, item["id"].(string)) {
+			continue
+		}
+		if len(names) > 0 && !contains(names, item["name"].(string)) {
+			continue
+		}
+		items = append(items, s.view(item))
+	}
+	if err := sortItems(items, q.Get("sort")); err != nil {
+		return nil, err
+	}
+	return s.page(path, q, items)
+}
+
+// view returns a copy of item, as of now.
+func (s *Server) view(item map[string]interface{}) map[string]interface{} {
+	m := map[string]interface{}{"_as_of": s.asOf()}
+	for k, v := range item {
+		m[k] = v
+	}
+	return m
+}
+
+// sortItems sorts items by the field of the sort query parameter, in
+// descending order if it ends with "-".
+func sortItems(items []interface{}, field string) *apiError {
+	if field == "" {
+		return nil
+	}
+	desc := strings.HasSuffix(field, "-")
+	field = strings.TrimSuffix(field, "-")
+	if strings.Contains(field, ",") {
+		return errorf("sort", "The fake Pure1 API only supports sorting by a single field.")
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		a := items[i].(map[string]interface{})[field]
+		b := items[j].(map[string]interface{})[field]
+		if desc {
+			return less(b, a)
+		}
+		return less(a, b)
+	})
+	return nil
+}
+
+// less compares JSON values, numbers numerically and other values by their
+// string representation.  Missing values come first.
+func less(a interface{}, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b != nil
+	}
+	x, okA := number(a)
+	y, okB := number(b)
+	if okA && okB {
+		return x < y
+	}
+	return fmt.Sprint(a) < fmt.Sprint(b)
+}
+
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// page returns the page of items requested by the limit, offset and
+// continuation_token query parameters, in the items envelope of Pure1.
+func (s *Server) page(path string, q url.Values, items []interface{}) (interface{}, *apiError) {
+	offset := 0
+	if token := q.Get("continuation_token"); token != "" {
+		c, ok := s.continuations[token]
+		if !ok || c.path != path {
+			return nil, errorf("continuation_token", "The continuation token is invalid.")
+		}
+		offset = c.offset
+	} else if o := q.Get("offset"); o != "" {
+		n, err := strconv.Atoi(o)
+		if err != nil || n < 0 {
+			return nil, errorf("offset", "offset must be a non-negative integer.")
+		}
+		offset = n
+	}
+	limit := 0
+	if l := q.Get("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 1 {
+			return nil, errorf("limit", "limit must be a positive integer.")
+		}
+		limit = n
+	}
+
+	total := len(items)
+	if offset > total {
+		offset = total
+	}
+	end := total
+	if limit > 0 && offset+limit < total {
+		end = offset + limit
+	}
+
+	var next interface{}
+	if end < total {
+		token := newToken()
+		s.continuations[token] = continuation{path: path, offset: end}
+		next = token
+	}
+	return map[string]interface{}{
+		"total_item_count":   total,
+		"continuation_token": next,
+		"items":              items[offset:end],
+	}, nil
+}
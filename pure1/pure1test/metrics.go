@@ -0,0 +1,232 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pure1test
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// maxDataPoints is the maximum number of data points of a metric history.
+const maxDataPoints = 10000
+
+// defaultMetrics are the metrics registered by NewServer, by resource type.
+var defaultMetrics = map[string][][2]string{
+	Arrays: {
+		{"array_read_iops", "operations/s"},
+		{"array_write_iops", "operations/s"},
+		{"array_read_bandwidth", "B/s"},
+		{"array_write_bandwidth", "B/s"},
+		{"array_read_latency_us", "us/op"},
+		{"array_write_latency_us", "us/op"},
+		{"array_total_load", "%"},
+		{"array_total_capacity", "B"},
+		{"array_effective_used_space", "B"},
+		{"array_data_reduction", "ratio"},
+	},
+	Volumes: {
+		{"volume_read_iops", "operations/s"},
+		{"volume_write_iops", "operations/s"},
+		{"volume_read_bandwidth", "B/s"},
+		{"volume_write_bandwidth", "B/s"},
+		{"volume_read_latency_us", "us/op"},
+		{"volume_write_latency_us", "us/op"},
+	},
+}
+
+// addDefaultMetrics adds the metrics of defaultMetrics, available with
+// resolutions of 30 seconds, 5 minutes and 1 day.
+func (s *Server) addDefaultMetrics() {
+	for _, resourceType := range []string{Arrays, Volumes} {
+		for _, m := range defaultMetrics[resourceType] {
+			s.add(Metrics, map[string]interface{}{
+				"name":           m[0],
+				"description":    m[0],
+				"unit":           m[1],
+				"resource_types": []string{resourceType},
+				"availabilities": []map[string]interface{}{
+					{"aggregations": []string{"avg", "max"}, "resolution": 30000, "retention": 86400000},
+					{"aggregations": []string{"avg", "max"}, "resolution": 300000, "retention": 604800000},
+					{"aggregations": []string{"avg", "max"}, "resolution": 86400000, "retention": 31536000000},
+				},
+			})
+		}
+	}
+}
+
+// metricValue returns the value of metric for the resource at t.
+func (s *Server) metricValue(metric string, resourceID string, t time.Time) float64 {
+	if s.MetricValue != nil {
+		return s.MetricValue(metric, resourceID, t)
+	}
+	h := fnv.New32a()
+	h.Write([]byte(metric + resourceID))
+	return float64(h.Sum32() % 1000)
+}
+
+// millis parses a required timestamp or duration query parameter, in milliseconds.
+func millis(q url.Values, key string) (int64, *apiError) {
+	v := q.Get(key)
+	if v == "" {
+		return 0, errorf(key, fmt.Sprintf("%s is required.", key))
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 0 {
+		return 0, errorf(key, fmt.Sprintf("%s must be a non-negative integer.", key))
+	}
+	return n, nil
+}
+
+// metricResources returns the resources selected by the resource_ids and
+// resource_names query parameters, searched in the collections of resourceTypes.
+func (s *Server) metricResources(q url.Values, resourceTypes []string) ([]map[string]interface{}, *apiError) {
+	ids := splitList(q, "resource_ids")
+	names := splitList(q, "resource_names")
+	if len(ids) == 0 && len(names) == 0 {
+		return nil, errorf("resource_ids", "Either resource_ids or resource_names is required.")
+	}
+
+	var resources []map[string]interface{}
+	find := func(id string, name string) *apiError {
+		for _, t := range resourceTypes {
+			if item := s.lookup(t, id, name); item != nil {
+				resources = append(resources, map[string]interface{}{"id": item["id"], "name": item["name"], "resource_type": t})
+				return nil
+			}
+		}
+		return errorf(id+name, fmt.Sprintf("Resource %s does not exist.", id+name))
+	}
+	for _, id := range ids {
+		if err := find(id, ""); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range names {
+		if err := find("", name); err != nil {
+			return nil, err
+		}
+	}
+	return resources, nil
+}
+
+// metricHistory serves metrics/history, the values of metrics for resources
+// over a time range.
+func (s *Server) metricHistory(path string, q url.Values) (interface{}, *apiError) {
+	names := splitList(q, "names")
+	if len(names) == 0 {
+		return nil, errorf("names", "names is required.")
+	}
+	aggregation := q.Get("aggregation")
+	if aggregation != "avg" && aggregation != "max" {
+		return nil, errorf("aggregation", "aggregation must be avg or max.")
+	}
+	start, err := millis(q, "start_time")
+	if err != nil {
+		return nil, err
+	}
+	end, err := millis(q, "end_time")
+	if err != nil {
+		return nil, err
+	}
+	resolution, err := millis(q, "resolution")
+	if err != nil {
+		return nil, err
+	}
+	if start > end {
+		return nil, errorf("start_time", "start_time must not be after end_time.")
+	}
+	if resolution == 0 {
+		return nil, errorf("resolution", "resolution must be positive.")
+	}
+	if (end-start)/resolution >= maxDataPoints {
+		return nil, errorf("resolution", fmt.Sprintf("The time range has more than %d data points at the resolution.", maxDataPoints))
+	}
+
+	items := []interface{}{}
+	for _, name := range names {
+		metric := s.lookup(Metrics, "", name)
+		if metric == nil {
+			return nil, errorf(name, fmt.Sprintf("Metric %s does not exist.", name))
+		}
+		if err := checkAvailability(metric, aggregation, resolution); err != nil {
+			return nil, err
+		}
+		resourceTypes := stringList(metric["resource_types"])
+		resources, err := s.metricResources(q, resourceTypes)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range resources {
+			data := [][]interface{}{}
+			for t := start - start%resolution; t <= end; t += resolution {
+				v := s.metricValue(name, r["id"].(string), time.Unix(0, t*int64(time.Millisecond)))
+				data = append(data, []interface{}{t, v})
+			}
+			items = append(items, map[string]interface{}{
+				"_as_of":      s.asOf(),
+				"id":          metric["id"],
+				"name":        name,
+				"aggregation": aggregation,
+				"resolution":  resolution,
+				"unit":        metric["unit"],
+				"resources":   []interface{}{r},
+				"data":        data,
+			})
+		}
+	}
+	return s.page(path, q, items)
+}
+
+// checkAvailability validates that metric is available with aggregation, at
+// resolution or a multiple of it.
+func checkAvailability(metric map[string]interface{}, aggregation string, resolution int64) *apiError {
+	availabilities := objectList(metric["availabilities"])
+	if len(availabilities) == 0 {
+		return nil
+	}
+	for _, a := range availabilities {
+		aggregations := stringList(a["aggregations"])
+		r, _ := number(a["resolution"])
+		if contains(aggregations, aggregation) && r > 0 && resolution%int64(r) == 0 {
+			return nil
+		}
+	}
+	return errorf("resolution", fmt.Sprintf("Metric %s is not available with aggregation %s at resolution %d.", metric["name"], aggregation, resolution))
+}
+
+// stringList returns the list of strings v, given as a []string or as a
+// decoded JSON array.
+func stringList(v interface{}) []string {
+	if l, ok := v.([]string); ok {
+		return l
+	}
+	var l []string
+	a, _ := v.([]interface{})
+	for _, e := range a {
+		if s, ok := e.(string); ok {
+			l = append(l, s)
+		}
+	}
+	return l
+}
+
+// objectList returns the list of JSON objects v, given as a
+// []map[string]interface{} or as a decoded JSON array.
+func objectList(v interface{}) []map[string]interface{} {
+	if l, ok := v.([]map[string]interface{}); ok {
+		return l
+	}
+	var l []map[string]interface{}
+	a, _ := v.([]interface{})
+	for _, e := range a {
+		if m, ok := e.(map[string]interface{}); ok {
+			l = append(l, m)
+		}
+	}
+	return l
+}
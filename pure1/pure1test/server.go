@@ -0,0 +1,317 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+// Package pure1test provides an in-memory Pure1 API for testing code that
+// uses the pure1 package without Pure1 credentials.
+//
+// The fake API implements the OAuth token exchange of Pure1, validating the
+// RS256 signed JWT of the API applications registered with the server, and
+// the arrays, tags, volumes, volume snapshots, pods, file systems, file
+// system snapshots, network interfaces and metrics endpoints of the REST 1.0
+// API.  Lists are returned in the items envelope of Pure1 and paginated with
+// limit and continuation_token.
+//
+//	s := pure1test.NewServer()
+//	defer s.Close()
+//	s.Add(pure1test.Arrays, map[string]interface{}{"name": "array1", "model": "FA-X70R3"})
+//
+//	c, err := pure1.New(s.AppID, s.PrivateKey,
+//		pure1.WithBaseURL(s.URL),
+//		pure1.WithHTTPClient(s.Client()))
+package pure1test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The collections of resources served by the fake API, as passed to Add.
+const (
+	Arrays              = "arrays"
+	Filesystems         = "file-systems"
+	FilesystemSnapshots = "file-system-snapshots"
+	Metrics             = "metrics"
+	NetworkInterfaces   = "network-interfaces"
+	Pods                = "pods"
+	Volumes             = "volumes"
+	VolumeSnapshots     = "volume-snapshots"
+)
+
+// collections are the collections served by the fake API.
+var collections = []string{Arrays, Filesystems, FilesystemSnapshots, Metrics, NetworkInterfaces, Pods, Volumes, VolumeSnapshots}
+
+const (
+	grantTypeTokenExchange = "urn:ietf:params:oauth:grant-type:token-exchange"
+	tokenTypeJWT           = "urn:ietf:params:oauth:token-type:jwt"
+	tokenTypeAccessToken   = "urn:ietf:params:oauth:token-type:access_token"
+)
+
+var (
+	keyOnce sync.Once
+	key     *rsa.PrivateKey
+)
+
+// defaultKey returns the key of the API application registered by NewServer.
+// Generating RSA keys is slow, so it is shared by all the servers.
+func defaultKey() *rsa.PrivateKey {
+	keyOnce.Do(func() {
+		k, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			panic("pure1test: generating private key: " + err.Error())
+		}
+		key = k
+	})
+	return key
+}
+
+// Server is a fake Pure1 API served by an httptest.Server over TLS.  Its
+// configuration must not be changed once the server is started, except for
+// Now which can be replaced between requests.
+type Server struct {
+	*httptest.Server
+
+	// AppID and PrivateKey are the ID and the PEM encoded private key of
+	// the API application registered by NewServer.
+	AppID      string
+	PrivateKey []byte
+	// TokenLifetime is the lifetime of the access tokens issued by the
+	// token exchange.
+	TokenLifetime time.Duration
+	// Versions are the REST API versions supported by the API.
+	Versions []string
+	// Now returns the time of the Pure1 clock.  Tests can replace it to
+	// let time pass, i.e. for access tokens to expire.
+	Now func() time.Time
+	// MetricValue returns the value of metric for the resource with the ID
+	// resourceID at t, as returned by metrics/history.  By default every
+	// metric and resource has a constant value.
+	MetricValue func(metric string, resourceID string, t time.Time) float64
+
+	mu            sync.Mutex
+	apps          map[string]*rsa.PublicKey
+	tokens        map[string]time.Time
+	issued        int
+	items         map[string][]map[string]interface{}
+	tags          []*tag
+	continuations map[string]continuation
+}
+
+// NewServer starts and returns a new fake Pure1 API.  The caller should call
+// Close when finished, to shut it down.
+func NewServer() *Server {
+	s := NewUnstartedServer()
+	s.StartTLS()
+	return s
+}
+
+// NewUnstartedServer returns a new fake Pure1 API but doesn't start it, so
+// that its configuration can be changed.  Call StartTLS to start it.
+func NewUnstartedServer() *Server {
+	k := defaultKey()
+	s := &Server{
+		AppID:         "pure1:apikey:" + newToken()[:16],
+		PrivateKey:    pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(k)}),
+		TokenLifetime: 10 * time.Hour,
+		Versions:      []string{"1.0"},
+		Now:           time.Now,
+		apps:          map[string]*rsa.PublicKey{},
+		tokens:        map[string]time.Time{},
+		items:         map[string][]map[string]interface{}{},
+		continuations: map[string]continuation{},
+	}
+	s.apps[s.AppID] = &k.PublicKey
+	s.addDefaultMetrics()
+	s.Server = httptest.NewUnstartedServer(s)
+	return s
+}
+
+// RegisterApp registers the API application appID, whose token exchange
+// requests are signed with the private key of publicKey.
+func (s *Server) RegisterApp(appID string, publicKey *rsa.PublicKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.apps[appID] = publicKey
+}
+
+// ExpireTokens expires the access tokens issued so far.
+func (s *Server) ExpireTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens = map[string]time.Time{}
+}
+
+// TokensIssued returns the number of access tokens issued so far.
+func (s *Server) TokensIssued() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.issued
+}
+
+func (s *Server) now() time.Time {
+	return s.Now()
+}
+
+// asOf returns the _as_of timestamp of the resources, in milliseconds.
+func (s *Server) asOf() int64 {
+	return s.now().UnixNano() / int64(time.Millisecond)
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 3)
+	if len(parts) < 3 || (parts[0] != "oauth2" && parts[0] != "api") {
+		writeError(w, notFoundError(r.URL.Path))
+		return
+	}
+	if !contains(s.Versions, parts[1]) {
+		writeError(w, notFoundError(r.URL.Path))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if parts[0] == "oauth2" {
+		if parts[2] != "token" {
+			writeError(w, notFoundError(r.URL.Path))
+			return
+		}
+		s.tokenExchange(w, r)
+		return
+	}
+
+	if err := s.authorize(r); err != nil {
+		writeError(w, err)
+		return
+	}
+	resp, err := s.serveAPI(r.Method, parts[2], r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// authorize validates the access token of r.
+func (s *Server) authorize(r *http.Request) *apiError {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return &apiError{status: http.StatusUnauthorized, msg: "Missing access token."}
+	}
+	expires, ok := s.tokens[strings.TrimPrefix(auth, "Bearer ")]
+	if !ok || !s.now().Before(expires) {
+		return &apiError{status: http.StatusUnauthorized, msg: "Access token is invalid or has expired."}
+	}
+	return nil
+}
+
+func (s *Server) serveAPI(method string, path string, r *http.Request) (interface{}, *apiError) {
+	q := r.URL.Query()
+	switch {
+	case path == "arrays/tags" && method == "GET":
+		return s.listTags(path, q)
+	case path == "arrays/tags" && method == "DELETE":
+		return s.deleteTags(q)
+	case path == "arrays/tags/batch" && method == "PUT":
+		var data []map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+			return nil, errorf("body", "The request body is not a list of tags.")
+		}
+		return s.putTags(q, data)
+	case path == "metrics/history" && method == "GET":
+		return s.metricHistory(path, q)
+	case contains(collections, path) && method == "GET":
+		return s.list(path, q)
+	case contains(collections, path), strings.HasPrefix(path, "arrays/tags"), path == "metrics/history":
+		return nil, &apiError{status: http.StatusMethodNotAllowed, msg: "Method not allowed."}
+	}
+	return nil, notFoundError(path)
+}
+
+// apiError is an error returned by the fake API.  The errors of the token
+// exchange are returned in the OAuth format, with error and error_description.
+type apiError struct {
+	status int
+	ctx    string
+	msg    string
+	oauth  string
+}
+
+func errorf(ctx string, msg string) *apiError {
+	return &apiError{status: http.StatusBadRequest, ctx: ctx, msg: msg}
+}
+
+func oauthError(code string, msg string) *apiError {
+	return &apiError{status: http.StatusBadRequest, oauth: code, msg: msg}
+}
+
+func notFoundError(path string) *apiError {
+	return &apiError{status: http.StatusNotFound, ctx: path, msg: "Resource not found."}
+}
+
+func writeError(w http.ResponseWriter, err *apiError) {
+	if err.oauth != "" {
+		writeJSON(w, err.status, map[string]string{"error": err.oauth, "error_description": err.msg})
+		return
+	}
+	writeJSON(w, err.status, map[string]interface{}{
+		"errors": []map[string]string{{"context": err.ctx, "message": err.msg}},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// newToken returns a random token, used for access and continuation tokens.
+func newToken() string {
+	b := make([]byte, 24)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// newID returns a random UUID, used for the IDs of resources.
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// splitList splits a list query parameter.  The values may be quoted, as in
+// names='vol1','vol2'.
+func splitList(q url.Values, key string) []string {
+	var l []string
+	for _, v := range q[key] {
+		for _, e := range strings.Split(v, ",") {
+			if e = strings.Trim(strings.TrimSpace(e), `'"`); e != "" {
+				l = append(l, e)
+			}
+		}
+	}
+	return l
+}
+
+func contains(l []string, s string) bool {
+	for _, e := range l {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,182 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pure1test_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/devans10/go-purestorage/pure1"
+	"github.com/devans10/go-purestorage/pure1/pure1test"
+)
+
+func testServer(t *testing.T) (*pure1test.Server, *pure1.Client) {
+	s := pure1test.NewServer()
+	t.Cleanup(s.Close)
+
+	c, err := pure1.New(s.AppID, s.PrivateKey, pure1.WithBaseURL(s.URL), pure1.WithHTTPClient(s.Client()), pure1.WithRetryPolicy(nil))
+	if err != nil {
+		t.Fatalf("error setting up client: %s", err)
+	}
+	return s, c
+}
+
+// page is the items envelope of a list returned by the API.
+type page struct {
+	TotalItemCount    int                      `json:"total_item_count"`
+	ContinuationToken string                   `json:"continuation_token"`
+	Items             []map[string]interface{} `json:"items"`
+}
+
+// testGet sends a GET request for path with the access token of c, and
+// returns the status code and the decoded page of the response.
+func testGet(t *testing.T, s *pure1test.Server, c *pure1.Client, path string, params map[string]string) (int, page) {
+	req, err := c.NewRequest("GET", path, params, nil)
+	if err != nil {
+		t.Fatalf("error building request: %s", err)
+	}
+	resp, err := s.Client().Do(req)
+	if err != nil {
+		t.Fatalf("error sending request: %s", err)
+	}
+	defer resp.Body.Close()
+
+	var p page
+	if resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(&p); err != nil {
+			t.Fatalf("error decoding response: %s", err)
+		}
+	}
+	return resp.StatusCode, p
+}
+
+func TestServerTokenExchange(t *testing.T) {
+	s, c := testServer(t)
+	now := time.Now()
+	s.Now = func() time.Time { return now }
+
+	if _, err := c.Arrays.GetArrays(nil); err != nil {
+		t.Fatalf("error getting arrays: %s", err)
+	}
+	if s.TokensIssued() != 1 {
+		t.Errorf("expected 1 access token to be issued; got %d", s.TokensIssued())
+	}
+
+	now = now.Add(s.TokenLifetime)
+	if status, _ := testGet(t, s, c, "arrays", nil); status != http.StatusUnauthorized {
+		t.Errorf("expected status 401 after the access token expired; got %d", status)
+	}
+	if _, err := pure1.New(s.AppID, s.PrivateKey, pure1.WithBaseURL(s.URL), pure1.WithHTTPClient(s.Client())); err == nil {
+		t.Errorf("An Error was NOT raised for a JWT that expired by the clock of the server")
+	}
+
+	now = time.Now()
+	s.ExpireTokens()
+	if status, _ := testGet(t, s, c, "arrays", nil); status != http.StatusUnauthorized {
+		t.Errorf("expected status 401 after the access tokens were expired; got %d", status)
+	}
+	if _, err := c.Arrays.GetArrays(nil); err != nil {
+		t.Errorf("error getting arrays with a new access token: %s", err)
+	}
+	if s.TokensIssued() != 2 {
+		t.Errorf("expected 2 access tokens to be issued; got %d", s.TokensIssued())
+	}
+}
+
+func TestServerPagination(t *testing.T) {
+	s, c := testServer(t)
+	for i := 0; i < 5; i++ {
+		s.Add(pure1test.Volumes, map[string]interface{}{"name": "vol" + strconv.Itoa(i), "provisioned": 1024 * i})
+	}
+
+	var names []string
+	params := map[string]string{"limit": "2", "sort": "name-"}
+	for {
+		status, p := testGet(t, s, c, "volumes", params)
+		if status != http.StatusOK {
+			t.Fatalf("expected status 200; got %d", status)
+		}
+		if p.TotalItemCount != 5 {
+			t.Errorf("expected total_item_count: 5; got %d", p.TotalItemCount)
+		}
+		for _, item := range p.Items {
+			names = append(names, item["name"].(string))
+		}
+		if p.ContinuationToken == "" {
+			break
+		}
+		params["continuation_token"] = p.ContinuationToken
+	}
+	if len(names) != 5 || names[0] != "vol4" || names[4] != "vol0" {
+		t.Errorf("expected 5 volumes in descending order; got %v", names)
+	}
+
+	_, p := testGet(t, s, c, "volumes", map[string]string{"names": "'vol1','vol3'"})
+	if p.TotalItemCount != 2 || p.ContinuationToken != "" {
+		t.Errorf("expected 2 volumes for the names filter; got %+v", p)
+	}
+	if status, _ := testGet(t, s, c, "volumes", map[string]string{"continuation_token": "invalid"}); status != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an invalid continuation token; got %d", status)
+	}
+}
+
+func TestServerTags(t *testing.T) {
+	s, c := testServer(t)
+	s.Add(pure1test.Arrays, map[string]interface{}{"name": "array1"}, map[string]interface{}{"name": "array2"})
+
+	if err := c.Arrays.CreateTags(map[string]string{"resource_names": "array1,array2"}, []pure1.Tag{{Key: "env", Value: "prod"}}); err != nil {
+		t.Fatalf("error creating tags: %s", err)
+	}
+	if err := c.Arrays.CreateTags(map[string]string{"resource_names": "array3"}, []pure1.Tag{{Key: "env", Value: "prod"}}); err == nil {
+		t.Errorf("An Error was NOT raised when tagging a missing array")
+	}
+	if _, p := testGet(t, s, c, "arrays/tags", nil); len(p.Items) != 2 {
+		t.Errorf("expected 2 tags; got %+v", p.Items)
+	}
+
+	if err := c.Arrays.DeleteTags(map[string]string{"resource_names": "array1", "keys": "env"}); err != nil {
+		t.Fatalf("error deleting tags: %s", err)
+	}
+	_, p := testGet(t, s, c, "arrays/tags", nil)
+	if len(p.Items) != 1 || p.Items[0]["resource"].(map[string]interface{})["name"] != "array2" {
+		t.Errorf("expected the tag of array2 to remain; got %+v", p.Items)
+	}
+}
+
+func TestServerMetricHistory(t *testing.T) {
+	s, c := testServer(t)
+	s.Add(pure1test.Arrays, map[string]interface{}{"name": "array1"})
+	s.MetricValue = func(metric string, resourceID string, ts time.Time) float64 { return 42 }
+
+	params := map[string]string{
+		"names":          "array_read_iops",
+		"resource_names": "array1",
+		"aggregation":    "avg",
+		"start_time":     "0",
+		"end_time":       "600000",
+		"resolution":     "300000",
+	}
+	status, p := testGet(t, s, c, "metrics/history", params)
+	if status != http.StatusOK || len(p.Items) != 1 {
+		t.Fatalf("expected the history of 1 metric; got %d, %+v", status, p.Items)
+	}
+	data := p.Items[0]["data"].([]interface{})
+	if len(data) != 3 || data[2].([]interface{})[1] != 42.0 {
+		t.Errorf("expected 3 data points of value 42; got %v", data)
+	}
+
+	params["resolution"] = "1000"
+	if status, _ := testGet(t, s, c, "metrics/history", params); status != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an unavailable resolution; got %d", status)
+	}
+	params["resolution"] = "300000"
+	params["names"] = "volume_read_iops"
+	if status, _ := testGet(t, s, c, "metrics/history", params); status != http.StatusBadRequest {
+		t.Errorf("expected status 400 for a metric of another resource type; got %d", status)
+	}
+}
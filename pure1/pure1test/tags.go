@@ -0,0 +1,177 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pure1test
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// tagOrganizationID is the ID of the organization owning the tags.
+const tagOrganizationID = 100
+
+// defaultNamespace is the namespace of the tags when none is given.
+const defaultNamespace = "default"
+
+// tag is a key-value pair attached to an array.
+type tag struct {
+	key       string
+	namespace string
+	value     string
+	array     map[string]interface{}
+}
+
+func (s *Server) tagView(t *tag) map[string]interface{} {
+	return map[string]interface{}{
+		"key":                 t.key,
+		"namespace":           t.namespace,
+		"value":               t.value,
+		"tag_organization_id": tagOrganizationID,
+		"resource": map[string]interface{}{
+			"id":            t.array["id"],
+			"name":          t.array["name"],
+			"resource_type": Arrays,
+		},
+	}
+}
+
+// taggedArrays returns the arrays selected by the resource_ids and
+// resource_names query parameters, which are required unless optional is true.
+func (s *Server) taggedArrays(q url.Values, optional bool) ([]map[string]interface{}, *apiError) {
+	ids := splitList(q, "resource_ids")
+	names := splitList(q, "resource_names")
+	if len(ids) == 0 && len(names) == 0 && !optional {
+		return nil, errorf("resource_ids", "Either resource_ids or resource_names is required.")
+	}
+
+	var arrays []map[string]interface{}
+	for _, id := range ids {
+		a := s.lookup(Arrays, id, "")
+		if a == nil {
+			return nil, errorf(id, fmt.Sprintf("Array with ID %s does not exist.", id))
+		}
+		arrays = append(arrays, a)
+	}
+	for _, name := range names {
+		a := s.lookup(Arrays, "", name)
+		if a == nil {
+			return nil, errorf(name, fmt.Sprintf("Array %s does not exist.", name))
+		}
+		arrays = append(arrays, a)
+	}
+	return arrays, nil
+}
+
+// tagged reports whether t is attached to one of arrays.
+func tagged(t *tag, arrays []map[string]interface{}) bool {
+	for _, a := range arrays {
+		if t.array["id"] == a["id"] {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) listTags(path string, q url.Values) (interface{}, *apiError) {
+	arrays, err := s.taggedArrays(q, true)
+	if err != nil {
+		return nil, err
+	}
+	namespaces := splitList(q, "namespaces")
+	keys := splitList(q, "keys")
+
+	items := []interface{}{}
+	for _, t := range s.tags {
+		if len(arrays) > 0 && !tagged(t, arrays) {
+			continue
+		}
+		if len(namespaces) > 0 && !contains(namespaces, t.namespace) {
+			continue
+		}
+		if len(keys) > 0 && !contains(keys, t.key) {
+			continue
+		}
+		items = append(items, s.tagView(t))
+	}
+	return s.page(path, q, items)
+}
+
+// namespace returns the single namespace of the namespaces query parameter.
+func namespace(q url.Values) (string, *apiError) {
+	namespaces := splitList(q, "namespaces")
+	switch len(namespaces) {
+	case 0:
+		return defaultNamespace, nil
+	case 1:
+		return namespaces[0], nil
+	}
+	return "", errorf("namespaces", "Only one namespace can be given.")
+}
+
+func (s *Server) putTags(q url.Values, data []map[string]interface{}) (interface{}, *apiError) {
+	arrays, err := s.taggedArrays(q, false)
+	if err != nil {
+		return nil, err
+	}
+	ns, err := namespace(q)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range data {
+		if key, _ := d["key"].(string); key == "" {
+			return nil, errorf("key", "Every tag must have a key.")
+		}
+		if _, ok := d["value"].(string); !ok {
+			return nil, errorf("value", "Every tag must have a string value.")
+		}
+	}
+
+	items := []interface{}{}
+	for _, a := range arrays {
+		for _, d := range data {
+			t := s.findTag(a, ns, d["key"].(string))
+			if t == nil {
+				t = &tag{key: d["key"].(string), namespace: ns, array: a}
+				s.tags = append(s.tags, t)
+			}
+			t.value = d["value"].(string)
+			items = append(items, s.tagView(t))
+		}
+	}
+	return s.page("arrays/tags/batch", url.Values{}, items)
+}
+
+func (s *Server) findTag(array map[string]interface{}, namespace string, key string) *tag {
+	for _, t := range s.tags {
+		if t.array["id"] == array["id"] && t.namespace == namespace && t.key == key {
+			return t
+		}
+	}
+	return nil
+}
+
+func (s *Server) deleteTags(q url.Values) (interface{}, *apiError) {
+	arrays, err := s.taggedArrays(q, false)
+	if err != nil {
+		return nil, err
+	}
+	keys := splitList(q, "keys")
+	if len(keys) == 0 {
+		return nil, errorf("keys", "keys is required.")
+	}
+	ns, err := namespace(q)
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []*tag
+	for _, t := range s.tags {
+		if !(tagged(t, arrays) && t.namespace == ns && contains(keys, t.key)) {
+			tags = append(tags, t)
+		}
+	}
+	s.tags = tags
+	return map[string]interface{}{}, nil
+}
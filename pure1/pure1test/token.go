@@ -0,0 +1,91 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pure1test
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// maxClockSkew is how far in the future the iat claim of a JWT may be.
+const maxClockSkew = time.Minute
+
+// tokenExchange serves the OAuth token exchange, which trades a JWT signed by
+// an API application for an access token.
+func (s *Server) tokenExchange(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		writeError(w, &apiError{status: http.StatusMethodNotAllowed, oauth: "invalid_request", msg: "The token exchange requires a POST request."})
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeError(w, oauthError("invalid_request", "The request body is not a form."))
+		return
+	}
+	if r.PostForm.Get("grant_type") != grantTypeTokenExchange {
+		writeError(w, oauthError("unsupported_grant_type", fmt.Sprintf("grant_type must be %s.", grantTypeTokenExchange)))
+		return
+	}
+	if r.PostForm.Get("subject_token_type") != tokenTypeJWT {
+		writeError(w, oauthError("invalid_request", fmt.Sprintf("subject_token_type must be %s.", tokenTypeJWT)))
+		return
+	}
+	if err := s.validateJWT(r.PostForm.Get("subject_token")); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	token := newToken()
+	s.tokens[token] = s.now().Add(s.TokenLifetime)
+	s.issued++
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token":      token,
+		"issued_token_type": tokenTypeAccessToken,
+		"token_type":        "Bearer",
+		"expires_in":        int(s.TokenLifetime / time.Second),
+	})
+}
+
+// validateJWT validates that the subject token of the token exchange is a JWT
+// signed with RS256 by a registered application, and that it has not expired.
+func (s *Server) validateJWT(subjectToken string) *apiError {
+	if subjectToken == "" {
+		return oauthError("invalid_request", "subject_token is required.")
+	}
+
+	parser := &jwt.Parser{ValidMethods: []string{"RS256"}, SkipClaimsValidation: true}
+	token, err := parser.Parse(subjectToken, func(token *jwt.Token) (interface{}, error) {
+		claims, _ := token.Claims.(jwt.MapClaims)
+		iss, _ := claims["iss"].(string)
+		key, ok := s.apps[iss]
+		if !ok {
+			return nil, fmt.Errorf("unknown application %q", iss)
+		}
+		return key, nil
+	})
+	if err != nil {
+		return oauthError("invalid_grant", fmt.Sprintf("The subject token is invalid: %s.", err))
+	}
+
+	claims := token.Claims.(jwt.MapClaims)
+	iat, ok := claims["iat"].(float64)
+	if !ok {
+		return oauthError("invalid_grant", "The subject token has no iat claim.")
+	}
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return oauthError("invalid_grant", "The subject token has no exp claim.")
+	}
+	now := s.now()
+	if time.Unix(int64(exp), 0).Before(now) {
+		return oauthError("invalid_grant", "The subject token has expired.")
+	}
+	if time.Unix(int64(iat), 0).After(now.Add(maxClockSkew)) {
+		return oauthError("invalid_grant", "The subject token was issued in the future.")
+	}
+	return nil
+}
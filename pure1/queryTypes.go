@@ -0,0 +1,197 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pure1
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// restVersionRegexp matches the versions of the Pure1 REST API, every one of
+// which supports the parameters of a Query.
+var restVersionRegexp = regexp.MustCompile(`^1\.([0-9]+|latest)$`)
+
+// sortRegexp matches the fields of the sort parameter, descending with a '-'
+// suffix.
+var sortRegexp = regexp.MustCompile(`^[a-z_][a-z0-9_.]*-?$`)
+
+// Query struct for the parameters of the getters, i.e. GetVolumes, converted
+// with Params.  Zero fields are not set.
+type Query struct {
+	// IDs and Names select the objects by ID or by name
+	IDs   []string
+	Names []string
+	// Filter selects the objects matching an expression, i.e.
+	// And(Eq("name", "vol*"), Gt("provisioned", 1<<40))
+	Filter Filter
+	// Sort sorts the objects by fields, descending if suffixed with '-',
+	// i.e. "provisioned-"
+	Sort []string
+	// Limit is the maximum number of objects returned, from Offset or from
+	// the page of ContinuationToken
+	Limit             int
+	Offset            int
+	ContinuationToken string
+}
+
+// Validate checks the combinations of parameters, and that the parameters
+// are supported by the REST version restVersion.
+func (q *Query) Validate(restVersion string) error {
+	if !restVersionRegexp.MatchString(restVersion) {
+		return &PureError{Reason: fmt.Sprintf("[error] REST version %s is not supported", restVersion)}
+	}
+	if err := validateList("id", q.IDs); err != nil {
+		return err
+	}
+	if err := validateList("name", q.Names); err != nil {
+		return err
+	}
+	for _, f := range q.Sort {
+		if !sortRegexp.MatchString(f) {
+			return &PureError{Reason: fmt.Sprintf("[error] Invalid sort field %q", f)}
+		}
+	}
+	if q.Limit < 0 || q.Offset < 0 {
+		return &PureError{Reason: "[error] Query limit and offset must not be negative"}
+	}
+	if q.Offset > 0 && q.ContinuationToken != "" {
+		return &PureError{Reason: "[error] Query can list either from an offset or from a continuation token"}
+	}
+	return nil
+}
+
+// Params returns the parameters of the query, once validated for the REST
+// version restVersion, i.e. of Client.RestVersion.
+//
+//	params, err := (&pure1.Query{Names: []string{"vol1"}}).Params(c.RestVersion)
+//	if err != nil {
+//		return err
+//	}
+//	volumes, err := c.Volumes.GetVolumes(params)
+func (q *Query) Params(restVersion string) (map[string]string, error) {
+	if err := q.Validate(restVersion); err != nil {
+		return nil, err
+	}
+
+	p := make(map[string]string)
+	if len(q.IDs) > 0 {
+		p["ids"] = quoteList(q.IDs)
+	}
+	if len(q.Names) > 0 {
+		p["names"] = quoteList(q.Names)
+	}
+	if q.Filter != "" {
+		p["filter"] = string(q.Filter)
+	}
+	if len(q.Sort) > 0 {
+		p["sort"] = strings.Join(q.Sort, ",")
+	}
+	if q.Limit > 0 {
+		p["limit"] = strconv.Itoa(q.Limit)
+	}
+	if q.Offset > 0 {
+		p["offset"] = strconv.Itoa(q.Offset)
+	}
+	if q.ContinuationToken != "" {
+		p["continuation_token"] = q.ContinuationToken
+	}
+	return p, nil
+}
+
+// Filter is a filter expression of a Query, built with Eq, Ne, Gt, Lt, And,
+// Or and Not.
+// The builders are the same as those of package flasharray, and are tested with the
+// cases of internal/filtertest.
+type Filter string
+
+// Eq returns a filter selecting the objects whose field equals value; string
+// values can have '*' wildcards.
+func Eq(field string, value interface{}) Filter {
+	return Filter(field + "=" + filterValue(value))
+}
+
+// Ne returns a filter selecting the objects whose field does not equal value.
+func Ne(field string, value interface{}) Filter {
+	return Filter(field + "!=" + filterValue(value))
+}
+
+// Gt returns a filter selecting the objects whose field is greater than value.
+func Gt(field string, value interface{}) Filter {
+	return Filter(field + ">" + filterValue(value))
+}
+
+// Lt returns a filter selecting the objects whose field is less than value.
+func Lt(field string, value interface{}) Filter {
+	return Filter(field + "<" + filterValue(value))
+}
+
+// And returns a filter selecting the objects selected by every filter.
+func And(filters ...Filter) Filter {
+	return joinFilters(" and ", filters)
+}
+
+// Or returns a filter selecting the objects selected by any filter.
+func Or(filters ...Filter) Filter {
+	return joinFilters(" or ", filters)
+}
+
+// Not returns a filter selecting the objects not selected by f.
+func Not(f Filter) Filter {
+	return Filter("not(" + string(f) + ")")
+}
+
+func joinFilters(op string, filters []Filter) Filter {
+	if len(filters) == 1 {
+		return filters[0]
+	}
+	l := make([]string, len(filters))
+	for i, f := range filters {
+		l[i] = "(" + string(f) + ")"
+	}
+	return Filter(strings.Join(l, op))
+}
+
+// filterValue formats a value of a filter, quoting strings with their
+// backslashes and quotes escaped, and nil as null.
+func filterValue(value interface{}) string {
+	if value == nil {
+		return "null"
+	}
+	if s, ok := value.(string); ok {
+		return quote(s)
+	}
+	return fmt.Sprint(value)
+}
+
+func quote(s string) string {
+	return "'" + strings.Replace(strings.Replace(s, `\`, `\\`, -1), "'", `\'`, -1) + "'"
+}
+
+// quoteList formats the ids or names parameters, i.e. 'vol1','vol2'.
+func quoteList(l []string) string {
+	q := make([]string, len(l))
+	for i, s := range l {
+		q[i] = quote(s)
+	}
+	return strings.Join(q, ",")
+}
+
+// validateList checks that the ids or names of a query are not empty or
+// duplicated.
+func validateList(kind string, l []string) error {
+	seen := make(map[string]bool)
+	for _, s := range l {
+		if s == "" {
+			return &PureError{Reason: fmt.Sprintf("[error] Empty %s", kind)}
+		}
+		if seen[s] {
+			return &PureError{Reason: fmt.Sprintf("[error] Duplicate %s %s", kind, s)}
+		}
+		seen[s] = true
+	}
+	return nil
+}
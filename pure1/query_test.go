@@ -0,0 +1,107 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pure1
+
+import (
+	"testing"
+
+	"github.com/devans10/go-purestorage/internal/filtertest"
+	"github.com/devans10/go-purestorage/pure1/pure1test"
+)
+
+func TestPure1QueryValidate(t *testing.T) {
+	valid := map[string]*Query{
+		"Empty":        {},
+		"Names":        {Names: []string{"vol1", "vol2"}},
+		"IDs":          {IDs: []string{"id1"}},
+		"Filter":       {Filter: Not(Eq("name", "vol*"))},
+		"Offset":       {Sort: []string{"provisioned-"}, Limit: 10, Offset: 20},
+		"Continuation": {Limit: 10, ContinuationToken: "abc"},
+	}
+	for n, q := range valid {
+		if err := q.Validate("1.latest"); err != nil {
+			t.Errorf("error validating query %s: %s", n, err)
+		}
+	}
+
+	invalid := map[string]*Query{
+		"EmptyName":     {Names: []string{""}},
+		"DuplicateID":   {IDs: []string{"id1", "id1"}},
+		"Sort":          {Sort: []string{"name desc"}},
+		"Limit":         {Limit: -1},
+		"Offset":        {Offset: -1},
+		"OffsetAndNext": {Offset: 10, ContinuationToken: "abc"},
+	}
+	for n, q := range invalid {
+		if err := q.Validate("1.0"); err == nil {
+			t.Errorf("An Error was NOT raised for query %s", n)
+		}
+	}
+
+	if err := (&Query{}).Validate("2"); err == nil {
+		t.Errorf("An Error was NOT raised for REST version 2")
+	}
+}
+
+func TestPure1QueryParams(t *testing.T) {
+	s, c := testFakePure1(t)
+	for _, n := range []string{"vol1", "vol2", "vol3"} {
+		s.Add(pure1test.Volumes, map[string]interface{}{"name": n})
+	}
+
+	q := &Query{Names: []string{"vol1", "vol3"}, Sort: []string{"name-"}, Limit: 1}
+	params, err := q.Params(c.RestVersion)
+	if err != nil {
+		t.Fatalf("error getting query params: %s", err)
+	}
+	if params["names"] != "'vol1','vol3'" {
+		t.Errorf("expected names 'vol1','vol3'; got %s", params["names"])
+	}
+	l, err := c.Volumes.GetVolumes(params)
+	if err != nil {
+		t.Fatalf("error getting volumes: %s", err)
+	}
+	if len(l) != 1 || l[0].Name != "vol3" {
+		t.Errorf("expected volume vol3; got %v", l)
+	}
+
+	f := And(Eq("name", "it's"), Or(Gt("provisioned", 10), Ne("pod", nil)))
+	if expected := `(name='it\'s') and ((provisioned>10) or (pod!=null))`; string(f) != expected {
+		t.Errorf("expected filter %s; got %s", expected, f)
+	}
+}
+
+// testFilter builds the filter expression e of the shared test cases.
+func testFilter(e filtertest.Expr) Filter {
+	args := make([]Filter, len(e.Args))
+	for i, a := range e.Args {
+		args[i] = testFilter(a)
+	}
+	switch e.Op {
+	case filtertest.Eq:
+		return Eq(e.Field, e.Value)
+	case filtertest.Ne:
+		return Ne(e.Field, e.Value)
+	case filtertest.Gt:
+		return Gt(e.Field, e.Value)
+	case filtertest.Lt:
+		return Lt(e.Field, e.Value)
+	case filtertest.And:
+		return And(args...)
+	case filtertest.Or:
+		return Or(args...)
+	case filtertest.Not:
+		return Not(args[0])
+	}
+	panic("unknown filter builder " + e.Op)
+}
+
+func TestPure1Filters(t *testing.T) {
+	for _, c := range filtertest.Cases {
+		if f := testFilter(c.Expr); string(f) != c.Expected {
+			t.Errorf("expected filter %s for %s; got %s", c.Expected, c.Name, f)
+		}
+	}
+}
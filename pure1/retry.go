@@ -0,0 +1,77 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pure1
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/devans10/go-purestorage/internal/retry"
+)
+
+// RetryPolicy decides whether a request that failed with a transient error is
+// sent again.  Retry is called after every failed attempt with the response or
+// the error of the HTTP client, and the number of attempts made so far.  It
+// returns how long to wait before the next attempt, and false to give up.
+type RetryPolicy = retry.Policy
+
+// ExponentialBackoff is a RetryPolicy that waits exponentially longer between
+// attempts, with random jitter.
+//
+// Requests with idempotent methods (GET, HEAD, OPTIONS, PUT and DELETE) are
+// retried when the Pure1 API responds with one of RetryableStatusCodes, or
+// when the request fails with a network error.  Other requests are only
+// retried when the Pure1 API certainly did not process them: when the
+// connection could not be established, or when the API responded with 429
+// Too Many Requests.  Set RetryNonIdempotent to retry them like idempotent
+// requests.
+type ExponentialBackoff = retry.ExponentialBackoff
+
+// DefaultRetryPolicy returns the retry policy used by NewClient: 4 attempts,
+// starting with a wait of 500ms, for 429, 502, 503 and 504 responses.
+func DefaultRetryPolicy() *ExponentialBackoff {
+	return retry.Default()
+}
+
+// sendWithRetry sends req, and sends it again as long as the retry policy of
+// the client allows.
+func (c *Client) sendWithRetry(req *http.Request) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		resp, err := c.httpClient().Do(req)
+		if c.RetryPolicy == nil {
+			return resp, err
+		}
+		wait, ok := c.RetryPolicy.Retry(req, resp, err, attempt)
+		if !ok {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		c.logf("[debug] %s %s: retrying in %s after attempt %d", req.Method, req.URL.String(), wait, attempt)
+		if err := retry.Sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+		if req, err = replayRequest(req); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// replayRequest returns a copy of req with a fresh body, so that it can be
+// sent again.
+func replayRequest(req *http.Request) (*http.Request, error) {
+	r := req.WithContext(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		r.Body = body
+	}
+	return r, nil
+}
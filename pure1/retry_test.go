@@ -0,0 +1,70 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pure1
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPure1Retry(t *testing.T) {
+	requests := 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{"items": []}`)
+	}))
+	defer s.Close()
+
+	p := DefaultRetryPolicy()
+	p.InitialBackoff = time.Millisecond
+	buf := &bytes.Buffer{}
+	c := &Client{RestVersion: "1.0", RetryPolicy: p, client: s.Client(), auth: &tokenSource{token: &pure1Token{AccessToken: "token"}}, logger: log.New(buf, "", 0)}
+
+	req, err := c.NewRequest("GET", s.URL+"/api/1.0/arrays", nil, nil)
+	if err != nil {
+		t.Fatalf("NewRequest function call returned error: %s", err)
+	}
+	if _, err := c.Do(req, &[]Array{}, false); err != nil {
+		t.Fatalf("request was not retried: %s", err)
+	}
+	if requests != 2 {
+		t.Errorf("expected 2 requests; got %d", requests)
+	}
+	if !strings.Contains(buf.String(), "retrying in") {
+		t.Errorf("expected the retry to be logged; got %q", buf.String())
+	}
+
+	requests = 0
+	req, _ = c.NewRequest("PUT", s.URL+"/api/1.0/arrays/tags/batch", nil, []Tag{{Key: "key", Value: "value"}})
+	c.RetryPolicy = nil
+	if _, err := c.Do(req, &[]Tag{}, false); err == nil {
+		t.Errorf("An Error was NOT raised when retries are disabled")
+	}
+}
+
+func TestPure1RequestFailedLogged(t *testing.T) {
+	s := httptest.NewServer(http.NotFoundHandler())
+	s.Close()
+
+	buf := &bytes.Buffer{}
+	c := &Client{RestVersion: "1.0", client: s.Client(), auth: &tokenSource{token: &pure1Token{AccessToken: "token"}}, logger: log.New(buf, "", 0)}
+	req, _ := c.NewRequest("GET", s.URL+"/api/1.0/arrays", nil, nil)
+	if _, err := c.Do(req, &[]Array{}, false); err == nil {
+		t.Fatalf("An Error was NOT raised for a closed server")
+	}
+	if !strings.Contains(buf.String(), "GET "+s.URL+"/api/1.0/arrays: request failed") {
+		t.Errorf("expected the failed request to be logged; got %q", buf.String())
+	}
+}
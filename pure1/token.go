@@ -0,0 +1,117 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pure1
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxTokenRefreshMargin caps how long before its expiry an access token is
+// refreshed.  Tokens are refreshed after 90% of their lifetime otherwise.
+const maxTokenRefreshMargin = 5 * time.Minute
+
+// tokenSource holds the access token of a client.  It is shared between a
+// client and the copies returned by WithContext, so that only one of them
+// exchanges a new token when the current one expires.
+type tokenSource struct {
+	mu         sync.Mutex
+	token      *pure1Token
+	refreshAt  time.Time
+	generation uint64
+	// now returns the current time; tests replace it to let time pass.
+	now func() time.Time
+}
+
+// current returns the access token in use and its generation, without refreshing it.
+func (s *tokenSource) current() (string, uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token == nil {
+		return "", s.generation
+	}
+	return s.token.AccessToken, s.generation
+}
+
+// set stores token, and schedules its refresh before it expires.
+func (s *tokenSource) set(token *pure1Token) {
+	s.token = token
+	s.generation++
+	s.refreshAt = time.Time{}
+	if token.ExpiresIn > 0 {
+		lifetime := time.Duration(token.ExpiresIn) * time.Second
+		margin := lifetime / 10
+		if margin > maxTokenRefreshMargin {
+			margin = maxTokenRefreshMargin
+		}
+		s.refreshAt = s.clock().Add(lifetime - margin)
+	}
+}
+
+func (s *tokenSource) clock() time.Time {
+	if s.now != nil {
+		return s.now()
+	}
+	return time.Now()
+}
+
+// accessToken returns the access token of c and its generation.  The token
+// is refreshed first if it is about to expire.
+func (c *Client) accessToken(ctx context.Context) (string, uint64, error) {
+	if c.auth == nil {
+		return "", 0, &PureError{Reason: "[error] Client does not have an access token; create it with New"}
+	}
+	c.auth.mu.Lock()
+	defer c.auth.mu.Unlock()
+
+	if c.auth.token == nil || (!c.auth.refreshAt.IsZero() && !c.auth.clock().Before(c.auth.refreshAt)) {
+		token, err := getToken(ctx, c)
+		if err != nil {
+			return "", 0, err
+		}
+		c.auth.set(token)
+	}
+	return c.auth.token.AccessToken, c.auth.generation, nil
+}
+
+// refreshToken exchanges a new access token, unless another request has
+// already done so since generation gen was observed.
+func (c *Client) refreshToken(ctx context.Context, gen uint64) error {
+	if c.auth == nil {
+		return &PureError{Reason: "[error] Client does not have an access token; create it with New"}
+	}
+	c.auth.mu.Lock()
+	defer c.auth.mu.Unlock()
+
+	if c.auth.generation != gen {
+		return nil
+	}
+	token, err := getToken(ctx, c)
+	if err != nil {
+		return err
+	}
+	c.auth.set(token)
+	return nil
+}
+
+// tokenRejected reports whether the response of req indicates that the access
+// token is no longer valid and the request can be sent again with a new one.
+func tokenRejected(req *http.Request, resp *http.Response) bool {
+	if resp.StatusCode != http.StatusUnauthorized {
+		return false
+	}
+	if strings.Contains(req.URL.Path, "/oauth2/") {
+		return false
+	}
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// authorize sets the access token of c in the Authorization header of req.
+func authorize(req *http.Request, token string) {
+	req.Header.Set("Authorization", "Bearer "+token)
+}
@@ -0,0 +1,87 @@
+// Copyright 2018 Dave Evans. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pure1
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPure1TokenRefresh(t *testing.T) {
+	s, c := testFakePure1(t)
+	now := time.Now()
+	c.auth.now = func() time.Time { return now }
+	c.auth.set(c.auth.token)
+
+	now = now.Add(s.TokenLifetime - 10*time.Minute)
+	if _, err := c.Arrays.GetArrays(nil); err != nil {
+		t.Fatalf("error getting arrays: %s", err)
+	}
+	if s.TokensIssued() != 1 {
+		t.Errorf("expected the access token not to be refreshed; got %d tokens", s.TokensIssued())
+	}
+
+	now = now.Add(6 * time.Minute)
+	if _, err := c.WithContext(c.Context()).Arrays.GetArrays(nil); err != nil {
+		t.Fatalf("error getting arrays: %s", err)
+	}
+	if s.TokensIssued() != 2 {
+		t.Errorf("expected the access token to be refreshed before it expires; got %d tokens", s.TokensIssued())
+	}
+	if _, err := c.Arrays.GetArrays(nil); err != nil || s.TokensIssued() != 2 {
+		t.Errorf("expected the refreshed access token to be shared with the copies of the client; got %d tokens, %v", s.TokensIssued(), err)
+	}
+}
+
+func TestPure1TokenRejected(t *testing.T) {
+	s, c := testFakePure1(t)
+
+	s.ExpireTokens()
+	if err := c.Arrays.CreateTags(map[string]string{"resource_names": "array1"}, []Tag{{Key: "key", Value: "value"}}); err != nil {
+		t.Fatalf("error creating tags after the access token was revoked: %s", err)
+	}
+	if s.TokensIssued() != 2 {
+		t.Errorf("expected a new access token to be exchanged once; got %d tokens", s.TokensIssued())
+	}
+	if tags, err := c.Arrays.GetTags(nil); err != nil || len(tags) != 1 {
+		t.Errorf("expected the tag to be created once; got %+v, %v", tags, err)
+	}
+
+	req, _ := c.NewRequest("GET", "arrays", nil, nil)
+	if _, err := c.Do(req, &[]Array{}, true); err != nil {
+		t.Fatalf("error getting arrays: %s", err)
+	}
+	if s.TokensIssued() != 3 {
+		t.Errorf("expected Do to exchange a new access token; got %d tokens", s.TokensIssued())
+	}
+}
+
+func TestPure1TokenRefreshConcurrent(t *testing.T) {
+	s, c := testFakePure1(t)
+
+	s.ExpireTokens()
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.WithContext(c.Context()).Arrays.GetArrays(nil)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("error getting arrays: %s", err)
+		}
+	}
+	if s.TokensIssued() != 2 {
+		t.Errorf("expected a single new access token for the concurrent requests; got %d tokens", s.TokensIssued())
+	}
+}
@@ -24,3 +24,21 @@ func (v *VolumeService) GetVolumes(params map[string]string) ([]Volume, error) {
 
 	return m, err
 }
+
+// Pages calls fn with the pages of the volume objects matching params, as
+// described in PageInfo.
+func (v *VolumeService) Pages(params map[string]string, fn func(page []Volume, info PageInfo) bool) error {
+	return v.client.pages("volumes", params, func() interface{} { return &[]Volume{} }, func(page interface{}, info PageInfo) bool {
+		return fn(*page.(*[]Volume), info)
+	})
+}
+
+// ListAll returns the volume objects matching params, from all the pages of the
+// list, as described in PageInfo.
+func (v *VolumeService) ListAll(params map[string]string) ([]Volume, error) {
+	m := []Volume{}
+	if err := v.client.listAll("volumes", params, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
@@ -9,10 +9,10 @@ import (
 )
 
 func TestPure1Volumes(t *testing.T) {
-	testAccPreChecks(t)
-	c := testAccGenerateClient(t)
+	c := testAccClient(t)
 
 	t.Run("GetVolumes", testPure1GetVolumes(c))
+	t.Run("ListAllVolumes", testPure1ListAllVolumes(c))
 }
 
 func testPure1GetVolumes(c *Client) func(t *testing.T) {
@@ -23,3 +23,12 @@ func testPure1GetVolumes(c *Client) func(t *testing.T) {
 		}
 	}
 }
+
+func testPure1ListAllVolumes(c *Client) func(t *testing.T) {
+	return func(t *testing.T) {
+		_, err := c.Volumes.ListAll(map[string]string{"limit": "1"})
+		if err != nil {
+			t.Fatalf("error listing all volumes: %s", err)
+		}
+	}
+}
@@ -24,3 +24,21 @@ func (v *VolumeSnapshotService) GetVolumeSnapshots(params map[string]string) ([]
 
 	return m, err
 }
+
+// Pages calls fn with the pages of the VolumeSnapshot objects matching params,
+// as described in PageInfo.
+func (v *VolumeSnapshotService) Pages(params map[string]string, fn func(page []VolumeSnapshot, info PageInfo) bool) error {
+	return v.client.pages("volume-snapshots", params, func() interface{} { return &[]VolumeSnapshot{} }, func(page interface{}, info PageInfo) bool {
+		return fn(*page.(*[]VolumeSnapshot), info)
+	})
+}
+
+// ListAll returns the VolumeSnapshot objects matching params, from all the
+// pages of the list, as described in PageInfo.
+func (v *VolumeSnapshotService) ListAll(params map[string]string) ([]VolumeSnapshot, error) {
+	m := []VolumeSnapshot{}
+	if err := v.client.listAll("volume-snapshots", params, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
@@ -9,10 +9,10 @@ import (
 )
 
 func TestPure1VolumeSnapshots(t *testing.T) {
-	testAccPreChecks(t)
-	c := testAccGenerateClient(t)
+	c := testAccClient(t)
 
 	t.Run("GetVolumeSnapshots", testPure1GetVolumeSnapshots(c))
+	t.Run("ListAllVolumeSnapshots", testPure1ListAllVolumeSnapshots(c))
 }
 
 func testPure1GetVolumeSnapshots(c *Client) func(t *testing.T) {
@@ -23,3 +23,12 @@ func testPure1GetVolumeSnapshots(c *Client) func(t *testing.T) {
 		}
 	}
 }
+
+func testPure1ListAllVolumeSnapshots(c *Client) func(t *testing.T) {
+	return func(t *testing.T) {
+		_, err := c.VolumeSnapshots.ListAll(map[string]string{"limit": "1"})
+		if err != nil {
+			t.Fatalf("error listing all volume snapshots: %s", err)
+		}
+	}
+}